@@ -0,0 +1,675 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math/rand/v2"
+	"net"
+	httplib "net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/artifact"
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+	"github.com/crowleyfelix/go-pipeline/pkg/schedule"
+	"github.com/crowleyfelix/go-pipeline/pkg/server"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"gopkg.in/yaml.v3"
+)
+
+func newRunCommand(cfg *config) *cobra.Command {
+	var (
+		names  []string
+		resume string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Executes the pipelines",
+		Example: "  go-pipeline run --dir ./example --id greet --var name=bob --var times=2\n" +
+			"  go-pipeline run --dir ./example --resume 7f3c9a1e52b04d8a",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			variables, err := cfg.variables()
+			if err != nil {
+				return err
+			}
+
+			pipelines, err := cfg.load()
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := interruptible()
+			defer stop()
+
+			opts, err := cfg.options()
+			if err != nil {
+				return err
+			}
+
+			// runs are seeded even without --seed, so the failed ones can be replayed.
+			seed := rand.Uint64()
+			if cfg.seed == "" {
+				opts = append(opts, pipeline.WithSeed(seed))
+			}
+
+			scope := pipeline.NewScope(pipelines, pipeline.WithVariables(variables))
+
+			if resume != "" {
+				_, err = pipelines.Resume(ctx, scope, resume, opts...)
+			} else {
+				_, err = pipelines.Execute(ctx, scope, lo.Compact(names), opts...)
+			}
+
+			var stepErr *pipeline.StepError
+			if errors.As(err, &stepErr) && len(stepErr.Snapshots) > 0 {
+				_ = stepErr.DumpSnapshots(cmd.ErrOrStderr())
+			}
+
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+
+			if err != nil && cfg.seed == "" {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Replay the execution with --seed %d\n", seed)
+			}
+
+			return err
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&names, "id", strings.Split(os.Getenv("PIPELINE_NAMES"), ","),
+		"names of the pipelines to execute, in order ($PIPELINE_NAMES, comma-separated)")
+	flags.StringVar(&resume, "resume", os.Getenv("RESUME_CHECKPOINT"), "ID of the checkpointed execution to resume ($RESUME_CHECKPOINT)")
+
+	return cmd
+}
+
+func newValidateCommand(cfg *config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Loads the pipelines, failing on invalid definitions, cycles, validation or lint issues",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			pipelines, err := cfg.load()
+			if err != nil {
+				return err
+			}
+
+			issues := append(pipeline.Validate(pipelines), pipelines.Lint()...)
+			for _, issue := range issues {
+				fmt.Fprintln(cmd.OutOrStdout(), issue)
+			}
+
+			if len(issues) > 0 {
+				return fmt.Errorf("%d issues", len(issues))
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "%d pipelines are valid\n", len(pipelines.Names()))
+
+			return nil
+		},
+	}
+}
+
+func newListCommand(cfg *config) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "Lists the pipelines with their description, tags, schedule and declared variables",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			pipelines, err := cfg.load()
+			if err != nil {
+				return err
+			}
+
+			for _, name := range pipelines.Names() {
+				pipe, _ := pipelines.Get(name)
+				printPipeline(cmd.OutOrStdout(), pipe)
+			}
+
+			return nil
+		},
+	}
+}
+
+func printPipeline(w io.Writer, pipe pipeline.Pipeline) {
+	fmt.Fprint(w, pipe.Name)
+
+	if len(pipe.Tags) > 0 {
+		fmt.Fprintf(w, " [%s]", strings.Join(pipe.Tags, ", "))
+	}
+
+	if pipe.Schedule != "" {
+		fmt.Fprintf(w, " (%s)", pipe.Schedule)
+	}
+
+	fmt.Fprintln(w)
+
+	if pipe.Description != "" {
+		fmt.Fprintf(w, "  %s\n", pipe.Description)
+	}
+
+	for _, variable := range pipe.Variables {
+		fmt.Fprintf(w, "  - %s: %s", variable.Name, lo.CoalesceOrEmpty(variable.Type, pipeline.InputTypeAny))
+
+		if variable.Default != nil {
+			fmt.Fprintf(w, " = %v", variable.Default)
+		}
+
+		if variable.Description != "" {
+			fmt.Fprintf(w, ", %s", variable.Description)
+		}
+
+		fmt.Fprintln(w)
+	}
+}
+
+func newGraphCommand(cfg *config) *cobra.Command {
+	return &cobra.Command{
+		Use:     "graph",
+		Short:   "Prints the pipelines executed by each pipeline as a Graphviz DOT graph, dashing the conditional ones",
+		Example: "  go-pipeline graph --dir ./example | dot -Tsvg > pipelines.svg",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			pipelines, err := cfg.load()
+			if err != nil {
+				return err
+			}
+
+			graph := pipelines.Graph()
+			w := cmd.OutOrStdout()
+
+			fmt.Fprintln(w, "digraph pipelines {")
+
+			for _, name := range slices.Sorted(maps.Keys(graph)) {
+				fmt.Fprintf(w, "  %q;\n", name)
+
+				for _, dependency := range graph[name] {
+					style := ""
+					if dependency.Conditional {
+						style = " [style=dashed]"
+					}
+
+					fmt.Fprintf(w, "  %q -> %q%s;\n", name, dependency.Pipeline, style)
+				}
+			}
+
+			fmt.Fprintln(w, "}")
+
+			return nil
+		},
+	}
+}
+
+func newServeCommand(cfg *config) *cobra.Command {
+	var (
+		addr, grpcAddr       string
+		keys, roles, tenants []string
+		profiles             bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Exposes the pipelines through the REST API and the web UI",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			authenticators, err := apiKeys(keys)
+			if err != nil {
+				return err
+			}
+
+			grants, err := parseRoles(roles)
+			if err != nil {
+				return err
+			}
+
+			if len(grants) > 0 && len(authenticators) == 0 {
+				return errors.New("--roles requires --api-keys granting them")
+			}
+
+			variables, err := cfg.variables()
+			if err != nil {
+				return err
+			}
+
+			opts, err := cfg.options()
+			if err != nil {
+				return err
+			}
+
+			opts = append(opts, pipeline.WithVariables(variables))
+
+			runnerOpts := []server.RunnerOption{server.WithExecuteOptions(opts...)}
+			if cfg.artifactDir != "" {
+				runnerOpts = append(runnerOpts, server.WithArtifacts(artifact.NewLocalStore(cfg.artifactDir)))
+			}
+
+			var (
+				api          = httplib.NewServeMux()
+				runners      []*server.Runner
+				registerGRPC func(grpc.ServiceRegistrar)
+			)
+
+			if len(tenants) > 0 {
+				served, err := cfg.tenants(tenants, grants, runnerOpts)
+				if err != nil {
+					return err
+				}
+
+				for _, name := range served.Names() {
+					runner, _ := served.Runner(name)
+					runners = append(runners, runner)
+				}
+
+				server.RegisterTenants(api, served)
+				registerGRPC = func(registrar grpc.ServiceRegistrar) { server.RegisterTenantsGRPC(registrar, served) }
+			} else {
+				pipelines, err := cfg.load()
+				if err != nil {
+					return err
+				}
+
+				runner := server.NewRunner(pipelines, append(runnerOpts, authorizer(pipelines, grants, ""))...)
+				runners = append(runners, runner)
+
+				server.RegisterAPI(api, runner)
+				server.RegisterUI(api, runner)
+				registerGRPC = func(registrar grpc.ServiceRegistrar) { server.RegisterGRPC(registrar, runner) }
+			}
+
+			if profiles {
+				server.RegisterPprof(api)
+			}
+
+			var (
+				handler  httplib.Handler = api
+				grpcOpts []grpc.ServerOption
+			)
+
+			if len(authenticators) > 0 {
+				handler = server.Authenticate(authenticators)(api)
+
+				unary, stream := server.AuthenticateGRPC(authenticators)
+				grpcOpts = append(grpcOpts, grpc.ChainUnaryInterceptor(unary), grpc.ChainStreamInterceptor(stream))
+			} else {
+				log.Log().Warn(context.Background(), "Serving without authentication, set --api-keys to require them")
+			}
+
+			probes := server.NewProbes()
+			probes.AddCheck("pipelines", func(context.Context) error {
+				for _, runner := range runners {
+					if len(runner.Pipelines().Names()) == 0 {
+						return errors.New("no pipelines loaded")
+					}
+				}
+
+				return nil
+			})
+
+			mux := httplib.NewServeMux()
+			server.RegisterProbes(mux, probes)
+			mux.Handle("/", handler)
+			// the UI pages ask for the API keys, which the browsers can't send in headers, see server.UIPage.
+			if len(tenants) > 0 {
+				mux.HandleFunc("GET /tenants/{tenant}/ui/{$}", server.UIPage)
+			} else {
+				mux.HandleFunc("GET /ui/{$}", server.UIPage)
+			}
+
+			if grpcAddr != "" {
+				listener, err := net.Listen("tcp", grpcAddr)
+				if err != nil {
+					return err
+				}
+
+				grpcServer := grpc.NewServer(grpcOpts...)
+				registerGRPC(grpcServer)
+
+				defer grpcServer.Stop()
+
+				go func() {
+					if err := grpcServer.Serve(listener); err != nil {
+						log.Log().Error(context.Background(), "Error serving the gRPC API: %s", err)
+					}
+				}()
+
+				log.Log().Info(context.Background(), "Serving the gRPC API on %s", grpcAddr)
+			}
+
+			log.Log().Info(context.Background(), "Serving pipelines on %s", addr)
+
+			return httplib.ListenAndServe(addr, mux)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&addr, "addr", lo.CoalesceOrEmpty(os.Getenv("SERVER_ADDR"), "localhost:8080"), "address to listen on ($SERVER_ADDR)")
+	flags.StringVar(&grpcAddr, "grpc-addr", os.Getenv("SERVER_GRPC_ADDR"),
+		"address the gRPC API listens on, disabled when empty ($SERVER_GRPC_ADDR)")
+	flags.StringSliceVar(&keys, "api-keys", lo.Compact(strings.Split(os.Getenv("SERVER_API_KEYS"), ",")),
+		"API keys required in the X-API-Key header of the API and gRPC calls, or asked by the UI page and kept in a cookie, "+
+			"as subject=key, or subject:role|...=key granting the --roles, unauthenticated when empty ($SERVER_API_KEYS, comma-separated)")
+	flags.StringSliceVar(&roles, "roles", lo.Compact(strings.Split(os.Getenv("SERVER_ROLES"), ",")),
+		"roles granting the actions (execute, read, cancel) on the pipelines with the tags as name=action|...:tag|..., "+
+			"'*' granting every action or tag, every authenticated call allowed when empty ($SERVER_ROLES, comma-separated)")
+	flags.StringSliceVar(&tenants, "tenants", lo.Compact(strings.Split(os.Getenv("SERVER_TENANTS"), ",")),
+		"tenants served under /tenants/{tenant}/ and by the x-tenant gRPC metadata, each one with the pipelines of its "+
+			"subdirectory of --dir and the roles named tenant/role besides the unqualified ones ($SERVER_TENANTS, comma-separated)")
+	flags.BoolVar(&profiles, "pprof", os.Getenv("SERVER_PPROF") == "true",
+		"serves the /debug/pprof/ profiles, behind the API keys too ($SERVER_PPROF)")
+
+	return cmd
+}
+
+// apiKeys returns the API keys of the subject=key entries, granting the roles of the subject:role|...=key ones.
+func apiKeys(entries []string) (server.APIKeys, error) {
+	keys := server.APIKeys{}
+
+	for _, entry := range entries {
+		principal, key, found := strings.Cut(entry, "=")
+		subject, roles, _ := strings.Cut(principal, ":")
+
+		if !found || subject == "" || key == "" {
+			return nil, errors.New("invalid API key, expected subject=key or subject:role|...=key")
+		}
+
+		keys[key] = server.Principal{Subject: subject, Roles: lo.Compact(strings.Split(roles, "|"))}
+	}
+
+	return keys, nil
+}
+
+// tenants returns the tenants served with the runner options, each one loading the pipelines of its --dir
+// subdirectory and authorized by its roles, see authorizer.
+func (c *config) tenants(names []string, roles []server.Role, opts []server.RunnerOption) (*server.Tenants, error) {
+	if c.source != "" {
+		return nil, errors.New("--tenants loads the pipelines of the --dir subdirectories, not from --source")
+	}
+
+	tenants := make([]server.Tenant, 0, len(names))
+
+	for _, name := range names {
+		tenantConfig := *c
+		tenantConfig.dir = filepath.Join(lo.CoalesceOrEmpty(c.dir, "."), name)
+
+		pipelines, err := tenantConfig.load()
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", name, err)
+		}
+
+		tenants = append(tenants, server.Tenant{
+			Name:      name,
+			Pipelines: pipelines,
+			Options:   append(slices.Clone(opts), authorizer(pipelines, roles, name)),
+		})
+	}
+
+	return server.NewTenants(tenants...)
+}
+
+// authorizer authorizes the calls by the roles, allowing every one without roles. Within a tenant, the roles
+// named tenant/role are granted besides the unqualified ones.
+func authorizer(pipelines pipeline.Pipelines, roles []server.Role, tenant string) server.RunnerOption {
+	if len(roles) == 0 {
+		return server.WithAuthorizer(nil)
+	}
+
+	granted := lo.Filter(roles, func(role server.Role, _ int) bool {
+		qualifier, _, qualified := strings.Cut(role.Name, "/")
+
+		return !qualified || qualifier == tenant
+	})
+
+	return server.WithAuthorizer(server.RoleAuthorizer(pipelines, granted...))
+}
+
+// parseRoles returns the roles of the name=action|...:tag|... entries.
+func parseRoles(entries []string) ([]server.Role, error) {
+	roles := make([]server.Role, 0, len(entries))
+
+	for _, entry := range entries {
+		name, grant, found := strings.Cut(entry, "=")
+		actions, tags, _ := strings.Cut(grant, ":")
+
+		if !found || name == "" || actions == "" || tags == "" {
+			return nil, fmt.Errorf("invalid role %q, expected name=action|...:tag|...", entry)
+		}
+
+		roles = append(roles, server.Role{Name: name, Actions: strings.Split(actions, "|"), Tags: strings.Split(tags, "|")})
+	}
+
+	return roles, nil
+}
+
+func newScheduleCommand(cfg *config) *cobra.Command {
+	var (
+		maxConcurrent int
+		preempt       bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "schedule",
+		Short: "Executes the pipelines on their schedules until interrupted",
+		Args:  cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			variables, err := cfg.variables()
+			if err != nil {
+				return err
+			}
+
+			pipelines, err := cfg.load()
+			if err != nil {
+				return err
+			}
+
+			opts, err := cfg.options()
+			if err != nil {
+				return err
+			}
+
+			opts = append(opts, pipeline.WithVariables(variables))
+
+			schedulerOpts := []schedule.SchedulerOption{schedule.WithExecuteOptions(opts...), schedule.WithMaxConcurrent(maxConcurrent)}
+			if preempt {
+				schedulerOpts = append(schedulerOpts, schedule.WithPreemption())
+			}
+
+			scheduler, err := schedule.NewScheduler(pipelines, schedulerOpts...)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := interruptible()
+			defer stop()
+
+			log.Log().Info(ctx, "Scheduling %d pipelines", len(scheduler.Jobs()))
+
+			scheduler.Run(ctx)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&maxConcurrent, "max-concurrent", 0, "executions running at once, granted by the pipelines priority, unbounded when 0")
+	cmd.Flags().BoolVar(&preempt, "preempt", false, "preempt the lower priority executions, resumed from their checkpoints with --checkpoint-dir")
+
+	return cmd
+}
+
+func newExprCommand(cfg *config) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "expr",
+		Short: "Evaluates template expressions outside the pipelines, to iterate on them",
+	}
+
+	cmd.AddCommand(newExprEvalCommand(cfg), newExprTestCommand())
+
+	return cmd
+}
+
+func newExprEvalCommand(cfg *config) *cobra.Command {
+	var fixture string
+
+	cmd := &cobra.Command{
+		Use:     "eval <expression>",
+		Short:   "Evaluates the expression against the scope of the fixture and the variables",
+		Example: `  go-pipeline expr eval '{{ variable . "user.name" | upper }}' --scope scope.yaml --var user.name=bob`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scope, err := readScope(fixture)
+			if err != nil {
+				return err
+			}
+
+			variables, err := cfg.variables()
+			if err != nil {
+				return err
+			}
+
+			maps.Copy(scope, variables)
+
+			result, err := pipeline.Eval(context.Background(), pipeline.NewScope(pipeline.Pipelines{}, pipeline.WithVariables(scope)), args[0])
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), result)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&fixture, "scope", "", "JSON or YAML file of the scope variables, keyed by their paths")
+
+	return cmd
+}
+
+// exprTests are the expression test cases of a file, evaluated against its scope.
+type exprTests struct {
+	// Scope holds the variables of the tests, keyed by their paths.
+	Scope map[string]any `yaml:"scope"`
+	Tests []exprTest     `yaml:"tests"`
+}
+
+type exprTest struct {
+	Name string `yaml:"name"`
+	Expr string `yaml:"expr"`
+	// Scope sets variables over the file ones for this test only.
+	Scope  map[string]any `yaml:"scope"`
+	Expect string         `yaml:"expect"`
+	// Error is a substring of the expected evaluation error, if any.
+	Error string `yaml:"error"`
+}
+
+func newExprTestCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "test <file>...",
+		Short: "Evaluates the expressions of the test files, failing when their results differ from the expected ones",
+		Long: "Evaluates the expressions of the test files, failing when their results differ from the expected ones.\n" +
+			"Each file holds the scope variables and the tests, eg.:\n\n" +
+			"  scope:\n    user: {name: bob}\n  tests:\n  - name: upper\n    expr: '{{ variable . \"user.name\" | upper }}'\n" +
+			"    expect: BOB\n  - name: missing\n    expr: '{{ variable . \"missing\" }}'\n    error: variable not found",
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			total, failed := 0, 0
+
+			for _, file := range args {
+				blob, err := os.ReadFile(file)
+				if err != nil {
+					return err
+				}
+
+				var tests exprTests
+				if err := yaml.Unmarshal(blob, &tests); err != nil {
+					return fmt.Errorf("decoding %s: %w", file, err)
+				}
+
+				for i, test := range tests.Tests {
+					name := fmt.Sprintf("%s: %s", file, lo.CoalesceOrEmpty(test.Name, fmt.Sprintf("test %d", i+1)))
+					total++
+
+					if err := test.run(tests.Scope); err != nil {
+						failed++
+
+						fmt.Fprintf(cmd.OutOrStdout(), "FAIL %s: %s\n", name, err)
+
+						continue
+					}
+
+					fmt.Fprintf(cmd.OutOrStdout(), "PASS %s\n", name)
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d tests failed", failed, total)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "%d tests passed\n", total)
+
+			return nil
+		},
+	}
+}
+
+// run evaluates the test expression against the scope, overridden by the test one.
+func (t exprTest) run(scope map[string]any) error {
+	variables := map[pipeline.VariablePath]any{}
+
+	for _, items := range []map[string]any{scope, t.Scope} {
+		for path, item := range items {
+			variables[pipeline.VariablePath(path)] = item
+		}
+	}
+
+	result, err := pipeline.Eval(context.Background(), pipeline.NewScope(pipeline.Pipelines{}, pipeline.WithVariables(variables)), t.Expr)
+
+	switch {
+	case t.Error != "" && err == nil:
+		return fmt.Errorf("expected error %q, got %q", t.Error, result)
+	case t.Error != "" && !strings.Contains(err.Error(), t.Error):
+		return fmt.Errorf("expected error %q, got %q", t.Error, err)
+	case t.Error != "":
+		return nil
+	case err != nil:
+		return err
+	case result != t.Expect:
+		return fmt.Errorf("expected %q, got %q", t.Expect, result)
+	}
+
+	return nil
+}
+
+// readScope reads the variables of the JSON or YAML fixture, keyed by their paths, none when it's not set.
+func readScope(fixture string) (map[pipeline.VariablePath]any, error) {
+	variables := map[pipeline.VariablePath]any{}
+	if fixture == "" {
+		return variables, nil
+	}
+
+	blob, err := os.ReadFile(fixture)
+	if err != nil {
+		return nil, err
+	}
+
+	var items map[string]any
+	if err := yaml.Unmarshal(blob, &items); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", fixture, err)
+	}
+
+	for path, item := range items {
+		variables[pipeline.VariablePath(path)] = item
+	}
+
+	return variables, nil
+}
@@ -2,33 +2,214 @@ package main
 
 import (
 	"context"
+	"encoding/csv"
+	"fmt"
 	httplib "net/http"
 	"os"
+	"os/signal"
+	"strconv"
 	"strings"
 
+	"github.com/crowleyfelix/go-pipeline/pkg/artifact"
+	"github.com/crowleyfelix/go-pipeline/pkg/cache"
+	"github.com/crowleyfelix/go-pipeline/pkg/checkpoint"
 	"github.com/crowleyfelix/go-pipeline/pkg/file"
 	"github.com/crowleyfelix/go-pipeline/pkg/http"
+	"github.com/crowleyfelix/go-pipeline/pkg/loader"
 	"github.com/crowleyfelix/go-pipeline/pkg/log"
 	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
 	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
-var (
-	pipelineDir = os.Getenv("PIPELINE_DIR")
-	pipelineNames = strings.Split(os.Getenv("PIPELINE_NAMES"), ",")
-)
+// config holds the flags shared by the commands, defaulting to their environment variables.
+type config struct {
+	dir           string
+	source        string
+	artifactDir   string
+	checkpointDir string
+	sandboxDir    string
+	cacheDir      string
+	vars          []string
+	seed          string
+	readOnly      string
+	cache         pipeline.Cache
+}
 
 func main() {
 	log.SetUp(log.Standard{})
 	http.RegisterStepExecutor(httplib.DefaultClient)
+	http.RegisterMockServerExecutor()
+	http.RegisterProfileExecutor()
 	file.RegisterStepExecutors()
 
-	pipelines := lo.Must(pipeline.Load(os.DirFS(pipelineDir)))
+	err := newRootCommand().Execute()
+
+	if err := pipeline.CloseClients(); err != nil {
+		log.Log().Warn(context.Background(), "Error closing the clients: %s", err)
+	}
+
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+func newRootCommand() *cobra.Command {
+	cfg := &config{}
+	run := newRunCommand(cfg)
+
+	root := &cobra.Command{
+		Use:   "go-pipeline",
+		Short: "Executes the YAML pipelines of a directory",
+		Long: "Executes the YAML pipelines of a directory.\n" +
+			"Without a command, the pipelines are run as by the run command, configured by environment variables.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE:         run.RunE,
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&cfg.dir, "dir", os.Getenv("PIPELINE_DIR"), "directory of the pipelines ($PIPELINE_DIR)")
+	flags.StringVar(&cfg.source, "source", os.Getenv("PIPELINE_SOURCE"),
+		"remote source of the pipelines instead of the directory: an http(s) URL, git+<repository>#<ref> or oci://<reference> ($PIPELINE_SOURCE)")
+	flags.StringVar(&cfg.artifactDir, "artifact-dir", os.Getenv("ARTIFACT_DIR"), "directory the artifacts are published to ($ARTIFACT_DIR)")
+	flags.StringVar(&cfg.checkpointDir, "checkpoint-dir", os.Getenv("CHECKPOINT_DIR"), "directory the checkpoints are saved to ($CHECKPOINT_DIR)")
+	flags.StringVar(&cfg.sandboxDir, "sandbox-dir", os.Getenv("SANDBOX_DIR"), "root of the files read by the template functions ($SANDBOX_DIR)")
+	flags.StringVar(&cfg.cacheDir, "cache-dir", os.Getenv("CACHE_DIR"), "directory of the default cache, in memory when empty ($CACHE_DIR)")
+	flags.StringVar(&cfg.seed, "seed", os.Getenv("PIPELINE_SEED"), "seed of the executions randomness, to replay them ($PIPELINE_SEED)")
+	flags.StringVar(&cfg.readOnly, "read-only", os.Getenv("PIPELINE_READ_ONLY"),
+		"blocks the side-effecting steps, failing them (block) or skipping them (noop), block without a value ($PIPELINE_READ_ONLY)")
+	flags.Lookup("read-only").NoOptDefVal = pipeline.ReadOnlyBlock
+	flags.StringArrayVar(&cfg.vars, "var", nil,
+		"variable set in the scope as key=value, the value decoded as YAML, eg.: times=2, overriding the $PIPELINE_VARS ones")
+
+	root.AddCommand(
+		run,
+		newValidateCommand(cfg),
+		newListCommand(cfg),
+		newGraphCommand(cfg),
+		newServeCommand(cfg),
+		newScheduleCommand(cfg),
+		newExprCommand(cfg),
+	)
+
+	return root
+}
+
+// load loads the pipelines of the directory or the remote source, registering the executors configured by the flags.
+func (c *config) load() (pipeline.Pipelines, error) {
+	if c.artifactDir != "" {
+		artifact.RegisterStepExecutor(artifact.NewLocalStore(c.artifactDir))
+	}
+
+	source := pipeline.FSLoader(os.DirFS(lo.CoalesceOrEmpty(c.dir, ".")))
+
+	if c.source != "" {
+		remote, err := loader.Parse(c.source)
+		if err != nil {
+			return pipeline.Pipelines{}, err
+		}
+
+		source = remote
+	}
+
+	pipelines, err := source.Load(context.Background())
+	if err != nil {
+		return pipeline.Pipelines{}, err
+	}
+
+	for _, issue := range pipelines.Lint() {
+		log.Log().Warn(context.Background(), "Lint: %s", issue)
+	}
+
+	return pipelines, nil
+}
+
+// options returns the options of the executions configured by the flags, sharing the default cache.
+func (c *config) options() ([]pipeline.Option, error) {
+	if c.cache == nil {
+		c.cache = cache.NewMemory()
+		if c.cacheDir != "" {
+			c.cache = cache.NewDisk(c.cacheDir)
+		}
+	}
+
+	opts := []pipeline.Option{pipeline.WithSandbox(c.sandboxDir), pipeline.WithCache(pipeline.DefaultCache, c.cache)}
+
+	if c.checkpointDir != "" {
+		opts = append(opts, pipeline.WithCheckpoints(checkpoint.NewFileStore(c.checkpointDir)))
+	}
+
+	if c.seed != "" {
+		seed, err := strconv.ParseUint(c.seed, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid seed %q: %w", c.seed, err)
+		}
+
+		opts = append(opts, pipeline.WithSeed(seed))
+	}
 
-	scope := pipeline.NewScope(pipelines)
+	switch c.readOnly {
+	case "":
+	case pipeline.ReadOnlyBlock, pipeline.ReadOnlyNoop:
+		opts = append(opts, pipeline.WithReadOnly(c.readOnly))
+	default:
+		return nil, fmt.Errorf("invalid read-only mode %q, expected %s or %s", c.readOnly, pipeline.ReadOnlyBlock, pipeline.ReadOnlyNoop)
+	}
+
+	return opts, nil
+}
+
+// variables returns the variables of the comma-separated $PIPELINE_VARS, quoted as CSV when holding commas,
+// overridden by the --var flags.
+func (c *config) variables() (map[pipeline.VariablePath]any, error) {
+	var vars []string
 
-	_, err := pipelines.Execute(context.Background(), scope, pipelineNames...)
-	if err != nil && err != context.Canceled {
-		log.Fatal(err)
+	if env := os.Getenv("PIPELINE_VARS"); env != "" {
+		records, err := csv.NewReader(strings.NewReader(env)).Read()
+		if err != nil {
+			return nil, fmt.Errorf("parsing PIPELINE_VARS: %w", err)
+		}
+
+		vars = records
 	}
+
+	return parseVars(append(vars, c.vars...))
+}
+
+// interruptible returns a context canceled when the process is interrupted.
+func interruptible() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt)
+}
+
+// parseVars parses the key=value variables, decoding the values as YAML, eg.: "times=2" as an int,
+// or keeping them as strings when they aren't valid YAML.
+func parseVars(vars []string) (map[pipeline.VariablePath]any, error) {
+	variables := make(map[pipeline.VariablePath]any, len(vars))
+
+	for _, v := range vars {
+		key, raw, found := strings.Cut(v, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid variable %q: expected key=value", v)
+		}
+
+		variables[pipeline.VariablePath(key)] = decodeValue(raw)
+	}
+
+	return variables, nil
+}
+
+func decodeValue(raw string) any {
+	var value any
+	if err := yaml.Unmarshal([]byte(raw), &value); err != nil {
+		return raw
+	}
+
+	switch value.(type) {
+	case bool, int, float64, []any, map[string]any:
+		return value
+	}
+
+	return raw
 }
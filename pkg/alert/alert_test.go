@@ -0,0 +1,112 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+type request struct {
+	path          string
+	query         string
+	authorization string
+	body          map[string]any
+}
+
+func newServer(t *testing.T, response string, requests *[]request) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		*requests = append(*requests, request{
+			path:          r.URL.Path,
+			query:         r.URL.RawQuery,
+			authorization: r.Header.Get("Authorization"),
+			body:          body,
+		})
+
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func execute(t *testing.T, provider Provider, params map[string]any) pipeline.Scope {
+	t.Helper()
+
+	scope := pipeline.NewScope(pipeline.Pipelines{}).WithVariable("rows", 42)
+	step := pipeline.NewStep("page", "alert", params)
+
+	scope, err := StepExecutor(map[string]Provider{"oncall": provider}).Execute(context.Background(), scope, step)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return scope
+}
+
+func TestPagerDuty(t *testing.T) {
+	t.Parallel()
+
+	var requests []request
+
+	server := newServer(t, `{"status":"success","dedup_key":"generated"}`, &requests)
+	provider := NewPagerDuty(server.Client(), "routing")
+	provider.URL = server.URL
+
+	scope := execute(t, provider, map[string]any{
+		"provider": "oncall",
+		"summary":  "Import failed",
+		"severity": "critical",
+		"details":  map[string]any{"rows": `{{ variable . "rows" }}`},
+	})
+
+	if result, _ := pipeline.Get[map[string]any](scope, "page"); result["key"] != "generated" || result["action"] != "trigger" {
+		t.Fatalf("unexpected result: %v", result)
+	}
+
+	payload, _ := requests[0].body["payload"].(map[string]any)
+	if requests[0].body["event_action"] != "trigger" || requests[0].body["routing_key"] != "routing" ||
+		payload["severity"] != "critical" || payload["custom_details"].(map[string]any)["rows"] != "42" {
+		t.Fatalf("unexpected event: %v", requests[0].body)
+	}
+
+	execute(t, provider, map[string]any{"provider": "oncall", "action": "resolve", "key": "generated"})
+
+	if requests[1].body["event_action"] != "resolve" || requests[1].body["dedup_key"] != "generated" {
+		t.Fatalf("unexpected event: %v", requests[1].body)
+	}
+}
+
+func TestOpsgenie(t *testing.T) {
+	t.Parallel()
+
+	var requests []request
+
+	server := newServer(t, `{"result":"Request will be processed"}`, &requests)
+	provider := NewOpsgenie(server.Client(), "secret")
+	provider.URL = server.URL + "/v2/alerts"
+
+	execute(t, provider, map[string]any{"provider": "oncall", "key": "import", "summary": "Import failed", "severity": "warning"})
+	execute(t, provider, map[string]any{"provider": "oncall", "action": "acknowledge", "key": "import"})
+
+	if got := requests[0]; got.authorization != "GenieKey secret" || got.body["alias"] != "import" || got.body["priority"] != "P3" {
+		t.Fatalf("unexpected create request: %+v", got)
+	}
+
+	if got := requests[1]; got.path != "/v2/alerts/import/acknowledge" || got.query != "identifierType=alias" {
+		t.Fatalf("unexpected acknowledge request: %+v", got)
+	}
+
+	if _, err := provider.Trigger(context.Background(), Alert{Summary: "no key"}); err == nil {
+		t.Fatal("expected a missing key error")
+	}
+}
@@ -0,0 +1,215 @@
+// Package alert provides a step paging on-call engineers through incident management services, eg.: PagerDuty or Opsgenie.
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Severities of an alert.
+const (
+	SeverityCritical = "critical"
+	SeverityError    = "error"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// Client sends the provider requests, eg.: http.DefaultClient.
+type Client interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// Alert is an incident to page on-call engineers about.
+type Alert struct {
+	// Key deduplicates the alerts of the same incident, and identifies it to acknowledge or resolve it.
+	Key      string
+	Summary  string
+	Severity string
+	Source   string
+	Details  map[string]any
+}
+
+// Provider creates, acknowledges and resolves incidents.
+type Provider interface {
+	// Trigger creates an incident, or updates the open one with the same key, returning its key.
+	Trigger(ctx context.Context, alert Alert) (string, error)
+	Acknowledge(ctx context.Context, key string) error
+	Resolve(ctx context.Context, key string) error
+}
+
+// send posts the JSON body, decoding the JSON response into out when set.
+func send(ctx context.Context, client Client, url string, header http.Header, body, out any) error {
+	blob, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(blob))
+	if err != nil {
+		return err
+	}
+
+	req.Header = header.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	blob, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%s: %s: %s", url, resp.Status, blob)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.Unmarshal(blob, out)
+}
+
+// DefaultPagerDutyURL is the PagerDuty Events API v2 endpoint.
+const DefaultPagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDuty sends events to a PagerDuty service integration with the Events API v2.
+type PagerDuty struct {
+	Client     Client
+	RoutingKey string
+	URL        string
+}
+
+// NewPagerDuty creates a PagerDuty provider for the integration routing key.
+func NewPagerDuty(client Client, routingKey string) *PagerDuty {
+	return &PagerDuty{Client: client, RoutingKey: routingKey, URL: DefaultPagerDutyURL}
+}
+
+func (p *PagerDuty) event(ctx context.Context, action, key string, payload map[string]any) (string, error) {
+	event := map[string]any{"routing_key": p.RoutingKey, "event_action": action}
+
+	if key != "" {
+		event["dedup_key"] = key
+	}
+
+	if payload != nil {
+		event["payload"] = payload
+	}
+
+	var response struct {
+		DedupKey string `json:"dedup_key"`
+	}
+
+	err := send(ctx, p.Client, p.URL, nil, event, &response)
+
+	return response.DedupKey, err
+}
+
+// Trigger creates an incident, with a key generated by PagerDuty when the alert has none.
+func (p *PagerDuty) Trigger(ctx context.Context, alert Alert) (string, error) {
+	severity := alert.Severity
+	if severity == "" {
+		severity = SeverityError
+	}
+
+	return p.event(ctx, "trigger", alert.Key, map[string]any{
+		"summary":        alert.Summary,
+		"severity":       severity,
+		"source":         alert.Source,
+		"custom_details": alert.Details,
+	})
+}
+
+// Acknowledge acknowledges the incident with the key.
+func (p *PagerDuty) Acknowledge(ctx context.Context, key string) error {
+	_, err := p.event(ctx, "acknowledge", key, nil)
+
+	return err
+}
+
+// Resolve resolves the incident with the key.
+func (p *PagerDuty) Resolve(ctx context.Context, key string) error {
+	_, err := p.event(ctx, "resolve", key, nil)
+
+	return err
+}
+
+// DefaultOpsgenieURL is the Opsgenie Alert API endpoint.
+const DefaultOpsgenieURL = "https://api.opsgenie.com/v2/alerts"
+
+// Opsgenie creates alerts with the Opsgenie Alert API, identified by their alias.
+type Opsgenie struct {
+	Client Client
+	APIKey string
+	URL    string
+}
+
+// NewOpsgenie creates an Opsgenie provider for the API integration key.
+func NewOpsgenie(client Client, apiKey string) *Opsgenie {
+	return &Opsgenie{Client: client, APIKey: apiKey, URL: DefaultOpsgenieURL}
+}
+
+var opsgeniePriorities = map[string]string{
+	SeverityCritical: "P1",
+	SeverityError:    "P2",
+	SeverityWarning:  "P3",
+	SeverityInfo:     "P5",
+}
+
+func (o *Opsgenie) header() http.Header {
+	return http.Header{"Authorization": {"GenieKey " + o.APIKey}}
+}
+
+// Trigger creates an alert, using the key as its alias. Opsgenie requires the key to deduplicate alerts.
+func (o *Opsgenie) Trigger(ctx context.Context, alert Alert) (string, error) {
+	if alert.Key == "" {
+		return "", errors.New("opsgenie alerts require a key")
+	}
+
+	details := make(map[string]string, len(alert.Details))
+	for key, value := range alert.Details {
+		details[key] = fmt.Sprint(value)
+	}
+
+	priority, found := opsgeniePriorities[alert.Severity]
+	if !found {
+		priority = opsgeniePriorities[SeverityError]
+	}
+
+	return alert.Key, send(ctx, o.Client, o.URL, o.header(), map[string]any{
+		"message":  alert.Summary,
+		"alias":    alert.Key,
+		"source":   alert.Source,
+		"priority": priority,
+		"details":  details,
+	}, nil)
+}
+
+// Acknowledge acknowledges the alert with the alias.
+func (o *Opsgenie) Acknowledge(ctx context.Context, key string) error {
+	return send(ctx, o.Client, o.aliasURL(key, "acknowledge"), o.header(), map[string]any{}, nil)
+}
+
+// Resolve closes the alert with the alias.
+func (o *Opsgenie) Resolve(ctx context.Context, key string) error {
+	return send(ctx, o.Client, o.aliasURL(key, "close"), o.header(), map[string]any{}, nil)
+}
+
+func (o *Opsgenie) aliasURL(alias, action string) string {
+	return fmt.Sprintf("%s/%s/%s?identifierType=alias", o.URL, url.PathEscape(alias), action)
+}
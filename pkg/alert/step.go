@@ -0,0 +1,114 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// RegisterStepExecutor registers the alert step using the named providers.
+func RegisterStepExecutor(providers map[string]Provider) {
+	pipeline.RegisterStepExecutor("alert", StepExecutor(providers))
+}
+
+type StepParams struct {
+	Provider expression.String               `yaml:"provider"`
+	Action   expression.String               `yaml:"action"`
+	Key      expression.String               `yaml:"key"`
+	Summary  expression.String               `yaml:"summary"`
+	Severity expression.String               `yaml:"severity"`
+	Source   expression.String               `yaml:"source"`
+	Details  expression.YAML[map[string]any] `yaml:"details"`
+}
+
+// StepExecutor triggers (by default), acknowledges or resolves an incident in a named provider.
+// The incident key and the action are stored in the step variable path.
+//
+// Example YAML:
+//
+//	id: alert-example
+//	steps:
+//	- id: page
+//	  type: alert
+//	  params:
+//	    provider: 'pagerduty'
+//	    key: 'nightly-import'
+//	    summary: 'Nightly import failed'
+//	    severity: 'critical'
+//	    source: 'go-pipeline'
+//	    details:
+//	      rows: '{{ variable . "import.rows" }}'
+//	      error: '{{ variable . "import.error" }}'
+func StepExecutor(providers map[string]Provider) pipeline.StepExecutor {
+	return pipeline.TypedStepExecutor[StepParams](
+		func(ctx context.Context, scope pipeline.Scope, step pipeline.Step, p StepParams) (pipeline.Scope, error) {
+			name, err := p.Provider.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			provider, found := providers[name]
+			if !found {
+				return scope, fmt.Errorf("alert provider %q not registered", name)
+			}
+
+			action, err := p.Action.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			key, err := p.Key.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			switch action {
+			case "", "trigger":
+				action = "trigger"
+
+				key, err = trigger(ctx, scope, provider, key, p)
+			case "acknowledge":
+				err = provider.Acknowledge(ctx, key)
+			case "resolve":
+				err = provider.Resolve(ctx, key)
+			default:
+				return scope, fmt.Errorf("unsupported alert action %q", action)
+			}
+
+			if err != nil {
+				return scope, err
+			}
+
+			log.Log().Info(ctx, "Alert %s: %s", key, action)
+
+			return scope.WithVariable(step.VariablePath(), map[string]any{"key": key, "action": action}), nil
+		},
+	)
+}
+
+func trigger(ctx context.Context, scope pipeline.Scope, provider Provider, key string, p StepParams) (string, error) {
+	alert := Alert{Key: key}
+
+	var err error
+
+	if alert.Summary, err = p.Summary.Eval(ctx, scope); err != nil {
+		return "", err
+	}
+
+	if alert.Severity, err = p.Severity.Eval(ctx, scope); err != nil {
+		return "", err
+	}
+
+	if alert.Source, err = p.Source.Eval(ctx, scope); err != nil {
+		return "", err
+	}
+
+	if alert.Details, err = p.Details.Eval(ctx, scope); err != nil {
+		return "", err
+	}
+
+	return provider.Trigger(ctx, alert)
+}
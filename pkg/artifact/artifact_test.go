@@ -0,0 +1,94 @@
+package artifact
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+func TestLocalStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := NewLocalStore(t.TempDir())
+
+	artifact, err := store.Put(ctx, "exec-1", "reports/summary.txt", strings.NewReader("done"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if artifact.Size != 4 || artifact.Name != "reports/summary.txt" {
+		t.Fatalf("unexpected artifact: %#v", artifact)
+	}
+
+	if _, err := store.Put(ctx, "exec-2", "log.txt", strings.NewReader("log")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := store.Get(ctx, "exec-1", "reports/summary.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	blob, _ := io.ReadAll(content)
+	_ = content.Close()
+
+	if string(blob) != "done" {
+		t.Fatalf("unexpected content: %q", blob)
+	}
+
+	if artifacts, _ := store.List(ctx, "exec-1"); len(artifacts) != 1 {
+		t.Fatalf("unexpected execution artifacts: %#v", artifacts)
+	}
+
+	if artifacts, _ := store.List(ctx, ""); len(artifacts) != 2 {
+		t.Fatalf("unexpected artifacts: %#v", artifacts)
+	}
+
+	if _, err := store.Put(ctx, "exec-1", "../escape.txt", strings.NewReader("")); err == nil {
+		t.Fatal("expected names escaping the execution folder to be rejected")
+	}
+
+	pruned, err := Prune(ctx, store, Retention{MaxAge: time.Hour}, time.Now().Add(2*time.Hour))
+	if err != nil || len(pruned) != 2 {
+		t.Fatalf("unexpected pruned artifacts: %#v %v", pruned, err)
+	}
+
+	if _, err := store.Get(ctx, "exec-1", "reports/summary.txt"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected pruned artifact to be missing: %v", err)
+	}
+}
+
+func TestStepExecutor(t *testing.T) {
+	t.Parallel()
+
+	store := NewLocalStore(t.TempDir())
+	step := pipeline.Step{
+		ID:     "report",
+		Type:   "artifact",
+		Params: map[string]any{"name": "report.txt", "text": `{{ variable . "greeting" }}`},
+	}
+
+	ctx := pipeline.WithExecutionID(context.Background(), "exec-1")
+	scope := pipeline.NewScope(pipeline.Pipelines{}).WithVariable("greeting", "hello")
+
+	scope, err := StepExecutor(store).Execute(ctx, scope, step)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	value, _ := scope.Variable("report")
+	if published, ok := value.(map[string]any); !ok || published["size"] != int64(5) {
+		t.Fatalf("unexpected published artifact: %#v", value)
+	}
+
+	artifacts, err := store.List(ctx, "exec-1")
+	if err != nil || len(artifacts) != 1 || artifacts[0].Name != "report.txt" {
+		t.Fatalf("unexpected artifacts: %#v %v", artifacts, err)
+	}
+}
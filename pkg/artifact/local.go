@@ -0,0 +1,131 @@
+package artifact
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const dirMode = 0o755
+
+// LocalStore stores the artifacts in a directory, under a folder per execution.
+type LocalStore struct {
+	dir string
+}
+
+// NewLocalStore creates a store in the directory, which is created when missing.
+func NewLocalStore(dir string) *LocalStore {
+	return &LocalStore{dir: dir}
+}
+
+func (s *LocalStore) Put(_ context.Context, executionID, name string, content io.Reader) (Artifact, error) {
+	if err := validateName(executionID, name); err != nil {
+		return Artifact{}, err
+	}
+
+	target := s.path(executionID, name)
+	if err := os.MkdirAll(filepath.Dir(target), dirMode); err != nil {
+		return Artifact{}, err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(target), ".artifact-*")
+	if err != nil {
+		return Artifact{}, err
+	}
+
+	defer func() { _ = os.Remove(file.Name()) }()
+
+	_, err = io.Copy(file, content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return Artifact{}, err
+	}
+
+	if err := os.Rename(file.Name(), target); err != nil {
+		return Artifact{}, err
+	}
+
+	return s.stat(executionID, name)
+}
+
+func (s *LocalStore) Get(_ context.Context, executionID, name string) (io.ReadCloser, error) {
+	if err := validateName(executionID, name); err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(s.path(executionID, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+
+	return file, err
+}
+
+func (s *LocalStore) List(_ context.Context, executionID string) ([]Artifact, error) {
+	root := s.dir
+	if executionID != "" {
+		root = filepath.Join(s.dir, executionID)
+	}
+
+	artifacts := []Artifact{}
+
+	err := filepath.WalkDir(root, func(current string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fs.SkipAll
+		}
+
+		if err != nil || d.IsDir() || filepath.Base(current)[0] == '.' {
+			return err
+		}
+
+		rel, err := filepath.Rel(s.dir, current)
+		if err != nil {
+			return err
+		}
+
+		execution, name, _ := cutPath(filepath.ToSlash(rel))
+
+		artifact, err := s.stat(execution, name)
+		if err != nil {
+			return err
+		}
+
+		artifacts = append(artifacts, artifact)
+
+		return nil
+	})
+
+	return artifacts, err
+}
+
+func (s *LocalStore) Delete(_ context.Context, executionID, name string) error {
+	if err := validateName(executionID, name); err != nil {
+		return err
+	}
+
+	err := os.Remove(s.path(executionID, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+
+	return err
+}
+
+func (s *LocalStore) path(executionID, name string) string {
+	return filepath.Join(s.dir, executionID, filepath.FromSlash(name))
+}
+
+func (s *LocalStore) stat(executionID, name string) (Artifact, error) {
+	info, err := os.Stat(s.path(executionID, name))
+	if err != nil {
+		return Artifact{}, err
+	}
+
+	return Artifact{ExecutionID: executionID, Name: name, Size: info.Size(), CreatedAt: info.ModTime()}, nil
+}
@@ -0,0 +1,119 @@
+package artifact
+
+import (
+	"context"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// S3Object describes an object listed by an S3Client.
+type S3Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// S3Client is the subset of an S3 compatible client used by S3Store, so any SDK can be adapted to it.
+// GetObject must return ErrNotFound for missing keys.
+type S3Client interface {
+	PutObject(ctx context.Context, bucket, key string, body io.Reader) error
+	GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, error)
+	ListObjects(ctx context.Context, bucket, prefix string) ([]S3Object, error)
+	DeleteObject(ctx context.Context, bucket, key string) error
+}
+
+// S3Store stores the artifacts in a bucket, under the <prefix>/<execution id>/<name> keys.
+type S3Store struct {
+	client S3Client
+	bucket string
+	prefix string
+}
+
+// NewS3Store creates a store in the bucket, with keys under the optional prefix.
+func NewS3Store(client S3Client, bucket, prefix string) *S3Store {
+	return &S3Store{client: client, bucket: bucket, prefix: strings.Trim(prefix, "/")}
+}
+
+func (s *S3Store) Put(ctx context.Context, executionID, name string, content io.Reader) (Artifact, error) {
+	if err := validateName(executionID, name); err != nil {
+		return Artifact{}, err
+	}
+
+	counter := &countingReader{reader: content}
+	if err := s.client.PutObject(ctx, s.bucket, s.key(executionID, name), counter); err != nil {
+		return Artifact{}, err
+	}
+
+	return Artifact{ExecutionID: executionID, Name: name, Size: counter.size, CreatedAt: time.Now()}, nil
+}
+
+func (s *S3Store) Get(ctx context.Context, executionID, name string) (io.ReadCloser, error) {
+	if err := validateName(executionID, name); err != nil {
+		return nil, err
+	}
+
+	return s.client.GetObject(ctx, s.bucket, s.key(executionID, name))
+}
+
+func (s *S3Store) List(ctx context.Context, executionID string) ([]Artifact, error) {
+	prefix := s.prefix
+	if executionID != "" {
+		prefix = path.Join(prefix, executionID)
+	}
+
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	objects, err := s.client.ListObjects(ctx, s.bucket, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts := make([]Artifact, 0, len(objects))
+
+	for _, object := range objects {
+		rel := strings.TrimPrefix(object.Key, s.prefix)
+		rel = strings.TrimPrefix(rel, "/")
+
+		execution, name, ok := cutPath(rel)
+		if !ok {
+			continue
+		}
+
+		artifacts = append(artifacts, Artifact{ExecutionID: execution, Name: name, Size: object.Size, CreatedAt: object.LastModified})
+	}
+
+	return artifacts, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, executionID, name string) error {
+	if err := validateName(executionID, name); err != nil {
+		return err
+	}
+
+	return s.client.DeleteObject(ctx, s.bucket, s.key(executionID, name))
+}
+
+func (s *S3Store) key(executionID, name string) string {
+	return path.Join(s.prefix, executionID, name)
+}
+
+// cutPath splits a slash separated path into the execution ID and the artifact name.
+func cutPath(rel string) (string, string, bool) {
+	return strings.Cut(rel, "/")
+}
+
+type countingReader struct {
+	reader io.Reader
+	size   int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.size += int64(n)
+
+	return n, err
+}
@@ -0,0 +1,120 @@
+package artifact
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+type options struct {
+	retention Retention
+}
+
+// Option configures the artifact step executor.
+type Option func(*options)
+
+// WithRetention prunes the artifacts not retained by the policy after each publication.
+func WithRetention(retention Retention) Option {
+	return func(o *options) {
+		o.retention = retention
+	}
+}
+
+// RegisterStepExecutor registers the artifact step publishing to the store.
+func RegisterStepExecutor(store Store, opts ...Option) {
+	pipeline.RegisterStepExecutor("artifact", StepExecutor(store, opts...))
+}
+
+type StepParams struct {
+	Name expression.String `yaml:"name"`
+	Path expression.String `yaml:"path"`
+	Text expression.String `yaml:"text"`
+}
+
+// StepExecutor publishes a file, or a text, as a named artifact of the execution.
+// The artifact (execution_id, name, size and created_at) is stored in the step variable path.
+//
+// Example YAML:
+//
+//	id: artifact-example
+//	steps:
+//	- id: report
+//	  type: artifact
+//	  params:
+//	    name: 'reports/summary.json'
+//	    path: '{{ workspace . "summary.json" }}'
+func StepExecutor(store Store, opts ...Option) pipeline.StepExecutor {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return pipeline.TypedStepExecutor[StepParams](
+		func(ctx context.Context, scope pipeline.Scope, step pipeline.Step, p StepParams) (pipeline.Scope, error) {
+			name, err := p.Name.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			content, err := open(ctx, scope, p)
+			if err != nil {
+				return scope, err
+			}
+
+			defer func() { _ = content.Close() }()
+
+			artifact, err := store.Put(ctx, pipeline.ExecutionID(ctx), name, content)
+			if err != nil {
+				return scope, err
+			}
+
+			log.Log().Info(ctx, "Published artifact %s (%d bytes)", artifact.Name, artifact.Size)
+
+			pruned, err := Prune(ctx, store, o.retention, time.Now())
+			if err != nil {
+				return scope, err
+			}
+
+			if len(pruned) > 0 {
+				log.Log().Debug(ctx, "Pruned %d artifacts", len(pruned))
+			}
+
+			return scope.WithVariable(step.VariablePath(), map[string]any{
+				"execution_id": artifact.ExecutionID,
+				"name":         artifact.Name,
+				"size":         artifact.Size,
+				"created_at":   artifact.CreatedAt,
+			}), nil
+		},
+	)
+}
+
+func open(ctx context.Context, scope pipeline.Scope, p StepParams) (io.ReadCloser, error) {
+	path, err := p.Path.Eval(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if path != "" {
+		//nolint:gosec // ignore G304: publishing files chosen by the pipeline is the purpose of the step.
+		return os.Open(path)
+	}
+
+	if p.Text == "" {
+		return nil, errors.New("artifact requires a path or a text")
+	}
+
+	text, err := p.Text.Eval(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(strings.NewReader(text)), nil
+}
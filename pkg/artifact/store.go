@@ -0,0 +1,83 @@
+// Package artifact publishes named files produced by executions (eg.: reports, logs) to a store.
+package artifact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when an artifact doesn't exist in the store.
+var ErrNotFound = errors.New("artifact not found")
+
+// Artifact describes a published file.
+type Artifact struct {
+	ExecutionID string    `json:"execution_id" yaml:"execution_id"`
+	Name        string    `json:"name" yaml:"name"`
+	Size        int64     `json:"size" yaml:"size"`
+	CreatedAt   time.Time `json:"created_at" yaml:"created_at"`
+}
+
+// Store persists the artifacts grouped by execution.
+type Store interface {
+	// Put stores the content as the artifact, replacing an existing one with the same name.
+	Put(ctx context.Context, executionID, name string, content io.Reader) (Artifact, error)
+	// Get returns the artifact content, which must be closed by the caller, or ErrNotFound.
+	Get(ctx context.Context, executionID, name string) (io.ReadCloser, error)
+	// List returns the artifacts of the execution, or of all the executions when executionID is empty.
+	List(ctx context.Context, executionID string) ([]Artifact, error)
+	// Delete removes the artifact.
+	Delete(ctx context.Context, executionID, name string) error
+}
+
+// Retention defines when artifacts are pruned from a store.
+type Retention struct {
+	// MaxAge prunes the artifacts created before it, disabled when zero.
+	MaxAge time.Duration
+}
+
+// Prune deletes the artifacts of all executions not retained by the policy, returning the deleted ones.
+func Prune(ctx context.Context, store Store, retention Retention, now time.Time) ([]Artifact, error) {
+	if retention.MaxAge <= 0 {
+		return nil, nil
+	}
+
+	artifacts, err := store.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []Artifact
+
+	for _, artifact := range artifacts {
+		if now.Sub(artifact.CreatedAt) <= retention.MaxAge {
+			continue
+		}
+
+		if err := store.Delete(ctx, artifact.ExecutionID, artifact.Name); err != nil {
+			return pruned, err
+		}
+
+		pruned = append(pruned, artifact)
+	}
+
+	return pruned, nil
+}
+
+// validateName rejects names escaping the execution folder, eg.: "../report.txt".
+func validateName(executionID, name string) error {
+	if !filepath.IsLocal(executionID) || strings.ContainsAny(executionID, `/\`) {
+		return fmt.Errorf("invalid execution id: %q", executionID)
+	}
+
+	if !filepath.IsLocal(name) || path.Clean(name) != name {
+		return fmt.Errorf("invalid artifact name: %q", name)
+	}
+
+	return nil
+}
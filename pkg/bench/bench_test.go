@@ -0,0 +1,132 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+	"gopkg.in/yaml.v3"
+)
+
+func run(b *testing.B, pipelines pipeline.Pipelines) {
+	b.Helper()
+	b.ReportAllocs()
+
+	for range b.N {
+		_, err := pipelines.Execute(context.Background(), pipeline.NewScope(pipelines), []string{Entrypoint})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDeepNesting(b *testing.B) {
+	for _, depth := range []int{10, 50} {
+		b.Run(fmt.Sprintf("depth=%d", depth), func(b *testing.B) {
+			run(b, DeepNesting(depth))
+		})
+	}
+}
+
+func BenchmarkWideFanout(b *testing.B) {
+	for _, width := range []int{10, 100} {
+		b.Run(fmt.Sprintf("width=%d", width), func(b *testing.B) {
+			run(b, WideFanout(width, 8))
+		})
+	}
+}
+
+func BenchmarkLargeScope(b *testing.B) {
+	for _, size := range []int{100, 1000} {
+		b.Run(fmt.Sprintf("variables=%d", size), func(b *testing.B) {
+			run(b, LargeScope(size))
+		})
+	}
+}
+
+func BenchmarkHeavyTemplating(b *testing.B) {
+	for _, items := range []int{10, 100} {
+		b.Run(fmt.Sprintf("items=%d", items), func(b *testing.B) {
+			run(b, HeavyTemplating(items))
+		})
+	}
+}
+
+func BenchmarkScopeWithVariable(b *testing.B) {
+	scope := pipeline.NewScope(pipeline.Pipelines{})
+	for i := range 1000 {
+		scope = scope.WithVariable(pipeline.VariablePath(fmt.Sprintf("var-%d", i)), i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		scope.WithVariable("extra", 1)
+	}
+}
+
+func BenchmarkExpressionEval(b *testing.B) {
+	scope := pipeline.NewScope(pipeline.Pipelines{}).WithVariable("setup", map[string]any{"counter": 1})
+	expr := expression.String(`{{ add (variableGet . "setup" "counter") 10 }}`)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		if _, err := expr.Eval(context.Background(), scope); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkStepParams(b *testing.B) {
+	const params = `{"method": "GET", "url": "https://example.com/{{ variable . \"id\" }}", "header": {"Accept": "application/json"}}`
+
+	var loaded pipeline.Step
+	if err := yaml.Unmarshal([]byte(`{"id": "request", "type": "set", "params": `+params+`}`), &loaded); err != nil {
+		b.Fatal(err)
+	}
+
+	built := pipeline.Step{ID: loaded.ID, Type: loaded.Type, Params: loaded.Params}
+	executor := pipeline.TypedStepExecutor[pipeline.SetParams](func(_ context.Context, scope pipeline.Scope, _ pipeline.Step, _ pipeline.SetParams) (pipeline.Scope, error) {
+		return scope, nil
+	})
+
+	for name, step := range map[string]pipeline.Step{"loaded": loaded, "built": built} {
+		b.Run(name, func(b *testing.B) {
+			scope := pipeline.NewScope(pipeline.Pipelines{})
+
+			b.ReportAllocs()
+
+			for range b.N {
+				if _, err := executor.Execute(context.Background(), scope, step); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestWorkloads(t *testing.T) {
+	t.Parallel()
+
+	workloads := map[string]pipeline.Pipelines{
+		"deep":     DeepNesting(3),
+		"fanout":   WideFanout(3, 2),
+		"scope":    LargeScope(3),
+		"template": HeavyTemplating(3),
+	}
+
+	for name, pipelines := range workloads {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := pipelines.Execute(context.Background(), pipeline.NewScope(pipelines), []string{Entrypoint}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+// Package bench provides synthetic pipelines to measure the engine performance.
+package bench
+
+import (
+	"fmt"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// Entrypoint is the name of the pipeline to execute in every synthetic workload.
+const Entrypoint = "bench"
+
+// DeepNesting builds a chain of depth pipelines, each one calling the next through a namespaced pipeline step.
+func DeepNesting(depth int) pipeline.Pipelines {
+	pipes := make([]pipeline.Pipeline, 0, depth+1)
+
+	for i := range depth {
+		pipes = append(pipes, pipeline.Pipeline{
+			Name: level(i),
+			Steps: []pipeline.Step{
+				setStep("value", map[string]any{"level": i}),
+				{
+					Type:   "pipeline",
+					Params: map[string]any{"id": fmt.Sprintf("l%d", i), "uses": level(i + 1)},
+				},
+			},
+		})
+	}
+
+	pipes = append(pipes, pipeline.Pipeline{Name: level(depth), Steps: []pipeline.Step{setStep("leaf", map[string]any{"ok": true})}})
+	pipes = append(pipes, pipeline.Pipeline{Name: Entrypoint, Uses: level(0)})
+
+	return pipeline.NewPipelines(pipes...)
+}
+
+// WideFanout builds a pipeline running width branches concurrently, each one setting a variable.
+func WideFanout(width, concurrency int) pipeline.Pipelines {
+	branches := make([]any, width)
+	for i := range branches {
+		branches[i] = map[string]any{
+			"id":    fmt.Sprintf("branch-%d", i),
+			"steps": []any{map[string]any{"id": "value", "type": "set", "params": map[string]any{"index": i}}},
+		}
+	}
+
+	return pipeline.NewPipelines(pipeline.Pipeline{
+		Name: Entrypoint,
+		Steps: []pipeline.Step{{
+			ID:     "fanout",
+			Type:   "fanout",
+			Params: map[string]any{"concurrency": fmt.Sprint(concurrency), "pipelines": branches},
+		}},
+	})
+}
+
+// LargeScope builds a pipeline setting size distinct variables.
+func LargeScope(size int) pipeline.Pipelines {
+	steps := make([]pipeline.Step, size)
+	for i := range steps {
+		steps[i] = setStep(pipeline.VariablePathNode(fmt.Sprintf("var-%d", i)), map[string]any{"value": i})
+	}
+
+	return pipeline.NewPipelines(pipeline.Pipeline{Name: Entrypoint, Steps: steps})
+}
+
+// HeavyTemplating builds a pipeline ranging over items and evaluating sprig-heavy expressions for each one.
+func HeavyTemplating(items int) pipeline.Pipelines {
+	return pipeline.NewPipelines(pipeline.Pipeline{
+		Name: Entrypoint,
+		Steps: []pipeline.Step{
+			setStep("source", map[string]any{"items": fmt.Sprintf(`{{ until %d | toJson }}`, items)}),
+			{
+				ID:   "range",
+				Type: "range",
+				Params: map[string]any{
+					"json": `{{ variableGet . "source" "items" }}`,
+					"steps": []any{map[string]any{
+						"id":   "map",
+						"type": "set",
+						"params": map[string]any{
+							"json":  `{{ dict "item" (variable . "range") "index" (variable . "range.$index") | toJson }}`,
+							"hash":  `{{ variable . "range" | toString | sha256sum }}`,
+							"upper": `{{ printf "item-%v" (variable . "range") | upper | replace "ITEM" "i" }}`,
+						},
+					}},
+				},
+			},
+		},
+	})
+}
+
+func setStep(id pipeline.VariablePathNode, params map[string]any) pipeline.Step {
+	return pipeline.Step{ID: id, Type: "set", Params: params}
+}
+
+func level(i int) string {
+	return fmt.Sprintf("level-%d", i)
+}
@@ -0,0 +1,115 @@
+// Package cache provides the backends of the caches shared across executions with pipeline.WithCache:
+// in memory, on disk or in Redis.
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+type options struct {
+	codec pipeline.Codec
+	now   func() time.Time
+}
+
+// Option configures the Disk and Redis caches.
+type Option func(*options)
+
+// WithCodec encodes the cached values with the codec, pipeline.NewJSONCodec by default.
+func WithCodec(codec pipeline.Codec) Option {
+	return func(o *options) {
+		o.codec = codec
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{codec: pipeline.NewJSONCodec(), now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+type entry struct {
+	value     any
+	expiresAt time.Time
+}
+
+// Memory caches the values in the process memory, as they are: don't change the cached maps and lists.
+// Expired values are dropped when read, or by Prune.
+type Memory struct {
+	mu      sync.Mutex
+	entries map[string]entry
+	now     func() time.Time
+}
+
+// NewMemory creates an empty in-memory cache.
+func NewMemory() *Memory {
+	return &Memory{entries: map[string]entry{}, now: time.Now}
+}
+
+func (m *Memory) Get(_ context.Context, key string) (any, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, found := m.entries[key]
+	if !found {
+		return nil, pipeline.ErrCacheMiss
+	}
+
+	if expired(e.expiresAt, m.now()) {
+		delete(m.entries, key)
+
+		return nil, pipeline.ErrCacheMiss
+	}
+
+	return e.value, nil
+}
+
+func (m *Memory) Set(_ context.Context, key string, value any, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries[key] = entry{value: value, expiresAt: expiration(m.now(), ttl)}
+
+	return nil
+}
+
+func (m *Memory) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+
+	return nil
+}
+
+// Prune drops the expired values, eg.: periodically in long-running processes caching many keys.
+func (m *Memory) Prune() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for key, e := range m.entries {
+		if expired(e.expiresAt, now) {
+			delete(m.entries, key)
+		}
+	}
+}
+
+// expiration returns when the values cached at the time expire after the TTL, or the zero time when it's zero.
+func expiration(now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+
+	return now.Add(ttl)
+}
+
+func expired(expiresAt, now time.Time) bool {
+	return !expiresAt.IsZero() && !now.Before(expiresAt)
+}
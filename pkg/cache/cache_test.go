@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+type redisFake map[string][]byte
+
+func (r redisFake) Get(_ context.Context, key string) ([]byte, error) {
+	value, found := r[key]
+	if !found {
+		return nil, pipeline.ErrCacheMiss
+	}
+
+	return value, nil
+}
+
+func (r redisFake) Set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	r[key] = value
+
+	return nil
+}
+
+func (r redisFake) Del(_ context.Context, key string) error {
+	delete(r, key)
+
+	return nil
+}
+
+func TestCaches(t *testing.T) {
+	t.Parallel()
+
+	redis := redisFake{}
+
+	caches := map[string]pipeline.Cache{
+		"memory": NewMemory(),
+		"disk":   NewDisk(t.TempDir()),
+		"redis":  NewRedis(redis, ""),
+	}
+
+	for name, cache := range caches {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+
+			if _, err := cache.Get(ctx, "token"); !errors.Is(err, pipeline.ErrCacheMiss) {
+				t.Fatalf("expected a miss, got %v", err)
+			}
+
+			value := map[string]any{"access_token": "abc", "expires_in": 3600}
+			if err := cache.Set(ctx, "token", value, time.Hour); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			cached, err := cache.Get(ctx, "token")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if m, ok := cached.(map[string]any); !ok || m["access_token"] != "abc" || m["expires_in"] != 3600 {
+				t.Fatalf("unexpected value: %#v", cached)
+			}
+
+			if err := cache.Delete(ctx, "token"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if _, err := cache.Get(ctx, "token"); !errors.Is(err, pipeline.ErrCacheMiss) {
+				t.Fatalf("expected a miss once deleted, got %v", err)
+			}
+
+			if err := cache.Delete(ctx, "token"); err != nil {
+				t.Fatalf("unexpected error deleting a missing key: %v", err)
+			}
+		})
+	}
+
+	if _, found := redis[DefaultRedisPrefix+"token"]; found {
+		t.Fatal("expected the redis key to be deleted")
+	}
+}
+
+func TestExpiration(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	memory := NewMemory()
+	memory.now = clock
+
+	disk := NewDisk(t.TempDir())
+	disk.o.now = clock
+
+	ctx := context.Background()
+
+	for name, cache := range map[string]pipeline.Cache{"memory": memory, "disk": disk} {
+		if err := cache.Set(ctx, "short", "value", time.Minute); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+
+		if err := cache.Set(ctx, "forever", "value", 0); err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+	}
+
+	now = now.Add(time.Minute)
+
+	memory.Prune()
+
+	if len(memory.entries) != 1 {
+		t.Fatalf("expected the expired entry to be pruned: %v", memory.entries)
+	}
+
+	for name, cache := range map[string]pipeline.Cache{"memory": memory, "disk": disk} {
+		if _, err := cache.Get(ctx, "short"); !errors.Is(err, pipeline.ErrCacheMiss) {
+			t.Fatalf("%s: expected the short key to expire, got %v", name, err)
+		}
+
+		if value, err := cache.Get(ctx, "forever"); err != nil || value != "value" {
+			t.Fatalf("%s: unexpected value: %v, %v", name, value, err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+const dirMode = 0o700
+
+// Disk caches the values in a directory, one file per key named by its hash, so they survive process restarts
+// and are shared by the processes using the directory. Expired files are removed when read.
+type Disk struct {
+	dir string
+	o   options
+}
+
+// diskEntry is the content of the Disk files.
+type diskEntry struct {
+	Key       string    `json:"key"`
+	Codec     string    `json:"codec"`
+	Value     []byte    `json:"value"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NewDisk creates a cache in the directory, which is created when missing.
+func NewDisk(dir string, opts ...Option) *Disk {
+	return &Disk{dir: dir, o: newOptions(opts)}
+}
+
+func (d *Disk) Get(_ context.Context, key string) (any, error) {
+	//nolint:gosec // ignore G304: the file name is the hash of the key.
+	blob, err := os.ReadFile(d.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, pipeline.ErrCacheMiss
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var e diskEntry
+	if err := json.Unmarshal(blob, &e); err != nil {
+		return nil, fmt.Errorf("decoding cache key %s: %w", key, err)
+	}
+
+	if expired(e.ExpiresAt, d.o.now()) {
+		_ = os.Remove(d.path(key))
+
+		return nil, pipeline.ErrCacheMiss
+	}
+
+	if e.Codec != d.o.codec.Name() {
+		return nil, fmt.Errorf("cache key %s encoded with codec %s, not %s", key, e.Codec, d.o.codec.Name())
+	}
+
+	return d.o.codec.Decode(e.Value)
+}
+
+func (d *Disk) Set(_ context.Context, key string, value any, ttl time.Duration) error {
+	encoded, err := d.o.codec.Encode(value)
+	if err != nil {
+		return err
+	}
+
+	blob, err := json.Marshal(diskEntry{Key: key, Codec: d.o.codec.Name(), Value: encoded, ExpiresAt: expiration(d.o.now(), ttl)})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(d.dir, dirMode); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(d.dir, ".cache-*")
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = os.Remove(file.Name()) }()
+
+	_, err = file.Write(blob)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), d.path(key))
+}
+
+func (d *Disk) Delete(_ context.Context, key string) error {
+	err := os.Remove(d.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+func (d *Disk) path(key string) string {
+	hash := sha256.Sum256([]byte(key))
+
+	return filepath.Join(d.dir, hex.EncodeToString(hash[:])+".json")
+}
@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultRedisPrefix prefixes the keys of the Redis cache.
+const DefaultRedisPrefix = "pipeline:cache:"
+
+// RedisClient is the subset of a Redis client used by Redis, so any client can be adapted to it.
+// Get must return pipeline.ErrCacheMiss for missing keys.
+type RedisClient interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set sets the key value, expiring it after the TTL unless it's zero.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Del(ctx context.Context, key string) error
+}
+
+// Redis caches the values in Redis, under the <prefix><key> keys, expired by Redis.
+type Redis struct {
+	client RedisClient
+	prefix string
+	o      options
+}
+
+// NewRedis creates a cache with keys under the prefix, DefaultRedisPrefix when empty.
+func NewRedis(client RedisClient, prefix string, opts ...Option) *Redis {
+	if prefix == "" {
+		prefix = DefaultRedisPrefix
+	}
+
+	return &Redis{client: client, prefix: prefix, o: newOptions(opts)}
+}
+
+func (r *Redis) Get(ctx context.Context, key string) (any, error) {
+	blob, err := r.client.Get(ctx, r.prefix+key)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.o.codec.Decode(blob)
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	blob, err := r.o.codec.Encode(value)
+	if err != nil {
+		return err
+	}
+
+	return r.client.Set(ctx, r.prefix+key, blob, ttl)
+}
+
+func (r *Redis) Delete(ctx context.Context, key string) error {
+	return r.client.Del(ctx, r.prefix+key)
+}
@@ -0,0 +1,120 @@
+package checkpoint
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+type redisFake map[string][]byte
+
+func (r redisFake) Get(_ context.Context, key string) ([]byte, error) {
+	value, found := r[key]
+	if !found {
+		return nil, pipeline.ErrCheckpointNotFound
+	}
+
+	return value, nil
+}
+
+func (r redisFake) Set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	r[key] = value
+
+	return nil
+}
+
+func (r redisFake) Del(_ context.Context, key string) error {
+	delete(r, key)
+
+	return nil
+}
+
+func TestStores(t *testing.T) {
+	t.Parallel()
+
+	redis := redisFake{}
+
+	stores := map[string]pipeline.CheckpointStore{
+		"file":  NewFileStore(t.TempDir()),
+		"redis": NewRedisStore(redis, "", time.Hour),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+			checkpoint := pipeline.Checkpoint{ID: "exec-1", Pipelines: []string{"main"}, Step: 2, Codec: "json", Variables: []byte(`{"a":1}`)}
+
+			if err := store.Save(ctx, checkpoint); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			loaded, err := store.Load(ctx, "exec-1")
+			if err != nil || loaded.Step != 2 || string(loaded.Variables) != `{"a":1}` {
+				t.Fatalf("unexpected checkpoint: %+v, %v", loaded, err)
+			}
+
+			if err := store.Delete(ctx, "exec-1"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if _, err := store.Load(ctx, "exec-1"); !errors.Is(err, pipeline.ErrCheckpointNotFound) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := store.Save(ctx, pipeline.Checkpoint{ID: "../escape"}); err == nil {
+				t.Fatal("expected ids escaping the store to be rejected")
+			}
+		})
+	}
+
+	if _, found := redis[DefaultRedisPrefix+"exec-1"]; found {
+		t.Fatal("expected the redis key to be deleted")
+	}
+}
+
+func TestResume(t *testing.T) {
+	t.Parallel()
+
+	fail := true
+
+	engine := pipeline.NewEngine()
+	engine.RegisterStepExecutor("flaky", pipeline.FuncExecutor(func(context.Context, struct{}) (string, error) {
+		if fail {
+			return "", errors.New("interrupted")
+		}
+
+		return "done", nil
+	}))
+
+	pipelines := pipeline.NewPipelines(pipeline.New("main").
+		Set("started", map[string]any{"at": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}).
+		Step(pipeline.NewStep("flaky", "flaky", map[string]any{})).
+		Build())
+
+	store := NewFileStore(t.TempDir())
+	ctx := pipeline.WithExecutionID(context.Background(), "exec-1")
+
+	if _, err := engine.Execute(ctx, pipeline.NewScope(pipelines), []string{"main"}, pipeline.WithCheckpoints(store)); err == nil {
+		t.Fatal("expected the execution to fail")
+	}
+
+	fail = false
+
+	scope, err := engine.Resume(context.Background(), pipeline.NewScope(pipelines), "exec-1", pipeline.WithCheckpoints(store))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if started, _ := pipeline.Get[map[string]any](scope, "started"); started["at"] != time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) {
+		t.Fatalf("unexpected restored variable: %v", started)
+	}
+
+	if flaky, _ := pipeline.Get[string](scope, "flaky"); flaky != "done" {
+		t.Fatalf("unexpected step result: %v", flaky)
+	}
+}
@@ -0,0 +1,86 @@
+package checkpoint
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+const dirMode = 0o700
+
+// FileStore stores the checkpoints in a directory, as <id>.json files.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a store in the directory, which is created when missing.
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+func (s *FileStore) Save(_ context.Context, checkpoint pipeline.Checkpoint) error {
+	blob, err := encode(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.dir, dirMode); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(s.dir, ".checkpoint-*")
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = os.Remove(file.Name()) }()
+
+	_, err = file.Write(blob)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), s.path(checkpoint.ID))
+}
+
+func (s *FileStore) Load(_ context.Context, id string) (pipeline.Checkpoint, error) {
+	if err := validateID(id); err != nil {
+		return pipeline.Checkpoint{}, err
+	}
+
+	blob, err := os.ReadFile(s.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return pipeline.Checkpoint{}, pipeline.ErrCheckpointNotFound
+	}
+
+	if err != nil {
+		return pipeline.Checkpoint{}, err
+	}
+
+	return decode(blob)
+}
+
+func (s *FileStore) Delete(_ context.Context, id string) error {
+	if err := validateID(id); err != nil {
+		return err
+	}
+
+	err := os.Remove(s.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
@@ -0,0 +1,67 @@
+package checkpoint
+
+import (
+	"context"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// DefaultRedisPrefix prefixes the keys of the RedisStore checkpoints.
+const DefaultRedisPrefix = "pipeline:checkpoint:"
+
+// RedisClient is the subset of a Redis client used by RedisStore, so any client can be adapted to it.
+// Get must return pipeline.ErrCheckpointNotFound for missing keys.
+type RedisClient interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set sets the key value, expiring it after the TTL unless it's zero.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Del(ctx context.Context, key string) error
+}
+
+// RedisStore stores the checkpoints in Redis, under the <prefix><id> keys.
+type RedisStore struct {
+	client RedisClient
+	prefix string
+	ttl    time.Duration
+}
+
+// NewRedisStore creates a store with keys under the prefix, DefaultRedisPrefix when empty, expiring after the TTL
+// unless it's zero, eg.: to drop the checkpoints of abandoned executions.
+func NewRedisStore(client RedisClient, prefix string, ttl time.Duration) *RedisStore {
+	if prefix == "" {
+		prefix = DefaultRedisPrefix
+	}
+
+	return &RedisStore{client: client, prefix: prefix, ttl: ttl}
+}
+
+func (s *RedisStore) Save(ctx context.Context, checkpoint pipeline.Checkpoint) error {
+	blob, err := encode(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	return s.client.Set(ctx, s.prefix+checkpoint.ID, blob, s.ttl)
+}
+
+func (s *RedisStore) Load(ctx context.Context, id string) (pipeline.Checkpoint, error) {
+	if err := validateID(id); err != nil {
+		return pipeline.Checkpoint{}, err
+	}
+
+	blob, err := s.client.Get(ctx, s.prefix+id)
+	if err != nil {
+		return pipeline.Checkpoint{}, err
+	}
+
+	return decode(blob)
+}
+
+func (s *RedisStore) Delete(ctx context.Context, id string) error {
+	if err := validateID(id); err != nil {
+		return err
+	}
+
+	return s.client.Del(ctx, s.prefix+id)
+}
@@ -0,0 +1,70 @@
+package checkpoint
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/artifact"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// S3Store stores the checkpoints in a bucket, under the <prefix>/<id>.json keys.
+// It shares the client adapters of the artifact.S3Store.
+type S3Store struct {
+	client artifact.S3Client
+	bucket string
+	prefix string
+}
+
+// NewS3Store creates a store in the bucket, with keys under the optional prefix.
+func NewS3Store(client artifact.S3Client, bucket, prefix string) *S3Store {
+	return &S3Store{client: client, bucket: bucket, prefix: strings.Trim(prefix, "/")}
+}
+
+func (s *S3Store) Save(ctx context.Context, checkpoint pipeline.Checkpoint) error {
+	blob, err := encode(checkpoint)
+	if err != nil {
+		return err
+	}
+
+	return s.client.PutObject(ctx, s.bucket, s.key(checkpoint.ID), bytes.NewReader(blob))
+}
+
+func (s *S3Store) Load(ctx context.Context, id string) (pipeline.Checkpoint, error) {
+	if err := validateID(id); err != nil {
+		return pipeline.Checkpoint{}, err
+	}
+
+	object, err := s.client.GetObject(ctx, s.bucket, s.key(id))
+	if errors.Is(err, artifact.ErrNotFound) {
+		return pipeline.Checkpoint{}, pipeline.ErrCheckpointNotFound
+	}
+
+	if err != nil {
+		return pipeline.Checkpoint{}, err
+	}
+	defer object.Close()
+
+	blob, err := io.ReadAll(object)
+	if err != nil {
+		return pipeline.Checkpoint{}, err
+	}
+
+	return decode(blob)
+}
+
+func (s *S3Store) Delete(ctx context.Context, id string) error {
+	if err := validateID(id); err != nil {
+		return err
+	}
+
+	return s.client.DeleteObject(ctx, s.bucket, s.key(id))
+}
+
+func (s *S3Store) key(id string) string {
+	return path.Join(s.prefix, id+".json")
+}
@@ -0,0 +1,38 @@
+// Package checkpoint stores the execution checkpoints saved with pipeline.WithCheckpoints, so long-running
+// executions survive process restarts and are resumed with pipeline.Pipelines.Resume.
+package checkpoint
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// validateID rejects IDs escaping the store folder or prefix, eg.: "../checkpoint".
+func validateID(id string) error {
+	if !filepath.IsLocal(id) || strings.ContainsAny(id, `/\`) {
+		return fmt.Errorf("invalid checkpoint id: %q", id)
+	}
+
+	return nil
+}
+
+func encode(checkpoint pipeline.Checkpoint) ([]byte, error) {
+	if err := validateID(checkpoint.ID); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(checkpoint)
+}
+
+func decode(blob []byte) (pipeline.Checkpoint, error) {
+	var checkpoint pipeline.Checkpoint
+	if err := json.Unmarshal(blob, &checkpoint); err != nil {
+		return pipeline.Checkpoint{}, fmt.Errorf("decoding checkpoint: %w", err)
+	}
+
+	return checkpoint, nil
+}
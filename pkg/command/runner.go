@@ -0,0 +1,99 @@
+// Package command runs external commands for the steps wrapping CLIs, eg.: terraform and helm.
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// BudgetSeconds is the budget spent by the seconds ExecRunner commands run, see pipeline.WithBudget.
+const BudgetSeconds = "command.seconds"
+
+// Command is an external command to run.
+type Command struct {
+	Name string
+	Args []string
+	// Dir is the working directory, the process one when empty.
+	Dir string
+	// Env is added to the process environment, in the KEY=value format.
+	Env   []string
+	Stdin io.Reader
+}
+
+// String returns the command line.
+func (c Command) String() string {
+	return strings.Join(append([]string{c.Name}, c.Args...), " ")
+}
+
+// Result is the output of a finished command.
+type Result struct {
+	Stdout []byte
+	Stderr []byte
+}
+
+// ExitError is returned when a command exits with a non-zero code.
+type ExitError struct {
+	Command  Command
+	ExitCode int
+	Stderr   string
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("%s exited with code %d: %s", e.Command, e.ExitCode, strings.TrimSpace(e.Stderr))
+}
+
+// Runner runs commands, replaceable in tests or to run them remotely.
+type Runner interface {
+	Run(ctx context.Context, cmd Command) (Result, error)
+}
+
+// RunnerFunc is a function implementing Runner.
+type RunnerFunc func(ctx context.Context, cmd Command) (Result, error)
+
+// Run calls the function.
+func (f RunnerFunc) Run(ctx context.Context, cmd Command) (Result, error) {
+	return f(ctx, cmd)
+}
+
+// ExecRunner runs commands as local processes, killed when the context is done.
+// Their output is written to the step output streams too, see pipeline.Output.
+type ExecRunner struct{}
+
+// Run runs the command, returning an ExitError when it exits with a non-zero code.
+// The seconds it runs are spent from the BudgetSeconds budget of the execution, failing once it's exhausted.
+func (ExecRunner) Run(ctx context.Context, cmd Command) (Result, error) {
+	//nolint:gosec // ignore G204: running the command chosen by the step is the purpose of the runner.
+	process := exec.CommandContext(ctx, cmd.Name, cmd.Args...)
+	process.Dir = cmd.Dir
+	process.Env = append(os.Environ(), cmd.Env...)
+	process.Stdin = cmd.Stdin
+
+	var stdout, stderr bytes.Buffer
+
+	process.Stdout = io.MultiWriter(&stdout, pipeline.Output(ctx, pipeline.StreamStdout))
+	process.Stderr = io.MultiWriter(&stderr, pipeline.Output(ctx, pipeline.StreamStderr))
+
+	started := time.Now()
+	err := process.Run()
+	result := Result{Stdout: stdout.Bytes(), Stderr: stderr.Bytes()}
+
+	if spendErr := pipeline.Spend(ctx, BudgetSeconds, time.Since(started).Seconds()); spendErr != nil && err == nil {
+		err = spendErr
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return result, &ExitError{Command: cmd, ExitCode: exitErr.ExitCode(), Stderr: stderr.String()}
+	}
+
+	return result, err
+}
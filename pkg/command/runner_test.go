@@ -0,0 +1,33 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExecRunner(t *testing.T) {
+	t.Parallel()
+
+	result, err := ExecRunner{}.Run(context.Background(), Command{
+		Name:  "sh",
+		Args:  []string{"-c", "cat; echo $GREETING"},
+		Env:   []string{"GREETING=hello"},
+		Stdin: strings.NewReader("input\n"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := string(result.Stdout); got != "input\nhello\n" {
+		t.Fatalf("unexpected stdout: %q", got)
+	}
+
+	_, err = ExecRunner{}.Run(context.Background(), Command{Name: "sh", Args: []string{"-c", "echo failed >&2; exit 3"}})
+
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode != 3 || exitErr.Stderr != "failed\n" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,256 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+	"gopkg.in/yaml.v3"
+)
+
+// fakeDriver records the executed statements and the inserted migration versions per DSN.
+type fakeDriver struct {
+	mu        sync.Mutex
+	databases map[string]*fakeDatabase
+}
+
+type fakeDatabase struct {
+	statements []string
+	versions   []int64
+}
+
+var (
+	fake         = &fakeDriver{databases: map[string]*fakeDatabase{}}
+	insertValues = regexp.MustCompile(`VALUES \((\d+),`)
+)
+
+func init() {
+	sql.Register("fake", fake)
+}
+
+func (d *fakeDriver) Open(dsn string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.databases[dsn] == nil {
+		d.databases[dsn] = &fakeDatabase{}
+	}
+
+	return &fakeConn{driver: d, db: d.databases[dsn]}, nil
+}
+
+type fakeConn struct {
+	driver  *fakeDriver
+	db      *fakeDatabase
+	pending []int64
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{conn: c, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return c, nil }
+
+func (c *fakeConn) Commit() error {
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+
+	c.db.versions = append(c.db.versions, c.pending...)
+	c.pending = nil
+
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.pending = nil
+
+	return nil
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	if strings.Contains(s.query, "FAIL") {
+		return nil, errors.New("syntax error")
+	}
+
+	s.conn.driver.mu.Lock()
+	s.conn.db.statements = append(s.conn.db.statements, s.query)
+	s.conn.driver.mu.Unlock()
+
+	if match := insertValues.FindStringSubmatch(s.query); match != nil {
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		s.conn.pending = append(s.conn.pending, version)
+	}
+
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	s.conn.driver.mu.Lock()
+	defer s.conn.driver.mu.Unlock()
+
+	return &fakeRows{versions: append([]int64(nil), s.conn.db.versions...)}, nil
+}
+
+type fakeRows struct {
+	versions []int64
+}
+
+func (r *fakeRows) Columns() []string { return []string{"version"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.versions) == 0 {
+		return io.EOF
+	}
+
+	dest[0], r.versions = r.versions[0], r.versions[1:]
+
+	return nil
+}
+
+func writeMigrations(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestReadMigrations(t *testing.T) {
+	t.Parallel()
+
+	dir := writeMigrations(t, map[string]string{
+		"2_add_email.up.sql":   "ALTER TABLE users ADD email TEXT",
+		"2_add_email.down.sql": "ALTER TABLE users DROP email",
+		"10_index.sql":         "CREATE INDEX users_email ON users (email)",
+		"1_users.sql":          "CREATE TABLE users (id INT)",
+		"README.md":            "ignored",
+	})
+
+	migrations, err := ReadMigrations(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var names []string
+	for _, migration := range migrations {
+		names = append(names, strconv.FormatUint(migration.Version, 10)+"_"+migration.Name)
+	}
+
+	if got, want := strings.Join(names, ","), "1_users,2_add_email,10_index"; got != want {
+		t.Fatalf("unexpected migrations: got %s want %s", got, want)
+	}
+
+	_, err = ReadMigrations(writeMigrations(t, map[string]string{"1_a.sql": "", "01_b.sql": ""}))
+	if err == nil {
+		t.Fatal("expected duplicate version error")
+	}
+}
+
+func TestMigrateExecutor(t *testing.T) {
+	t.Parallel()
+
+	db, err := sql.Open("fake", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := writeMigrations(t, map[string]string{
+		"1_users.sql":        "CREATE TABLE users (id INT)",
+		"2_add_email.up.sql": "ALTER TABLE users ADD email TEXT",
+	})
+
+	var step pipeline.Step
+
+	err = yaml.Unmarshal([]byte(`
+id: migrate
+type: sql-migrate
+params:
+  connection: 'main'
+  dir: '`+dir+`'
+`), &step)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	executor := MigrateExecutor(map[string]*sql.DB{"main": db})
+	scope := pipeline.NewScope(pipeline.Pipelines{})
+
+	for _, want := range []string{"map[applied:[1 2] current:2]", "map[applied:[] current:2]"} {
+		scope, err = executor.Execute(context.Background(), scope, step)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		value, _ := scope.Variable("migrate")
+		if got := fmt.Sprint(value); got != want {
+			t.Fatalf("unexpected result: got %s want %s", got, want)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "3_broken.sql"), []byte("FAIL"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := executor.Execute(context.Background(), scope, step); err == nil || !strings.Contains(err.Error(), "3_broken") {
+		t.Fatalf("expected migration error, got %v", err)
+	}
+
+	if _, err := MigrateExecutor(nil).Execute(context.Background(), scope, step); err == nil {
+		t.Fatal("expected unregistered connection error")
+	}
+}
+
+func TestPooledMigrateExecutor(t *testing.T) {
+	t.Parallel()
+
+	dir := writeMigrations(t, map[string]string{"1_users.sql": "CREATE TABLE users (id INT)"})
+
+	engine := pipeline.NewEngine()
+	engine.RegisterStepExecutor("sql-migrate", PooledMigrateExecutor(map[string]DataSource{"main": {Driver: "fake", DSN: t.Name()}}))
+
+	pipelines := pipeline.NewPipelines(pipeline.New("migrate").
+		Step(pipeline.NewStep("migrate", "sql-migrate", map[string]any{"connection": "main", "dir": dir})).
+		Build())
+
+	for _, want := range []string{"map[applied:[1] current:1]", "map[applied:[] current:1]"} {
+		scope, err := engine.Execute(context.Background(), pipeline.NewScope(pipelines), []string{"migrate"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		value, _ := scope.Variable("migrate")
+		if got := fmt.Sprint(value); got != want {
+			t.Fatalf("unexpected result: got %s want %s", got, want)
+		}
+	}
+
+	if err := engine.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+}
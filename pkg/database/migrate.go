@@ -0,0 +1,158 @@
+// Package database provides steps running against registered database/sql connections.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultMigrationsTable is the table recording the applied migration versions.
+const DefaultMigrationsTable = "schema_migrations"
+
+var (
+	migrationFile = regexp.MustCompile(`^(\d+)_(.+?)(\.up)?\.sql$`)
+	identifier    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+)
+
+// Migration is a SQL file applied once per database.
+type Migration struct {
+	Version uint64
+	Name    string
+	Path    string
+}
+
+// ReadMigrations lists the migrations of a directory ordered by version.
+// Files are named {version}_{name}.sql, or {version}_{name}.up.sql in the golang-migrate format
+// whose .down.sql files are ignored.
+func ReadMigrations(dir string) ([]Migration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		migrations []Migration
+		versions   = map[uint64]string{}
+	)
+
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), ".down.sql") {
+			continue
+		}
+
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration %s: %w", entry.Name(), err)
+		}
+
+		if other, found := versions[version]; found {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, other, entry.Name())
+		}
+
+		versions[version] = entry.Name()
+		migrations = append(migrations, Migration{Version: version, Name: match[2], Path: filepath.Join(dir, entry.Name())})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Migrate applies the migrations not recorded in the table, each in a transaction, and returns the applied ones.
+func Migrate(ctx context.Context, db *sql.DB, migrations []Migration, table string) ([]Migration, error) {
+	if !identifier.MatchString(table) {
+		return nil, fmt.Errorf("invalid migrations table %q", table)
+	}
+
+	_, err := db.ExecContext(ctx, fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (version BIGINT PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP)",
+		table,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("error creating migrations table: %w", err)
+	}
+
+	applied, err := appliedVersions(ctx, db, table)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+
+	for _, migration := range migrations {
+		if applied[migration.Version] {
+			continue
+		}
+
+		if err := apply(ctx, db, migration, table); err != nil {
+			return done, fmt.Errorf("error applying migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+func appliedVersions(ctx context.Context, db *sql.DB, table string) (map[uint64]bool, error) {
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT version FROM %s", table))
+	if err != nil {
+		return nil, fmt.Errorf("error reading applied migrations: %w", err)
+	}
+
+	defer func() { _ = rows.Close() }()
+
+	applied := map[uint64]bool{}
+
+	for rows.Next() {
+		var version uint64
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+
+		applied[version] = true
+	}
+
+	return applied, rows.Err()
+}
+
+func apply(ctx context.Context, db *sql.DB, migration Migration, table string) error {
+	content, err := os.ReadFile(migration.Path)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, string(content)); err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+
+	// The values are inlined because placeholders differ between drivers; the name only holds
+	// file name characters, quotes are escaped anyway.
+	_, err = tx.ExecContext(ctx, fmt.Sprintf(
+		"INSERT INTO %s (version, name) VALUES (%d, '%s')",
+		table, migration.Version, strings.ReplaceAll(migration.Name, "'", "''"),
+	))
+	if err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,130 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// RegisterStepExecutors registers the database steps running against the named connections.
+func RegisterStepExecutors(connections map[string]*sql.DB) {
+	pipeline.RegisterStepExecutor("sql-migrate", MigrateExecutor(connections))
+}
+
+// DataSource is the driver and the data source name a pooled connection is opened with, see RegisterPooledStepExecutors.
+type DataSource struct {
+	Driver string
+	DSN    string
+}
+
+// RegisterPooledStepExecutors registers the database steps running against the named data sources, whose connections
+// are opened on their first use and pooled by the engine, shared by the executions until it's closed,
+// see pipeline.Engine.Close.
+func RegisterPooledStepExecutors(sources map[string]DataSource) {
+	pipeline.RegisterStepExecutor("sql-migrate", PooledMigrateExecutor(sources))
+}
+
+type MigrateParams struct {
+	Connection expression.String `yaml:"connection"`
+	Dir        expression.String `yaml:"dir"`
+	Table      expression.String `yaml:"table"`
+}
+
+// MigrateExecutor applies the SQL migrations of a directory not applied yet to a named connection,
+// recording their versions in a table (schema_migrations by default).
+// The applied versions and the current version are stored in the step variable path.
+//
+// Example YAML:
+//
+//	id: prepare-environment
+//	steps:
+//	- id: migrate
+//	  type: sql-migrate
+//	  params:
+//	    connection: 'main'
+//	    dir: './migrations'
+func MigrateExecutor(connections map[string]*sql.DB) pipeline.StepExecutor {
+	return migrateExecutor(func(_ context.Context, name string) (*sql.DB, error) {
+		db, found := connections[name]
+		if !found {
+			return nil, fmt.Errorf("database connection %q not registered", name)
+		}
+
+		return db, nil
+	})
+}
+
+// PooledMigrateExecutor is the MigrateExecutor of the data sources connections pooled by the engine.
+func PooledMigrateExecutor(sources map[string]DataSource) pipeline.StepExecutor {
+	return migrateExecutor(func(ctx context.Context, name string) (*sql.DB, error) {
+		source, found := sources[name]
+		if !found {
+			return nil, fmt.Errorf("database connection %q not registered", name)
+		}
+
+		return pipeline.Client(ctx, "sql:"+name, func() (*sql.DB, error) {
+			return sql.Open(source.Driver, source.DSN)
+		})
+	})
+}
+
+func migrateExecutor(connection func(ctx context.Context, name string) (*sql.DB, error)) pipeline.StepExecutor {
+	return pipeline.TypedStepExecutor[MigrateParams](
+		func(ctx context.Context, scope pipeline.Scope, step pipeline.Step, p MigrateParams) (pipeline.Scope, error) {
+			name, err := p.Connection.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			db, err := connection(ctx, name)
+			if err != nil {
+				return scope, err
+			}
+
+			dir, err := p.Dir.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			table, err := p.Table.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			if table == "" {
+				table = DefaultMigrationsTable
+			}
+
+			migrations, err := ReadMigrations(dir)
+			if err != nil {
+				return scope, err
+			}
+
+			applied, err := Migrate(ctx, db, migrations, table)
+			if err != nil {
+				return scope, err
+			}
+
+			versions := make([]any, 0, len(applied))
+			for _, migration := range applied {
+				log.Log().Info(ctx, "Applied migration %d_%s", migration.Version, migration.Name)
+
+				versions = append(versions, migration.Version)
+			}
+
+			var current uint64
+			if len(migrations) > 0 {
+				current = migrations[len(migrations)-1].Version
+			}
+
+			return scope.WithVariable(step.VariablePath(), map[string]any{
+				"applied": versions,
+				"current": current,
+			}), nil
+		},
+	)
+}
@@ -0,0 +1,305 @@
+package expression
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConditionFunc is the name of the template function evaluating the structured conditions, see Condition.
+// It's registered by the pipeline package, looking the variables up in the scope.
+const ConditionFunc = "condition"
+
+// Operators of the condition leafs.
+const (
+	OpEq       = "eq"
+	OpNe       = "ne"
+	OpGt       = "gt"
+	OpGte      = "gte"
+	OpLt       = "lt"
+	OpLte      = "lte"
+	OpIn       = "in"
+	OpContains = "contains"
+	OpMatches  = "matches"
+	OpExists   = "exists"
+)
+
+// ErrInvalidCondition is returned for conditions without exactly one of all, any, not or var, or with unknown operators.
+var ErrInvalidCondition = errors.New("invalid condition")
+
+// Condition is a structured alternative to the boolean templates, decoded by Bool from YAML mappings:
+//
+//	condition:
+//	  all:
+//	  - {var: http.$status, op: gte, value: 500}
+//	  - not: {var: env, op: in, value: [dev, test]}
+//	  - any:
+//	    - {var: retries, op: lt, value: 3}
+//	    - {var: force, value: true}
+//
+// Leafs compare the variable with the value by their operator, eq by default. Numbers and numeric strings
+// are compared as numbers, eg.: the "5" values rendered by set steps. Leafs fail on missing variables,
+// but the exists ones: guard them with all, which stops on the first false condition, like any on the first true one.
+type Condition struct {
+	All   []Condition `yaml:"all" json:"all,omitempty"`
+	Any   []Condition `yaml:"any" json:"any,omitempty"`
+	Not   *Condition  `yaml:"not" json:"not,omitempty"`
+	Var   string      `yaml:"var" json:"var,omitempty"`
+	Op    string      `yaml:"op" json:"op,omitempty"`
+	Value any         `yaml:"value" json:"value,omitempty"`
+}
+
+// ParseCondition decodes the JSON condition, validating it.
+func ParseCondition(spec string) (Condition, error) {
+	var c Condition
+	if err := json.Unmarshal([]byte(spec), &c); err != nil {
+		return c, fmt.Errorf("%w: %w", ErrInvalidCondition, err)
+	}
+
+	return c, c.Validate()
+}
+
+// Validate checks the condition and its nested ones.
+func (c Condition) Validate() error {
+	set := 0
+	for _, isSet := range []bool{c.All != nil, c.Any != nil, c.Not != nil, c.Var != ""} {
+		if isSet {
+			set++
+		}
+	}
+
+	if set != 1 {
+		return fmt.Errorf("%w: expected one of all, any, not or var", ErrInvalidCondition)
+	}
+
+	switch {
+	case c.Not != nil:
+		return c.Not.Validate()
+	case c.Var != "":
+		return c.validateLeaf()
+	}
+
+	for _, nested := range append(c.All, c.Any...) {
+		if err := nested.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c Condition) validateLeaf() error {
+	switch c.Op {
+	case "", OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpContains, OpExists:
+	case OpIn:
+		if kind := reflect.ValueOf(c.Value).Kind(); kind != reflect.Slice && kind != reflect.Array {
+			return fmt.Errorf("%w: %s in expects a list value", ErrInvalidCondition, c.Var)
+		}
+	case OpMatches:
+		if _, err := regexp.Compile(fmt.Sprint(c.Value)); err != nil {
+			return fmt.Errorf("%w: %s matches: %w", ErrInvalidCondition, c.Var, err)
+		}
+	default:
+		return fmt.Errorf("%w: unknown operator %s", ErrInvalidCondition, c.Op)
+	}
+
+	return nil
+}
+
+// Eval evaluates the condition with the variables returned by lookup, which reports whether they exist.
+func (c Condition) Eval(lookup func(path string) (any, bool)) (bool, error) {
+	switch {
+	case c.All != nil:
+		for _, nested := range c.All {
+			if ok, err := nested.Eval(lookup); err != nil || !ok {
+				return false, err
+			}
+		}
+
+		return true, nil
+	case c.Any != nil:
+		for _, nested := range c.Any {
+			if ok, err := nested.Eval(lookup); err != nil || ok {
+				return ok, err
+			}
+		}
+
+		return false, nil
+	case c.Not != nil:
+		ok, err := c.Not.Eval(lookup)
+
+		return !ok && err == nil, err
+	}
+
+	value, found := lookup(c.Var)
+	if c.Op == OpExists {
+		return found, nil
+	}
+
+	if !found {
+		return false, fmt.Errorf("condition variable %s not found", c.Var)
+	}
+
+	return c.compare(value)
+}
+
+// compare applies the leaf operator to the variable value.
+func (c Condition) compare(value any) (bool, error) {
+	switch c.Op {
+	case "", OpEq:
+		return conditionEqual(value, c.Value), nil
+	case OpNe:
+		return !conditionEqual(value, c.Value), nil
+	case OpGt, OpGte, OpLt, OpLte:
+		order, err := conditionOrder(value, c.Value)
+		if err != nil {
+			return false, fmt.Errorf("condition %s %s: %w", c.Var, c.Op, err)
+		}
+
+		switch c.Op {
+		case OpGt:
+			return order > 0, nil
+		case OpGte:
+			return order >= 0, nil
+		case OpLt:
+			return order < 0, nil
+		}
+
+		return order <= 0, nil
+	case OpIn:
+		return conditionContains(c.Value, value), nil
+	case OpContains:
+		if text, ok := value.(string); ok {
+			return strings.Contains(text, fmt.Sprint(c.Value)), nil
+		}
+
+		return conditionContains(value, c.Value), nil
+	case OpMatches:
+		return regexp.MatchString(fmt.Sprint(c.Value), fmt.Sprint(value))
+	}
+
+	return false, fmt.Errorf("%w: unknown operator %s", ErrInvalidCondition, c.Op)
+}
+
+// conditionNumber returns the value as a number, when it's a number or a numeric string.
+func conditionNumber(value any) (float64, bool) {
+	v := reflect.ValueOf(value)
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v.String()), 64)
+
+		return n, err == nil
+	}
+
+	return 0, false
+}
+
+func conditionEqual(a, b any) bool {
+	if x, ok := conditionNumber(a); ok {
+		if y, ok := conditionNumber(b); ok {
+			return x == y
+		}
+	}
+
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+
+	_, aString := a.(string)
+	_, bString := b.(string)
+
+	return (aString || bString) && fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// conditionOrder compares numbers, or strings, returning -1, 0 or 1.
+func conditionOrder(a, b any) (int, error) {
+	if x, ok := conditionNumber(a); ok {
+		if y, ok := conditionNumber(b); ok {
+			switch {
+			case x < y:
+				return -1, nil
+			case x > y:
+				return 1, nil
+			}
+
+			return 0, nil
+		}
+	}
+
+	x, aString := a.(string)
+	y, bString := b.(string)
+
+	if !aString || !bString {
+		return 0, fmt.Errorf("can't order %T and %T", a, b)
+	}
+
+	return strings.Compare(x, y), nil
+}
+
+// conditionContains reports whether the list holds the item, or the map the key.
+func conditionContains(container, item any) bool {
+	v := reflect.ValueOf(container)
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			if conditionEqual(v.Index(i).Interface(), item) {
+				return true
+			}
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			if conditionEqual(key.Interface(), item) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// UnmarshalYAML decodes the boolean templates, or the structured conditions of the YAML mappings,
+// compiled into templates calling ConditionFunc.
+func (b *Bool) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		var value string
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+
+		*b = Bool(value)
+
+		return nil
+	}
+
+	var c Condition
+	if err := node.Decode(&c); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCondition, err)
+	}
+
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	spec, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	*b = Bool(fmt.Sprintf("{{ %s . %s }}", ConditionFunc, strconv.Quote(string(spec))))
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package expression
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestConditionEval(t *testing.T) {
+	t.Parallel()
+
+	variables := map[string]any{
+		"status": "503",
+		"count":  3,
+		"env":    "prod",
+		"tags":   []any{"api", "critical"},
+		"labels": map[string]any{"team": "payments"},
+		"force":  true,
+	}
+
+	lookup := func(path string) (any, bool) {
+		value, found := variables[path]
+
+		return value, found
+	}
+
+	tests := []struct {
+		condition string
+		expected  bool
+		err       bool
+	}{
+		{condition: `{var: status, op: gte, value: 500}`, expected: true},
+		{condition: `{var: status, value: 503}`, expected: true},
+		{condition: `{var: count, op: lt, value: "3"}`, expected: false},
+		{condition: `{var: count, op: lte, value: 3.0}`, expected: true},
+		{condition: `{var: env, op: ne, value: dev}`, expected: true},
+		{condition: `{var: env, op: gt, value: dev}`, expected: true},
+		{condition: `{var: env, op: in, value: [dev, test]}`, expected: false},
+		{condition: `{var: tags, op: contains, value: critical}`, expected: true},
+		{condition: `{var: labels, op: contains, value: team}`, expected: true},
+		{condition: `{var: env, op: contains, value: ro}`, expected: true},
+		{condition: `{var: env, op: matches, value: "^p.o"}`, expected: true},
+		{condition: `{var: force, value: true}`, expected: true},
+		{condition: `{var: missing, op: exists}`, expected: false},
+		{condition: `{not: {var: missing, op: exists}}`, expected: true},
+		{condition: `{all: [{var: missing, op: exists}, {var: missing, op: gt, value: 1}]}`, expected: false},
+		{condition: `{any: [{var: env, value: prod}, {var: missing, value: 1}]}`, expected: true},
+		{condition: `{all: [{var: env, value: prod}, {any: [{var: count, op: gt, value: 5}, {var: force, value: true}]}]}`, expected: true},
+		{condition: `{var: missing, value: 1}`, err: true},
+		{condition: `{var: labels, op: gt, value: 1}`, err: true},
+	}
+
+	for _, tc := range tests {
+		var c Condition
+		if err := yaml.Unmarshal([]byte(tc.condition), &c); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.condition, err)
+		}
+
+		if err := c.Validate(); err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.condition, err)
+		}
+
+		got, err := c.Eval(lookup)
+		if tc.err != (err != nil) || got != tc.expected {
+			t.Fatalf("%s: unexpected result: %v, %v", tc.condition, got, err)
+		}
+	}
+}
+
+func TestConditionValidate(t *testing.T) {
+	t.Parallel()
+
+	for _, condition := range []string{
+		`{}`,
+		`{var: env, any: [{var: env}]}`,
+		`{var: env, op: between}`,
+		`{var: env, op: in, value: prod}`,
+		`{var: env, op: matches, value: "("}`,
+		`{all: [{op: eq}]}`,
+	} {
+		var b Bool
+		if err := yaml.Unmarshal([]byte(condition), &b); !errors.Is(err, ErrInvalidCondition) {
+			t.Fatalf("%s: unexpected error: %v", condition, err)
+		}
+	}
+}
+
+func TestBoolUnmarshalYAML(t *testing.T) {
+	t.Parallel()
+
+	var params struct {
+		Template  Bool `yaml:"template"`
+		Condition Bool `yaml:"condition"`
+		Literal   Bool `yaml:"literal"`
+	}
+
+	blob := "template: '{{ true }}'\ncondition: {var: env, value: prod}\nliteral: true\n"
+	if err := yaml.Unmarshal([]byte(blob), &params); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if params.Template != "{{ true }}" || params.Literal != "true" {
+		t.Fatalf("unexpected templates: %q, %q", params.Template, params.Literal)
+	}
+
+	quoted, found := strings.CutPrefix(string(params.Condition), "{{ "+ConditionFunc+" . ")
+	if !found {
+		t.Fatalf("unexpected condition: %q", params.Condition)
+	}
+
+	spec, err := strconv.Unquote(strings.TrimSuffix(quoted, " }}"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c, err := ParseCondition(spec)
+	if err != nil || c.Var != "env" || c.Value != "prod" {
+		t.Fatalf("unexpected condition: %+v, %v", c, err)
+	}
+}
@@ -0,0 +1,28 @@
+package expression
+
+// ExpressionError is returned when an expression can't be parsed, executed or converted to its type.
+// Its message is the message of the wrapped error.
+type ExpressionError struct {
+	Expression string
+	Err        error
+}
+
+func (e *ExpressionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExpressionError) Unwrap() error {
+	return e.Err
+}
+
+func wrap(expression string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := err.(*ExpressionError); ok {
+		return err
+	}
+
+	return &ExpressionError{Expression: expression, Err: err}
+}
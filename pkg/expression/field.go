@@ -4,7 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"math"
 	"strconv"
+	"text/template"
+	"text/template/parse"
 	"time"
 
 	"github.com/crowleyfelix/go-pipeline/pkg/log"
@@ -18,19 +23,19 @@ type String string
 func (f String) Eval(ctx context.Context, scope any) (string, error) {
 	log.Log().Debug(ctx, "field template: %s", f)
 
-	templ, err := templ.Clone()
+	templ, err := templateFrom(ctx).clone()
 	if err != nil {
 		return "", err
 	}
 
 	parsed, err := templ.Parse(string(f))
 	if err != nil {
-		return "", err
+		return "", wrap(string(f), err)
 	}
 
 	var nodeBuff bytes.Buffer
 	if err = parsed.Execute(&nodeBuff, scope); err != nil {
-		return "", err
+		return "", wrap(string(f), err)
 	}
 
 	log.Log().Debug(ctx, "field evaluated: %s", nodeBuff.String())
@@ -38,43 +43,148 @@ func (f String) Eval(ctx context.Context, scope any) (string, error) {
 	return nodeBuff.String(), nil
 }
 
+// Validate parses the expression without evaluating it, failing on syntax errors or functions unknown to the
+// context template.
+func (f String) Validate(ctx context.Context) error {
+	templ, err := templateFrom(ctx).clone()
+	if err != nil {
+		return err
+	}
+
+	_, err = templ.Parse(string(f))
+
+	return wrap(string(f), err)
+}
+
+// valueFunc is the function capturing the value of the single action expressions, see Any.
+const valueFunc = "__expressionValue"
+
+// Any is an expression keeping the type of its value, see Any.Eval.
+type Any String
+
+// Eval the value with the context. Expressions made of a single action, eg.: '{{ variable . "users" }}',
+// evaluate to the value of the action, eg.: the []any or map[string]any variable, instead of its rendered string.
+// Other expressions evaluate to the rendered string, like String.
+func (a Any) Eval(ctx context.Context, scope any) (any, error) {
+	pipe, ok := singleAction(string(a))
+	if !ok {
+		return String(a).Eval(ctx, scope)
+	}
+
+	log.Log().Debug(ctx, "field template: %s", a)
+
+	templ, err := templateFrom(ctx).clone()
+	if err != nil {
+		return nil, err
+	}
+
+	var value any
+
+	templ = templ.Funcs(template.FuncMap{valueFunc: func(v any) string {
+		value = v
+
+		return ""
+	}})
+
+	parsed, err := templ.Parse(fmt.Sprintf("{{ %s (%s) }}", valueFunc, pipe))
+	if err != nil {
+		return nil, wrap(string(a), err)
+	}
+
+	if err := parsed.Execute(io.Discard, scope); err != nil {
+		return nil, wrap(string(a), err)
+	}
+
+	log.Log().Debug(ctx, "field evaluated: %v", value)
+
+	return value, nil
+}
+
+// singleAction returns the pipeline of the expression when it's made of a single action without declarations.
+func singleAction(text string) (*parse.PipeNode, bool) {
+	tree := parse.New("")
+	tree.Mode = parse.SkipFuncCheck
+
+	if _, err := tree.Parse(text, "", "", map[string]*parse.Tree{}); err != nil || len(tree.Root.Nodes) != 1 {
+		return nil, false
+	}
+
+	action, ok := tree.Root.Nodes[0].(*parse.ActionNode)
+	if !ok || len(action.Pipe.Decl) > 0 {
+		return nil, false
+	}
+
+	return action.Pipe, true
+}
+
 type Bool String
 
+// Eval the value with the context, keeping the bool values of single action expressions, see Any.
 func (b Bool) Eval(ctx context.Context, scope any) (bool, error) {
-	value, err := String(b).Eval(ctx, scope)
-
+	value, err := Any(b).Eval(ctx, scope)
 	if err != nil {
 		return false, err
 	}
 
-	if value == "" {
+	if typed, ok := value.(bool); ok {
+		return typed, nil
+	}
+
+	text := render(value)
+	if text == "" {
 		return false, nil
 	}
 
-	return strconv.ParseBool(value)
+	parsed, err := strconv.ParseBool(text)
+
+	return parsed, wrap(string(b), err)
 }
 
 type Int String
 
+// Eval the value with the context, keeping the integral values of single action expressions, see Any.
 func (i Int) Eval(ctx context.Context, scope any) (int, error) {
-	value, err := String(i).Eval(ctx, scope)
-
+	value, err := Any(i).Eval(ctx, scope)
 	if err != nil {
 		return 0, err
 	}
 
-	if value == "" {
+	switch typed := value.(type) {
+	case int:
+		return typed, nil
+	case int64:
+		return int(typed), nil
+	case float64:
+		if typed == math.Trunc(typed) {
+			return int(typed), nil
+		}
+	}
+
+	text := render(value)
+	if text == "" {
 		return 0, nil
 	}
 
-	intValue, err := strconv.Atoi(value)
+	intValue, err := strconv.Atoi(text)
 	if err != nil {
-		return 0, err
+		return 0, wrap(string(i), err)
 	}
 
 	return intValue, nil
 }
 
+// render returns the value as text/template renders it.
+func render(value any) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case nil:
+		return "<no value>"
+	}
+
+	return fmt.Sprint(value)
+}
+
 type Duration String
 
 func (d Duration) Eval(ctx context.Context, scope any) (time.Duration, error) {
@@ -88,23 +198,40 @@ func (d Duration) Eval(ctx context.Context, scope any) (time.Duration, error) {
 		return 0, nil
 	}
 
-	return time.ParseDuration(value)
+	duration, err := time.ParseDuration(value)
+
+	return duration, wrap(string(d), err)
 }
 
 type JSON[T any] String
 
+// Eval the value with the context, decoding the JSON it renders. The values of single action expressions, eg.:
+// '{{ variable . "users" }}', are kept when they're a T, or converted through JSON otherwise, see Any.
 func (j JSON[T]) Eval(ctx context.Context, scope any) (T, error) {
 	var t T
 
-	value, err := String(j).Eval(ctx, scope)
-
+	value, err := Any(j).Eval(ctx, scope)
 	if err != nil {
 		return t, err
 	}
 
-	err = json.Unmarshal([]byte(value), &t)
+	blob, ok := value.(string)
+	if !ok {
+		if typed, ok := value.(T); ok {
+			return typed, nil
+		}
+
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return t, wrap(string(j), err)
+		}
+
+		blob = string(encoded)
+	}
+
+	err = json.Unmarshal([]byte(blob), &t)
 	if err != nil {
-		return t, err
+		return t, wrap(string(j), err)
 	}
 
 	return t, nil
@@ -119,6 +246,20 @@ func (f *YAML[T]) UnmarshalYAML(node *yaml.Node) error {
 	return err
 }
 
+// MarshalYAML encodes the expression back as the YAML it was decoded from.
+func (y YAML[T]) MarshalYAML() (any, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(y), &node); err != nil {
+		return nil, err
+	}
+
+	if len(node.Content) == 0 {
+		return nil, nil
+	}
+
+	return node.Content[0], nil
+}
+
 func (y YAML[T]) Eval(ctx context.Context, scope any) (T, error) {
 	var t T
 
@@ -130,7 +271,7 @@ func (y YAML[T]) Eval(ctx context.Context, scope any) (T, error) {
 
 	err = yaml.Unmarshal([]byte(value), &t)
 	if err != nil {
-		return t, err
+		return t, wrap(string(y), err)
 	}
 
 	return t, nil
@@ -0,0 +1,111 @@
+package expression
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAny(t *testing.T) {
+	t.Parallel()
+
+	scope := map[string]any{
+		"users": []any{"bob", "alice"},
+		"db":    map[string]any{"port": 5432},
+		"big":   int64(9007199254740993),
+	}
+
+	tests := []struct {
+		expression string
+		expected   any
+	}{
+		{expression: `{{ .users }}`, expected: []any{"bob", "alice"}},
+		{expression: `{{- .db -}}`, expected: map[string]any{"port": 5432}},
+		{expression: `{{ .db.port }}`, expected: 5432},
+		{expression: `{{ .big }}`, expected: int64(9007199254740993)},
+		{expression: `{{ .users | len }}`, expected: 2},
+		{expression: `{{ index .users 1 | upper }}`, expected: "ALICE"},
+		{expression: `{{ .missing }}`, expected: nil},
+		{expression: `users: {{ .users }}`, expected: "users: [bob alice]"},
+		{expression: `{{ $users := .users }}{{ $users }}`, expected: "[bob alice]"},
+		{expression: `{{ if .users }}yes{{ end }}`, expected: "yes"},
+		{expression: `plain`, expected: "plain"},
+	}
+
+	for _, tc := range tests {
+		value, err := Any(tc.expression).Eval(context.Background(), scope)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.expression, err)
+		}
+
+		if !reflect.DeepEqual(value, tc.expected) {
+			t.Fatalf("%s: got %#v want %#v", tc.expression, value, tc.expected)
+		}
+	}
+
+	if _, err := Any(`{{ fail "boom" }}`).Eval(context.Background(), scope); err == nil {
+		t.Fatal("expected an error for failing expressions")
+	}
+}
+
+func TestTypedNativeValues(t *testing.T) {
+	t.Parallel()
+
+	scope := map[string]any{"users": []any{"bob", 1}, "count": 3.0, "enabled": true}
+	ctx := context.Background()
+
+	users, err := JSON[[]any](`{{ .users }}`).Eval(ctx, scope)
+	if err != nil || !reflect.DeepEqual(users, []any{"bob", 1}) {
+		t.Fatalf("unexpected users: %#v, %v", users, err)
+	}
+
+	names, err := JSON[[]string](`{{ list "a" "b" }}`).Eval(ctx, scope)
+	if err != nil || !reflect.DeepEqual(names, []string{"a", "b"}) {
+		t.Fatalf("unexpected names: %#v, %v", names, err)
+	}
+
+	encoded, err := JSON[[]any](`{{ .users | toJson }}`).Eval(ctx, scope)
+	if err != nil || !reflect.DeepEqual(encoded, []any{"bob", float64(1)}) {
+		t.Fatalf("unexpected encoded users: %#v, %v", encoded, err)
+	}
+
+	if count, err := Int(`{{ .count }}`).Eval(ctx, scope); err != nil || count != 3 {
+		t.Fatalf("unexpected count: %v, %v", count, err)
+	}
+
+	if _, err := Int(`{{ 2.5 }}`).Eval(ctx, scope); err == nil {
+		t.Fatal("expected an error for fractional numbers")
+	}
+
+	if enabled, err := Bool(`{{ .enabled }}`).Eval(ctx, scope); err != nil || !enabled {
+		t.Fatalf("unexpected enabled: %v, %v", enabled, err)
+	}
+}
+
+func TestStringValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expression string
+		valid      bool
+	}{
+		{expression: `{{ .users | len }}`, valid: true},
+		{expression: `plain`, valid: true},
+		{expression: `{{ .users `, valid: false},
+		{expression: `{{ unknownFunc .users }}`, valid: false},
+		{expression: `{{ if .users }}yes`, valid: false},
+	}
+
+	for _, tc := range tests {
+		err := String(tc.expression).Validate(context.Background())
+		if tc.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.expression, err)
+		}
+
+		var exprErr *ExpressionError
+		if !tc.valid && !errors.As(err, &exprErr) {
+			t.Errorf("%s: expected an ExpressionError, got %v", tc.expression, err)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package expression
+
+import (
+	"context"
+	"testing"
+)
+
+func FuzzStringEval(f *testing.F) {
+	f.Add("plain text")
+	f.Add(`{{ .name | upper }}`)
+	f.Add(`{{ index .items 1 }}`)
+	f.Add(`{{ if eq .name "bob" }}yes{{ else }}no{{ end }}`)
+	f.Add(`{{ .missing.field }}`)
+	f.Add(`{{`)
+
+	scope := map[string]any{
+		"name":  "bob",
+		"items": []any{1, "two", map[string]any{"three": 3}},
+	}
+
+	f.Fuzz(func(t *testing.T, text string) {
+		_, _ = String(text).Eval(context.Background(), scope)
+		_, _ = Any(text).Eval(context.Background(), scope)
+		_, _ = Bool(text).Eval(context.Background(), scope)
+		_, _ = Int(text).Eval(context.Background(), scope)
+		_, _ = JSON[[]any](text).Eval(context.Background(), scope)
+		_, _ = YAML[map[string]any](text).Eval(context.Background(), scope)
+	})
+}
@@ -1,18 +1,69 @@
 package expression
 
 import (
+	"context"
+	"sync"
 	"text/template"
 
 	"github.com/Masterminds/sprig/v3"
 )
 
-var templ *template.Template
+// Template holds the functions available to expressions. It's safe for concurrent use.
+type Template struct {
+	mu    sync.RWMutex
+	templ *template.Template
+}
 
-func init() {
-	templ = template.New("").
-		Funcs(sprig.FuncMap())
+// NewTemplate creates a template with the sprig functions plus the given ones.
+func NewTemplate(funcs ...template.FuncMap) *Template {
+	return NewBaseTemplate(sprig.FuncMap(), funcs...)
 }
 
+// NewBaseTemplate creates a template with the base functions instead of the sprig ones, plus the given ones,
+// eg.: to keep the functions of a previous sprig version available to existing expressions.
+func NewBaseTemplate(base template.FuncMap, funcs ...template.FuncMap) *Template {
+	t := &Template{templ: template.New("").Funcs(base)}
+	for _, f := range funcs {
+		t.RegisterFuncs(f)
+	}
+
+	return t
+}
+
+// RegisterFuncs makes the functions available to expressions evaluated with the template.
+func (t *Template) RegisterFuncs(funcs template.FuncMap) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.templ = t.templ.Funcs(funcs)
+}
+
+func (t *Template) clone() (*template.Template, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.templ.Clone()
+}
+
+var defaultTemplate = NewTemplate()
+
+// RegisterFuncs makes the functions available to expressions evaluated without a context template, see WithTemplate.
+// It's safe for concurrent use.
 func RegisterFuncs(funcs template.FuncMap) {
-	templ = templ.Funcs(funcs)
+	defaultTemplate.RegisterFuncs(funcs)
+}
+
+type templateKey struct{}
+
+// WithTemplate returns a copy of the context whose expressions are evaluated with the template functions.
+func WithTemplate(ctx context.Context, t *Template) context.Context {
+	return context.WithValue(ctx, templateKey{}, t)
+}
+
+func templateFrom(ctx context.Context) *Template {
+	if t, ok := ctx.Value(templateKey{}).(*Template); ok {
+		return t
+	}
+
+	return defaultTemplate
 }
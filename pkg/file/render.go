@@ -0,0 +1,182 @@
+package file
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	RenderModeAggregate = "aggregate"
+	RenderModeRecord    = "record"
+)
+
+type RenderParams struct {
+	Data     expression.String `yaml:"data"`
+	Format   expression.String `yaml:"format"`
+	Template expression.String `yaml:"template"`
+	Mode     expression.String `yaml:"mode"`
+	Output   expression.String `yaml:"output"`
+}
+
+// RenderExecutor renders a template file with the records of a JSON, YAML or CSV data file (the format is
+// inferred from the extension unless set), once with all the records (aggregate mode, the default) or once
+// per record (record mode), eg.: for reports and mail merges.
+// The template and the output path are evaluated with the records, index and record fields,
+// and the scope field to use the scope functions (eg.: {{ variable .scope "id" }}).
+// The rendered text, or the output path when written to a file, is stored in the step variable path:
+// a map with output and text in aggregate mode, or a list of them in record mode.
+//
+// Example YAML:
+//
+//	id: render-example
+//	steps:
+//	- id: letters
+//	  type: file-render
+//	  params:
+//	    data: './customers.csv'
+//	    template: './letter.tmpl'
+//	    mode: 'record'
+//	    output: '{{ workspace .scope (printf "%s.txt" .record.id) }}'
+func RenderExecutor(ctx context.Context, scope pipeline.Scope, step pipeline.Step, params RenderParams) (pipeline.Scope, error) {
+	dataPath, err := params.Data.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	format, err := params.Format.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	records, err := readRecords(dataPath, format)
+	if err != nil {
+		return scope, err
+	}
+
+	templatePath, err := params.Template.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	//nolint:gosec // ignore G304: reading the template chosen by the pipeline is the purpose of the step.
+	content, err := os.ReadFile(templatePath)
+	if err != nil {
+		return scope, err
+	}
+
+	mode, err := params.Mode.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	data := map[string]any{"records": records, "scope": scope}
+
+	switch mode {
+	case "", RenderModeAggregate:
+		rendered, err := render(ctx, expression.String(content), params.Output, data)
+
+		return scope.WithVariable(step.VariablePath(), rendered), err
+	case RenderModeRecord:
+		rendered := make([]any, 0, len(records))
+
+		for index, record := range records {
+			data["index"], data["record"] = index, record
+
+			result, err := render(ctx, expression.String(content), params.Output, data)
+			if err != nil {
+				return scope, fmt.Errorf("error rendering record %d: %w", index, err)
+			}
+
+			rendered = append(rendered, result)
+		}
+
+		return scope.WithVariable(step.VariablePath(), rendered), nil
+	default:
+		return scope, fmt.Errorf("unsupported render mode %q", mode)
+	}
+}
+
+// render evaluates the template with the data, writing it to the output when set.
+func render(ctx context.Context, template, output expression.String, data map[string]any) (map[string]any, error) {
+	text, err := template.Eval(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := output.Eval(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if path == "" {
+		return map[string]any{"text": text}, nil
+	}
+
+	return map[string]any{"output": path}, os.WriteFile(path, []byte(text), fileMode)
+}
+
+// readRecords reads the data file as a list of records, or a single record for other documents.
+func readRecords(path, format string) ([]any, error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	//nolint:gosec // ignore G304: reading the data chosen by the pipeline is the purpose of the step.
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var document any
+
+	switch format {
+	case "json":
+		err = json.Unmarshal(content, &document)
+	case "yaml", "yml":
+		err = yaml.Unmarshal(content, &document)
+	case "csv":
+		return readCSV(content)
+	default:
+		return nil, fmt.Errorf("unsupported data format %q", format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if records, ok := document.([]any); ok {
+		return records, nil
+	}
+
+	return []any{document}, nil
+}
+
+// readCSV reads the rows as records keyed by the header columns.
+func readCSV(content []byte) ([]any, error) {
+	rows, err := csv.NewReader(strings.NewReader(string(content))).ReadAll()
+	if err != nil || len(rows) == 0 {
+		return []any{}, err
+	}
+
+	records := make([]any, 0, len(rows)-1)
+
+	for _, row := range rows[1:] {
+		record := make(map[string]any, len(row))
+		for i, column := range rows[0] {
+			record[column] = row[i]
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
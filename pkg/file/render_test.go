@@ -0,0 +1,84 @@
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+func TestRenderExecutor(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"customers.csv":  "id,name\n1,Ada\n2,Grace\n",
+		"customers.yaml": "- id: 1\n  name: Ada\n- id: 2\n  name: Grace\n",
+		"letter.tmpl":    `Dear {{ .record.name }}, from {{ variable .scope "sender" }}`,
+		"report.tmpl":    `{{ range .records }}{{ .name }};{{ end }}`,
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := func(name string) expression.String {
+		return expression.String(filepath.Join(dir, name))
+	}
+
+	tests := []struct {
+		name     string
+		params   RenderParams
+		expected string
+	}{
+		{
+			name:     "aggregate",
+			params:   RenderParams{Data: path("customers.yaml"), Template: path("report.tmpl")},
+			expected: "map[text:Ada;Grace;]",
+		},
+		{
+			name:     "per record",
+			params:   RenderParams{Data: path("customers.csv"), Template: path("letter.tmpl"), Mode: RenderModeRecord},
+			expected: "[map[text:Dear Ada, from Acme] map[text:Dear Grace, from Acme]]",
+		},
+		{
+			name:     "per record to files",
+			params:   RenderParams{Data: path("customers.csv"), Template: path("letter.tmpl"), Mode: RenderModeRecord, Output: path("letter-{{ .record.id }}.txt")},
+			expected: fmt.Sprintf("[map[output:%s] map[output:%s]]", path("letter-1.txt"), path("letter-2.txt")),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			scope := pipeline.NewScope(pipeline.Pipelines{}).WithVariable("sender", "Acme")
+			step := pipeline.Step{ID: "rendered", Type: "file-render"}
+
+			scope, err := RenderExecutor(context.Background(), scope, step, tc.params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			rendered, _ := scope.Variable("rendered")
+			if got := fmt.Sprint(rendered); got != tc.expected {
+				t.Fatalf("unexpected result:\ngot:  %s\nwant: %s", got, tc.expected)
+			}
+
+			if tc.params.Output == "" {
+				return
+			}
+
+			content, err := os.ReadFile(string(path("letter-2.txt")))
+			if err != nil || string(content) != "Dear Grace, from Acme" {
+				t.Fatalf("unexpected letter: %s %v", content, err)
+			}
+		})
+	}
+}
@@ -12,6 +12,7 @@ const fileMode = 0644
 
 func RegisterStepExecutors() {
 	pipeline.RegisterStepExecutor("file-write", pipeline.TypedStepExecutor[WriteParams](WriteExecutor))
+	pipeline.RegisterStepExecutor("file-render", pipeline.TypedStepExecutor[RenderParams](RenderExecutor))
 }
 
 type WriteParams struct {
@@ -22,6 +23,8 @@ type WriteParams struct {
 
 // WriteExecutor writes the provided text to a file at the specified path.
 // It supports appending to the file if the `append` parameter is set to true.
+// Relative paths are resolved from the process working directory; use the workspace function
+// to write in the execution working directory instead.
 //
 // Example YAML:
 //
@@ -30,7 +33,7 @@ type WriteParams struct {
 //	- id: write-step
 //	  type: file-write
 //	  params:
-//	    path: './output.txt'
+//	    path: '{{ workspace . "output.txt" }}'
 //	    text: 'Hello, World!'
 //	    append: true
 func WriteExecutor(ctx context.Context, scope pipeline.Scope, step pipeline.Step, params WriteParams) (pipeline.Scope, error) {
@@ -0,0 +1,139 @@
+package helm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/command"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// fakeHelm answers the helm commands with the given statuses, one per install, upgrade or status call.
+type fakeHelm struct {
+	revision int
+	statuses []string
+	commands []string
+	values   string
+}
+
+func (f *fakeHelm) Run(_ context.Context, cmd command.Command) (command.Result, error) {
+	f.commands = append(f.commands, strings.Join(cmd.Args, " "))
+
+	if cmd.Stdin != nil {
+		values, _ := io.ReadAll(cmd.Stdin)
+		f.values = string(values)
+	}
+
+	switch cmd.Args[0] {
+	case "install", "upgrade", "status":
+		status := f.statuses[0]
+		if len(f.statuses) > 1 {
+			f.statuses = f.statuses[1:]
+		}
+
+		return command.Result{Stdout: []byte(fmt.Sprintf(
+			`{"name":"api","namespace":"staging","version":%d,"info":{"status":%q}}`, f.revision, status,
+		))}, nil
+	}
+
+	return command.Result{}, nil
+}
+
+func params(command string, extra map[string]any) map[string]any {
+	p := map[string]any{
+		"command":       command,
+		"release":       "api",
+		"chart":         "./charts/api",
+		"namespace":     "staging",
+		"poll_interval": "1ms",
+		"values":        map[string]any{"image": map[string]any{"tag": `{{ variable . "tag" }}`}},
+	}
+
+	for key, value := range extra {
+		p[key] = value
+	}
+
+	return p
+}
+
+func TestStepExecutor(t *testing.T) {
+	t.Parallel()
+
+	helm := &fakeHelm{revision: 2, statuses: []string{"pending-upgrade", "pending-upgrade", "deployed"}}
+	step := pipeline.NewStep("deploy", "helm", params("upgrade", map[string]any{"version": "1.4.0"}))
+	scope := pipeline.NewScope(pipeline.Pipelines{}).WithVariable("tag", "v2")
+
+	scope, err := StepExecutor(helm).Execute(context.Background(), scope, step)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		"upgrade --install api ./charts/api --version 1.4.0 --values - --output json --namespace staging",
+		"status api --output json --namespace staging",
+		"status api --output json --namespace staging",
+	}
+	if got := strings.Join(helm.commands, "\n"); got != strings.Join(want, "\n") {
+		t.Fatalf("unexpected commands:\n%s", got)
+	}
+
+	if helm.values != "image:\n    tag: v2\n" {
+		t.Fatalf("unexpected values: %q", helm.values)
+	}
+
+	value, _ := scope.Variable("deploy")
+	if got := fmt.Sprint(value); got != "map[namespace:staging release:api revision:2 status:deployed]" {
+		t.Fatalf("unexpected result: %s", got)
+	}
+}
+
+func TestStepExecutorRollback(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		revision int
+		undo     string
+	}{
+		{name: "rolls back upgrades", revision: 3, undo: "rollback api --wait --namespace staging"},
+		{name: "uninstalls first installs", revision: 1, undo: "uninstall api --namespace staging"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			helm := &fakeHelm{revision: tc.revision, statuses: []string{"failed"}}
+			step := pipeline.NewStep("deploy", "helm", params("upgrade", map[string]any{"rollback_on_failure": true}))
+
+			_, err := StepExecutor(helm).Execute(context.Background(), pipeline.NewScope(pipeline.Pipelines{}).WithVariable("tag", "v1"), step)
+			if !errors.Is(err, ErrReleaseFailed) {
+				t.Fatalf("expected release failure, got %v", err)
+			}
+
+			if got := helm.commands[len(helm.commands)-1]; got != tc.undo {
+				t.Fatalf("unexpected undo command: %s", got)
+			}
+		})
+	}
+}
+
+func TestStepExecutorUninstall(t *testing.T) {
+	t.Parallel()
+
+	helm := &fakeHelm{}
+	step := pipeline.NewStep("remove", "helm", params("uninstall", nil))
+
+	scope, err := StepExecutor(helm).Execute(context.Background(), pipeline.NewScope(pipeline.Pipelines{}).WithVariable("tag", "v1"), step)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if status, _ := pipeline.Get[map[string]any](scope, "remove"); status["status"] != "uninstalled" || helm.commands[0] != "uninstall api --namespace staging" {
+		t.Fatalf("unexpected result: %v %v", status, helm.commands)
+	}
+}
@@ -0,0 +1,331 @@
+// Package helm provides a step deploying Helm charts with the helm CLI.
+package helm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/command"
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultBinary is the CLI run by the step, see WithBinary.
+	DefaultBinary = "helm"
+	// DefaultPollInterval is how often the release status is checked.
+	DefaultPollInterval = 2 * time.Second
+	// DefaultTimeout is how long a release can stay pending.
+	DefaultTimeout = 5 * time.Minute
+
+	statusDeployed = "deployed"
+)
+
+// ErrReleaseFailed is returned when a release isn't deployed, eg.: failed or pending after the timeout.
+var ErrReleaseFailed = errors.New("helm release failed")
+
+type options struct {
+	binary string
+}
+
+// Option configures the helm step executor.
+type Option func(*options)
+
+// WithBinary sets the helm CLI path.
+func WithBinary(name string) Option {
+	return func(o *options) {
+		o.binary = name
+	}
+}
+
+// RegisterStepExecutor registers the helm step running the CLI with the runner.
+func RegisterStepExecutor(runner command.Runner, opts ...Option) {
+	pipeline.RegisterStepExecutor("helm", StepExecutor(runner, opts...))
+}
+
+type StepParams struct {
+	Command           expression.String               `yaml:"command"`
+	Release           expression.String               `yaml:"release"`
+	Chart             expression.String               `yaml:"chart"`
+	Version           expression.String               `yaml:"version"`
+	Namespace         expression.String               `yaml:"namespace"`
+	Values            expression.YAML[map[string]any] `yaml:"values"`
+	Timeout           expression.Duration             `yaml:"timeout"`
+	PollInterval      expression.Duration             `yaml:"poll_interval"`
+	RollbackOnFailure expression.Bool                 `yaml:"rollback_on_failure"`
+}
+
+// StepExecutor installs, upgrades (installing when missing) or uninstalls a release. Install and upgrade pass the
+// values rendered from the scope to the chart, then poll the release status until deployed, failing with
+// ErrReleaseFailed when it fails or is still pending after the timeout. With rollback_on_failure, failed upgrades
+// are rolled back to the previous revision and failed installs are uninstalled.
+// The release, namespace, revision and status are stored in the step variable path.
+//
+// Example YAML:
+//
+//	id: helm-example
+//	steps:
+//	- id: deploy
+//	  type: helm
+//	  params:
+//	    command: 'upgrade'
+//	    release: 'api'
+//	    chart: 'oci://registry.example.com/charts/api'
+//	    version: '1.4.0'
+//	    namespace: 'staging'
+//	    values:
+//	      image:
+//	        tag: '{{ variable . "build.tag" }}'
+//	    rollback_on_failure: true
+func StepExecutor(runner command.Runner, opts ...Option) pipeline.StepExecutor {
+	o := options{binary: DefaultBinary}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return pipeline.TypedStepExecutor[StepParams](
+		func(ctx context.Context, scope pipeline.Scope, step pipeline.Step, p StepParams) (pipeline.Scope, error) {
+			cli, err := p.cli(ctx, scope, runner, o.binary)
+			if err != nil {
+				return scope, err
+			}
+
+			name, err := p.Command.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			var result release
+
+			switch name {
+			case "install", "upgrade":
+				result, err = cli.deploy(ctx, name)
+			case "uninstall":
+				result, err = cli.uninstall(ctx)
+			default:
+				return scope, fmt.Errorf("unsupported helm command %q", name)
+			}
+
+			if err != nil {
+				return scope, err
+			}
+
+			return scope.WithVariable(step.VariablePath(), map[string]any{
+				"release":   result.Name,
+				"namespace": result.Namespace,
+				"revision":  result.Version,
+				"status":    result.Info.Status,
+			}), nil
+		},
+	)
+}
+
+// release is the JSON output of the helm install, upgrade and status commands.
+type release struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version"`
+	Info      struct {
+		Status string `json:"status"`
+	} `json:"info"`
+}
+
+// cli runs the helm commands of a release.
+type cli struct {
+	runner   command.Runner
+	binary   string
+	release  string
+	chart    string
+	version  string
+	ns       string
+	values   map[string]any
+	timeout  time.Duration
+	interval time.Duration
+	rollback bool
+}
+
+func (p StepParams) cli(ctx context.Context, scope pipeline.Scope, runner command.Runner, binary string) (cli, error) {
+	c := cli{runner: runner, binary: binary}
+
+	var err error
+
+	if c.release, err = p.Release.Eval(ctx, scope); err != nil {
+		return c, err
+	}
+
+	if c.chart, err = p.Chart.Eval(ctx, scope); err != nil {
+		return c, err
+	}
+
+	if c.version, err = p.Version.Eval(ctx, scope); err != nil {
+		return c, err
+	}
+
+	if c.ns, err = p.Namespace.Eval(ctx, scope); err != nil {
+		return c, err
+	}
+
+	if c.release == "" {
+		return c, errors.New("helm requires a release")
+	}
+
+	if c.values, err = p.Values.Eval(ctx, scope); err != nil {
+		return c, err
+	}
+
+	if c.timeout, err = p.Timeout.Eval(ctx, scope); err != nil {
+		return c, err
+	}
+
+	if c.interval, err = p.PollInterval.Eval(ctx, scope); err != nil {
+		return c, err
+	}
+
+	if c.rollback, err = p.RollbackOnFailure.Eval(ctx, scope); err != nil {
+		return c, err
+	}
+
+	if c.timeout <= 0 {
+		c.timeout = DefaultTimeout
+	}
+
+	if c.interval <= 0 {
+		c.interval = DefaultPollInterval
+	}
+
+	return c, nil
+}
+
+func (c cli) run(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	if c.ns != "" {
+		args = append(args, "--namespace", c.ns)
+	}
+
+	cmd := command.Command{Name: c.binary, Args: args}
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+
+	log.Log().Debug(ctx, "Running %s", cmd)
+
+	result, err := c.runner.Run(ctx, cmd)
+
+	return result.Stdout, err
+}
+
+func (c cli) deploy(ctx context.Context, name string) (release, error) {
+	values, err := yaml.Marshal(c.values)
+	if err != nil {
+		return release{}, err
+	}
+
+	args := []string{"install", c.release, c.chart}
+	if name == "upgrade" {
+		args = []string{"upgrade", "--install", c.release, c.chart}
+	}
+
+	if c.version != "" {
+		args = append(args, "--version", c.version)
+	}
+
+	args = append(args, "--values", "-", "--output", "json")
+
+	stdout, err := c.run(ctx, values, args...)
+	if err == nil {
+		var deployed release
+
+		if err = json.Unmarshal(stdout, &deployed); err == nil {
+			deployed, err = c.wait(ctx, deployed)
+		}
+
+		if err == nil {
+			return deployed, nil
+		}
+	}
+
+	if c.rollback {
+		err = errors.Join(err, c.undo(ctx))
+	}
+
+	return release{}, err
+}
+
+// wait polls the release status while pending.
+func (c cli) wait(ctx context.Context, current release) (release, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for strings.HasPrefix(current.Info.Status, "pending") {
+		select {
+		case <-ctx.Done():
+			return current, fmt.Errorf("%w: %s still %s: %w", ErrReleaseFailed, c.release, current.Info.Status, ctx.Err())
+		case <-ticker.C:
+		}
+
+		stdout, err := c.run(ctx, nil, "status", c.release, "--output", "json")
+		if err != nil {
+			return current, err
+		}
+
+		if err := json.Unmarshal(stdout, &current); err != nil {
+			return current, err
+		}
+	}
+
+	if current.Info.Status != statusDeployed {
+		return current, fmt.Errorf("%w: %s is %s", ErrReleaseFailed, c.release, current.Info.Status)
+	}
+
+	return current, nil
+}
+
+// undo rolls the release back to the previous revision, or uninstalls it when it has no previous revision.
+// It uses a context not canceled by the failure, eg.: a timeout.
+func (c cli) undo(ctx context.Context) error {
+	ctx = context.WithoutCancel(ctx)
+
+	stdout, err := c.run(ctx, nil, "status", c.release, "--output", "json")
+	if err != nil {
+		return err
+	}
+
+	var current release
+	if err := json.Unmarshal(stdout, &current); err != nil {
+		return err
+	}
+
+	if current.Version <= 1 {
+		log.Log().Info(ctx, "Uninstalling failed release %s", c.release)
+
+		_, err = c.run(ctx, nil, "uninstall", c.release)
+
+		return err
+	}
+
+	log.Log().Info(ctx, "Rolling back release %s to the previous revision", c.release)
+
+	_, err = c.run(ctx, nil, "rollback", c.release, "--wait")
+
+	return err
+}
+
+func (c cli) uninstall(ctx context.Context) (release, error) {
+	if _, err := c.run(ctx, nil, "uninstall", c.release); err != nil {
+		return release{}, err
+	}
+
+	uninstalled := release{Name: c.release, Namespace: c.ns}
+	uninstalled.Info.Status = "uninstalled"
+
+	return uninstalled, nil
+}
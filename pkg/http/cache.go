@@ -0,0 +1,225 @@
+package http
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// Cache statuses of the responses, set in the step $cache path when caching, see WithCache.
+const (
+	// CacheMiss is a response requested without a cached one.
+	CacheMiss = "miss"
+	// CacheHit is a fresh cached response, returned without requesting.
+	CacheHit = "hit"
+	// CacheRevalidated is a cached response confirmed by the server with 304 Not Modified.
+	CacheRevalidated = "revalidated"
+)
+
+// WithCache caches the GET responses within each execution, so pipelines polling the same endpoints
+// (eg.: within until loops) don't repeat the requests. Responses are fresh for their Cache-Control max-age
+// or until their Expires header, and stale responses with an ETag or Last-Modified header are revalidated
+// with conditional requests. Responses with Cache-Control no-store aren't cached, and requests with
+// Cache-Control no-cache or no-store revalidate or skip the cached responses.
+func WithCache() Option {
+	return func(o *options) {
+		o.cache = &caches{executions: map[string]*cache{}}
+	}
+}
+
+// caches are the caches of the running executions, removed once they finish.
+type caches struct {
+	mu         sync.Mutex
+	executions map[string]*cache
+}
+
+// of returns the cache of the execution running with the context, nil when not caching or outside executions.
+func (c *caches) of(ctx context.Context) *cache {
+	id := pipeline.ExecutionID(ctx)
+	if c == nil || id == "" {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if current, found := c.executions[id]; found {
+		return current
+	}
+
+	current := &cache{entries: map[string]cacheEntry{}}
+
+	err := pipeline.OnFinish(ctx, func(context.Context, error) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		delete(c.executions, id)
+	})
+	if err != nil {
+		return nil
+	}
+
+	c.executions[id] = current
+
+	return current
+}
+
+type cacheEntry struct {
+	response *Response
+	expires  time.Time
+}
+
+// cache holds the responses of an execution by request.
+type cache struct {
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// do returns the cached response of the request when fresh, otherwise requests it with fetch,
+// conditionally when a stale response is cached, and caches the response.
+func (c *cache) do(req *http.Request, fetch func(req *http.Request) (*Response, error)) (*Response, string, error) {
+	directives := cacheControl(req.Header)
+
+	if c == nil || req.Method != http.MethodGet || directives.has("no-store") {
+		resp, err := fetch(req)
+
+		return resp, "", err
+	}
+
+	key := cacheKey(req)
+
+	c.mu.Lock()
+	entry, found := c.entries[key]
+	c.mu.Unlock()
+
+	if found && !directives.has("no-cache") && time.Now().Before(entry.expires) {
+		return entry.response, CacheHit, nil
+	}
+
+	if found {
+		if etag := entry.response.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+
+		if modified := entry.response.Header.Get("Last-Modified"); modified != "" {
+			req.Header.Set("If-Modified-Since", modified)
+		}
+	}
+
+	resp, err := fetch(req)
+	if err != nil {
+		return resp, "", err
+	}
+
+	status := CacheMiss
+
+	if found && resp.StatusCode == http.StatusNotModified {
+		status = CacheRevalidated
+		resp = revalidated(entry.response, resp.Header)
+	}
+
+	c.store(key, resp)
+
+	return resp, status, nil
+}
+
+func (c *cache) store(key string, resp *Response) {
+	directives := cacheControl(resp.Header)
+
+	validated := resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+	expires := freshUntil(resp.Header, directives)
+
+	if resp.StatusCode != http.StatusOK || directives.has("no-store") || (!validated && !time.Now().Before(expires)) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = cacheEntry{response: resp, expires: expires}
+}
+
+// revalidated returns the cached response updated with the headers of the 304 response.
+func revalidated(cached *Response, header http.Header) *Response {
+	updated := *cached
+	updated.Header = cached.Header.Clone()
+
+	for name, values := range header {
+		if name == "Content-Length" {
+			continue
+		}
+
+		updated.Header[name] = values
+	}
+
+	return &updated
+}
+
+// cacheKey identifies the request by its URL and headers, so responses aren't shared across credentials.
+func cacheKey(req *http.Request) string {
+	var key strings.Builder
+
+	key.WriteString(req.URL.String())
+
+	for _, name := range slices.Sorted(maps.Keys(req.Header)) {
+		key.WriteString("\n" + name + ": " + strings.Join(req.Header.Values(name), ","))
+	}
+
+	return key.String()
+}
+
+type directives map[string]string
+
+func (d directives) has(name string) bool {
+	_, found := d[name]
+
+	return found
+}
+
+func cacheControl(header http.Header) directives {
+	d := directives{}
+
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name != "" {
+				d[strings.ToLower(name)] = strings.Trim(arg, `"`)
+			}
+		}
+	}
+
+	return d
+}
+
+// freshUntil returns when the response becomes stale: after its max-age or at its Expires header.
+// Responses with no-cache are always stale.
+func freshUntil(header http.Header, d directives) time.Time {
+	if d.has("no-cache") {
+		return time.Time{}
+	}
+
+	if maxAge, found := d["max-age"]; found {
+		seconds, err := strconv.Atoi(maxAge)
+		if err != nil {
+			return time.Time{}
+		}
+
+		return time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+
+	if expires := header.Get("Expires"); expires != "" {
+		// invalid dates are in the past, see RFC 9111.
+		t, _ := http.ParseTime(expires)
+
+		return t
+	}
+
+	return time.Time{}
+}
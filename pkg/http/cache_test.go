@@ -0,0 +1,96 @@
+package http
+
+import (
+	"context"
+	"io"
+	nethttp "net/http"
+	"strings"
+	"testing"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+type clientFunc func(*nethttp.Request) (*nethttp.Response, error)
+
+func (f clientFunc) Do(req *nethttp.Request) (*nethttp.Response, error) {
+	return f(req)
+}
+
+func TestStepExecutor_Cache(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		header   nethttp.Header
+		requests int
+		statuses []string
+	}{
+		{
+			name:     "fresh responses",
+			header:   nethttp.Header{"Cache-Control": {"max-age=60"}},
+			requests: 1,
+			statuses: []string{CacheMiss, CacheHit, CacheHit},
+		},
+		{
+			name:     "revalidated responses",
+			header:   nethttp.Header{"Cache-Control": {"no-cache"}, "Etag": {`"v1"`}},
+			requests: 3,
+			statuses: []string{CacheMiss, CacheRevalidated, CacheRevalidated},
+		},
+		{
+			name:     "uncacheable responses",
+			header:   nethttp.Header{"Cache-Control": {"no-store"}, "Etag": {`"v1"`}},
+			requests: 3,
+			statuses: []string{CacheMiss, CacheMiss, CacheMiss},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			requests := 0
+			client := clientFunc(func(req *nethttp.Request) (*nethttp.Response, error) {
+				requests++
+
+				status, body := nethttp.StatusOK, "payload"
+				if etag := req.Header.Get("If-None-Match"); etag != "" && etag == tc.header.Get("Etag") {
+					status, body = nethttp.StatusNotModified, ""
+				}
+
+				return &nethttp.Response{StatusCode: status, Header: tc.header.Clone(), Body: io.NopCloser(strings.NewReader(body))}, nil
+			})
+
+			engine := pipeline.NewEngine()
+			engine.RegisterStepExecutor("http", StepExecutor(client, WithCache()))
+
+			builder := pipeline.New("poll")
+			for _, id := range []pipeline.VariablePathNode{"first", "second", "third"} {
+				builder.Step(Get(id, "https://example.com/status"))
+			}
+
+			pipelines := pipeline.NewPipelines(builder.Build())
+
+			scope, err := engine.Execute(context.Background(), pipeline.NewScope(pipelines), []string{"poll"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if requests != tc.requests {
+				t.Fatalf("unexpected requests: got %d want %d", requests, tc.requests)
+			}
+
+			for i, id := range []string{"first", "second", "third"} {
+				status, _ := scope.Variable(pipeline.VariablePath(id + ".$cache"))
+				if status != tc.statuses[i] {
+					t.Fatalf("unexpected %s cache status: got %v want %s", id, status, tc.statuses[i])
+				}
+
+				body, _ := scope.Variable(pipeline.VariablePath(id + ".$body"))
+				if body != "payload" {
+					t.Fatalf("unexpected %s body: %v", id, body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// MockRoute defines the response served by a mock server for a method and path.
+type MockRoute struct {
+	Method string            `yaml:"method"`
+	Path   string            `yaml:"path"`
+	Status int               `yaml:"status"`
+	Header map[string]string `yaml:"header"`
+	Body   string            `yaml:"body"`
+}
+
+// NewMockServer starts an httptest.Server answering the routes. Unknown routes get 404.
+// Routes without method match any method. Callers must close the server.
+func NewMockServer(routes ...MockRoute) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, route := range routes {
+			if route.Path != r.URL.Path || (route.Method != "" && route.Method != r.Method) {
+				continue
+			}
+
+			for k, v := range route.Header {
+				w.Header().Set(k, v)
+			}
+
+			status := route.Status
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(route.Body))
+
+			return
+		}
+
+		http.NotFound(w, r)
+	}))
+}
+
+// RegisterMockServerExecutor registers the http-mock step.
+func RegisterMockServerExecutor() {
+	pipeline.RegisterStepExecutor("http-mock", pipeline.TypedStepExecutor[MockServerParams](MockServerExecutor))
+}
+
+// MockServerRoute is a MockRoute whose body is an expression.
+type MockServerRoute struct {
+	Method string            `yaml:"method"`
+	Path   string            `yaml:"path"`
+	Status int               `yaml:"status"`
+	Header map[string]string `yaml:"header"`
+	Body   expression.String `yaml:"body"`
+}
+
+type MockServerParams struct {
+	Routes []MockServerRoute `yaml:"routes"`
+}
+
+// ReadOnly reports the mock server as read-only, serving on a local listener, see pipeline.ReadOnlyParams.
+func (MockServerParams) ReadOnly() bool {
+	return true
+}
+
+// MockServerExecutor starts a mock HTTP server and stores its base URL in the step variable path.
+// Route bodies are evaluated when the step runs, and the server is closed when the execution finishes,
+// or when the context is done outside of an execution.
+// It's meant for end-to-end pipeline tests without external services.
+//
+// Example YAML:
+//
+//	id: http-mock-example
+//	steps:
+//	- id: api
+//	  type: http-mock
+//	  params:
+//	    routes:
+//	    - method: GET
+//	      path: /users
+//	      status: 200
+//	      header:
+//	        Content-Type: application/json
+//	      body: '[{"name": "bob"}]'
+//	- id: users
+//	  type: http
+//	  params:
+//	    method: GET
+//	    url: '{{ variable . "api" }}/users'
+//	    read: true
+func MockServerExecutor(ctx context.Context, scope pipeline.Scope, step pipeline.Step, params MockServerParams) (pipeline.Scope, error) {
+	routes := make([]MockRoute, len(params.Routes))
+
+	for i, route := range params.Routes {
+		body, err := route.Body.Eval(ctx, scope)
+		if err != nil {
+			return scope, err
+		}
+
+		routes[i] = MockRoute{
+			Method: route.Method,
+			Path:   route.Path,
+			Status: route.Status,
+			Header: route.Header,
+			Body:   body,
+		}
+	}
+
+	server := NewMockServer(routes...)
+
+	closeServer := func(ctx context.Context, _ error) {
+		log.Log().Debug(ctx, "Closing mock server %s", server.URL)
+		server.Close()
+	}
+
+	if err := pipeline.OnFinish(ctx, closeServer); err != nil {
+		context.AfterFunc(ctx, func() { closeServer(ctx, nil) })
+	}
+
+	return scope.WithVariable(step.VariablePath(), server.URL), nil
+}
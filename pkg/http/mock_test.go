@@ -0,0 +1,75 @@
+package http
+
+import (
+	"context"
+	nethttp "net/http"
+	"testing"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+func TestMockServerExecutor(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	scope := pipeline.NewScope(pipeline.Pipelines{}).WithVariable("user", "bob")
+
+	scope, err := pipeline.TypedStepExecutor[MockServerParams](MockServerExecutor).Execute(ctx, scope, pipeline.Step{
+		ID:   "api",
+		Type: "http-mock",
+		Params: map[string]any{
+			"routes": []any{
+				map[string]any{"method": "GET", "path": "/users", "status": 201, "body": `[{"name": "{{ variable . "user" }}"}]`},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	scope, err = StepExecutor(nethttp.DefaultClient).Execute(ctx, scope, pipeline.Step{
+		ID:     "users",
+		Type:   "http",
+		Params: map[string]any{"method": "GET", "url": `{{ variable . "api" }}/users`, "read": true},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	body, _ := scope.Variable("users.$body")
+	if body != `[{"name": "bob"}]` {
+		t.Fatalf("unexpected body: %#v", body)
+	}
+
+	value, _ := scope.Variable("users")
+	if resp, ok := value.(*Response); !ok || resp.StatusCode != 201 {
+		t.Fatalf("unexpected response: %#v", value)
+	}
+}
+
+func TestMockServerExecutor_OutlivesStepTimeout(t *testing.T) {
+	t.Parallel()
+
+	engine := pipeline.NewEngine()
+	engine.RegisterStepExecutor("http", StepExecutor(nethttp.DefaultClient))
+	engine.RegisterStepExecutor("http-mock", pipeline.TypedStepExecutor[MockServerParams](MockServerExecutor))
+
+	mock := pipeline.NewStep("api", "http-mock", MockServerParams{
+		Routes: []MockServerRoute{{Path: "/users", Body: `[{"name": "bob"}]`}},
+	}).WithTimeout(time.Minute)
+
+	pipelines := pipeline.NewPipelines(pipeline.New("users").Step(mock, Get("users", `{{ variable . "api" }}/users`)).Build())
+
+	scope, err := engine.Execute(context.Background(), pipeline.NewScope(pipelines), []string{"users"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	body, _ := scope.Variable("users.$body")
+	if body != `[{"name": "bob"}]` {
+		t.Fatalf("unexpected body: %#v", body)
+	}
+}
@@ -0,0 +1,246 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// VariablePathNodeProfile holds the profile declared by the http-profile step.
+const VariablePathNodeProfile pipeline.VariablePathNode = "$profile"
+
+// ErrUnknownProfile is returned by the http steps referencing profiles neither declared nor set by WithProfiles.
+var ErrUnknownProfile = errors.New("unknown http profile")
+
+// Profile holds the settings shared by the http steps referencing it, so hosts and tokens aren't repeated across steps.
+type Profile struct {
+	// BaseURL prefixes the relative step URLs.
+	BaseURL string
+	// Header is the default request header, the step header values prevailing.
+	Header http.Header
+	// Auth sets the Authorization header when the step doesn't set it, left out of the encoded scopes.
+	Auth Auth `json:"-"`
+	// Client sends the requests, eg.: built by NewTLSClient, the step executor client by default.
+	Client Client `json:"-"`
+}
+
+// Auth is the authorization of the profile requests, either a bearer token or basic credentials.
+type Auth struct {
+	Bearer   string
+	Username string
+	Password string
+}
+
+// TLS configures the TLS connections of the profile clients, see NewTLSClient.
+type TLS struct {
+	// CAFile is the PEM file of the CAs verifying the servers, the system ones by default.
+	CAFile string
+	// CertFile and KeyFile are the PEM files of the client certificate, eg.: for mutual TLS.
+	CertFile string
+	KeyFile  string
+	// InsecureSkipVerify disables the verification of the servers certificates.
+	InsecureSkipVerify bool
+}
+
+// WithProfiles sets the profiles available to the http steps of every pipeline, by name.
+// Profiles declared by http-profile steps prevail over them.
+func WithProfiles(profiles map[string]Profile) Option {
+	return func(o *options) {
+		o.profiles = maps.Clone(profiles)
+	}
+}
+
+// NewTLSClient creates an HTTP client with the TLS configuration.
+func NewTLSClient(config TLS) (*http.Client, error) {
+	//nolint:gosec // ignore G402: skipping the verification is opted in by the profile.
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.InsecureSkipVerify}
+
+	if config.CAFile != "" {
+		pem, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", config.CAFile)
+		}
+	}
+
+	if config.CertFile != "" || config.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// RegisterProfileExecutor registers the http-profile step.
+func RegisterProfileExecutor() {
+	pipeline.RegisterStepExecutor("http-profile", pipeline.TypedStepExecutor[ProfileParams](ProfileExecutor))
+}
+
+type AuthParams struct {
+	Bearer   expression.String `yaml:"bearer"`
+	Username expression.String `yaml:"username"`
+	Password expression.String `yaml:"password"`
+}
+
+type TLSParams struct {
+	CAFile             expression.String `yaml:"ca_file"`
+	CertFile           expression.String `yaml:"cert_file"`
+	KeyFile            expression.String `yaml:"key_file"`
+	InsecureSkipVerify expression.Bool   `yaml:"insecure_skip_verify"`
+}
+
+type ProfileParams struct {
+	BaseURL expression.String `yaml:"base_url"`
+	Header  http.Header       `yaml:"header"`
+	Auth    AuthParams        `yaml:"auth"`
+	TLS     TLSParams         `yaml:"tls"`
+}
+
+// ReadOnly reports the profile declaration as read-only, see pipeline.ReadOnlyParams.
+func (ProfileParams) ReadOnly() bool {
+	return true
+}
+
+// ProfileExecutor declares a profile named after the step id, stored in the step $profile path, so the following
+// http steps of the pipeline and its nested ones can reference it with the `profile` param.
+// The TLS clients are pooled by the engine, shared by the profiles with the same TLS settings across executions,
+// and closed with it, see pipeline.Engine.Close.
+//
+// Example YAML:
+//
+//	id: profile-example
+//	steps:
+//	- id: api
+//	  type: http-profile
+//	  params:
+//	    base_url: https://api.example.com/v1
+//	    header:
+//	      Accept: ['application/json']
+//	    auth:
+//	      bearer: '{{ env "API_TOKEN" }}'
+//	    tls:
+//	      ca_file: /etc/ssl/api-ca.pem
+//	- id: users
+//	  type: http
+//	  params:
+//	    profile: api
+//	    url: /users
+//	    decode: json
+func ProfileExecutor(ctx context.Context, scope pipeline.Scope, step pipeline.Step, p ProfileParams) (pipeline.Scope, error) {
+	var (
+		profile = Profile{Header: p.Header.Clone()}
+		config  TLS
+		err     error
+	)
+
+	fields := []struct {
+		expression expression.String
+		value      *string
+	}{
+		{p.BaseURL, &profile.BaseURL},
+		{p.Auth.Bearer, &profile.Auth.Bearer},
+		{p.Auth.Username, &profile.Auth.Username},
+		{p.Auth.Password, &profile.Auth.Password},
+		{p.TLS.CAFile, &config.CAFile},
+		{p.TLS.CertFile, &config.CertFile},
+		{p.TLS.KeyFile, &config.KeyFile},
+	}
+
+	for _, field := range fields {
+		if *field.value, err = field.expression.Eval(ctx, scope); err != nil {
+			return scope, err
+		}
+	}
+
+	if config.InsecureSkipVerify, err = p.TLS.InsecureSkipVerify.Eval(ctx, scope); err != nil {
+		return scope, err
+	}
+
+	if config != (TLS{}) {
+		client, err := pipeline.Client(ctx, fmt.Sprintf("http:tls:%+v", config), func() (*http.Client, error) {
+			return NewTLSClient(config)
+		})
+		if err != nil {
+			return scope, err
+		}
+
+		profile.Client = client
+	}
+
+	return scope.WithVariable(step.VariablePath(VariablePathNodeProfile), profile), nil
+}
+
+// profile returns the profile declared by the http-profile step with the name, or set by WithProfiles.
+func (o options) profile(scope pipeline.Scope, name string) (Profile, error) {
+	if declared, err := scope.Variable(pipeline.VariablePath(name + "." + string(VariablePathNodeProfile))); err == nil {
+		if profile, ok := declared.(Profile); ok {
+			return profile, nil
+		}
+	}
+
+	if profile, found := o.profiles[name]; found {
+		return profile, nil
+	}
+
+	return Profile{}, fmt.Errorf("%w: %s", ErrUnknownProfile, name)
+}
+
+// url returns the step URL resolved against the profile base URL, unless absolute.
+func (p Profile) url(raw string) (string, error) {
+	if p.BaseURL == "" {
+		return raw, nil
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.IsAbs() {
+		return raw, err
+	}
+
+	if raw == "" {
+		return p.BaseURL, nil
+	}
+
+	return strings.TrimSuffix(p.BaseURL, "/") + "/" + strings.TrimPrefix(raw, "/"), nil
+}
+
+// apply sets the profile header and authorization not set by the step.
+func (p Profile) apply(req *http.Request) {
+	for name, values := range p.Header {
+		if req.Header.Get(name) == "" {
+			req.Header[http.CanonicalHeaderKey(name)] = slices.Clone(values)
+		}
+	}
+
+	if req.Header.Get("Authorization") != "" {
+		return
+	}
+
+	switch {
+	case p.Auth.Bearer != "":
+		req.Header.Set("Authorization", "Bearer "+p.Auth.Bearer)
+	case p.Auth.Username != "":
+		req.SetBasicAuth(p.Auth.Username, p.Auth.Password)
+	}
+}
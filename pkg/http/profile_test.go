@@ -0,0 +1,128 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	nethttp "net/http"
+	"strings"
+	"testing"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+func TestStepExecutor_Profile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		profile      string
+		url          string
+		header       nethttp.Header
+		expectURL    string
+		expectAccept string
+		expectAuth   string
+		expectClient bool
+	}{
+		{
+			name:         "declared profile",
+			profile:      "declared",
+			url:          "/users?page=2",
+			expectURL:    "https://declared.example.com/v1/users?page=2",
+			expectAccept: "application/json",
+			expectAuth:   "Bearer declared-token",
+		},
+		{
+			name:         "engine profile",
+			profile:      "engine",
+			url:          "users",
+			expectURL:    "https://engine.example.com/users",
+			expectAccept: "text/plain",
+			expectAuth:   "Basic dXNlcjpwYXNz",
+			expectClient: true,
+		},
+		{
+			name:         "step values prevail",
+			profile:      "declared",
+			url:          "https://other.example.com/users",
+			header:       nethttp.Header{"Accept": {"text/csv"}, "Authorization": {"Bearer step-token"}},
+			expectURL:    "https://other.example.com/users",
+			expectAccept: "text/csv",
+			expectAuth:   "Bearer step-token",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			client, profileClient := &recordingClient{}, &recordingClient{}
+
+			engine := pipeline.NewEngine()
+			engine.RegisterStepExecutor("http-profile", pipeline.TypedStepExecutor[ProfileParams](ProfileExecutor))
+			engine.RegisterStepExecutor("http", StepExecutor(client, WithProfiles(map[string]Profile{
+				"engine": {
+					BaseURL: "https://engine.example.com",
+					Header:  nethttp.Header{"Accept": {"text/plain"}},
+					Auth:    Auth{Username: "user", Password: "pass"},
+					Client:  profileClient,
+				},
+				"declared": {BaseURL: "https://ignored.example.com"},
+			})))
+
+			pipelines := pipeline.NewPipelines(pipeline.New("profiles").
+				Step(pipeline.NewStep("declared", "http-profile", map[string]any{
+					"base_url": "https://declared.example.com/v1/",
+					"header":   map[string]any{"Accept": []any{"application/json"}},
+					"auth":     map[string]any{"bearer": `{{ variable . "token" }}`},
+				})).
+				Step(Request("request", ExecutorParams{Profile: "{{ variable . \"profile\" }}", URL: "{{ variable . \"url\" }}", Header: tc.header})).
+				Build())
+
+			scope := pipeline.NewScope(pipelines).
+				WithVariable("token", "declared-token").
+				WithVariable("profile", tc.profile).
+				WithVariable("url", tc.url)
+
+			if _, err := engine.Execute(context.Background(), scope, []string{"profiles"}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			req := client.request
+			if tc.expectClient {
+				req = profileClient.request
+			}
+
+			if req == nil {
+				t.Fatal("expected the request to be sent by the client")
+			}
+
+			if got := req.URL.String(); got != tc.expectURL {
+				t.Fatalf("unexpected url: got %s want %s", got, tc.expectURL)
+			}
+
+			if got := req.Header.Get("Accept"); got != tc.expectAccept {
+				t.Fatalf("unexpected accept header: got %s want %s", got, tc.expectAccept)
+			}
+
+			if got := req.Header.Get("Authorization"); got != tc.expectAuth {
+				t.Fatalf("unexpected authorization header: got %s want %s", got, tc.expectAuth)
+			}
+		})
+	}
+}
+
+func TestStepExecutor_UnknownProfile(t *testing.T) {
+	t.Parallel()
+
+	executor := StepExecutor(mockClient{
+		response: &nethttp.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("")), Header: nethttp.Header{}},
+	})
+
+	step := Request("http", ExecutorParams{Profile: "missing", URL: "/users"})
+
+	_, err := executor.Execute(context.Background(), pipeline.NewScope(pipeline.Pipelines{}), step)
+	if !errors.Is(err, ErrUnknownProfile) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+package http
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	// DefaultSpoolThreshold is the size above which response bodies are spooled to a temporary file.
+	DefaultSpoolThreshold int64 = 1 << 20
+)
+
+// Body decodings, see ExecutorParams.Decode.
+const (
+	DecodeText   = "text"
+	DecodeBase64 = "base64"
+	DecodeJSON   = "json"
+	DecodeAuto   = "auto"
+)
+
+// ErrBodyTooLarge is returned when a response body exceeds the maximum size, see WithMaxBodySize.
+var ErrBodyTooLarge = errors.New("response body too large")
+
+// Response is the response of an http step, stored in the step variable path.
+// Its body is fully read and closed when the step runs, so the connection is always released.
+type Response struct {
+	Status        string
+	StatusCode    int
+	Proto         string
+	Header        http.Header
+	ContentLength int64
+	Body          *Body
+}
+
+func newResponse(resp *http.Response, body *Body) *Response {
+	return &Response{
+		Status:        resp.Status,
+		StatusCode:    resp.StatusCode,
+		Proto:         resp.Proto,
+		Header:        resp.Header,
+		ContentLength: resp.ContentLength,
+		Body:          body,
+	}
+}
+
+// Body is a replayable response body. Small bodies are kept in memory and larger ones are spooled
+// to a temporary file, removed when the execution finishes.
+type Body struct {
+	data []byte
+	path string
+	size int64
+}
+
+// readBody reads the reader into a body, spooling it to a temporary file above threshold bytes.
+// A positive limit bounds the body size, returning ErrBodyTooLarge when exceeded.
+func readBody(r io.Reader, threshold, limit int64) (*Body, error) {
+	if limit > 0 {
+		r = io.LimitReader(r, limit+1)
+	}
+
+	var buffer bytes.Buffer
+
+	n, err := io.CopyN(&buffer, r, threshold+1)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if n <= threshold {
+		return &Body{data: buffer.Bytes(), size: n}, checkLimit(n, limit)
+	}
+
+	file, err := os.CreateTemp("", "go-pipeline-http-*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	body := &Body{path: file.Name()}
+
+	written, err := io.Copy(file, io.MultiReader(&buffer, r))
+	body.size = written
+
+	if err == nil {
+		err = checkLimit(written, limit)
+	}
+
+	if err != nil {
+		_ = body.Close()
+
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func checkLimit(size, limit int64) error {
+	if limit > 0 && size > limit {
+		return fmt.Errorf("%w: more than %d bytes", ErrBodyTooLarge, limit)
+	}
+
+	return nil
+}
+
+// Open returns a new reader of the whole body, which must be closed by the caller.
+func (b *Body) Open() (io.ReadCloser, error) {
+	if b.path == "" {
+		return io.NopCloser(bytes.NewReader(b.data)), nil
+	}
+
+	return os.Open(b.path)
+}
+
+// Bytes returns the whole body.
+func (b *Body) Bytes() ([]byte, error) {
+	if b.path == "" {
+		return b.data, nil
+	}
+
+	return os.ReadFile(b.path)
+}
+
+// Size returns the body size in bytes.
+func (b *Body) Size() int64 {
+	return b.size
+}
+
+// Spooled reports whether the body is stored in a temporary file.
+func (b *Body) Spooled() bool {
+	return b.path != ""
+}
+
+// String returns the whole body, or an empty string if it can't be read.
+func (b *Body) String() string {
+	blob, err := b.Bytes()
+	if err != nil {
+		return ""
+	}
+
+	return string(blob)
+}
+
+// MarshalJSON encodes the body as a JSON string.
+func (b *Body) MarshalJSON() ([]byte, error) {
+	blob, err := b.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(string(blob))
+}
+
+// WriteFile writes the whole body to a file, without converting it.
+func (b *Body) WriteFile(path string) error {
+	content, err := b.Open()
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = content.Close() }()
+
+	//nolint:gosec // ignore G304: writing the file chosen by the pipeline is the purpose of the output param.
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(file, content); err != nil {
+		_ = file.Close()
+
+		return err
+	}
+
+	return file.Close()
+}
+
+// decodeBody decodes the body as text, base64, json, or by its content type when auto.
+func decodeBody(body *Body, decoding, contentType string) (any, error) {
+	if decoding == DecodeAuto {
+		decoding = decodingOf(contentType)
+	}
+
+	blob, err := body.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	switch decoding {
+	case DecodeText:
+		return string(blob), nil
+	case DecodeBase64:
+		return base64.StdEncoding.EncodeToString(blob), nil
+	case DecodeJSON:
+		var decoded any
+		if err := json.Unmarshal(blob, &decoded); err != nil {
+			return nil, fmt.Errorf("error decoding json body: %w", err)
+		}
+
+		return decoded, nil
+	default:
+		return nil, fmt.Errorf("unsupported body decoding %q", decoding)
+	}
+}
+
+// decodingOf returns json for JSON media types, text for textual ones and base64 for the others, eg.: images.
+func decodingOf(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return DecodeBase64
+	}
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return DecodeJSON
+	case strings.HasPrefix(mediaType, "text/"), mediaType == "application/xml", strings.HasSuffix(mediaType, "+xml"),
+		mediaType == "application/x-www-form-urlencoded", mediaType == "application/javascript", mediaType == "application/yaml":
+		return DecodeText
+	default:
+		return DecodeBase64
+	}
+}
+
+// Close removes the temporary file of a spooled body.
+func (b *Body) Close() error {
+	if b.path == "" {
+		return nil
+	}
+
+	return os.Remove(b.path)
+}
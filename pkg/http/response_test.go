@@ -0,0 +1,224 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	nethttp "net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+type trackingBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *trackingBody) Close() error {
+	b.closed = true
+
+	return nil
+}
+
+func TestStepExecutor_ResponseBody(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		content   string
+		spooled   bool
+		expectErr error
+	}{
+		{name: "in memory", content: "small body"},
+		{name: "spooled", opts: []Option{WithSpoolThreshold(4)}, content: "larger than the threshold", spooled: true},
+		{name: "too large", opts: []Option{WithMaxBodySize(4)}, content: "larger than the limit", expectErr: ErrBodyTooLarge},
+		{name: "spooled too large", opts: []Option{WithSpoolThreshold(2), WithMaxBodySize(4)}, content: "larger", expectErr: ErrBodyTooLarge},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			respBody := &trackingBody{Reader: strings.NewReader(tc.content)}
+			executor := StepExecutor(mockClient{
+				response: &nethttp.Response{StatusCode: 200, Body: respBody, Header: nethttp.Header{}},
+			}, tc.opts...)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			step := pipeline.Step{ID: "http", Type: "http", Params: map[string]any{"url": "https://example.com", "method": "GET"}}
+
+			scope, err := executor.Execute(ctx, pipeline.NewScope(pipeline.Pipelines{}), step)
+			if !respBody.closed {
+				t.Fatal("expected the response body to be closed")
+			}
+
+			if tc.expectErr != nil {
+				if !errors.Is(err, tc.expectErr) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			value, _ := scope.Variable("http.$body")
+
+			body, ok := value.(*Body)
+			if !ok || body.Spooled() != tc.spooled {
+				t.Fatalf("unexpected body: %#v", value)
+			}
+
+			for range 2 {
+				got, err := expression.String(`{{ variable . "http.$body" | read }}`).Eval(ctx, scope)
+				if err != nil || got != tc.content {
+					t.Fatalf("unexpected body content: %q %v", got, err)
+				}
+			}
+
+			if !tc.spooled {
+				return
+			}
+
+			cancel()
+
+			for range 100 {
+				if _, err := os.Stat(body.path); os.IsNotExist(err) {
+					return
+				}
+
+				time.Sleep(time.Millisecond)
+			}
+
+			t.Fatal("expected the spooled body to be removed once the execution finishes")
+		})
+	}
+}
+
+func TestStepExecutor_BinaryBody(t *testing.T) {
+	t.Parallel()
+
+	binary := string([]byte{0x89, 'P', 'N', 'G', 0x00, 0xff})
+	output := t.TempDir() + "/image.png"
+
+	tests := []struct {
+		name        string
+		params      map[string]any
+		contentType string
+		content     string
+		expected    any
+	}{
+		{name: "base64", params: map[string]any{"decode": "base64"}, content: binary, expected: "iVBORwD/"},
+		{name: "auto binary", params: map[string]any{"decode": "auto"}, contentType: "image/png", content: binary, expected: "iVBORwD/"},
+		{name: "auto json", params: map[string]any{"decode": "auto"}, contentType: "application/problem+json", content: `{"ok":true}`,
+			expected: map[string]any{"ok": true}},
+		{name: "auto text", params: map[string]any{"decode": "auto"}, contentType: "text/plain; charset=utf-8", content: "hello", expected: "hello"},
+		{name: "output", params: map[string]any{"output": output}, content: binary},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			executor := StepExecutor(mockClient{response: &nethttp.Response{
+				StatusCode: 200,
+				Body:       io.NopCloser(strings.NewReader(tc.content)),
+				Header:     nethttp.Header{"Content-Type": {tc.contentType}},
+			}})
+
+			params := map[string]any{"url": "https://example.com", "method": "GET"}
+			for key, value := range tc.params {
+				params[key] = value
+			}
+
+			scope, err := executor.Execute(context.Background(), pipeline.NewScope(pipeline.Pipelines{}), pipeline.Step{ID: "http", Type: "http", Params: params})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tc.expected != nil {
+				value, _ := scope.Variable("http.$body")
+				if fmt.Sprint(value) != fmt.Sprint(tc.expected) {
+					t.Fatalf("unexpected body: %#v", value)
+				}
+
+				return
+			}
+
+			path, _ := scope.Variable("http.$file")
+
+			written, err := os.ReadFile(fmt.Sprint(path))
+			if err != nil || string(written) != binary {
+				t.Fatalf("unexpected file content: %q %v", written, err)
+			}
+		})
+	}
+}
+
+func TestStepExecutor_SpooledBodyLifetime(t *testing.T) {
+	tests := []struct {
+		name      string
+		step      pipeline.Step
+		responses []int
+	}{
+		{
+			name:      "outlives the step timeout",
+			step:      Request("http", ExecutorParams{Method: nethttp.MethodGet, URL: "https://example.com"}).WithTimeout(time.Minute),
+			responses: []int{nethttp.StatusOK},
+		},
+		{
+			name:      "removes the retried responses",
+			step:      Request("http", ExecutorParams{Method: nethttp.MethodGet, URL: "https://example.com", Retries: "1", RetryBackoff: "1ms"}),
+			responses: []int{nethttp.StatusServiceUnavailable, nethttp.StatusOK},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("TMPDIR", dir)
+
+			sent := 0
+			client := clientFunc(func(*nethttp.Request) (*nethttp.Response, error) {
+				status := tc.responses[sent]
+				sent++
+
+				return &nethttp.Response{StatusCode: status, Header: nethttp.Header{}, Body: io.NopCloser(strings.NewReader("larger than the threshold"))}, nil
+			})
+
+			engine := pipeline.NewEngine()
+			engine.RegisterStepExecutor("http", StepExecutor(client, WithSpoolThreshold(4)))
+			engine.RegisterStepExecutor("spooled", pipeline.FuncExecutor(func(context.Context, struct{}) (int, error) {
+				entries, err := os.ReadDir(dir)
+
+				return len(entries), err
+			}))
+
+			pipelines := pipeline.NewPipelines(pipeline.New("download").Step(tc.step, pipeline.NewStep("spooled", "spooled", struct{}{})).Build())
+
+			scope, err := engine.Execute(context.Background(), pipeline.NewScope(pipelines), []string{"download"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if spooled, _ := scope.Variable("spooled"); spooled != 1 {
+				t.Fatalf("unexpected spooled bodies while executing: %v", spooled)
+			}
+
+			if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+				t.Fatalf("unexpected spooled bodies once finished: %d", len(entries))
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// DefaultRetryBackoff is the wait before the first request retry, see ExecutorParams.RetryBackoff.
+const DefaultRetryBackoff = time.Second
+
+// MaxRetryAfter caps the wait of the Retry-After response header, so servers can't stall the retried steps.
+const MaxRetryAfter = 5 * time.Minute
+
+const idempotencyKeySize = 16
+
+// ErrUnexpectedStatus is returned by the http steps whose response status isn't expected, see ExecutorParams.ExpectStatus.
+var ErrUnexpectedStatus = errors.New("unexpected response status")
+
+// retryableStatus reports whether the responses with the status are retried: timeouts, throttling and server errors.
+func retryableStatus(code int) bool {
+	return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// retryableError reports whether the requests failing with the error are retried: exhausted budgets,
+// too large bodies and done contexts are not.
+func retryableError(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && !errors.Is(err, pipeline.ErrBudgetExhausted) && !errors.Is(err, ErrBodyTooLarge)
+}
+
+// sendWithRetries sends the request, sending it again up to retries times on transport errors or retryable statuses,
+// waiting the backoff doubled on each retry or the Retry-After header of the response, when longer, see MaxRetryAfter.
+func sendWithRetries(ctx context.Context, client Client, req *http.Request, o options, retries int, backoff time.Duration) (*Response, error) {
+	if backoff <= 0 {
+		backoff = DefaultRetryBackoff
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := send(ctx, client, req, o)
+
+		switch {
+		case attempt > retries:
+			return resp, err
+		case err != nil && !retryableError(ctx, err):
+			return resp, err
+		case err == nil && !retryableStatus(resp.StatusCode):
+			return resp, nil
+		}
+
+		wait := backoff << (attempt - 1)
+		if wait < backoff {
+			wait = backoff
+		}
+
+		reason := fmt.Sprint(err)
+		if err == nil {
+			reason = resp.Status
+			wait = max(wait, retryAfter(resp.Header))
+		}
+
+		log.Log().Warn(ctx, "Request %s %s failed on attempt %d of %d, retrying in %s: %s",
+			req.Method, req.URL.Redacted(), attempt, retries+1, wait, reason)
+
+		select {
+		case <-ctx.Done():
+			return resp, errors.Join(err, ctx.Err())
+		case <-time.After(wait):
+		}
+
+		discard(ctx, resp)
+
+		if req, err = rewind(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// discard removes the spooled body of a retried response, so it doesn't wait for the execution to finish.
+func discard(ctx context.Context, resp *Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+
+	if err := resp.Body.Close(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Log().Error(ctx, "failed to remove spooled response body %v", err)
+	}
+}
+
+// setIdempotencyKey sets a random idempotency key in the header of the retried POST and PATCH requests,
+// so the servers can detect the duplicated ones, eg.: when the response of a created resource is lost.
+func (o options) setIdempotencyKey(req *http.Request, retries int) error {
+	if o.idempotencyHeader == "" || retries <= 0 || req.Header.Get(o.idempotencyHeader) != "" {
+		return nil
+	}
+
+	if method := strings.ToUpper(req.Method); method != http.MethodPost && method != http.MethodPatch {
+		return nil
+	}
+
+	key := make([]byte, idempotencyKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+
+	req.Header.Set(o.idempotencyHeader, hex.EncodeToString(key))
+
+	return nil
+}
+
+// rewind returns a copy of the sent request with its body restored, so it can be sent again.
+func rewind(ctx context.Context, req *http.Request) (*http.Request, error) {
+	next := req.Clone(ctx)
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+
+		next.Body = body
+	}
+
+	return next, nil
+}
+
+// retryAfter returns the wait of the Retry-After header, either in seconds or until a date, up to MaxRetryAfter.
+func retryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(min(seconds, int(MaxRetryAfter/time.Second))) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return min(time.Until(date), MaxRetryAfter)
+	}
+
+	return 0
+}
+
+// expectStatus returns ErrUnexpectedStatus unless the status matches one of the expected codes, eg.: "200",
+// or classes, eg.: "2xx". Every status is expected when none is.
+func expectStatus(expected []string, resp *Response) error {
+	if len(expected) == 0 {
+		return nil
+	}
+
+	code := strconv.Itoa(resp.StatusCode)
+
+	for _, status := range expected {
+		status = strings.ToLower(strings.TrimSpace(status))
+
+		if class, found := strings.CutSuffix(status, "xx"); found && len(class) == 1 && strings.HasPrefix(code, class) {
+			return nil
+		}
+
+		if status == code {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s, expected %s", ErrUnexpectedStatus, resp.Status, strings.Join(expected, ", "))
+}
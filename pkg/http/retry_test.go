@@ -0,0 +1,216 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	nethttp "net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+func TestStepExecutor_Retries(t *testing.T) {
+	t.Parallel()
+
+	transportErr := errors.New("connection reset")
+
+	tests := []struct {
+		name        string
+		responses   []any
+		params      map[string]any
+		expectSent  int
+		expectError error
+		expectBody  any
+	}{
+		{
+			name:       "retries server errors and decodes json",
+			responses:  []any{nethttp.StatusServiceUnavailable, transportErr, nethttp.StatusOK},
+			params:     map[string]any{"retries": 2, "decode_json": true, "expect_status": []any{200}},
+			expectSent: 3,
+			expectBody: map[string]any{"ok": true},
+		},
+		{
+			name:        "fails once retries are exhausted",
+			responses:   []any{nethttp.StatusBadGateway, nethttp.StatusBadGateway},
+			params:      map[string]any{"retries": 1, "expect_status": []any{"2xx"}},
+			expectSent:  2,
+			expectError: ErrUnexpectedStatus,
+		},
+		{
+			name:        "doesn't retry client errors",
+			responses:   []any{nethttp.StatusNotFound},
+			params:      map[string]any{"retries": 3, "expect_status": []any{"2xx", 304}},
+			expectSent:  1,
+			expectError: ErrUnexpectedStatus,
+		},
+		{
+			name:        "returns the last transport error",
+			responses:   []any{transportErr, transportErr},
+			params:      map[string]any{"retries": "{{ 1 }}"},
+			expectSent:  2,
+			expectError: transportErr,
+		},
+		{
+			name:       "keeps unexpected statuses without expectations",
+			responses:  []any{nethttp.StatusInternalServerError},
+			params:     map[string]any{"read": true},
+			expectSent: 1,
+			expectBody: `{"ok":true}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			sent := 0
+			client := clientFunc(func(req *nethttp.Request) (*nethttp.Response, error) {
+				body, _ := io.ReadAll(req.Body)
+				if string(body) != "payload" {
+					t.Errorf("unexpected request body %q", body)
+				}
+
+				response := tc.responses[sent]
+				sent++
+
+				if err, ok := response.(error); ok {
+					return nil, err
+				}
+
+				return &nethttp.Response{
+					StatusCode: response.(int),
+					Status:     nethttp.StatusText(response.(int)),
+					Header:     nethttp.Header{"Content-Type": {"application/json"}},
+					Body:       io.NopCloser(strings.NewReader(`{"ok":true}`)),
+				}, nil
+			})
+
+			params := map[string]any{"url": "https://example.com", "method": "POST", "body": "payload", "retry_backoff": "1ms"}
+			for key, value := range tc.params {
+				params[key] = value
+			}
+
+			scope, err := StepExecutor(client).Execute(context.Background(), pipeline.NewScope(pipeline.Pipelines{}), pipeline.NewStep("http", "http", params))
+
+			if sent != tc.expectSent {
+				t.Fatalf("unexpected requests: got %d want %d", sent, tc.expectSent)
+			}
+
+			if tc.expectError != nil {
+				if !errors.Is(err, tc.expectError) {
+					t.Fatalf("unexpected error: got %v want %v", err, tc.expectError)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			body, _ := scope.Variable("http.$body")
+			if !reflect.DeepEqual(body, tc.expectBody) {
+				t.Fatalf("unexpected body: %#v", body)
+			}
+		})
+	}
+}
+
+func TestStepExecutor_IdempotencyKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		opts           []Option
+		params         map[string]any
+		header         string
+		expectKey      string
+		expectGenerate bool
+	}{
+		{
+			name:           "retried post",
+			params:         map[string]any{"method": "POST", "retries": 1},
+			header:         DefaultIdempotencyHeader,
+			expectGenerate: true,
+		},
+		{
+			name:           "custom header",
+			opts:           []Option{WithIdempotencyHeader("X-Request-Key")},
+			params:         map[string]any{"method": "patch", "retries": 1},
+			header:         "X-Request-Key",
+			expectGenerate: true,
+		},
+		{
+			name:      "step key",
+			params:    map[string]any{"method": "POST", "retries": 1, "header": map[string]any{"Idempotency-Key": []any{"order-42"}}},
+			header:    DefaultIdempotencyHeader,
+			expectKey: "order-42",
+		},
+		{name: "without retries", params: map[string]any{"method": "POST"}, header: DefaultIdempotencyHeader},
+		{name: "idempotent method", params: map[string]any{"method": "PUT", "retries": 1}, header: DefaultIdempotencyHeader},
+		{
+			name:   "disabled",
+			opts:   []Option{WithIdempotencyHeader("")},
+			params: map[string]any{"method": "POST", "retries": 1},
+			header: DefaultIdempotencyHeader,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var keys []string
+			client := clientFunc(func(req *nethttp.Request) (*nethttp.Response, error) {
+				keys = append(keys, req.Header.Get(tc.header))
+
+				return &nethttp.Response{StatusCode: nethttp.StatusBadGateway, Header: nethttp.Header{}, Body: io.NopCloser(strings.NewReader(""))}, nil
+			})
+
+			params := map[string]any{"url": "https://example.com", "retry_backoff": "1ms"}
+			for key, value := range tc.params {
+				params[key] = value
+			}
+
+			step := pipeline.NewStep("http", "http", params)
+
+			_, err := StepExecutor(client, tc.opts...).Execute(context.Background(), pipeline.NewScope(pipeline.Pipelines{}), step)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, key := range keys {
+				switch {
+				case tc.expectKey != "" && key != tc.expectKey:
+					t.Fatalf("unexpected key: got %q want %q", key, tc.expectKey)
+				case tc.expectKey == "" && tc.expectGenerate && (key == "" || key != keys[0]):
+					t.Fatalf("expected the same generated key across the retries, got %q", keys)
+				case tc.expectKey == "" && !tc.expectGenerate && key != "":
+					t.Fatalf("unexpected key: %q", key)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]time.Duration{
+		"":        0,
+		"2":       2 * time.Second,
+		"3600":    MaxRetryAfter,
+		"invalid": 0,
+		time.Now().Add(24 * time.Hour).UTC().Format(nethttp.TimeFormat): MaxRetryAfter,
+	}
+
+	for value, expected := range tests {
+		if got := retryAfter(nethttp.Header{"Retry-After": {value}}); got != expected {
+			t.Fatalf("retryAfter(%q) = %s, expected %s", value, got, expected)
+		}
+	}
+}
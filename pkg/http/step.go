@@ -2,8 +2,10 @@ package http
 
 import (
 	"context"
+	"errors"
 	"io"
 	"net/http"
+	"os"
 	"strings"
 
 	"github.com/crowleyfelix/go-pipeline/pkg/expression"
@@ -13,29 +15,150 @@ import (
 
 const (
 	VariablePathNodeBody pipeline.VariablePathNode = "$body"
+	VariablePathNodeFile pipeline.VariablePathNode = "$file"
+	// VariablePathNodeCache holds the cache status of the response when caching, see WithCache.
+	VariablePathNodeCache pipeline.VariablePathNode = "$cache"
+
+	// DefaultCorrelationHeader is the request header carrying the pipeline execution ID.
+	DefaultCorrelationHeader = "X-Correlation-ID"
+	// DefaultIdempotencyHeader is the request header carrying the idempotency key of the retried requests.
+	DefaultIdempotencyHeader = "Idempotency-Key"
+
+	// BudgetRequests is the budget spent by each request, see pipeline.WithBudget.
+	BudgetRequests = "http.requests"
 )
 
 type Client interface {
 	Do(*http.Request) (*http.Response, error)
 }
 
-func RegisterStepExecutor(client Client) {
-	pipeline.RegisterStepExecutor("http", StepExecutor(client))
+type options struct {
+	correlationHeader string
+	idempotencyHeader string
+	spoolThreshold    int64
+	maxBodySize       int64
+	cache             *caches
+	profiles          map[string]Profile
+}
+
+// Option configures the http step executor.
+type Option func(*options)
+
+// WithCorrelationHeader sets the request header used to propagate the execution ID.
+// An empty name disables the propagation.
+func WithCorrelationHeader(name string) Option {
+	return func(o *options) {
+		o.correlationHeader = name
+	}
+}
+
+// WithIdempotencyHeader sets the request header carrying the idempotency key of the retried requests,
+// see ExecutorParams.Retries. An empty name disables the keys.
+func WithIdempotencyHeader(name string) Option {
+	return func(o *options) {
+		o.idempotencyHeader = name
+	}
+}
+
+// WithSpoolThreshold sets the size above which response bodies are spooled to a temporary file
+// instead of kept in memory, DefaultSpoolThreshold by default.
+func WithSpoolThreshold(size int64) Option {
+	return func(o *options) {
+		o.spoolThreshold = size
+	}
+}
+
+// WithMaxBodySize limits the response bodies size, failing the step with ErrBodyTooLarge when exceeded.
+// Bodies are unlimited by default.
+func WithMaxBodySize(size int64) Option {
+	return func(o *options) {
+		o.maxBodySize = size
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		correlationHeader: DefaultCorrelationHeader,
+		idempotencyHeader: DefaultIdempotencyHeader,
+		spoolThreshold:    DefaultSpoolThreshold,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+func RegisterStepExecutor(client Client, opts ...Option) {
+	pipeline.RegisterStepExecutor("http", StepExecutor(client, opts...))
 }
 
 type ExecutorParams struct {
-	URL    expression.String   `yaml:"url"`
-	Method expression.String   `yaml:"method"`
-	Body   expression.String   `yaml:"body"`
-	Header http.Header         `yaml:"header"`
-	Read   bool                `yaml:"read"`
-	Set    pipeline.SetParams  `yaml:"set"`
-	Stop   pipeline.StopParams `yaml:"stop"`
+	// Profile is the name of the profile of the request, see ProfileExecutor and WithProfiles.
+	Profile expression.String   `yaml:"profile"`
+	URL     expression.String   `yaml:"url"`
+	Method  expression.String   `yaml:"method"`
+	Body    expression.String   `yaml:"body"`
+	Header  http.Header         `yaml:"header"`
+	Read    bool                `yaml:"read"`
+	Decode  expression.String   `yaml:"decode"`
+	Output  expression.String   `yaml:"output"`
+	Set     pipeline.SetParams  `yaml:"set"`
+	Stop    pipeline.StopParams `yaml:"stop"`
+
+	// DecodeJSON decodes the body as JSON, like `decode: json`.
+	DecodeJSON bool `yaml:"decode_json"`
+	// ExpectStatus fails the step on the statuses other than these codes, eg.: "200", or classes, eg.: "2xx".
+	ExpectStatus []string `yaml:"expect_status"`
+	// Retries is the number of times the request is sent again on transport errors, timeouts (408),
+	// throttling (429) and server errors (5xx). Retried POST and PATCH requests carry an idempotency key,
+	// the same across the retries, unless the step sets it, see WithIdempotencyHeader.
+	Retries expression.Int `yaml:"retries"`
+	// RetryBackoff is the wait before the first retry, doubled on each following one, DefaultRetryBackoff by default.
+	RetryBackoff expression.Duration `yaml:"retry_backoff"`
+}
+
+// ReadOnly reports whether the request method is a safe one, GET, HEAD or OPTIONS, not writing the body to an output
+// file, see pipeline.ReadOnlyParams.
+func (p ExecutorParams) ReadOnly() bool {
+	if p.Output != "" {
+		return false
+	}
+
+	switch strings.ToUpper(string(p.Method)) {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	return false
+}
+
+// Request creates an http step with the given params.
+func Request(id pipeline.VariablePathNode, params ExecutorParams) pipeline.Step {
+	return pipeline.NewStep(id, "http", params)
+}
+
+// Get creates an http step reading the response of a GET request.
+func Get(id pipeline.VariablePathNode, url string) pipeline.Step {
+	return Request(id, ExecutorParams{Method: http.MethodGet, URL: expression.String(url), Read: true})
+}
+
+// Post creates an http step reading the response of a POST request.
+func Post(id pipeline.VariablePathNode, url, body string, header http.Header) pipeline.Step {
+	return Request(id, ExecutorParams{Method: http.MethodPost, URL: expression.String(url), Body: expression.String(body), Header: header, Read: true})
 }
 
 // StepExecutor executes an HTTP request based on the provided parameters.
 // It supports setting the HTTP method, URL, headers, and body.
-// If the `read` parameter is true, the response body is read and stored in the pipeline scope.
+// The response is stored as a Response in the step variable path, and its body is always read and closed,
+// kept in memory or spooled to a temporary file above the spool threshold until the execution finishes.
+// If the `read` parameter is true, the response body is stored as a string in the `$body` path, otherwise as a Body.
+// The `decode` parameter stores it decoded instead: as text, base64 (safe for binary bodies), json, or auto to choose
+// by the response Content-Type. The `output` parameter writes the body to a file, whose path is stored in `$file`.
+// The execution ID is sent in the correlation header (X-Correlation-ID by default) unless the step sets it.
+// The `expect_status` parameter fails the step on unexpected statuses, and the `retries` parameter sends the request
+// again on transport errors and retryable statuses, waiting the `retry_backoff` doubled on each retry.
+// The `profile` parameter applies the base URL, default header, authorization and client of a profile.
 //
 // Example YAML:
 //
@@ -56,14 +179,32 @@ type ExecutorParams struct {
 //	    condition: '{{ ne (variable . "http-step").StatusCode 200 }}'
 //	    message: 'unexpected response status'
 //	    is_error: true
-func StepExecutor(client Client) pipeline.StepExecutor {
+func StepExecutor(client Client, opts ...Option) pipeline.StepExecutor {
+	o := newOptions(opts)
+
 	return pipeline.TypedStepExecutor[ExecutorParams](
 		func(ctx context.Context, scope pipeline.Scope, step pipeline.Step, p ExecutorParams) (pipeline.Scope, error) {
+			profileName, err := p.Profile.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			var profile Profile
+			if profileName != "" {
+				if profile, err = o.profile(scope, profileName); err != nil {
+					return scope, err
+				}
+			}
+
 			url, err := p.URL.Eval(ctx, scope)
 			if err != nil {
 				return scope, err
 			}
 
+			if url, err = profile.url(url); err != nil {
+				return scope, err
+			}
+
 			method, err := p.Method.Eval(ctx, scope)
 			if err != nil {
 				return scope, err
@@ -84,9 +225,39 @@ func StepExecutor(client Client) pipeline.StepExecutor {
 				return scope, err
 			}
 
-			req.Header = p.Header
+			req.Header = p.Header.Clone()
+			if req.Header == nil {
+				req.Header = http.Header{}
+			}
 
-			resp, err := client.Do(req)
+			profile.apply(req)
+
+			if id := pipeline.ExecutionID(ctx); o.correlationHeader != "" && id != "" && req.Header.Get(o.correlationHeader) == "" {
+				req.Header.Set(o.correlationHeader, id)
+			}
+
+			retries, err := p.Retries.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			backoff, err := p.RetryBackoff.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			if err := o.setIdempotencyKey(req, retries); err != nil {
+				return scope, err
+			}
+
+			sender := client
+			if profile.Client != nil {
+				sender = profile.Client
+			}
+
+			resp, cacheStatus, err := o.cache.of(ctx).do(req, func(req *http.Request) (*Response, error) {
+				return sendWithRetries(ctx, sender, req, o, retries, backoff)
+			})
 			if err != nil {
 				return scope, err
 			}
@@ -96,20 +267,46 @@ func StepExecutor(client Client) pipeline.StepExecutor {
 				step.VariablePath(VariablePathNodeBody): resp.Body,
 			}
 
-			if p.Read {
-				defer func() {
-					err = resp.Body.Close()
-					if err != nil {
-						log.Log().Error(ctx, "failed to close response body %v", err)
-					}
-				}()
+			if cacheStatus != "" {
+				variables[step.VariablePath(VariablePathNodeCache)] = cacheStatus
+			}
+
+			if err := expectStatus(p.ExpectStatus, resp); err != nil {
+				return scope.WithVariables(variables), err
+			}
+
+			decode, err := p.Decode.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			switch {
+			case p.DecodeJSON && decode == "":
+				decode = DecodeJSON
+			case p.Read && decode == "":
+				decode = DecodeText
+			}
+
+			if decode != "" {
+				decoded, err := decodeBody(resp.Body, decode, resp.Header.Get("Content-Type"))
+				if err != nil {
+					return scope, err
+				}
+
+				variables[step.VariablePath(VariablePathNodeBody)] = decoded
+			}
+
+			output, err := p.Output.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
 
-				blob, readErr := io.ReadAll(resp.Body)
-				if readErr != nil {
-					return scope, readErr
+			if output != "" {
+				if err := resp.Body.WriteFile(output); err != nil {
+					return scope, err
 				}
 
-				variables[step.VariablePath(VariablePathNodeBody)] = string(blob)
+				variables[step.VariablePath(VariablePathNodeFile)] = output
 			}
 
 			scope = scope.WithVariables(variables)
@@ -134,3 +331,57 @@ func StepExecutor(client Client) pipeline.StepExecutor {
 		},
 	)
 }
+
+// send spends the request budget and sends the request, reading its response.
+func send(ctx context.Context, client Client, req *http.Request, o options) (*Response, error) {
+	if err := pipeline.Spend(ctx, BudgetRequests, 1); err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := readResponseBody(ctx, resp, o)
+	if err != nil {
+		return nil, err
+	}
+
+	return newResponse(resp, body), nil
+}
+
+// readResponseBody reads and closes the response body, removing it once the execution finishes when spooled.
+func readResponseBody(ctx context.Context, resp *http.Response, o options) (*Body, error) {
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Log().Error(ctx, "failed to close response body %v", err)
+		}
+	}()
+
+	body, err := readBody(resp.Body, o.spoolThreshold, o.maxBodySize)
+	if err != nil {
+		return nil, err
+	}
+
+	if body.Spooled() {
+		removeOnFinish(ctx, body)
+	}
+
+	return body, nil
+}
+
+// removeOnFinish removes the spooled body once the execution finishes, so the later steps can still read it
+// after the step context is done, eg.: on step timeouts or concurrent workers. Outside of an execution,
+// it is removed once the context is done.
+func removeOnFinish(ctx context.Context, body *Body) {
+	remove := func(ctx context.Context, _ error) {
+		if err := body.Close(); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Log().Error(ctx, "failed to remove spooled response body %v", err)
+		}
+	}
+
+	if err := pipeline.OnFinish(ctx, remove); err != nil {
+		context.AfterFunc(ctx, func() { remove(ctx, nil) })
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
 	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
 )
 
@@ -173,3 +174,93 @@ func TestStepExecutor_SetParams(t *testing.T) {
 		t.Fatalf("unexpected ok value: %#v", values["ok"])
 	}
 }
+
+type recordingClient struct {
+	request *nethttp.Request
+}
+
+func (r *recordingClient) Do(req *nethttp.Request) (*nethttp.Response, error) {
+	r.request = req
+
+	return &nethttp.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("")), Header: nethttp.Header{}}, nil
+}
+
+func TestStepExecutor_CorrelationHeader(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		header   string
+		expected string
+	}{
+		{name: "default header", header: DefaultCorrelationHeader, expected: "exec-1"},
+		{name: "custom header", opts: []Option{WithCorrelationHeader("X-Request-ID")}, header: "X-Request-ID", expected: "exec-1"},
+		{name: "disabled", opts: []Option{WithCorrelationHeader("")}, header: DefaultCorrelationHeader, expected: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := &recordingClient{}
+			step := pipeline.Step{
+				ID:     "http",
+				Type:   "http",
+				Params: map[string]any{"url": "https://example.com", "method": "GET"},
+			}
+
+			ctx := pipeline.WithExecutionID(context.Background(), "exec-1")
+
+			_, err := StepExecutor(client, tc.opts...).Execute(ctx, pipeline.NewScope(pipeline.Pipelines{}), step)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := client.request.Header.Get(tc.header); got != tc.expected {
+				t.Fatalf("unexpected %s header: got %q want %q", tc.header, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestRequestBuildsExecutableStep(t *testing.T) {
+	t.Parallel()
+
+	step := Request("http", ExecutorParams{
+		Method: nethttp.MethodGet,
+		URL:    "https://example.com",
+		Read:   true,
+		Set:    pipeline.SetParams{YAML: "status: '{{ (variable . \"http\").StatusCode }}'"},
+	})
+
+	executor := StepExecutor(mockClient{
+		response: &nethttp.Response{StatusCode: 204, Body: io.NopCloser(strings.NewReader("")), Header: nethttp.Header{}},
+	})
+
+	result, err := executor.Execute(context.Background(), pipeline.NewScope(pipeline.Pipelines{}), step)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	value, _ := result.Variable("http")
+	if values, ok := value.(map[string]any); !ok || values["status"] != "204" {
+		t.Fatalf("unexpected value: %#v", value)
+	}
+}
+
+func TestExecutorParamsReadOnly(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]bool{"": true, "get": true, "HEAD": true, "OPTIONS": true, "POST": false, "DELETE": false, "{{ .method }}": false}
+
+	for method, expected := range tests {
+		if got := (ExecutorParams{Method: expression.String(method)}).ReadOnly(); got != expected {
+			t.Fatalf("ReadOnly() of %q = %v, expected %v", method, got, expected)
+		}
+	}
+
+	if (ExecutorParams{Method: "GET", Output: "report.json"}).ReadOnly() {
+		t.Fatalf("expected requests writing an output file to be side-effecting")
+	}
+}
@@ -0,0 +1,123 @@
+package issue
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// RegisterStepExecutor registers the issue step using the named trackers.
+func RegisterStepExecutor(trackers map[string]Tracker) {
+	pipeline.RegisterStepExecutor("issue", StepExecutor(trackers))
+}
+
+type StepParams struct {
+	Tracker expression.String         `yaml:"tracker"`
+	Action  expression.String         `yaml:"action"`
+	ID      expression.String         `yaml:"id"`
+	Title   expression.String         `yaml:"title"`
+	Body    expression.String         `yaml:"body"`
+	Labels  expression.YAML[[]string] `yaml:"labels"`
+	State   expression.String         `yaml:"state"`
+}
+
+// StepExecutor creates (by default) or updates an issue, or comments an issue or pull request, in a named tracker.
+// The issue or comment id and url are stored in the step variable path.
+//
+// Example YAML:
+//
+//	id: issue-example
+//	steps:
+//	- id: ticket
+//	  type: issue
+//	  params:
+//	    tracker: 'github'
+//	    title: 'Import rejected {{ len (variable . "import.rejected") }} rows'
+//	    body: '{{ variable . "import.rejected" | toJson }}'
+//	    labels: ['data-quality']
+//	- id: follow-up
+//	  type: issue
+//	  params:
+//	    tracker: 'github'
+//	    action: 'comment'
+//	    id: '{{ (variable . "ticket").id }}'
+//	    body: 'Retried at {{ now }}'
+func StepExecutor(trackers map[string]Tracker) pipeline.StepExecutor {
+	return pipeline.TypedStepExecutor[StepParams](
+		func(ctx context.Context, scope pipeline.Scope, step pipeline.Step, p StepParams) (pipeline.Scope, error) {
+			name, err := p.Tracker.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			tracker, found := trackers[name]
+			if !found {
+				return scope, fmt.Errorf("issue tracker %q not registered", name)
+			}
+
+			action, err := p.Action.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			update, err := p.update(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			id, err := p.ID.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			var ref Ref
+
+			switch action {
+			case "", "create":
+				ref, err = tracker.Create(ctx, Issue{Title: update.Title, Body: update.Body, Labels: update.Labels})
+			case "update":
+				ref, err = tracker.Update(ctx, id, update)
+			case "comment":
+				ref, err = tracker.Comment(ctx, id, update.Body)
+			default:
+				return scope, fmt.Errorf("unsupported issue action %q", action)
+			}
+
+			if err != nil {
+				return scope, err
+			}
+
+			log.Log().Info(ctx, "Issue %s: %s", ref.URL, action)
+
+			return scope.WithVariable(step.VariablePath(), map[string]any{"id": ref.ID, "url": ref.URL}), nil
+		},
+	)
+}
+
+func (p StepParams) update(ctx context.Context, scope pipeline.Scope) (Update, error) {
+	var (
+		update Update
+		err    error
+	)
+
+	if update.Title, err = p.Title.Eval(ctx, scope); err != nil {
+		return update, err
+	}
+
+	if update.Body, err = p.Body.Eval(ctx, scope); err != nil {
+		return update, err
+	}
+
+	if update.Labels, err = p.Labels.Eval(ctx, scope); err != nil {
+		return update, err
+	}
+
+	if update.State, err = p.State.Eval(ctx, scope); err != nil {
+		return update, err
+	}
+
+	return update, nil
+}
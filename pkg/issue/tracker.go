@@ -0,0 +1,249 @@
+// Package issue provides a step filing issues and comments in trackers, eg.: GitHub or Jira.
+package issue
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupported is returned when a tracker doesn't support an operation.
+var ErrUnsupported = errors.New("unsupported by the issue tracker")
+
+// Client sends the tracker requests, eg.: http.DefaultClient.
+type Client interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// Issue is the content of an issue.
+type Issue struct {
+	Title  string
+	Body   string
+	Labels []string
+}
+
+// Update changes the fields of an issue that are set.
+type Update struct {
+	Title  string
+	Body   string
+	Labels []string
+	// State opens or closes the issue, eg.: open or closed.
+	State string
+}
+
+// Ref identifies an issue or a comment.
+type Ref struct {
+	ID  string
+	URL string
+}
+
+// Tracker files issues and comments.
+type Tracker interface {
+	Create(ctx context.Context, issue Issue) (Ref, error)
+	Update(ctx context.Context, id string, update Update) (Ref, error)
+	// Comment comments an issue, or a pull request when supported by the tracker.
+	Comment(ctx context.Context, id, body string) (Ref, error)
+}
+
+// send sends the JSON body, decoding the JSON response into out when set.
+func send(ctx context.Context, client Client, method, url string, header http.Header, body, out any) error {
+	blob, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(blob))
+	if err != nil {
+		return err
+	}
+
+	req.Header = header.Clone()
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	blob, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%s %s: %s: %s", method, url, resp.Status, blob)
+	}
+
+	if out == nil || len(blob) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(blob, out)
+}
+
+// DefaultGitHubURL is the GitHub REST API endpoint.
+const DefaultGitHubURL = "https://api.github.com"
+
+// GitHub files issues in a repository with the REST API. Issues are identified by their number,
+// and comments on pull requests use the pull request number.
+type GitHub struct {
+	Client Client
+	Token  string
+	// Repository is the owner/name of the repository.
+	Repository string
+	URL        string
+}
+
+// NewGitHub creates a GitHub tracker of the owner/name repository.
+func NewGitHub(client Client, token, repository string) *GitHub {
+	return &GitHub{Client: client, Token: token, Repository: repository, URL: DefaultGitHubURL}
+}
+
+func (g *GitHub) send(ctx context.Context, method, path string, body any) (Ref, error) {
+	header := http.Header{
+		"Accept":        {"application/vnd.github+json"},
+		"Authorization": {"Bearer " + g.Token},
+	}
+
+	var response struct {
+		ID      int64  `json:"id"`
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+
+	err := send(ctx, g.Client, method, g.URL+"/repos/"+g.Repository+path, header, body, &response)
+
+	id := strconv.Itoa(response.Number)
+	if response.Number == 0 {
+		id = strconv.FormatInt(response.ID, 10)
+	}
+
+	return Ref{ID: id, URL: response.HTMLURL}, err
+}
+
+// Create opens an issue.
+func (g *GitHub) Create(ctx context.Context, issue Issue) (Ref, error) {
+	return g.send(ctx, http.MethodPost, "/issues", map[string]any{"title": issue.Title, "body": issue.Body, "labels": issue.Labels})
+}
+
+// Update edits the issue with the number.
+func (g *GitHub) Update(ctx context.Context, id string, update Update) (Ref, error) {
+	fields := map[string]any{}
+
+	if update.Title != "" {
+		fields["title"] = update.Title
+	}
+
+	if update.Body != "" {
+		fields["body"] = update.Body
+	}
+
+	if update.Labels != nil {
+		fields["labels"] = update.Labels
+	}
+
+	if update.State != "" {
+		fields["state"] = update.State
+	}
+
+	return g.send(ctx, http.MethodPatch, "/issues/"+url.PathEscape(id), fields)
+}
+
+// Comment comments the issue or pull request with the number.
+func (g *GitHub) Comment(ctx context.Context, id, body string) (Ref, error) {
+	return g.send(ctx, http.MethodPost, "/issues/"+url.PathEscape(id)+"/comments", map[string]any{"body": body})
+}
+
+// DefaultJiraIssueType is the type of the issues created in Jira.
+const DefaultJiraIssueType = "Task"
+
+// Jira files issues in a project with the Jira REST API v2. Issues are identified by their key, eg.: OPS-42.
+type Jira struct {
+	Client Client
+	// URL is the site URL, eg.: https://example.atlassian.net.
+	URL   string
+	Email string
+	Token string
+	// Project is the key of the project issues are created in.
+	Project   string
+	IssueType string
+}
+
+// NewJira creates a Jira tracker of the project, authenticated with the user email and API token.
+func NewJira(client Client, url, email, token, project string) *Jira {
+	return &Jira{Client: client, URL: strings.TrimSuffix(url, "/"), Email: email, Token: token, Project: project, IssueType: DefaultJiraIssueType}
+}
+
+func (j *Jira) send(ctx context.Context, method, path string, body, out any) error {
+	credentials := base64.StdEncoding.EncodeToString([]byte(j.Email + ":" + j.Token))
+	header := http.Header{"Accept": {"application/json"}, "Authorization": {"Basic " + credentials}}
+
+	return send(ctx, j.Client, method, j.URL+"/rest/api/2"+path, header, body, out)
+}
+
+func (j *Jira) browse(key string) string {
+	return j.URL + "/browse/" + key
+}
+
+// Create creates an issue.
+func (j *Jira) Create(ctx context.Context, issue Issue) (Ref, error) {
+	var response struct {
+		Key string `json:"key"`
+	}
+
+	err := j.send(ctx, http.MethodPost, "/issue", map[string]any{"fields": map[string]any{
+		"project":     map[string]any{"key": j.Project},
+		"issuetype":   map[string]any{"name": j.IssueType},
+		"summary":     issue.Title,
+		"description": issue.Body,
+		"labels":      issue.Labels,
+	}}, &response)
+
+	return Ref{ID: response.Key, URL: j.browse(response.Key)}, err
+}
+
+// Update edits the issue with the key. Changing the state requires workflow transitions, unsupported by the tracker.
+func (j *Jira) Update(ctx context.Context, id string, update Update) (Ref, error) {
+	if update.State != "" {
+		return Ref{}, fmt.Errorf("%w: jira state", ErrUnsupported)
+	}
+
+	fields := map[string]any{}
+
+	if update.Title != "" {
+		fields["summary"] = update.Title
+	}
+
+	if update.Body != "" {
+		fields["description"] = update.Body
+	}
+
+	if update.Labels != nil {
+		fields["labels"] = update.Labels
+	}
+
+	err := j.send(ctx, http.MethodPut, "/issue/"+url.PathEscape(id), map[string]any{"fields": fields}, nil)
+
+	return Ref{ID: id, URL: j.browse(id)}, err
+}
+
+// Comment comments the issue with the key.
+func (j *Jira) Comment(ctx context.Context, id, body string) (Ref, error) {
+	var response struct {
+		ID string `json:"id"`
+	}
+
+	err := j.send(ctx, http.MethodPost, "/issue/"+url.PathEscape(id)+"/comment", map[string]any{"body": body}, &response)
+
+	return Ref{ID: response.ID, URL: j.browse(id) + "?focusedCommentId=" + response.ID}, err
+}
@@ -0,0 +1,89 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+func newEndpoint(t *testing.T, content, finishReason string, received *map[string]any) Endpoint {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		_ = json.NewDecoder(r.Body).Decode(received)
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"model":   "gpt-4o-mini-2024-07-18",
+			"choices": []any{map[string]any{"message": map[string]any{"role": "assistant", "content": content}, "finish_reason": finishReason}},
+			"usage":   map[string]any{"prompt_tokens": 1000, "completion_tokens": 500, "total_tokens": 1500},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	return Endpoint{URL: server.URL + "/v1/", APIKey: "secret", Client: server.Client()}
+}
+
+func TestStepExecutor(t *testing.T) {
+	t.Parallel()
+
+	var received map[string]any
+
+	endpoint := newEndpoint(t, `{"category":"billing"}`, "stop", &received)
+	step := pipeline.NewStep("triage", "llm", map[string]any{
+		"model":       "gpt-4o-mini",
+		"system":      "Classify support tickets.",
+		"prompt":      `{{ variable . "ticket" }}`,
+		"max_tokens":  200,
+		"temperature": "0.2",
+		"json":        true,
+	})
+	scope := pipeline.NewScope(pipeline.Pipelines{}).WithVariable("ticket", "I was charged twice")
+
+	scope, err := StepExecutor(endpoint, WithPrice("gpt-4o-mini", Price{Input: 0.15, Output: 0.6})).Execute(context.Background(), scope, step)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := fmt.Sprint(received["messages"]); got != "[map[content:Classify support tickets. role:system] map[content:I was charged twice role:user]]" {
+		t.Fatalf("unexpected messages: %s", got)
+	}
+
+	if received["max_tokens"] != float64(200) || received["temperature"] != 0.2 || fmt.Sprint(received["response_format"]) != "map[type:json_object]" {
+		t.Fatalf("unexpected request: %v", received)
+	}
+
+	result, _ := pipeline.Get[map[string]any](scope, "triage")
+	if fmt.Sprint(result["json"]) != "map[category:billing]" || fmt.Sprint(result["cost"]) != "0.00045" {
+		t.Fatalf("unexpected result: %v", result)
+	}
+}
+
+func TestStepExecutorTruncated(t *testing.T) {
+	t.Parallel()
+
+	var received map[string]any
+
+	endpoint := newEndpoint(t, `{"category":`, "length", &received)
+	step := pipeline.NewStep("triage", "llm", map[string]any{"model": "local", "prompt": "classify", "json": true})
+
+	scope, err := StepExecutor(endpoint).Execute(context.Background(), pipeline.NewScope(pipeline.Pipelines{}), step)
+	if !errors.Is(err, ErrTruncated) {
+		t.Fatalf("expected truncated error, got %v", err)
+	}
+
+	if result, _ := pipeline.Get[map[string]any](scope, "triage"); result["content"] != `{"category":` || result["cost"] != nil {
+		t.Fatalf("unexpected result: %v", result)
+	}
+}
@@ -0,0 +1,300 @@
+// Package llm provides a step prompting language models through OpenAI-compatible chat completions APIs.
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// DefaultURL is the OpenAI API base URL.
+const DefaultURL = "https://api.openai.com/v1"
+
+const tokensPerPrice = 1_000_000
+
+const (
+	// BudgetTokens is the budget spent by the total tokens of each completion, see pipeline.WithBudget.
+	BudgetTokens = "llm.tokens"
+	// BudgetCost is the budget spent by the cost of each completion of a priced model.
+	BudgetCost = "llm.cost"
+)
+
+// ErrTruncated is returned when a JSON mode completion is cut by the token limit, so it can't be decoded.
+var ErrTruncated = errors.New("completion truncated by the token limit")
+
+// Client sends the API requests, eg.: http.DefaultClient.
+type Client interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// Endpoint is an OpenAI-compatible API, eg.: OpenAI, Azure OpenAI, vLLM or Ollama.
+type Endpoint struct {
+	// URL is the API base URL, DefaultURL when empty.
+	URL    string
+	APIKey string
+	Client Client
+}
+
+// Price is the cost of a model per million tokens, in any currency.
+type Price struct {
+	Input  float64
+	Output float64
+}
+
+type options struct {
+	prices map[string]Price
+}
+
+// Option configures the llm step executor.
+type Option func(*options)
+
+// WithPrice sets the price of a model, used to compute the cost of its completions.
+func WithPrice(model string, price Price) Option {
+	return func(o *options) {
+		o.prices[model] = price
+	}
+}
+
+// RegisterStepExecutor registers the llm step sending the completions requests to the endpoint.
+func RegisterStepExecutor(endpoint Endpoint, opts ...Option) {
+	pipeline.RegisterStepExecutor("llm", StepExecutor(endpoint, opts...))
+}
+
+// Message is a chat message.
+type Message struct {
+	Role    string `yaml:"role" json:"role"`
+	Content string `yaml:"content" json:"content"`
+}
+
+type StepParams struct {
+	Model       expression.String          `yaml:"model"`
+	System      expression.String          `yaml:"system"`
+	Prompt      expression.String          `yaml:"prompt"`
+	Messages    expression.YAML[[]Message] `yaml:"messages"`
+	MaxTokens   expression.Int             `yaml:"max_tokens"`
+	Temperature expression.String          `yaml:"temperature"`
+	JSON        expression.Bool            `yaml:"json"`
+}
+
+// StepExecutor sends a chat completion request with the messages built from the scope: the system message,
+// the messages and the prompt as the last user message. The completion content, the decoded json (in JSON mode),
+// the model, finish_reason, token usage and cost (when the model price is set) are stored in the step variable path.
+//
+// Example YAML:
+//
+//	id: llm-example
+//	steps:
+//	- id: triage
+//	  type: llm
+//	  params:
+//	    model: 'gpt-4o-mini'
+//	    system: 'Classify support tickets. Answer with a JSON object with a "category" field.'
+//	    prompt: '{{ variable . "ticket.body" }}'
+//	    max_tokens: 200
+//	    json: true
+func StepExecutor(endpoint Endpoint, opts ...Option) pipeline.StepExecutor {
+	o := options{prices: map[string]Price{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return pipeline.TypedStepExecutor[StepParams](
+		func(ctx context.Context, scope pipeline.Scope, step pipeline.Step, p StepParams) (pipeline.Scope, error) {
+			request, err := p.request(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			completion, err := endpoint.complete(ctx, request)
+			if err != nil {
+				return scope, err
+			}
+
+			if len(completion.Choices) == 0 {
+				return scope, errors.New("completion without choices")
+			}
+
+			choice := completion.Choices[0]
+			result := map[string]any{
+				"content":       choice.Message.Content,
+				"model":         completion.Model,
+				"finish_reason": choice.FinishReason,
+				"usage": map[string]any{
+					"prompt_tokens":     completion.Usage.PromptTokens,
+					"completion_tokens": completion.Usage.CompletionTokens,
+					"total_tokens":      completion.Usage.TotalTokens,
+				},
+			}
+
+			if price, found := o.prices[request.Model]; found {
+				cost := (float64(completion.Usage.PromptTokens)*price.Input +
+					float64(completion.Usage.CompletionTokens)*price.Output) / tokensPerPrice
+				result["cost"] = cost
+
+				if err := pipeline.Spend(ctx, BudgetCost, cost); err != nil {
+					return scope.WithVariable(step.VariablePath(), result), err
+				}
+			}
+
+			log.Log().Debug(ctx, "Completion of %s used %d tokens", completion.Model, completion.Usage.TotalTokens)
+
+			if err := pipeline.Spend(ctx, BudgetTokens, float64(completion.Usage.TotalTokens)); err != nil {
+				return scope.WithVariable(step.VariablePath(), result), err
+			}
+
+			if request.ResponseFormat != nil {
+				if choice.FinishReason == "length" {
+					return scope.WithVariable(step.VariablePath(), result), ErrTruncated
+				}
+
+				var decoded any
+				if err := json.Unmarshal([]byte(choice.Message.Content), &decoded); err != nil {
+					return scope.WithVariable(step.VariablePath(), result), fmt.Errorf("error decoding completion: %w", err)
+				}
+
+				result["json"] = decoded
+			}
+
+			return scope.WithVariable(step.VariablePath(), result), nil
+		},
+	)
+}
+
+type chatRequest struct {
+	Model          string            `json:"model"`
+	Messages       []Message         `json:"messages"`
+	MaxTokens      int               `json:"max_tokens,omitempty"`
+	Temperature    *float64          `json:"temperature,omitempty"`
+	ResponseFormat map[string]string `json:"response_format,omitempty"`
+}
+
+type chatResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      Message `json:"message"`
+		FinishReason string  `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+func (p StepParams) request(ctx context.Context, scope pipeline.Scope) (chatRequest, error) {
+	var (
+		request chatRequest
+		err     error
+	)
+
+	if request.Model, err = p.Model.Eval(ctx, scope); err != nil {
+		return request, err
+	}
+
+	system, err := p.System.Eval(ctx, scope)
+	if err != nil {
+		return request, err
+	}
+
+	if system != "" {
+		request.Messages = append(request.Messages, Message{Role: "system", Content: system})
+	}
+
+	messages, err := p.Messages.Eval(ctx, scope)
+	if err != nil {
+		return request, err
+	}
+
+	request.Messages = append(request.Messages, messages...)
+
+	prompt, err := p.Prompt.Eval(ctx, scope)
+	if err != nil {
+		return request, err
+	}
+
+	if prompt != "" {
+		request.Messages = append(request.Messages, Message{Role: "user", Content: prompt})
+	}
+
+	if request.MaxTokens, err = p.MaxTokens.Eval(ctx, scope); err != nil {
+		return request, err
+	}
+
+	temperature, err := p.Temperature.Eval(ctx, scope)
+	if err != nil {
+		return request, err
+	}
+
+	if temperature != "" {
+		value, err := strconv.ParseFloat(temperature, 64)
+		if err != nil {
+			return request, fmt.Errorf("invalid temperature %q: %w", temperature, err)
+		}
+
+		request.Temperature = &value
+	}
+
+	jsonMode, err := p.JSON.Eval(ctx, scope)
+	if err != nil {
+		return request, err
+	}
+
+	if jsonMode {
+		request.ResponseFormat = map[string]string{"type": "json_object"}
+	}
+
+	return request, nil
+}
+
+func (e Endpoint) complete(ctx context.Context, request chatRequest) (chatResponse, error) {
+	var response chatResponse
+
+	blob, err := json.Marshal(request)
+	if err != nil {
+		return response, err
+	}
+
+	base := e.URL
+	if base == "" {
+		base = DefaultURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(base, "/")+"/chat/completions", bytes.NewReader(blob))
+	if err != nil {
+		return response, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if e.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.APIKey)
+	}
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return response, err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	blob, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return response, err
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return response, fmt.Errorf("chat completion: %s: %s", resp.Status, blob)
+	}
+
+	return response, json.Unmarshal(blob, &response)
+}
@@ -0,0 +1,93 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/command"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// Git loads the pipelines of a git repository ref, eg.: a branch, a tag or a commit, with the git CLI.
+// The ref is resolved to its commit on each load, reusing the pipelines of the last one when it hasn't moved.
+type Git struct {
+	runner     command.Runner
+	repository string
+	ref        string
+	o          options
+
+	cache
+}
+
+// NewGit creates the loader of the ref of the repository, running git with the runner, command.ExecRunner when nil.
+// See WithDir to load a directory of the repository only.
+func NewGit(runner command.Runner, repository, ref string, opts ...Option) *Git {
+	if runner == nil {
+		runner = command.ExecRunner{}
+	}
+
+	return &Git{runner: runner, repository: repository, ref: ref, o: newOptions(opts)}
+}
+
+func (l *Git) Load(ctx context.Context) (pipeline.Pipelines, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	commit, err := l.resolve(ctx)
+	if err != nil {
+		return pipeline.Pipelines{}, err
+	}
+
+	if pipelines, found := l.get(commit); found {
+		return pipelines, nil
+	}
+
+	dir, err := os.MkdirTemp("", "pipelines-")
+	if err != nil {
+		return pipeline.Pipelines{}, err
+	}
+	defer os.RemoveAll(dir)
+
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"fetch", "--quiet", "--depth", "1", l.repository, commit},
+		{"checkout", "--quiet", "FETCH_HEAD"},
+	} {
+		if _, err := l.runner.Run(ctx, command.Command{Name: "git", Args: args, Dir: dir}); err != nil {
+			return pipeline.Pipelines{}, fmt.Errorf("fetching %s of %s: %w", l.ref, l.repository, err)
+		}
+	}
+
+	pipelines, err := loadDir(dir, l.o)
+	if err != nil {
+		return pipeline.Pipelines{}, fmt.Errorf("loading %s of %s: %w", l.ref, l.repository, err)
+	}
+
+	l.set(commit, pipelines)
+
+	return pipelines, nil
+}
+
+// resolve returns the commit of the ref, listing the remote refs unless it's a commit already.
+func (l *Git) resolve(ctx context.Context) (string, error) {
+	if commitPattern.MatchString(l.ref) {
+		return l.ref, nil
+	}
+
+	result, err := l.runner.Run(ctx, command.Command{Name: "git", Args: []string{"ls-remote", l.repository, l.ref}})
+	if err != nil {
+		return "", fmt.Errorf("resolving %s of %s: %w", l.ref, l.repository, err)
+	}
+
+	commit, _, _ := strings.Cut(string(result.Stdout), "\t")
+	if !commitPattern.MatchString(commit) {
+		return "", fmt.Errorf("ref %s is not found in %s", l.ref, l.repository)
+	}
+
+	return commit, nil
+}
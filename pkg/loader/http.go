@@ -0,0 +1,104 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// HTTP loads the pipelines of a URL, either a YAML file or a tar archive of them, gzipped or not.
+// It's conditionally fetched with the etag of the last response, reusing its pipelines when it's not modified,
+// as when its content has the same checksum.
+type HTTP struct {
+	client Doer
+	url    string
+	o      options
+
+	cache
+	etag string
+}
+
+// NewHTTP creates the loader of the URL, fetched with the client, http.DefaultClient when nil.
+func NewHTTP(client Doer, url string, opts ...Option) *HTTP {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &HTTP{client: client, url: url, o: newOptions(opts)}
+}
+
+func (l *HTTP) Load(ctx context.Context) (pipeline.Pipelines, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.url, nil)
+	if err != nil {
+		return pipeline.Pipelines{}, err
+	}
+
+	req.Header = l.o.header.Clone()
+	if l.etag != "" {
+		req.Header.Set("If-None-Match", l.etag)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return pipeline.Pipelines{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return l.pipelines, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return pipeline.Pipelines{}, fmt.Errorf("fetching %s: unexpected status %s", l.url, resp.Status)
+	}
+
+	content, err := readAll(resp.Body, l.o.maxSize)
+	if err != nil {
+		return pipeline.Pipelines{}, fmt.Errorf("fetching %s: %w", l.url, err)
+	}
+
+	sum := checksum(content)
+	if l.o.checksum != "" && sum != l.o.checksum {
+		return pipeline.Pipelines{}, fmt.Errorf("fetching %s: checksum %s doesn't match %s", l.url, sum, l.o.checksum)
+	}
+
+	pipelines, found := l.get(sum)
+	if !found {
+		fetched := files{}
+		if err := fetched.add(fileName(l.url), content, l.o.maxSize); err != nil {
+			return pipeline.Pipelines{}, err
+		}
+
+		if pipelines, err = fetched.load(l.o); err != nil {
+			return pipeline.Pipelines{}, fmt.Errorf("loading %s: %w", l.url, err)
+		}
+
+		l.set(sum, pipelines)
+	}
+
+	l.etag = resp.Header.Get("ETag")
+
+	return pipelines, nil
+}
+
+// fileName returns the name of the file of the URL, pipelines.yaml when it has no YAML extension, as the
+// archives ones.
+func fileName(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "pipelines.yaml"
+	}
+
+	if name := path.Base(parsed.Path); path.Ext(name) == ".yaml" || path.Ext(name) == ".yml" {
+		return name
+	}
+
+	return "pipelines.yaml"
+}
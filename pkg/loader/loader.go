@@ -0,0 +1,233 @@
+// Package loader loads pipeline definitions from remote sources, eg.: a URL, a git ref, an S3 bucket or an OCI
+// artifact, so deployments don't need the YAML baked into their images. Its loaders implement pipeline.Loader,
+// caching the pipelines of the unchanged sources by their etag, commit, checksum or digest.
+package loader
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+	"github.com/samber/lo"
+)
+
+// DefaultMaxSize is the maximum size of each fetched or extracted file, see WithMaxSize.
+const DefaultMaxSize int64 = 32 << 20
+
+// ErrTooLarge is returned when a fetched or extracted file exceeds the maximum size, see WithMaxSize.
+var ErrTooLarge = errors.New("too large")
+
+// Doer sends HTTP requests, eg.: *http.Client.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Option configures the loaders.
+type Option func(*options)
+
+type options struct {
+	load      []pipeline.LoadOption
+	header    http.Header
+	checksum  string
+	dir       string
+	plainHTTP bool
+	maxSize   int64
+}
+
+// WithLoadOptions configures how the fetched definitions are loaded, eg.: pipeline.WithDirectoryNamespaces.
+func WithLoadOptions(opts ...pipeline.LoadOption) Option {
+	return func(o *options) {
+		o.load = append(o.load, opts...)
+	}
+}
+
+// WithHeader adds the header to the HTTP and OCI loaders requests, eg.: Authorization.
+func WithHeader(name, value string) Option {
+	return func(o *options) {
+		o.header.Add(name, value)
+	}
+}
+
+// WithChecksum pins the hex SHA-256 checksum of the content fetched by the HTTP loader, failing when it differs.
+func WithChecksum(sum string) Option {
+	return func(o *options) {
+		o.checksum = strings.ToLower(strings.TrimPrefix(sum, "sha256:"))
+	}
+}
+
+// WithDir loads the pipelines of a directory of the fetched files only, eg.: the pipelines directory of a repository.
+func WithDir(dir string) Option {
+	return func(o *options) {
+		o.dir = dir
+	}
+}
+
+// WithPlainHTTP sends the OCI loader requests to the registry over HTTP, eg.: a local one.
+func WithPlainHTTP() Option {
+	return func(o *options) {
+		o.plainHTTP = true
+	}
+}
+
+// WithMaxSize limits the size of each file, response, object or blob fetched by the loaders, and of each file
+// extracted from their archives, DefaultMaxSize by default, failing with ErrTooLarge beyond it.
+func WithMaxSize(size int64) Option {
+	return func(o *options) {
+		o.maxSize = size
+	}
+}
+
+// Parse returns the loader of the source: an http(s) URL, a git+<repository>#<ref> git ref, HEAD by default,
+// or an oci://<reference> artifact, fetched with the default clients. S3 buckets need a client, see NewS3.
+func Parse(source string, opts ...Option) (pipeline.Loader, error) {
+	switch {
+	case strings.HasPrefix(source, "http://"), strings.HasPrefix(source, "https://"):
+		return NewHTTP(nil, source, opts...), nil
+	case strings.HasPrefix(source, "git+"):
+		repository, ref, _ := strings.Cut(strings.TrimPrefix(source, "git+"), "#")
+
+		return NewGit(nil, repository, lo.CoalesceOrEmpty(ref, "HEAD"), opts...), nil
+	case strings.HasPrefix(source, "oci://"):
+		return NewOCI(nil, strings.TrimPrefix(source, "oci://"), opts...), nil
+	}
+
+	return nil, fmt.Errorf("unsupported pipelines source %q", source)
+}
+
+func newOptions(opts []Option) options {
+	o := options{header: http.Header{}, maxSize: DefaultMaxSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// cache holds the pipelines loaded from a version of a source, eg.: its etag or commit.
+type cache struct {
+	mu        sync.Mutex
+	version   string
+	pipelines pipeline.Pipelines
+}
+
+// get returns the pipelines of the version, if they were the last ones loaded.
+func (c *cache) get(version string) (pipeline.Pipelines, bool) {
+	if version == "" || version != c.version {
+		return pipeline.Pipelines{}, false
+	}
+
+	return c.pipelines, true
+}
+
+func (c *cache) set(version string, pipelines pipeline.Pipelines) {
+	c.version, c.pipelines = version, pipelines
+}
+
+// files are the fetched files by their slash separated paths.
+type files map[string][]byte
+
+// add adds the content by its name, extracting the YAML files of the tar archives, gzipped or not, up to the size.
+func (f files) add(name string, content []byte, size int64) error {
+	if bytes.HasPrefix(content, []byte{0x1f, 0x8b}) {
+		reader, err := gzip.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return fmt.Errorf("decompressing %s: %w", name, err)
+		}
+
+		if content, err = readAll(reader, size); err != nil {
+			return fmt.Errorf("decompressing %s: %w", name, err)
+		}
+	}
+
+	if len(content) <= 262 || string(content[257:262]) != "ustar" {
+		f[name] = content
+
+		return nil
+	}
+
+	archive := tar.NewReader(bytes.NewReader(content))
+
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("extracting %s: %w", name, err)
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		blob, err := readAll(archive, size)
+		if err != nil {
+			return fmt.Errorf("extracting %s: %w", name, err)
+		}
+
+		f[header.Name] = blob
+	}
+}
+
+// load loads the pipelines of the files, written to a temporary directory removed afterwards.
+func (f files) load(o options) (pipeline.Pipelines, error) {
+	dir, err := os.MkdirTemp("", "pipelines-")
+	if err != nil {
+		return pipeline.Pipelines{}, err
+	}
+	defer os.RemoveAll(dir)
+
+	for name, content := range f {
+		// Cleaning the rooted path keeps the files, eg.: ../name ones of archives, in the directory.
+		target := filepath.Join(dir, filepath.FromSlash(path.Clean("/"+name)))
+
+		if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+			return pipeline.Pipelines{}, err
+		}
+
+		if err := os.WriteFile(target, content, 0o600); err != nil {
+			return pipeline.Pipelines{}, err
+		}
+	}
+
+	return loadDir(dir, o)
+}
+
+// loadDir loads the pipelines of the directory, or of its WithDir subdirectory.
+func loadDir(dir string, o options) (pipeline.Pipelines, error) {
+	return pipeline.Load(os.DirFS(filepath.Join(dir, filepath.FromSlash(path.Clean("/"+o.dir)))), o.load...)
+}
+
+// readAll reads the reader up to the size, failing with ErrTooLarge beyond it.
+func readAll(reader io.Reader, size int64) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(reader, size+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(content)) > size {
+		return nil, fmt.Errorf("%w: exceeds %d bytes, see WithMaxSize", ErrTooLarge, size)
+	}
+
+	return content, nil
+}
+
+// checksum returns the hex SHA-256 checksum of the content.
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+
+	return hex.EncodeToString(sum[:])
+}
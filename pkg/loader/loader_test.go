@@ -0,0 +1,442 @@
+package loader
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/artifact"
+	"github.com/crowleyfelix/go-pipeline/pkg/command"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+const definition = "name: greet\nsteps:\n  - type: set\n    params:\n      message: hello\n"
+
+func TestHTTP(t *testing.T) {
+	t.Parallel()
+
+	var fetches atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		fetches.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(definition))
+	}))
+	defer server.Close()
+
+	loader := NewHTTP(server.Client(), server.URL+"/greet.yaml", WithHeader("Authorization", "Bearer token"),
+		WithChecksum("sha256:"+checksum([]byte(definition))))
+
+	for range 2 {
+		pipelines, err := loader.Load(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, found := pipelines.Get("greet"); !found {
+			t.Fatalf("unexpected pipelines: %v", pipelines.Names())
+		}
+	}
+
+	if fetches.Load() != 1 {
+		t.Fatalf("expected the not modified content to be reused, fetched %d times", fetches.Load())
+	}
+
+	if _, err := NewHTTP(server.Client(), server.URL, WithHeader("Authorization", "Bearer token"),
+		WithChecksum("abc")).Load(context.Background()); err == nil {
+		t.Fatal("expected a checksum mismatch error")
+	}
+
+	if _, err := NewHTTP(server.Client(), server.URL).Load(context.Background()); err == nil {
+		t.Fatal("expected an unexpected status error")
+	}
+}
+
+func TestHTTPArchive(t *testing.T) {
+	t.Parallel()
+
+	archive := tarGz(t, map[string]string{
+		"pipelines/greet.yaml":    definition,
+		"pipelines/billing/a.yml": "name: charge\nsteps: []\n",
+		"README.md":               "# pipelines",
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(archive)
+	}))
+	defer server.Close()
+
+	pipelines, err := NewHTTP(nil, server.URL+"/pipelines.tar.gz", WithDir("pipelines"),
+		WithLoadOptions(pipeline.WithDirectoryNamespaces())).Load(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if names := strings.Join(pipelines.Names(), ","); names != "billing/charge,greet" {
+		t.Fatalf("unexpected pipelines: %s", names)
+	}
+}
+
+func TestGit(t *testing.T) {
+	t.Parallel()
+
+	commit := strings.Repeat("a", 40)
+
+	var checkouts atomic.Int32
+
+	runner := command.RunnerFunc(func(_ context.Context, cmd command.Command) (command.Result, error) {
+		switch cmd.Args[0] {
+		case "ls-remote":
+			if cmd.Args[2] != "main" {
+				return command.Result{}, nil
+			}
+
+			return command.Result{Stdout: []byte(commit + "\trefs/heads/main\n")}, nil
+		case "fetch":
+			if cmd.Args[5] != commit {
+				t.Errorf("unexpected fetched commit: %v", cmd.Args)
+			}
+		case "checkout":
+			checkouts.Add(1)
+
+			if err := os.MkdirAll(filepath.Join(cmd.Dir, "deploy"), 0o750); err != nil {
+				return command.Result{}, err
+			}
+
+			return command.Result{}, os.WriteFile(filepath.Join(cmd.Dir, "deploy", "greet.yaml"), []byte(definition), 0o600)
+		}
+
+		return command.Result{}, nil
+	})
+
+	loader := NewGit(runner, "https://example.com/pipelines.git", "main", WithDir("deploy"))
+
+	for range 2 {
+		pipelines, err := loader.Load(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, found := pipelines.Get("greet"); !found {
+			t.Fatalf("unexpected pipelines: %v", pipelines.Names())
+		}
+	}
+
+	if checkouts.Load() != 1 {
+		t.Fatalf("expected the pipelines of the same commit to be reused, checked out %d times", checkouts.Load())
+	}
+
+	if _, err := NewGit(runner, "https://example.com/pipelines.git", "missing").Load(context.Background()); err == nil {
+		t.Fatal("expected a missing ref error")
+	}
+}
+
+func TestS3(t *testing.T) {
+	t.Parallel()
+
+	client := &memoryS3{objects: map[string]string{
+		"deploy/greet.yaml": definition,
+		"deploy/notes.txt":  "ignored",
+		"other/bye.yaml":    "name: bye\nsteps: []\n",
+	}}
+
+	loader := NewS3(client, "bucket", "/deploy/")
+
+	for range 2 {
+		pipelines, err := loader.Load(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if names := strings.Join(pipelines.Names(), ","); names != "greet" {
+			t.Fatalf("unexpected pipelines: %s", names)
+		}
+	}
+
+	if client.gets.Load() != 1 {
+		t.Fatalf("expected the unchanged objects to be reused, fetched %d", client.gets.Load())
+	}
+
+	client.objects["deploy/bye.yml"] = "name: bye\nsteps: []\n"
+
+	pipelines, err := loader.Load(context.Background())
+	if err != nil || len(pipelines.Names()) != 2 {
+		t.Fatalf("expected the changed objects to be loaded again: %v %v", pipelines.Names(), err)
+	}
+}
+
+func TestOCI(t *testing.T) {
+	t.Parallel()
+
+	layer := tarGz(t, map[string]string{"greet.yaml": definition})
+	layerDigest := "sha256:" + checksum(layer)
+	single := []byte("name: bye\nsteps: []\n")
+	singleDigest := "sha256:" + checksum(single)
+
+	manifest, _ := json.Marshal(map[string]any{
+		"schemaVersion": 2,
+		"layers": []map[string]any{
+			{"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "digest": layerDigest},
+			{
+				"mediaType":   "application/yaml",
+				"digest":      singleDigest,
+				"annotations": map[string]string{annotationTitle: "bye.yaml"},
+			},
+		},
+	})
+
+	var blobs atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/acme/pipelines/manifests/v1":
+			w.Header().Set("Docker-Content-Digest", "sha256:"+checksum(manifest))
+			_, _ = w.Write(manifest)
+		case "/v2/acme/pipelines/blobs/" + layerDigest:
+			blobs.Add(1)
+			_, _ = w.Write(layer)
+		case "/v2/acme/pipelines/blobs/" + singleDigest:
+			_, _ = w.Write(single)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	reference := strings.TrimPrefix(server.URL, "http://") + "/acme/pipelines:v1"
+	loader := NewOCI(server.Client(), reference, WithPlainHTTP())
+
+	for range 2 {
+		pipelines, err := loader.Load(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if names := strings.Join(pipelines.Names(), ","); names != "bye,greet" {
+			t.Fatalf("unexpected pipelines: %s", names)
+		}
+	}
+
+	if blobs.Load() != 1 {
+		t.Fatalf("expected the layers of the same manifest to be reused, fetched %d times", blobs.Load())
+	}
+
+	if _, err := NewOCI(server.Client(), "pipelines", WithPlainHTTP()).Load(context.Background()); err == nil {
+		t.Fatal("expected an invalid reference error")
+	}
+}
+
+func TestOCIManifestDigest(t *testing.T) {
+	t.Parallel()
+
+	manifest := func(content string) []byte {
+		digest := "sha256:" + checksum([]byte(content))
+		blob, _ := json.Marshal(map[string]any{"layers": []map[string]any{
+			{"mediaType": "application/yaml", "digest": digest, "annotations": map[string]string{annotationTitle: "p.yaml"}},
+		}})
+
+		return blob
+	}
+
+	greet, bye := manifest(definition), manifest("name: bye\nsteps: []\n")
+
+	var current atomic.Pointer[[]byte]
+
+	current.Store(&greet)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/manifests/"):
+			// a stale digest header mustn't keep the pipelines of the previous manifest.
+			w.Header().Set("Docker-Content-Digest", "sha256:"+checksum(greet))
+			_, _ = w.Write(*current.Load())
+		case strings.HasSuffix(r.URL.Path, checksum([]byte(definition))):
+			_, _ = w.Write([]byte(definition))
+		default:
+			_, _ = w.Write([]byte("name: bye\nsteps: []\n"))
+		}
+	}))
+	defer server.Close()
+
+	registry := strings.TrimPrefix(server.URL, "http://")
+	loader := NewOCI(server.Client(), registry+"/acme/pipelines:v1", WithPlainHTTP())
+
+	for _, expected := range []string{"greet", "bye"} {
+		pipelines, err := loader.Load(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if names := strings.Join(pipelines.Names(), ","); names != expected {
+			t.Fatalf("unexpected pipelines: %s, want %s", names, expected)
+		}
+
+		current.Store(&bye)
+	}
+
+	if _, err := NewOCI(server.Client(), registry+"/acme/pipelines@sha256:"+checksum(bye), WithPlainHTTP()).Load(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err := NewOCI(server.Client(), registry+"/acme/pipelines@sha256:"+checksum(greet), WithPlainHTTP()).Load(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "doesn't match its digest") {
+		t.Fatalf("expected a digest mismatch error, got %v", err)
+	}
+}
+
+func TestMaxSize(t *testing.T) {
+	t.Parallel()
+
+	large := "name: large\ndescription: " + strings.Repeat("a", 1024) + "\nsteps: []\n"
+	archive := tarGz(t, map[string]string{"large.yaml": large})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".tar.gz") {
+			_, _ = w.Write(archive)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(large))
+	}))
+	defer server.Close()
+
+	if _, err := NewHTTP(nil, server.URL+"/large.yaml", WithMaxSize(int64(len(large)))).Load(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := NewHTTP(nil, server.URL+"/large.yaml", WithMaxSize(512)).Load(context.Background()); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("expected the response to be too large, got %v", err)
+	}
+
+	if _, err := NewHTTP(nil, server.URL+"/large.tar.gz", WithMaxSize(int64(len(archive)))).Load(context.Background()); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("expected the extracted archive to be too large, got %v", err)
+	}
+
+	s3 := &memoryS3{objects: map[string]string{"pipelines/large.yaml": large}}
+	if _, err := NewS3(s3, "bucket", "pipelines/", WithMaxSize(512)).Load(context.Background()); !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("expected the object to be too large, got %v", err)
+	}
+}
+
+func TestParseReference(t *testing.T) {
+	t.Parallel()
+
+	for reference, expected := range map[string][3]string{
+		"ghcr.io/acme/pipelines:v1":         {"ghcr.io", "acme/pipelines", "v1"},
+		"localhost:5000/pipelines":          {"localhost:5000", "pipelines", "latest"},
+		"ghcr.io/acme/pipelines@sha256:abc": {"ghcr.io", "acme/pipelines", "sha256:abc"},
+	} {
+		registry, repository, tag, err := parseReference(reference)
+		if err != nil || [3]string{registry, repository, tag} != expected {
+			t.Fatalf("unexpected reference %s parts: %s %s %s %v", reference, registry, repository, tag, err)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	if loader, err := Parse("https://example.com/pipelines.yaml"); err != nil || loader.(*HTTP).url != "https://example.com/pipelines.yaml" {
+		t.Fatalf("unexpected HTTP loader: %#v %v", loader, err)
+	}
+
+	if loader, err := Parse("git+https://example.com/repo.git#v1"); err != nil || loader.(*Git).ref != "v1" {
+		t.Fatalf("unexpected git loader: %#v %v", loader, err)
+	}
+
+	if loader, err := Parse("git+https://example.com/repo.git"); err != nil || loader.(*Git).ref != "HEAD" {
+		t.Fatalf("expected the HEAD ref by default: %#v %v", loader, err)
+	}
+
+	if loader, err := Parse("oci://ghcr.io/acme/pipelines:v1"); err != nil || loader.(*OCI).reference != "ghcr.io/acme/pipelines:v1" {
+		t.Fatalf("unexpected OCI loader: %#v %v", loader, err)
+	}
+
+	if _, err := Parse("ftp://example.com"); err == nil {
+		t.Fatal("expected an unsupported source error")
+	}
+}
+
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	archive := tar.NewWriter(gz)
+
+	for name, content := range files {
+		if err := archive.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(content))}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := archive.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+type memoryS3 struct {
+	artifact.S3Client
+
+	objects map[string]string
+	gets    atomic.Int32
+}
+
+func (m *memoryS3) GetObject(_ context.Context, _, key string) (io.ReadCloser, error) {
+	content, found := m.objects[key]
+	if !found {
+		return nil, artifact.ErrNotFound
+	}
+
+	m.gets.Add(1)
+
+	return io.NopCloser(strings.NewReader(content)), nil
+}
+
+func (m *memoryS3) ListObjects(_ context.Context, _, prefix string) ([]artifact.S3Object, error) {
+	var objects []artifact.S3Object
+
+	for key, content := range m.objects {
+		if strings.HasPrefix(key, prefix) {
+			objects = append(objects, artifact.S3Object{Key: key, Size: int64(len(content)), LastModified: time.Unix(0, 0)})
+		}
+	}
+
+	return objects, nil
+}
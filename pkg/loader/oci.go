@@ -0,0 +1,163 @@
+package loader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// Media types of the manifests accepted by the OCI loader.
+const (
+	mediaTypeOCIManifest    = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// annotationTitle is the layer annotation holding its file name, as set by oras push.
+const annotationTitle = "org.opencontainers.image.title"
+
+// OCI loads the pipelines of the layers of an OCI artifact, eg.: pushed with oras push, each one either a YAML
+// file named by its title annotation or a tar archive of them, gzipped or not. The manifest is fetched on each
+// load, reusing the pipelines of the last one when its digest is the same, and the manifest of a digest reference
+// and the layers are verified against their digests. Registries requiring a token are authenticated with WithHeader.
+type OCI struct {
+	client    Doer
+	reference string
+	o         options
+
+	cache
+}
+
+type ociManifest struct {
+	Layers []struct {
+		MediaType   string            `json:"mediaType"`
+		Digest      string            `json:"digest"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"layers"`
+}
+
+// NewOCI creates the loader of the artifact reference, eg.: ghcr.io/acme/pipelines:v1 or
+// registry.local/pipelines@sha256:..., the latest tag by default, fetched with the client, http.DefaultClient when nil.
+func NewOCI(client Doer, reference string, opts ...Option) *OCI {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &OCI{client: client, reference: reference, o: newOptions(opts)}
+}
+
+func (l *OCI) Load(ctx context.Context) (pipeline.Pipelines, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	registry, repository, tag, err := parseReference(l.reference)
+	if err != nil {
+		return pipeline.Pipelines{}, err
+	}
+
+	scheme := "https"
+	if l.o.plainHTTP {
+		scheme = "http"
+	}
+
+	base := fmt.Sprintf("%s://%s/v2/%s", scheme, registry, repository)
+
+	blob, err := l.fetch(ctx, base+"/manifests/"+tag, mediaTypeOCIManifest+", "+mediaTypeDockerManifest)
+	if err != nil {
+		return pipeline.Pipelines{}, err
+	}
+
+	digest := "sha256:" + checksum(blob)
+	// the digests of the references are algorithm:hex, unlike their tags.
+	if strings.Contains(tag, ":") && digest != tag {
+		return pipeline.Pipelines{}, fmt.Errorf("manifest of %s doesn't match its digest %s", l.reference, digest)
+	}
+
+	if pipelines, found := l.get(digest); found {
+		return pipelines, nil
+	}
+
+	var manifest ociManifest
+	if err := json.Unmarshal(blob, &manifest); err != nil {
+		return pipeline.Pipelines{}, fmt.Errorf("decoding manifest of %s: %w", l.reference, err)
+	}
+
+	fetched := files{}
+
+	for _, layer := range manifest.Layers {
+		content, err := l.fetch(ctx, base+"/blobs/"+layer.Digest, layer.MediaType)
+		if err != nil {
+			return pipeline.Pipelines{}, err
+		}
+
+		if sum := "sha256:" + checksum(content); sum != layer.Digest {
+			return pipeline.Pipelines{}, fmt.Errorf("layer %s of %s doesn't match its digest %s", layer.Digest, l.reference, sum)
+		}
+
+		name := layer.Annotations[annotationTitle]
+		if name == "" {
+			name = strings.TrimPrefix(layer.Digest, "sha256:") + ".yaml"
+		}
+
+		if err := fetched.add(name, content, l.o.maxSize); err != nil {
+			return pipeline.Pipelines{}, err
+		}
+	}
+
+	pipelines, err := fetched.load(l.o)
+	if err != nil {
+		return pipeline.Pipelines{}, fmt.Errorf("loading %s: %w", l.reference, err)
+	}
+
+	l.set(digest, pipelines)
+
+	return pipelines, nil
+}
+
+func (l *OCI) fetch(ctx context.Context, url, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header = l.o.header.Clone()
+	req.Header.Set("Accept", accept)
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: unexpected status %s", url, resp.Status)
+	}
+
+	content, err := readAll(resp.Body, l.o.maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", url, err)
+	}
+
+	return content, nil
+}
+
+// parseReference splits the registry/repository[:tag|@digest] reference.
+func parseReference(reference string) (registry, repository, tag string, err error) {
+	registry, repository, found := strings.Cut(reference, "/")
+	if !found || registry == "" || repository == "" {
+		return "", "", "", fmt.Errorf("invalid OCI reference %q: expected registry/repository[:tag|@digest]", reference)
+	}
+
+	if name, digest, found := strings.Cut(repository, "@"); found {
+		return registry, name, digest, nil
+	}
+
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		return registry, repository[:i], repository[i+1:], nil
+	}
+
+	return registry, repository, "latest", nil
+}
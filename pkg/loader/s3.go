@@ -0,0 +1,96 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/artifact"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// S3 loads the pipelines of the YAML objects under a prefix of a bucket, sharing the client adapters of the
+// artifact.S3Store. The objects are fetched again only when the checksum of their keys, sizes and modification
+// times changes.
+type S3 struct {
+	client artifact.S3Client
+	bucket string
+	prefix string
+	o      options
+
+	cache
+}
+
+// NewS3 creates the loader of the objects of the bucket under the optional prefix.
+func NewS3(client artifact.S3Client, bucket, prefix string, opts ...Option) *S3 {
+	return &S3{client: client, bucket: bucket, prefix: strings.Trim(prefix, "/"), o: newOptions(opts)}
+}
+
+func (l *S3) Load(ctx context.Context) (pipeline.Pipelines, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	prefix := l.prefix
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	objects, err := l.client.ListObjects(ctx, l.bucket, prefix)
+	if err != nil {
+		return pipeline.Pipelines{}, fmt.Errorf("listing %s/%s: %w", l.bucket, prefix, err)
+	}
+
+	objects = slices.DeleteFunc(objects, func(object artifact.S3Object) bool {
+		return !strings.HasSuffix(object.Key, ".yaml") && !strings.HasSuffix(object.Key, ".yml")
+	})
+
+	slices.SortFunc(objects, func(a, b artifact.S3Object) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	var listing strings.Builder
+	for _, object := range objects {
+		fmt.Fprintf(&listing, "%s %d %s\n", object.Key, object.Size, object.LastModified.UTC())
+	}
+
+	sum := checksum([]byte(listing.String()))
+	if pipelines, found := l.get(sum); found {
+		return pipelines, nil
+	}
+
+	fetched := files{}
+
+	for _, object := range objects {
+		content, err := l.getObject(ctx, object.Key)
+		if err != nil {
+			return pipeline.Pipelines{}, err
+		}
+
+		fetched[strings.TrimPrefix(object.Key, prefix)] = content
+	}
+
+	pipelines, err := fetched.load(l.o)
+	if err != nil {
+		return pipeline.Pipelines{}, fmt.Errorf("loading %s/%s: %w", l.bucket, prefix, err)
+	}
+
+	l.set(sum, pipelines)
+
+	return pipelines, nil
+}
+
+func (l *S3) getObject(ctx context.Context, key string) ([]byte, error) {
+	body, err := l.client.GetObject(ctx, l.bucket, key)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s/%s: %w", l.bucket, key, err)
+	}
+	defer body.Close()
+
+	content, err := readAll(body, l.o.maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s/%s: %w", l.bucket, key, err)
+	}
+
+	return content, nil
+}
@@ -0,0 +1,91 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type (
+	fieldsKey struct{}
+	loggerKey struct{}
+)
+
+// WithLogger returns a copy of the context whose messages are logged by the given logger instead of the configured one.
+func WithLogger(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// From returns the logger set in the context by WithLogger, or the logger configured by SetUp.
+func From(ctx context.Context) Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(loggerKey{}).(Logger); ok {
+			return l
+		}
+	}
+
+	return logger
+}
+
+// Field is a key-value pair attached to every message logged with a context.
+type Field struct {
+	Key   string
+	Value any
+}
+
+func (f Field) String() string {
+	return fmt.Sprintf("%s=%v", f.Key, f.Value)
+}
+
+// WithFields returns a copy of the context carrying the given fields.
+// Fields with a key already present in the context replace the previous value.
+func WithFields(ctx context.Context, fields ...Field) context.Context {
+	current := Fields(ctx)
+	merged := make([]Field, 0, len(current)+len(fields))
+	merged = append(merged, current...)
+
+	for _, field := range fields {
+		replaced := false
+
+		for i := range merged {
+			if merged[i].Key == field.Key {
+				merged[i] = field
+				replaced = true
+
+				break
+			}
+		}
+
+		if !replaced {
+			merged = append(merged, field)
+		}
+	}
+
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// Fields returns the fields attached to the context.
+func Fields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+
+	fields, _ := ctx.Value(fieldsKey{}).([]Field)
+
+	return fields
+}
+
+// formatFields formats the fields of the context, with its secrets redacted like the messages, see RedactContext.
+func formatFields(ctx context.Context) string {
+	fields := Fields(ctx)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = RedactContext(ctx, field.String())
+	}
+
+	return " " + strings.Join(parts, " ")
+}
@@ -7,6 +7,11 @@ var (
 )
 
 func SetUp(l Logger) {
+	if _, ok := l.(redacting); ok {
+		// Log() delegates to the configured logger, so it can't be configured itself.
+		return
+	}
+
 	logger = l
 }
 
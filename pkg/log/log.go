@@ -17,6 +17,7 @@ type Logger interface {
 	Debug(ctx context.Context, msg string, any ...any)
 }
 
+// Log returns a logger delegating to the context logger, see From. Messages are redacted before reaching it, see Redact.
 func Log() Logger {
-	return logger
+	return redacting{}
 }
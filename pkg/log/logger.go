@@ -9,22 +9,22 @@ type Standard struct{}
 
 // Error - logs an error message.
 func (s Standard) Error(ctx context.Context, msg string, any ...any) {
-	log.Printf("[ERROR] "+msg, any...)
+	log.Printf("[ERROR] "+msg+"%s", append(any, formatFields(ctx))...)
 }
 
 // Warn - logs a warning message.
 func (s Standard) Warn(ctx context.Context, msg string, any ...any) {
-	log.Printf("[WARN] "+msg, any...)
+	log.Printf("[WARN] "+msg+"%s", append(any, formatFields(ctx))...)
 }
 
 // Info - logs an informational message.
 func (s Standard) Info(ctx context.Context, msg string, any ...any) {
-	log.Printf("[INFO] "+msg, any...)
+	log.Printf("[INFO] "+msg+"%s", append(any, formatFields(ctx))...)
 }
 
 // Debug - logs a debug message.
 func (s Standard) Debug(ctx context.Context, msg string, any ...any) {
-	log.Printf("[DEBUG] "+msg, any...)
+	log.Printf("[DEBUG] "+msg+"%s", append(any, formatFields(ctx))...)
 }
 
 type Noop struct{}
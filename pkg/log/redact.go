@@ -0,0 +1,181 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces sensitive content in logged messages.
+const Redacted = "[REDACTED]"
+
+// minSecretLength avoids masking every occurrence of trivially short values.
+const minSecretLength = 4
+
+var secretKey = regexp.MustCompile(`(?i)^[\w.-]*(password|passwd|secret|token|api[_-]?key|access[_-]?key|authorization|credential)[\w.-]*$`)
+
+// Redaction replaces the matches of Pattern by Replacement, which may reference submatches (eg.: ${1}).
+type Redaction struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+var (
+	redactionsMu sync.RWMutex
+	redactions   = []Redaction{
+		{
+			Pattern:     regexp.MustCompile(`(?i)(bearer\s+)[a-z0-9\-._~+/]+=*`),
+			Replacement: "${1}" + Redacted,
+		},
+		{
+			Pattern: regexp.MustCompile(
+				`(?i)("?[\w-]*(?:password|passwd|secret|token|api[_-]?key|access[_-]?key|authorization)[\w-]*"?` +
+					`\s*[:=]\s*"?(?:(?:bearer|basic|token)\s+)?)([^"\s,&}]+)`,
+			),
+			Replacement: "${1}" + Redacted,
+		},
+		{
+			Pattern:     regexp.MustCompile(`(://[^:/\s]+:)[^@/\s]+@`),
+			Replacement: "${1}" + Redacted + "@",
+		},
+	}
+	secrets = map[string]struct{}{}
+)
+
+// RegisterRedaction adds a pattern applied to every logged message.
+func RegisterRedaction(redaction Redaction) {
+	redactionsMu.Lock()
+	defer redactionsMu.Unlock()
+
+	redactions = append(redactions, redaction)
+}
+
+// RegisterSecret registers values that must never be logged in plaintext by any execution, eg.: process credentials.
+// Values shorter than 4 characters are ignored. Secrets of an execution are registered in its Secrets instead,
+// so they're dropped once it finishes, see WithSecrets.
+func RegisterSecret(values ...string) {
+	redactionsMu.Lock()
+	defer redactionsMu.Unlock()
+
+	for _, value := range values {
+		if len(value) < minSecretLength {
+			continue
+		}
+
+		secrets[value] = struct{}{}
+	}
+}
+
+// Secrets holds values that must never be logged in plaintext within a context, eg.: an execution, see WithSecrets.
+// It's safe for concurrent use.
+type Secrets struct {
+	mu     sync.RWMutex
+	values map[string]struct{}
+}
+
+// NewSecrets creates an empty set of secrets.
+func NewSecrets() *Secrets {
+	return &Secrets{values: map[string]struct{}{}}
+}
+
+// Add registers the values. Values shorter than 4 characters are ignored.
+func (s *Secrets) Add(values ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, value := range values {
+		if len(value) >= minSecretLength {
+			s.values[value] = struct{}{}
+		}
+	}
+}
+
+// Redact masks the secrets in the message.
+func (s *Secrets) Redact(msg string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for secret := range s.values {
+		msg = strings.ReplaceAll(msg, secret, Redacted)
+	}
+
+	return msg
+}
+
+type secretsKey struct{}
+
+// WithSecrets returns a copy of the context whose logged messages mask the secrets too,
+// besides the ones of the parent contexts and the registered ones.
+func WithSecrets(ctx context.Context, s *Secrets) context.Context {
+	current, _ := ctx.Value(secretsKey{}).([]*Secrets)
+
+	return context.WithValue(ctx, secretsKey{}, append(current[:len(current):len(current)], s))
+}
+
+// IsSecretKey reports whether a key name (eg.: "api_key", "access_token") usually holds a secret.
+func IsSecretKey(key string) bool {
+	return secretKey.MatchString(key)
+}
+
+// Redact masks registered secrets and redaction patterns in the message.
+func Redact(msg string) string {
+	redactionsMu.RLock()
+	defer redactionsMu.RUnlock()
+
+	for secret := range secrets {
+		msg = strings.ReplaceAll(msg, secret, Redacted)
+	}
+
+	for _, redaction := range redactions {
+		msg = redaction.Pattern.ReplaceAllString(msg, redaction.Replacement)
+	}
+
+	return msg
+}
+
+// RedactContext masks the secrets of the context, see WithSecrets, besides the ones masked by Redact.
+func RedactContext(ctx context.Context, msg string) string {
+	if ctx != nil {
+		current, _ := ctx.Value(secretsKey{}).([]*Secrets)
+		for _, s := range current {
+			msg = s.Redact(msg)
+		}
+	}
+
+	return Redact(msg)
+}
+
+// redacting formats and redacts messages before delegating them to the context logger, see From.
+type redacting struct{}
+
+func (redacting) Error(ctx context.Context, msg string, any ...any) {
+	if l := From(ctx); !isNoop(l) {
+		l.Error(ctx, "%s", RedactContext(ctx, fmt.Sprintf(msg, any...)))
+	}
+}
+
+func (redacting) Warn(ctx context.Context, msg string, any ...any) {
+	if l := From(ctx); !isNoop(l) {
+		l.Warn(ctx, "%s", RedactContext(ctx, fmt.Sprintf(msg, any...)))
+	}
+}
+
+func (redacting) Info(ctx context.Context, msg string, any ...any) {
+	if l := From(ctx); !isNoop(l) {
+		l.Info(ctx, "%s", RedactContext(ctx, fmt.Sprintf(msg, any...)))
+	}
+}
+
+func (redacting) Debug(ctx context.Context, msg string, any ...any) {
+	if l := From(ctx); !isNoop(l) {
+		l.Debug(ctx, "%s", RedactContext(ctx, fmt.Sprintf(msg, any...)))
+	}
+}
+
+func isNoop(l Logger) bool {
+	_, ok := l.(Noop)
+
+	return ok
+}
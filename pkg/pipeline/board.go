@@ -0,0 +1,107 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+)
+
+// execution holds the state shared by all the pipelines of an execution, including the concurrent branches.
+type execution struct {
+	board     *board
+	workspace *workspace
+	finishers *finishers
+	budgets   *budgets
+	limits    *limits
+	sandbox   sandbox
+	snapshots *snapshots
+	caches    map[string]Cache
+	random    *random
+	// secrets are the values redacted from the execution logs, see registerSecrets.
+	secrets *log.Secrets
+}
+
+func executionFrom(ctx context.Context) *execution {
+	exec, _ := ctx.Value(executionKey{}).(*execution)
+
+	return exec
+}
+
+// board shares the variables set by the steps across the concurrent branches of an execution, see WaitForExecutor.
+type board struct {
+	mu        sync.Mutex
+	variables map[VariablePath]any
+	changed   chan struct{}
+}
+
+func newBoard() *board {
+	return &board{variables: map[VariablePath]any{}, changed: make(chan struct{})}
+}
+
+func boardFrom(ctx context.Context) *board {
+	if exec := executionFrom(ctx); exec != nil {
+		return exec.board
+	}
+
+	return nil
+}
+
+// publish shares the step variable, notifying the waiting steps.
+func (b *board) publish(scope Scope, step Step) {
+	if b == nil || step.ID == "" {
+		return
+	}
+
+	path := scope.qualifyPath(step.VariablePath())
+
+	value, found := scope.variables.get(path)
+	if !found {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.variables[path] = value
+
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// snapshot returns the shared variables and a channel closed on the next change.
+func (b *board) snapshot() (map[VariablePath]any, <-chan struct{}) {
+	if b == nil {
+		return nil, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	variables := make(map[VariablePath]any, len(b.variables))
+	for path, value := range b.variables {
+		variables[path] = value
+	}
+
+	return variables, b.changed
+}
+
+// withShared returns the scope with the shared variables it doesn't have yet.
+func (c Scope) withShared(shared map[VariablePath]any) Scope {
+	if len(shared) == 0 {
+		return c
+	}
+
+	missing := map[VariablePath]any{}
+
+	for path, value := range shared {
+		if _, found := c.variables.get(path); !found {
+			missing[path] = value
+		}
+	}
+
+	merged := c.copy()
+	merged.variables = c.variables.with(missing)
+
+	return merged
+}
@@ -0,0 +1,111 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+)
+
+// ErrBudgetExhausted is returned by Spend when a budget set with WithBudget is exhausted.
+var ErrBudgetExhausted = errors.New("budget exhausted")
+
+// Budget limits a resource used by the steps of an execution, eg.: HTTP requests, LLM cost or command time.
+type Budget struct {
+	Limit float64
+	// Degrade keeps the execution running once the budget is exhausted: spending only logs a warning,
+	// and pipelines can check the remaining budget with the budget function to skip optional work.
+	Degrade bool
+}
+
+// budgets are the remaining budgets of an execution.
+type budgets struct {
+	mu        sync.Mutex
+	limits    map[string]Budget
+	remaining map[string]float64
+}
+
+func (b *budgets) set(name string, budget Budget) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.limits == nil {
+		b.limits, b.remaining = map[string]Budget{}, map[string]float64{}
+	}
+
+	b.limits[name] = budget
+	b.remaining[name] = budget.Limit
+}
+
+func (b *budgets) spend(name string, amount float64) (remaining float64, budget Budget, found bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	budget, found = b.limits[name]
+	if !found {
+		return 0, budget, false
+	}
+
+	b.remaining[name] -= amount
+
+	return b.remaining[name], budget, true
+}
+
+func (b *budgets) get(name string) (float64, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	remaining, found := b.remaining[name]
+
+	return remaining, found
+}
+
+// WithBudget limits a named resource of the execution, decremented by the steps with Spend.
+// Built-in names are set by the packages spending them, eg.: http.BudgetRequests.
+func WithBudget(name string, budget Budget) Option {
+	return func(o *options) {
+		if o.budgets == nil {
+			o.budgets = map[string]Budget{}
+		}
+
+		o.budgets[name] = budget
+	}
+}
+
+// Spend decrements the named budget of the execution running with the context by the amount.
+// It returns an error wrapping ErrBudgetExhausted when the amount exceeds the remaining budget,
+// unless the budget degrades. Spending budgets not set for the execution does nothing.
+func Spend(ctx context.Context, name string, amount float64) error {
+	exec := executionFrom(ctx)
+	if exec == nil {
+		return nil
+	}
+
+	remaining, budget, found := exec.budgets.spend(name, amount)
+	if !found || remaining >= 0 {
+		return nil
+	}
+
+	if budget.Degrade {
+		if remaining+amount >= 0 {
+			log.Log().Warn(ctx, "Budget %s of %v exhausted, degrading", name, budget.Limit)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s of %v", ErrBudgetExhausted, name, budget.Limit)
+}
+
+// Remaining returns the remaining named budget of the execution running with the context,
+// negative once exhausted, and whether the budget is set.
+func Remaining(ctx context.Context, name string) (float64, bool) {
+	exec := executionFrom(ctx)
+	if exec == nil {
+		return 0, false
+	}
+
+	return exec.budgets.get(name)
+}
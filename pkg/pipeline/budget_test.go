@@ -0,0 +1,54 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBudgets(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine()
+	engine.RegisterStepExecutor("call", FuncExecutor(func(ctx context.Context, _ map[string]any) (bool, error) {
+		return true, Spend(ctx, "calls", 1)
+	}))
+
+	pipelines := NewPipelines(New("main").
+		Step(Step{ID: "first", Type: "call"}).
+		Step(Step{ID: "second", Type: "call"}).
+		Set("left", map[string]any{"calls": `{{ budget . "calls" }}`}).
+		Build())
+
+	t.Run("fails once exhausted", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := engine.Execute(context.Background(), NewScope(pipelines), []string{"main"}, WithBudget("calls", Budget{Limit: 1}))
+		assert.ErrorIs(t, err, ErrBudgetExhausted)
+	})
+
+	t.Run("degrades once exhausted", func(t *testing.T) {
+		t.Parallel()
+
+		scope, err := engine.Execute(context.Background(), NewScope(pipelines), []string{"main"},
+			WithBudget("calls", Budget{Limit: 1, Degrade: true}))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		left, _ := scope.Variable("left")
+		assert.Equal(t, map[string]any{"calls": "-1"}, left)
+	})
+
+	t.Run("ignores budgets not set", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := engine.Execute(context.Background(), NewScope(NewPipelines(New("main").
+			Step(Step{ID: "first", Type: "call"}).Build())), []string{"main"})
+		assert.NoError(t, err)
+
+		_, found := Remaining(context.Background(), "calls")
+		assert.False(t, found)
+	})
+}
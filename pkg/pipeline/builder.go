@@ -0,0 +1,184 @@
+package pipeline
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"gopkg.in/yaml.v3"
+)
+
+// Builder constructs pipelines in code, eg.:
+//
+//	pipe := pipeline.New("users").
+//		Step(http.Get("users", "https://api.example.com/users")).
+//		Range("user", pipeline.RangeParams{JSON: `{{ variable . "users.$body" }}`},
+//			pipeline.LogStep(`{{ variable . "user" }}`)).
+//		Build()
+type Builder struct {
+	pipeline Pipeline
+}
+
+// New starts building a pipeline with the given name.
+func New(name string) *Builder {
+	return &Builder{pipeline: Pipeline{Name: name}}
+}
+
+// ID sets the pipeline namespace.
+func (b *Builder) ID(id string) *Builder {
+	b.pipeline.ID = id
+
+	return b
+}
+
+// Description sets the pipeline description.
+func (b *Builder) Description(description string) *Builder {
+	b.pipeline.Description = description
+
+	return b
+}
+
+// Tags labels the pipeline.
+func (b *Builder) Tags(tags ...string) *Builder {
+	b.pipeline.Tags = append(b.pipeline.Tags, tags...)
+
+	return b
+}
+
+// Input declares a pipeline input.
+func (b *Builder) Input(name string, input Input) *Builder {
+	if b.pipeline.Inputs == nil {
+		b.pipeline.Inputs = map[string]Input{}
+	}
+
+	b.pipeline.Inputs[name] = input
+
+	return b
+}
+
+// Variable declares a variable of the pipeline data contract.
+func (b *Builder) Variable(variable VariableDeclaration) *Builder {
+	b.pipeline.Variables = append(b.pipeline.Variables, variable)
+
+	return b
+}
+
+// Output declares a pipeline output evaluated from the expression.
+func (b *Builder) Output(name, expr string) *Builder {
+	if b.pipeline.Outputs == nil {
+		b.pipeline.Outputs = map[string]expression.YAML[any]{}
+	}
+
+	b.pipeline.Outputs[name] = expression.YAML[any](expr)
+
+	return b
+}
+
+// APIVersion sets the template functions of the pipeline expressions, see Engine.RegisterAPIVersion.
+func (b *Builder) APIVersion(apiVersion string) *Builder {
+	b.pipeline.APIVersion = apiVersion
+
+	return b
+}
+
+// Uses sets the pipeline executed before the steps.
+func (b *Builder) Uses(name string) *Builder {
+	b.pipeline.Uses = name
+
+	return b
+}
+
+// Retry re-executes the pipeline on failure, see Retries.
+func (b *Builder) Retry(retries Retries) *Builder {
+	b.pipeline.Retries = retries
+
+	return b
+}
+
+// Step appends steps to the pipeline.
+func (b *Builder) Step(steps ...Step) *Builder {
+	b.pipeline.Steps = append(b.pipeline.Steps, steps...)
+
+	return b
+}
+
+// Set appends a set step.
+func (b *Builder) Set(id VariablePathNode, values map[string]any) *Builder {
+	return b.Step(SetStep(id, values))
+}
+
+// Log appends a log step.
+func (b *Builder) Log(message string) *Builder {
+	return b.Step(LogStep(message))
+}
+
+// Wait appends a wait step.
+func (b *Builder) Wait(duration time.Duration) *Builder {
+	return b.Step(NewStep("", "wait", WaitParams{Duration: expression.Duration(duration.String())}))
+}
+
+// Stop appends a stop step.
+func (b *Builder) Stop(params StopParams) *Builder {
+	return b.Step(NewStep("", "stop", params))
+}
+
+// Call appends a pipeline step executing another pipeline by name, namespaced by id when set.
+func (b *Builder) Call(id, uses string) *Builder {
+	return b.Step(NewStep("", "pipeline", Pipeline{ID: id, Uses: uses}))
+}
+
+// Range appends a range step executing the steps for each item.
+func (b *Builder) Range(id VariablePathNode, params RangeParams, steps ...Step) *Builder {
+	params.Steps = append(params.Steps, steps...)
+
+	return b.Step(NewStep(id, "range", params))
+}
+
+// Until appends an until step executing the steps while the condition is true.
+func (b *Builder) Until(condition expression.Bool, steps ...Step) *Builder {
+	return b.Step(NewStep("", "until", UntilParams{Condition: condition, Pipeline: Pipeline{Steps: steps}}))
+}
+
+// Fanout appends a fanout step executing the pipelines concurrently.
+func (b *Builder) Fanout(id VariablePathNode, concurrency int, pipelines ...Pipeline) *Builder {
+	return b.Step(NewStep(id, "fanout", FanoutParams{Concurrency: expression.Int(fmt.Sprint(concurrency)), Pipelines: pipelines}))
+}
+
+// Parallel appends a parallel step executing the steps concurrently, merging their variables with the merge strategy.
+func (b *Builder) Parallel(id VariablePathNode, merge string, steps ...Step) *Builder {
+	return b.Step(NewStep(id, "parallel", ParallelParams{Merge: expression.String(merge), Steps: steps}))
+}
+
+// Build returns the built pipeline.
+func (b *Builder) Build() Pipeline {
+	pipe := b.pipeline
+	pipe.Steps = append([]Step{}, b.pipeline.Steps...)
+
+	return pipe
+}
+
+// NewStep creates a step from typed params, which are encoded with their yaml tags.
+// It panics if the params can't be encoded, which is a programming error.
+func NewStep[Params any](id VariablePathNode, stepType string, params Params) Step {
+	var node yaml.Node
+	if err := node.Encode(params); err != nil {
+		panic(fmt.Sprintf("encoding %s step params: %v", stepType, err))
+	}
+
+	raw := map[string]any{}
+	if err := node.Decode(&raw); err != nil {
+		panic(fmt.Sprintf("encoding %s step params: %v", stepType, err))
+	}
+
+	return Step{ID: id, Type: stepType, Params: raw, params: &node}
+}
+
+// SetStep creates a set step.
+func SetStep(id VariablePathNode, values map[string]any) Step {
+	return Step{ID: id, Type: "set", Params: values}
+}
+
+// LogStep creates a log step.
+func LogStep(message string) Step {
+	return NewStep("", "log", LogParams{Message: expression.String(message)})
+}
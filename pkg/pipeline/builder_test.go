@@ -0,0 +1,40 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder(t *testing.T) {
+	t.Parallel()
+
+	child := New("child").ID("child").Set("value", map[string]any{"from": "child"}).Build()
+
+	main := New("main").
+		Description("built in code").
+		Set("setup", map[string]any{"items": `{{ list 1 2 3 | toJson }}`}).
+		Range("range", RangeParams{JSON: `{{ variableGet . "setup" "items" }}`},
+			SetStep("last", map[string]any{"item": `{{ variable . "range" }}`}),
+		).
+		Fanout("fanout", 1, child).
+		Until(`{{ ne (variableGet . "setup" "items") "" | and false }}`, LogStep("never")).
+		Log(`{{ variableGet . "child.value" "from" }}`).
+		Build()
+
+	pipelines := NewPipelines(main)
+
+	result, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	value, err := result.Variable("child.value")
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]any{"from": "child"}, value)
+	}
+
+	assert.Len(t, main.Steps, 5)
+	assert.Equal(t, "range", main.Steps[1].Type)
+}
@@ -0,0 +1,219 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+	"github.com/samber/lo"
+)
+
+// DefaultCache is the name of the cache used by the cache steps without a cache param.
+const DefaultCache = "default"
+
+// PathNodeHit is the cache step path node reporting whether its value was found in the cache.
+const PathNodeHit VariablePathNode = "$hit"
+
+// PathNodeValue is the cache step path node holding its value.
+const PathNodeValue VariablePathNode = "$value"
+
+var (
+	// ErrCacheMiss is returned by the caches for missing or expired keys.
+	ErrCacheMiss = errors.New("cache miss")
+	// ErrUnknownCache is returned when using a cache not set by WithCache.
+	ErrUnknownCache = errors.New("unknown cache")
+)
+
+// Cache keeps values across executions, eg.: exchanged tokens or reference data, see the cache package backends.
+type Cache interface {
+	// Get returns the value of the key, or ErrCacheMiss when it's missing or expired.
+	Get(ctx context.Context, key string) (any, error)
+	// Set sets the value of the key, expiring it after the TTL unless it's zero.
+	Set(ctx context.Context, key string, value any, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+// WithCache names a cache used by the cacheGet and cacheSet template functions and the cache steps.
+// Pass the same cache to the executions sharing its values.
+func WithCache(name string, cache Cache) Option {
+	return func(o *options) {
+		if o.caches == nil {
+			o.caches = map[string]Cache{}
+		}
+
+		o.caches[name] = cache
+	}
+}
+
+// CacheFrom returns the cache with the name set by WithCache in the execution.
+func CacheFrom(ctx context.Context, name string) (Cache, error) {
+	exec := executionFrom(ctx)
+	if exec == nil {
+		return nil, errOutsideExecution
+	}
+
+	return exec.cache(name)
+}
+
+func (e *execution) cache(name string) (Cache, error) {
+	cache, found := e.caches[name]
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCache, name)
+	}
+
+	return cache, nil
+}
+
+// cacheGet returns the cached value of the key, or nil when it's missing.
+// The template functions have no context: the caches are used with the background one.
+func cacheGet(scope Scope, name, key string) (any, error) {
+	if scope.execution == nil {
+		return nil, errOutsideExecution
+	}
+
+	cache, err := scope.execution.cache(name)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := cache.Get(context.Background(), key)
+	if errors.Is(err, ErrCacheMiss) {
+		return nil, nil
+	}
+
+	return value, err
+}
+
+// cacheSet caches the value of the key for the TTL, eg.: "10m", or without expiration when it's empty,
+// returning an empty string so it can be used within the expressions.
+func cacheSet(scope Scope, name, key string, value any, ttl ...string) (string, error) {
+	if scope.execution == nil {
+		return "", errOutsideExecution
+	}
+
+	cache, err := scope.execution.cache(name)
+	if err != nil {
+		return "", err
+	}
+
+	var expiration time.Duration
+
+	if len(ttl) > 0 && ttl[0] != "" {
+		if expiration, err = time.ParseDuration(ttl[0]); err != nil {
+			return "", err
+		}
+	}
+
+	return "", cache.Set(context.Background(), key, value, expiration)
+}
+
+// CacheParams defines the parameters for the CacheExecutor.
+type CacheParams struct {
+	// Cache is the name of the cache, DefaultCache when empty.
+	Cache expression.String `yaml:"cache"`
+	Key   expression.String `yaml:"key"`
+	// TTL expires the cached value, which never expires when zero.
+	TTL expression.Duration `yaml:"ttl"`
+	// Value is evaluated once the steps are executed, to be cached.
+	Value    expression.YAML[any] `yaml:"value"`
+	Pipeline `yaml:",inline"`
+}
+
+// Validate checks the cache key is set.
+func (p CacheParams) Validate() error {
+	if p.Key == "" {
+		return errors.New("cache key is required")
+	}
+
+	return nil
+}
+
+// CacheExecutor returns the value cached under the key or, on misses, executes the steps and caches the evaluated
+// value for the TTL, so repeated executions can reuse expensive lookups. The value is set in the step $value path,
+// and whether it was cached in the $hit path.
+// Errors reading the cache are logged and handled as misses; errors caching the value fail the step.
+// Read-only executions only read the cache, not caching the values, see WithReadOnly.
+// Example YAML:
+//
+//	id: cache-example
+//	steps:
+//	- id: token
+//	  type: cache
+//	  params:
+//	    cache: 'tokens'
+//	    key: 'token-{{ variable . "client_id" }}'
+//	    ttl: '50m'
+//	    value: '{{ variable . "exchange.$body.access_token" }}'
+//	    steps:
+//	    - id: exchange
+//	      type: http
+//	      params:
+//	        method: 'POST'
+//	        url: 'https://auth.example.com/token'
+//	        decode_json: true
+//	- type: log
+//	  params:
+//	    message: 'token cached: {{ variable . "token.$hit" }}'
+func CacheExecutor(ctx context.Context, scope Scope, step Step, params CacheParams) (Scope, error) {
+	name, err := params.Cache.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	key, err := params.Key.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	if key == "" {
+		return scope, errors.New("cache key is required")
+	}
+
+	ttl, err := params.TTL.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	name = lo.CoalesceOrEmpty(name, DefaultCache)
+
+	cache, err := CacheFrom(ctx, name)
+	if err != nil {
+		return scope, err
+	}
+
+	value, err := cache.Get(ctx, key)
+
+	switch {
+	case err == nil:
+		return scope.WithVariables(map[VariablePath]any{
+			step.VariablePath(PathNodeValue): value,
+			step.VariablePath(PathNodeHit):   true,
+		}), nil
+	case !errors.Is(err, ErrCacheMiss):
+		log.Log().Warn(ctx, "Error reading the cache %s key %s: %s", name, key, err)
+	}
+
+	scope, err = params.Execute(ctx, scope)
+	if err != nil || scope.Finished {
+		return scope, err
+	}
+
+	value, err = params.Value.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	if readOnlyFrom(ctx) != "" {
+		log.Log().Debug(ctx, "Not caching the key %s of the cache %s in read-only mode", key, name)
+	} else if err := cache.Set(ctx, key, value, ttl); err != nil {
+		return scope, fmt.Errorf("caching key %s: %w", key, err)
+	}
+
+	return scope.WithVariables(map[VariablePath]any{
+		step.VariablePath(PathNodeValue): value,
+		step.VariablePath(PathNodeHit):   false,
+	}), nil
+}
@@ -0,0 +1,126 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mapCache struct {
+	mu     sync.Mutex
+	values map[string]any
+	ttls   map[string]time.Duration
+}
+
+func newMapCache() *mapCache {
+	return &mapCache{values: map[string]any{}, ttls: map[string]time.Duration{}}
+}
+
+func (c *mapCache) Get(_ context.Context, key string) (any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	value, found := c.values[key]
+	if !found {
+		return nil, ErrCacheMiss
+	}
+
+	return value, nil
+}
+
+func (c *mapCache) Set(_ context.Context, key string, value any, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[key], c.ttls[key] = value, ttl
+
+	return nil
+}
+
+func (c *mapCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.values, key)
+
+	return nil
+}
+
+func TestCacheExecutor(t *testing.T) {
+	t.Parallel()
+
+	exchanges := 0
+
+	engine := NewEngine()
+	engine.RegisterStepExecutor("exchange", ReadOnly(FuncExecutor(func(context.Context, struct{}) (string, error) {
+		exchanges++
+
+		return "token-1", nil
+	})))
+
+	pipelines := NewPipelines(New("main").
+		Step(NewStep("token", "cache", CacheParams{
+			Key:      `token-{{ variable . "client" }}`,
+			TTL:      "50m",
+			Value:    `'{{ variable . "exchange" }}'`,
+			Pipeline: Pipeline{Steps: []Step{NewStep("exchange", "exchange", map[string]any{})}},
+		})).
+		Build())
+
+	cache := newMapCache()
+
+	execute := func() Scope {
+		scope, err := engine.Execute(context.Background(), NewScope(pipelines), []string{"main"},
+			WithCache(DefaultCache, cache), WithVariables(map[VariablePath]any{"client": "ci"}))
+		assert.NoError(t, err)
+
+		return scope
+	}
+
+	scope := execute()
+	value, _ := scope.Variable("token.$value")
+	hit, _ := scope.Variable("token.$hit")
+	assert.Equal(t, "token-1", value)
+	assert.Equal(t, false, hit)
+	assert.Equal(t, 50*time.Minute, cache.ttls["token-ci"])
+
+	scope = execute()
+	value, _ = scope.Variable("token.$value")
+	hit, _ = scope.Variable("token.$hit")
+	assert.Equal(t, "token-1", value)
+	assert.Equal(t, true, hit)
+	assert.Equal(t, 1, exchanges)
+
+	_, err := engine.Execute(context.Background(), NewScope(pipelines), []string{"main"},
+		WithCache(DefaultCache, cache), WithVariables(map[VariablePath]any{"client": "audit"}), WithReadOnly(ReadOnlyBlock))
+	assert.NoError(t, err)
+	assert.NotContains(t, cache.values, "token-audit", "expected read-only executions not to cache the values")
+
+	_, err = engine.Execute(context.Background(), NewScope(pipelines), []string{"main"}, WithVariables(map[VariablePath]any{"client": "ci"}))
+	assert.ErrorIs(t, err, ErrUnknownCache)
+}
+
+func TestCacheTemplateFuncs(t *testing.T) {
+	t.Parallel()
+
+	cache := newMapCache()
+	pipelines := NewPipelines(New("main").
+		Set("first", map[string]any{"cached": `{{ cacheGet . "refs" "countries" }}`}).
+		Set("store", map[string]any{"result": `{{ cacheSet . "refs" "countries" "br,us" "1h" }}`}).
+		Set("second", map[string]any{"cached": `{{ cacheGet . "refs" "countries" }}`}).
+		Build())
+
+	scope, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"}, WithCache("refs", cache))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	first, _ := scope.Variable("first.cached")
+	second, _ := scope.Variable("second.cached")
+	assert.Equal(t, "<no value>", first)
+	assert.Equal(t, "br,us", second)
+	assert.Equal(t, time.Hour, cache.ttls["countries"])
+}
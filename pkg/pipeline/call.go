@@ -0,0 +1,101 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/samber/lo"
+)
+
+// CallParams defines the parameters for the CallExecutor.
+type CallParams struct {
+	Pipeline expression.String               `yaml:"pipeline"`
+	With     expression.YAML[map[string]any] `yaml:"with"`
+	Outputs  map[string]VariablePath         `yaml:"outputs"`
+}
+
+// Validate checks the called pipeline is set.
+func (p CallParams) Validate() error {
+	if p.Pipeline == "" {
+		return errors.New("call requires a pipeline")
+	}
+
+	return nil
+}
+
+// CallExecutor executes another pipeline like a function: the pipeline runs over its own scope, with only
+// the `with` variables set, and the `outputs` are read from its variables once it finishes, setting them
+// by name in the step variable path, the outputs declared by the pipeline by default.
+// The caller variables are neither visible to nor changed by the pipeline.
+// Stopping the execution within the pipeline stops the caller too.
+// Example YAML:
+//
+//	id: call-example
+//	steps:
+//	- id: user
+//	  type: call
+//	  params:
+//	    pipeline: 'fetch-user'
+//	    with:
+//	      user_id: '42'
+//	    outputs:
+//	      name: 'profile.name'
+//	- type: log
+//	  params:
+//	    message: 'Hello {{ variableGet . "user" "name" }}'
+func CallExecutor(ctx context.Context, scope Scope, step Step, params CallParams) (Scope, error) {
+	name, err := params.Pipeline.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	pipe, found := scope.Pipelines.pipelines[name]
+	if !found {
+		return scope, fmt.Errorf("Pipeline %s not found: available %+v", name, lo.Keys(scope.Pipelines.pipelines))
+	}
+
+	with, err := params.With.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	callee := NewScope(scope.Pipelines)
+	callee.execution = scope.execution
+
+	for key, value := range with {
+		callee = callee.WithVariable(VariablePath(key), value)
+	}
+
+	result, err := pipe.Execute(ctx, callee)
+	if err != nil {
+		return scope, err
+	}
+
+	paths := params.Outputs
+	if len(paths) == 0 {
+		paths = make(map[string]VariablePath, len(pipe.Outputs))
+		for name := range pipe.Outputs {
+			paths[name] = pipe.outputPath(name)
+		}
+	}
+
+	outputs := make(map[string]any, len(paths))
+
+	for key, path := range paths {
+		value, err := result.Variable(path)
+		if err != nil {
+			return scope, fmt.Errorf("output %s of pipeline %s: %w", key, name, err)
+		}
+
+		outputs[key] = value
+	}
+
+	if result.Finished && result.stopScope == StopScopeExecution {
+		scope.Finished = true
+		scope.stopScope = StopScopeExecution
+	}
+
+	return scope.WithVariable(step.VariablePath(), outputs), nil
+}
@@ -0,0 +1,70 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallExecutor(t *testing.T) {
+	t.Parallel()
+
+	call := func(params CallParams) Step {
+		return NewStep("user", "call", params)
+	}
+
+	pipelines := NewPipelines(
+		New("main").
+			Set("secret", map[string]any{"value": "caller"}).
+			Step(call(CallParams{
+				Pipeline: "fetch-user",
+				With:     `{ user_id: '{{ variableGet . "secret" "value" }}-42' }`,
+				Outputs:  map[string]VariablePath{"name": "profile"},
+			})).
+			Build(),
+		New("fetch-user").
+			Set("profile", map[string]any{"id": `{{ variable . "user_id" }}`}).
+			Set("secret", map[string]any{"value": "callee"}).
+			Build(),
+		New("missing-output").
+			Step(call(CallParams{Pipeline: "fetch-user", Outputs: map[string]VariablePath{"name": "unknown"}})).
+			Build(),
+	)
+
+	t.Run("sets the outputs", func(t *testing.T) {
+		t.Parallel()
+
+		scope, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		user, _ := scope.Variable("user")
+		assert.Equal(t, map[string]any{"name": map[string]any{"id": "caller-42"}}, user)
+
+		secret, _ := scope.Variable("secret")
+		assert.Equal(t, map[string]any{"value": "caller"}, secret, "callee variables must not leak")
+
+		_, err = scope.Variable("profile")
+		assert.ErrorIs(t, err, ErrVariableNotFound)
+	})
+
+	t.Run("fails on missing outputs", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"missing-output"})
+		assert.ErrorIs(t, err, ErrVariableNotFound)
+	})
+
+	t.Run("detects cycles", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Load(fstest.MapFS{
+			"a.yaml": {Data: []byte("name: a\nsteps:\n- type: call\n  params:\n    pipeline: b\n")},
+			"b.yaml": {Data: []byte("name: b\nuses: a\n")},
+		})
+		assert.ErrorIs(t, err, ErrCycle)
+	})
+}
@@ -0,0 +1,181 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrCheckpointNotFound is returned by the checkpoint stores when the checkpoint doesn't exist.
+	ErrCheckpointNotFound = errors.New("checkpoint not found")
+	// ErrNoCheckpointStore is returned when resuming an execution without a store, see WithCheckpoints.
+	ErrNoCheckpointStore = errors.New("no checkpoint store")
+)
+
+// Checkpoint records the progress of an execution after each step of the pipelines started by Pipelines.Execute,
+// so it can be resumed from the next step, eg.: after a process restart, see Pipelines.Resume.
+// Steps of nested pipelines (eg.: uses, pipeline and range steps) aren't recorded: they run again as a whole.
+type Checkpoint struct {
+	// ID is the execution ID.
+	ID string `json:"id"`
+	// Pipelines are the names of the executed pipelines.
+	Pipelines []string `json:"pipelines"`
+	// Pipeline is the index of the running pipeline in Pipelines.
+	Pipeline int `json:"pipeline"`
+	// Step is the number of steps of the running pipeline already executed.
+	Step int `json:"step"`
+	// Codec is the name of the codec encoding the variables, see CodecFrom.
+	Codec string `json:"codec"`
+	// Variables are the encoded scope variables by path, secrets included: protect the stores accordingly.
+	Variables []byte    `json:"variables"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CheckpointStore persists the checkpoints by execution ID, eg.: in files, S3 buckets or Redis, see the checkpoint package.
+type CheckpointStore interface {
+	// Save stores the checkpoint, replacing the previous one of the execution.
+	Save(ctx context.Context, checkpoint Checkpoint) error
+	// Load returns the checkpoint of the execution, or ErrCheckpointNotFound.
+	Load(ctx context.Context, id string) (Checkpoint, error)
+	// Delete removes the checkpoint of the execution, if any.
+	Delete(ctx context.Context, id string) error
+}
+
+// WithCheckpoints saves a checkpoint in the store after each step of the executed pipelines, deleting it once
+// the execution succeeds, so failed or interrupted executions can be resumed with Pipelines.Resume.
+func WithCheckpoints(store CheckpointStore) Option {
+	return func(o *options) {
+		o.checkpoints = store
+	}
+}
+
+type (
+	checkpointsKey struct{}
+	checkpointKey  struct{}
+)
+
+// Resume resumes the execution of the checkpoint with the ID, loaded from the store set by WithCheckpoints,
+// from the step following the last executed one. The checkpoint variables are set over the scope ones.
+// The execution keeps the checkpoint ID, so it saves the following checkpoints over the loaded one.
+func (p Pipelines) Resume(ctx context.Context, scope Scope, checkpointID string, opts ...Option) (Scope, error) {
+	store := newOptions(opts).checkpoints
+	if store == nil {
+		return scope, ErrNoCheckpointStore
+	}
+
+	checkpoint, err := store.Load(ctx, checkpointID)
+	if err != nil {
+		return scope, err
+	}
+
+	variables, err := checkpoint.variables(ctx)
+	if err != nil {
+		return scope, err
+	}
+
+	ctx = context.WithValue(WithExecutionID(ctx, checkpoint.ID), checkpointKey{}, checkpoint)
+
+	return p.Execute(ctx, scope, checkpoint.Pipelines, append([]Option{WithVariables(variables)}, opts...)...)
+}
+
+// Resume resumes the execution of the checkpoint with the engine, see Pipelines.Resume.
+func (e *Engine) Resume(ctx context.Context, scope Scope, checkpointID string, opts ...Option) (Scope, error) {
+	return scope.Pipelines.Resume(e.context(ctx), scope, checkpointID, opts...)
+}
+
+// variables decodes the checkpoint variables with the codec of the context, which must be the one encoding them.
+func (c Checkpoint) variables(ctx context.Context) (map[VariablePath]any, error) {
+	codec := CodecFrom(ctx)
+	if codec.Name() != c.Codec {
+		return nil, fmt.Errorf("checkpoint %s encoded with codec %s, not %s", c.ID, c.Codec, codec.Name())
+	}
+
+	decoded, err := codec.Decode(c.Variables)
+	if err != nil {
+		return nil, fmt.Errorf("decoding checkpoint %s: %w", c.ID, err)
+	}
+
+	values, ok := decoded.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("decoding checkpoint %s: unexpected variables %T", c.ID, decoded)
+	}
+
+	variables := make(map[VariablePath]any, len(values))
+	for path, value := range values {
+		variables[VariablePath(path)] = value
+	}
+
+	return variables, nil
+}
+
+// checkpointing saves the checkpoints of a pipeline started by Pipelines.Execute.
+type checkpointing struct {
+	store     CheckpointStore
+	pipelines []string
+	pipeline  int
+	// start is the number of steps skipped, executed before the resumed checkpoint.
+	start int
+}
+
+// withCheckpointing returns the context saving the checkpoints of the pipeline with the index among the executed ones,
+// and whether the pipeline finished before the resumed checkpoint, so it's skipped.
+// The context is returned unchanged for nested executions or without a store.
+func withCheckpointing(ctx context.Context, names []string, index int) (context.Context, bool) {
+	store, _ := ctx.Value(checkpointsKey{}).(CheckpointStore)
+	if store == nil || FromContext(ctx).Depth > 0 {
+		return ctx, false
+	}
+
+	c := checkpointing{store: store, pipelines: names, pipeline: index}
+
+	if resumed, ok := ctx.Value(checkpointKey{}).(Checkpoint); ok {
+		if index < resumed.Pipeline {
+			return ctx, true
+		}
+
+		if index == resumed.Pipeline {
+			c.start = resumed.Step
+		}
+	}
+
+	return context.WithValue(ctx, checkpointKey{}, c), false
+}
+
+// checkpointingFrom returns the checkpointing of the pipeline running with the context, ignoring the nested ones.
+func checkpointingFrom(ctx context.Context) (checkpointing, bool) {
+	c, ok := ctx.Value(checkpointKey{}).(checkpointing)
+	if !ok || FromContext(ctx).Depth != 1 {
+		return checkpointing{}, false
+	}
+
+	return c, true
+}
+
+// save saves the checkpoint of the scope with the number of executed steps.
+func (c checkpointing) save(ctx context.Context, scope Scope, step int) error {
+	codec := CodecFrom(ctx)
+
+	variables := scope.Variables()
+	values := make(map[string]any, len(variables))
+
+	for path, value := range variables {
+		values[string(path)] = value
+	}
+
+	encoded, err := codec.Encode(values)
+	if err != nil {
+		return fmt.Errorf("encoding checkpoint: %w", err)
+	}
+
+	return c.store.Save(ctx, Checkpoint{
+		ID:        ExecutionID(ctx),
+		Pipelines: c.pipelines,
+		Pipeline:  c.pipeline,
+		Step:      step,
+		Codec:     codec.Name(),
+		Variables: encoded,
+		CreatedAt: time.Now(),
+	})
+}
@@ -0,0 +1,120 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryCheckpoints struct {
+	mu          sync.Mutex
+	checkpoints map[string]Checkpoint
+}
+
+func (m *memoryCheckpoints) Save(_ context.Context, checkpoint Checkpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.checkpoints[checkpoint.ID] = checkpoint
+
+	return nil
+}
+
+func (m *memoryCheckpoints) Load(_ context.Context, id string) (Checkpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	checkpoint, found := m.checkpoints[id]
+	if !found {
+		return Checkpoint{}, ErrCheckpointNotFound
+	}
+
+	return checkpoint, nil
+}
+
+func (m *memoryCheckpoints) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.checkpoints, id)
+
+	return nil
+}
+
+func TestCheckpoints(t *testing.T) {
+	t.Parallel()
+
+	var (
+		runs = map[string]int{}
+		fail = true
+	)
+
+	engine := NewEngine()
+	engine.RegisterStepExecutor("count", FuncExecutor(func(ctx context.Context, in struct {
+		Name string `yaml:"name"`
+	}) (int, error) {
+		runs[in.Name]++
+
+		if in.Name == "flaky" && fail {
+			return 0, errors.New("interrupted")
+		}
+
+		return runs[in.Name], nil
+	}))
+
+	pipelines := NewPipelines(
+		New("setup").ID("setup").Step(NewStep("first", "count", map[string]any{"name": "first"})).Build(),
+		New("main").ID("main").
+			Step(NewStep("second", "count", map[string]any{"name": "second"})).
+			Step(NewStep("flaky", "count", map[string]any{"name": "flaky"})).
+			Step(NewStep("third", "count", map[string]any{"name": "third"})).
+			Build(),
+	)
+
+	store := &memoryCheckpoints{checkpoints: map[string]Checkpoint{}}
+	ctx := WithExecutionID(context.Background(), "exec-1")
+
+	_, err := engine.Execute(ctx, NewScope(pipelines), []string{"setup", "main"}, WithCheckpoints(store))
+	assert.ErrorContains(t, err, "interrupted")
+
+	checkpoint, err := store.Load(ctx, "exec-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"setup", "main"}, checkpoint.Pipelines)
+	assert.Equal(t, 1, checkpoint.Pipeline)
+	assert.Equal(t, 1, checkpoint.Step)
+	assert.Equal(t, "json", checkpoint.Codec)
+
+	fail = false
+
+	scope, err := engine.Resume(context.Background(), NewScope(pipelines), "exec-1", WithCheckpoints(store))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"first": 1, "second": 1, "flaky": 2, "third": 1}, runs)
+	assert.Equal(t, 1, MustGet[int](scope, "setup.first"))
+	assert.Equal(t, 1, MustGet[int](scope, "main.second"))
+	assert.Equal(t, 1, MustGet[int](scope, "main.third"))
+
+	_, err = store.Load(ctx, "exec-1")
+	assert.ErrorIs(t, err, ErrCheckpointNotFound, "succeeded executions delete their checkpoint")
+
+	t.Run("requires a store", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := engine.Resume(context.Background(), NewScope(pipelines), "exec-1")
+		assert.ErrorIs(t, err, ErrNoCheckpointStore)
+	})
+
+	t.Run("requires the checkpoint codec", func(t *testing.T) {
+		t.Parallel()
+
+		gob := NewEngine()
+		gob.SetCodec(NewGobCodec())
+
+		store := &memoryCheckpoints{checkpoints: map[string]Checkpoint{"exec-2": {ID: "exec-2", Codec: "json"}}}
+
+		_, err := gob.Resume(context.Background(), NewScope(pipelines), "exec-2", WithCheckpoints(store))
+		assert.ErrorContains(t, err, "encoded with codec json, not gob")
+	})
+}
@@ -0,0 +1,67 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+)
+
+var errCleanupOutsidePipeline = errors.New("cleanup function registered outside a pipeline")
+
+type cleanupsKey struct{}
+
+// CleanupFunc tears down a resource opened by a step, see Cleanup.
+type CleanupFunc func(ctx context.Context) error
+
+// cleanups are the functions called once the pipeline finishes.
+type cleanups struct {
+	mu    sync.Mutex
+	funcs []CleanupFunc
+}
+
+func withCleanups(ctx context.Context) (context.Context, *cleanups) {
+	c := &cleanups{}
+
+	return context.WithValue(ctx, cleanupsKey{}, c), c
+}
+
+// run calls the functions in reverse registration order, like deferred calls, returning the error
+// of the pipeline joined with theirs.
+func (c *cleanups) run(ctx context.Context, err error) error {
+	c.mu.Lock()
+	funcs := c.funcs
+	c.funcs = nil
+	c.mu.Unlock()
+
+	ctx = context.WithoutCancel(ctx)
+
+	for i := len(funcs) - 1; i >= 0; i-- {
+		if cleanupErr := funcs[i](ctx); cleanupErr != nil {
+			log.Log().Error(ctx, "Error cleaning up: %s", cleanupErr)
+
+			err = errors.Join(err, cleanupErr)
+		}
+	}
+
+	return err
+}
+
+// Cleanup registers a function called once the pipeline running with the context finishes, like testing.T.Cleanup,
+// eg.: for executors closing connections, sessions or temporary files they open. The functions are called in reverse
+// registration order, even when the pipeline fails or panics, with a context not canceled by the execution.
+// Their errors fail the pipeline.
+func Cleanup(ctx context.Context, fn CleanupFunc) error {
+	c, _ := ctx.Value(cleanupsKey{}).(*cleanups)
+	if c == nil {
+		return errCleanupOutsidePipeline
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.funcs = append(c.funcs, fn)
+
+	return nil
+}
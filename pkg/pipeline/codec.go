@@ -0,0 +1,256 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Keys of the JSON objects holding the values of registered types, see JSONCodec.RegisterType.
+const (
+	codecKeyType  = "$type"
+	codecKeyValue = "$value"
+)
+
+// Codec serializes the scope values crossing process boundaries, eg.: checkpoints, remote executors and server APIs.
+type Codec interface {
+	// Name identifies the codec, eg.: to store it alongside the encoded values.
+	Name() string
+	Encode(value any) ([]byte, error)
+	Decode(data []byte) (any, error)
+}
+
+// JSONCodec encodes the values as JSON, the default codec. The values of the registered types are wrapped
+// in {"$type": name, "$value": value} objects, so they're decoded back into their types instead of maps or strings.
+// time.Time and time.Duration are registered as "time" and "duration". Integral numbers are decoded as int.
+type JSONCodec struct {
+	mu    sync.RWMutex
+	types map[string]reflect.Type
+	names map[reflect.Type]string
+}
+
+// NewJSONCodec creates a JSON codec.
+func NewJSONCodec() *JSONCodec {
+	c := &JSONCodec{types: map[string]reflect.Type{}, names: map[reflect.Type]string{}}
+	c.RegisterType("time", time.Time{})
+	c.RegisterType("duration", time.Duration(0))
+
+	return c
+}
+
+// RegisterType registers the type of the value by name, replacing the type registered with the same name.
+// The values are encoded and decoded by encoding/json, following their json tags and marshalers.
+func (c *JSONCodec) RegisterType(name string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := reflect.TypeOf(value)
+	c.types[name] = t
+	c.names[t] = name
+}
+
+// Name returns "json".
+func (c *JSONCodec) Name() string {
+	return "json"
+}
+
+// Encode encodes the value as JSON.
+func (c *JSONCodec) Encode(value any) ([]byte, error) {
+	return json.Marshal(c.Wrap(value))
+}
+
+// Decode decodes the JSON into maps, lists and scalars, or the registered types.
+func (c *JSONCodec) Decode(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return c.Unwrap(value)
+}
+
+// Wrap returns the value with the values of the registered types wrapped, ready to be encoded as JSON,
+// eg.: within larger documents like the server API responses.
+func (c *JSONCodec) Wrap(value any) any {
+	c.mu.RLock()
+	name, registered := c.names[reflect.TypeOf(value)]
+	c.mu.RUnlock()
+
+	if registered {
+		return map[string]any{codecKeyType: name, codecKeyValue: value}
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		wrapped := make(map[string]any, len(value))
+		for key, v := range value {
+			wrapped[key] = c.Wrap(v)
+		}
+
+		return wrapped
+	case []any:
+		wrapped := make([]any, len(value))
+		for i, v := range value {
+			wrapped[i] = c.Wrap(v)
+		}
+
+		return wrapped
+	}
+
+	return value
+}
+
+// Unwrap returns the value decoded from JSON with the wrapped values of the registered types restored,
+// and the json.Number values converted to int, or float64 when not integral.
+func (c *JSONCodec) Unwrap(value any) (any, error) {
+	switch value := value.(type) {
+	case json.Number:
+		if n, err := value.Int64(); err == nil && n >= math.MinInt && n <= math.MaxInt {
+			return int(n), nil
+		}
+
+		return value.Float64()
+	case []any:
+		unwrapped := make([]any, len(value))
+
+		for i, v := range value {
+			u, err := c.Unwrap(v)
+			if err != nil {
+				return nil, err
+			}
+
+			unwrapped[i] = u
+		}
+
+		return unwrapped, nil
+	case map[string]any:
+		if name, ok := value[codecKeyType].(string); ok && len(value) == 2 {
+			if inner, found := value[codecKeyValue]; found {
+				return c.unwrapType(name, inner)
+			}
+		}
+
+		unwrapped := make(map[string]any, len(value))
+
+		for key, v := range value {
+			u, err := c.Unwrap(v)
+			if err != nil {
+				return nil, err
+			}
+
+			unwrapped[key] = u
+		}
+
+		return unwrapped, nil
+	}
+
+	return value, nil
+}
+
+func (c *JSONCodec) unwrapType(name string, value any) (any, error) {
+	c.mu.RLock()
+	t, found := c.types[name]
+	c.mu.RUnlock()
+
+	if !found {
+		return nil, fmt.Errorf("unregistered codec type %s", name)
+	}
+
+	blob, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded := reflect.New(t)
+	if err := json.Unmarshal(blob, decoded.Interface()); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", name, err)
+	}
+
+	return decoded.Elem().Interface(), nil
+}
+
+// GobCodec encodes the values with encoding/gob, keeping the Go types of the values registered with RegisterType.
+type GobCodec struct{}
+
+// gobValue wraps the encoded values, since gob only encodes interface values within structs.
+type gobValue struct {
+	Value any
+}
+
+// NewGobCodec creates a gob codec, registering the types of the maps, lists and times held by the scopes.
+func NewGobCodec() GobCodec {
+	gob.Register(map[string]any{})
+	gob.Register([]any{})
+	gob.Register(time.Time{})
+	gob.Register(time.Duration(0))
+
+	return GobCodec{}
+}
+
+// RegisterType registers the type of the value by name, see gob.RegisterName.
+// Types must be registered before being encoded or decoded, by both the encoding and the decoding processes.
+func (GobCodec) RegisterType(name string, value any) {
+	gob.RegisterName(name, value)
+}
+
+// Name returns "gob".
+func (GobCodec) Name() string {
+	return "gob"
+}
+
+// Encode encodes the value with gob.
+func (GobCodec) Encode(value any) ([]byte, error) {
+	var buffer bytes.Buffer
+
+	if err := gob.NewEncoder(&buffer).Encode(gobValue{Value: value}); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// Decode decodes the value encoded with gob.
+func (GobCodec) Decode(data []byte) (any, error) {
+	var decoded gobValue
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	return decoded.Value, nil
+}
+
+// SetCodec sets the codec of the engine executions, NewJSONCodec by default, see CodecFrom.
+func (e *Engine) SetCodec(codec Codec) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.codec = codec
+}
+
+// Codec returns the codec of the engine executions.
+func (e *Engine) Codec() Codec {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return e.codec
+}
+
+// SetCodec sets the codec of the default engine.
+func SetCodec(codec Codec) {
+	defaultEngine.SetCodec(codec)
+}
+
+// CodecFrom returns the codec of the engine running with the context, eg.: for executors sending
+// the scope values to remote processes.
+func CodecFrom(ctx context.Context) Codec {
+	return engineFrom(ctx).Codec()
+}
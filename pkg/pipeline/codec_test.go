@@ -0,0 +1,86 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type codecPoint struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+func TestJSONCodec(t *testing.T) {
+	t.Parallel()
+
+	codec := NewJSONCodec()
+	codec.RegisterType("point", codecPoint{})
+
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	value := map[string]any{
+		"at":      at,
+		"timeout": 5 * time.Second,
+		"points":  []any{codecPoint{X: 1, Y: 2}},
+		"count":   3,
+		"ratio":   0.5,
+		"name":    "main",
+	}
+
+	data, err := codec.Encode(value)
+	assert.NoError(t, err)
+
+	decoded, err := codec.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, value, decoded)
+
+	t.Run("fails on unregistered types", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := codec.Decode([]byte(`{"$type": "unknown", "$value": 1}`))
+		assert.ErrorContains(t, err, "unregistered codec type unknown")
+	})
+}
+
+func TestGobCodec(t *testing.T) {
+	t.Parallel()
+
+	codec := NewGobCodec()
+	codec.RegisterType("pipeline.codecPoint", codecPoint{})
+
+	value := map[string]any{
+		"at":     time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		"points": []any{codecPoint{X: 1, Y: 2}},
+		"count":  3,
+	}
+
+	data, err := codec.Encode(value)
+	assert.NoError(t, err)
+
+	decoded, err := codec.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, value, decoded)
+}
+
+func TestCodecFrom(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine()
+	assert.Equal(t, "json", engine.Codec().Name())
+
+	engine.SetCodec(NewGobCodec())
+	engine.RegisterStepExecutor("codec", FuncExecutor(func(ctx context.Context, _ struct{}) (string, error) {
+		return CodecFrom(ctx).Name(), nil
+	}))
+
+	pipelines := NewPipelines(New("main").Step(NewStep("name", "codec", map[string]any{})).Build())
+
+	scope, err := engine.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+	assert.NoError(t, err)
+
+	name, err := Get[string](scope, "name")
+	assert.NoError(t, err)
+	assert.Equal(t, "gob", name)
+}
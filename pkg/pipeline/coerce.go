@@ -0,0 +1,122 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Types a value can be coerced to, see the `$types` param of the set step.
+const (
+	TypeString   = "string"
+	TypeInt      = "int"
+	TypeFloat    = "float"
+	TypeBool     = "bool"
+	TypeJSON     = "json"
+	TypeDuration = "duration"
+)
+
+// decode converts a value into out through its yaml tags, parsing strings as YAML scalars
+// so template-produced values (eg.: "3", "true") fit numeric and boolean fields.
+func decode(value any, out any) error {
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return err
+	}
+
+	untagStrings(&node)
+
+	return node.Decode(out)
+}
+
+func untagStrings(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" {
+		node.Tag = ""
+		node.Style = 0
+	}
+
+	for _, child := range node.Content {
+		untagStrings(child)
+	}
+}
+
+// Get returns the variable converted to T. Values that aren't a T are converted through their yaml tags,
+// eg.: a map[string]any into a struct or a template-produced "10" into an int.
+func Get[T any](scope Scope, path VariablePath) (T, error) {
+	var t T
+
+	value, err := scope.Variable(path)
+	if err != nil {
+		return t, err
+	}
+
+	if typed, ok := value.(T); ok {
+		return typed, nil
+	}
+
+	if err := decode(value, &t); err != nil {
+		return t, fmt.Errorf("variable %s: cannot convert %T to %T: %w", path, value, t, err)
+	}
+
+	return t, nil
+}
+
+// MustGet is like Get but panics on error.
+func MustGet[T any](scope Scope, path VariablePath) T {
+	t, err := Get[T](scope, path)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+// Set stores the value in the scope. Structs are stored as map[string]any following their yaml tags,
+// so expressions can read their fields with variableGet.
+func Set[T any](scope Scope, path VariablePath, value T) (Scope, error) {
+	if reflect.Indirect(reflect.ValueOf(value)).Kind() != reflect.Struct {
+		return scope.WithVariable(path, value), nil
+	}
+
+	var item map[string]any
+	if err := decode(value, &item); err != nil {
+		return scope, fmt.Errorf("variable %s: cannot convert %T to map[string]any: %w", path, value, err)
+	}
+
+	return scope.WithVariable(path, item), nil
+}
+
+// coerce converts a value, eg.: a template-produced string, into the type.
+func coerce(value any, typ string) (any, error) {
+	text := fmt.Sprint(value)
+
+	switch typ {
+	case TypeString:
+		return text, nil
+	case TypeInt:
+		return strconv.Atoi(text)
+	case TypeFloat:
+		return strconv.ParseFloat(text, 64)
+	case TypeBool:
+		return strconv.ParseBool(text)
+	case TypeDuration:
+		return time.ParseDuration(text)
+	case TypeJSON:
+		if _, ok := value.(string); !ok {
+			return value, nil
+		}
+
+		var decoded any
+		if err := json.Unmarshal([]byte(text), &decoded); err != nil {
+			return nil, err
+		}
+
+		return decoded, nil
+	}
+
+	return nil, fmt.Errorf("unknown type %s", typ)
+}
@@ -0,0 +1,59 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type account struct {
+	ID      int           `yaml:"id"`
+	Name    string        `yaml:"name"`
+	Active  bool          `yaml:"active"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+func TestGet(t *testing.T) {
+	t.Parallel()
+
+	scope := NewScope(Pipelines{}).
+		WithVariable("count", "10").
+		WithVariable("account", map[string]any{"id": "42", "name": "bob", "active": "true", "timeout": "5s"}).
+		WithVariable("list", []any{"1", 2})
+
+	count, err := Get[int](scope, "count")
+	assert.NoError(t, err)
+	assert.Equal(t, 10, count)
+
+	acc, err := Get[account](scope, "account")
+	assert.NoError(t, err)
+	assert.Equal(t, account{ID: 42, Name: "bob", Active: true, Timeout: 5 * time.Second}, acc)
+
+	list, err := Get[[]int](scope, "list")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, list)
+
+	_, err = Get[int](scope, "account")
+	assert.Error(t, err)
+
+	_, err = Get[int](scope, "missing")
+	assert.ErrorIs(t, err, ErrVariableNotFound)
+
+	assert.Panics(t, func() { MustGet[int](scope, "missing") })
+}
+
+func TestSet(t *testing.T) {
+	t.Parallel()
+
+	scope, err := Set(NewScope(Pipelines{}), "account", account{ID: 1, Name: "bob"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	value, _ := scope.Variable("account")
+	assert.Equal(t, map[string]any{"id": 1, "name": "bob", "active": false, "timeout": "0s"}, value)
+
+	acc := MustGet[account](scope, "account")
+	assert.Equal(t, "bob", acc.Name)
+}
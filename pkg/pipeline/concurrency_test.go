@@ -0,0 +1,70 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConcurrentExecution is meant to be run with the race detector, see make test-race.
+func TestConcurrentExecution(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine()
+	pipelines := NewPipelines(New("main").
+		Set("value", map[string]any{"id": `{{ variableGet . "input" "id" }}`, "upper": `{{ upper "ok" }}`}).
+		Range("item", RangeParams{JSON: `[1, 2, 3]`}, SetStep("copy", map[string]any{"item": `{{ variable . "item" }}`})).
+		Build())
+
+	const executions = 20
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, executions)
+
+	for i := range executions {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			scope := NewScope(pipelines).WithVariable("input", map[string]any{"id": fmt.Sprint(i)})
+
+			result, err := engine.Execute(context.Background(), scope, []string{"main"})
+			if err != nil {
+				errs <- err
+
+				return
+			}
+
+			value, _ := result.Variable("value")
+			if id := value.(map[string]any)["id"]; id != fmt.Sprint(i) {
+				errs <- fmt.Errorf("execution %d got id %v", i, id)
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			name := fmt.Sprintf("custom-%d", i)
+			engine.RegisterStepExecutor(name, TypedStepExecutor[struct{}](func(_ context.Context, scope Scope, _ Step, _ struct{}) (Scope, error) {
+				return scope, nil
+			}))
+			engine.RegisterFuncs(template.FuncMap{fmt.Sprintf("custom%d", i): func() string { return name }})
+			engine.SetInterceptor(defaultInterceptor)
+			engine.SetStepInterceptor(defaultStepInterceptorfunc)
+			engine.UnregisterStepExecutor(name)
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+}
@@ -0,0 +1,132 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"maps"
+	mathrand "math/rand/v2"
+	"slices"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+)
+
+const (
+	LogFieldExecutionID = "execution_id"
+	LogFieldPipeline    = "pipeline"
+	LogFieldStep        = "step"
+	LogFieldIndex       = "index"
+	// LogFieldAnnotation prefixes the step annotations logged as fields, eg.: "annotation.team".
+	LogFieldAnnotation = "annotation."
+)
+
+const executionIDSize = 16
+
+type (
+	executionKey   struct{}
+	executionIDKey struct{}
+	infoKey        struct{}
+)
+
+// Info describes where an execution is running, see FromContext.
+type Info struct {
+	ExecutionID string
+	// Pipeline is the ID, or the name when not namespaced, of the running pipeline.
+	Pipeline string
+	// Step is the running step, empty while a pipeline runs its uses or between steps.
+	Step Step
+	// Depth is the number of pipelines running, 1 for the pipelines started by Pipelines.Execute.
+	Depth int
+}
+
+// FromContext returns the execution, pipeline and step running with the context,
+// eg.: for custom executors, interceptors and loggers to enrich their behavior.
+func FromContext(ctx context.Context) Info {
+	info, _ := ctx.Value(infoKey{}).(Info)
+	info.ExecutionID = ExecutionID(ctx)
+
+	return info
+}
+
+// WithExecutionID sets the execution ID used by the executions started with the context,
+// eg.: to reuse a correlation ID received by a server.
+func WithExecutionID(ctx context.Context, id string) context.Context {
+	ctx = context.WithValue(ctx, executionIDKey{}, id)
+
+	return log.WithFields(ctx, log.Field{Key: LogFieldExecutionID, Value: id})
+}
+
+// ExecutionID returns the ID of the execution running with the context, or an empty string outside executions.
+func ExecutionID(ctx context.Context) string {
+	id, _ := ctx.Value(executionIDKey{}).(string)
+
+	return id
+}
+
+// withExecution starts an execution unless the context already belongs to one, eg.: a pipeline executed by uses.
+// The returned function is called with the execution error once it finishes: it calls the OnFinish functions and cancels
+// the execution context, releasing the resources bound to it with context.AfterFunc and the workspace.
+// The execution context carries a new execution ID unless one is already present.
+func withExecution(ctx context.Context) (context.Context, func(error)) {
+	if ctx.Value(executionKey{}) != nil {
+		return ctx, func(error) {}
+	}
+
+	exec := &execution{
+		board:     newBoard(),
+		workspace: &workspace{},
+		finishers: &finishers{},
+		budgets:   &budgets{},
+		limits:    &limits{},
+		snapshots: newSnapshots(),
+		caches:    map[string]Cache{},
+		random:    newRandom(mathrand.Uint64()),
+		secrets:   log.NewSecrets(),
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	ctx = context.WithValue(ctx, executionKey{}, exec)
+	ctx = log.WithSecrets(ctx, exec.secrets)
+
+	if ExecutionID(ctx) == "" {
+		ctx = WithExecutionID(ctx, newExecutionID())
+	}
+
+	return ctx, func(err error) {
+		exec.finishers.run(context.WithoutCancel(ctx), err)
+
+		cancel()
+
+		if err := exec.workspace.close(); err != nil {
+			log.Log().Error(ctx, "Error removing the workspace: %s", err)
+		}
+	}
+}
+
+func newExecutionID() string {
+	blob := make([]byte, executionIDSize)
+	_, _ = rand.Read(blob)
+
+	return hex.EncodeToString(blob)
+}
+
+func withPipeline(ctx context.Context, p Pipeline) context.Context {
+	info := FromContext(ctx)
+	ctx = context.WithValue(ctx, infoKey{}, Info{Pipeline: p.String(), Depth: info.Depth + 1})
+
+	return log.WithFields(ctx, log.Field{Key: LogFieldPipeline, Value: p.String()})
+}
+
+func withStep(ctx context.Context, step Step) context.Context {
+	info := FromContext(ctx)
+	info.Step = step
+	ctx = context.WithValue(ctx, infoKey{}, info)
+
+	fields := []log.Field{{Key: LogFieldStep, Value: step.String()}}
+
+	for _, key := range slices.Sorted(maps.Keys(step.Annotations)) {
+		fields = append(fields, log.Field{Key: LogFieldAnnotation + key, Value: step.Annotations[key]})
+	}
+
+	return log.WithFields(ctx, fields...)
+}
@@ -0,0 +1,157 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+
+	var info Info
+
+	engine := NewEngine()
+	engine.RegisterStepExecutor("inspect", FuncExecutor(func(ctx context.Context, _ map[string]any) (bool, error) {
+		info = FromContext(ctx)
+
+		return true, nil
+	}))
+
+	pipelines := NewPipelines(
+		New("main").Call("child", "child").Build(),
+		New("child").Step(Step{ID: "current", Type: "inspect"}).Build(),
+	)
+
+	_, err := engine.Execute(WithExecutionID(context.Background(), "exec-1"), NewScope(pipelines), []string{"main"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "exec-1", info.ExecutionID)
+	assert.Equal(t, "child", info.Pipeline)
+	assert.Equal(t, VariablePathNode("current"), info.Step.ID)
+	// main, the pipeline step namespaced by child, and the child pipeline it uses.
+	assert.Equal(t, 3, info.Depth)
+	assert.Equal(t, Info{}, FromContext(context.Background()))
+}
+
+func TestOnFinish(t *testing.T) {
+	t.Parallel()
+
+	var outcomes []string
+
+	engine := NewEngine()
+	engine.RegisterStepExecutor("hook", FuncExecutor(func(ctx context.Context, in struct {
+		Name string `yaml:"name"`
+	}) (string, error) {
+		return in.Name, OnFinish(ctx, func(ctx context.Context, err error) {
+			outcomes = append(outcomes, fmt.Sprintf("%s:%v:%v", in.Name, err != nil, ctx.Err()))
+		})
+	}))
+
+	pipelines := NewPipelines(
+		New("success").
+			Step(Step{ID: "first", Type: "hook", Params: map[string]any{"name": "first"}}).
+			Step(Step{ID: "second", Type: "hook", Params: map[string]any{"name": "second"}}).
+			Build(),
+		New("failure").
+			Step(Step{ID: "hook", Type: "hook", Params: map[string]any{"name": "failed"}}).
+			Stop(StopParams{Condition: "true", Message: "boom", IsError: "true"}).
+			Build(),
+	)
+
+	_, err := engine.Execute(context.Background(), NewScope(pipelines), []string{"success"})
+	assert.NoError(t, err)
+
+	_, err = engine.Execute(context.Background(), NewScope(pipelines), []string{"failure"}, WithTimeout(time.Second))
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{"second:false:<nil>", "first:false:<nil>", "failed:true:<nil>"}, outcomes)
+
+	assert.Error(t, OnFinish(context.Background(), func(context.Context, error) {}))
+}
+
+func TestCleanup(t *testing.T) {
+	t.Parallel()
+
+	var cleaned []string
+
+	engine := NewEngine()
+	engine.RegisterStepExecutor("open", FuncExecutor(func(ctx context.Context, in struct {
+		Name string `yaml:"name"`
+		Fail bool   `yaml:"fail"`
+	}) (string, error) {
+		return in.Name, Cleanup(ctx, func(ctx context.Context) error {
+			cleaned = append(cleaned, in.Name)
+
+			if in.Fail {
+				return errors.New("closing " + in.Name)
+			}
+
+			return nil
+		})
+	}))
+
+	pipelines := NewPipelines(
+		New("main").
+			Step(Step{ID: "outer", Type: "open", Params: map[string]any{"name": "outer"}}).
+			Step(NewStep("", "pipeline", Pipeline{Steps: []Step{{ID: "inner", Type: "open", Params: map[string]any{"name": "inner"}}}})).
+			Step(Step{ID: "last", Type: "open", Params: map[string]any{"name": "last"}}).
+			Stop(StopParams{Condition: "true", Message: "boom", IsError: "true"}).
+			Build(),
+		New("failing-cleanup").
+			Step(Step{ID: "conn", Type: "open", Params: map[string]any{"name": "conn", "fail": true}}).
+			Build(),
+	)
+
+	_, err := engine.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+	assert.ErrorContains(t, err, "boom")
+	assert.Equal(t, []string{"inner", "last", "outer"}, cleaned)
+
+	_, err = engine.Execute(context.Background(), NewScope(pipelines), []string{"failing-cleanup"})
+	assert.ErrorContains(t, err, "closing conn")
+
+	assert.Error(t, Cleanup(context.Background(), func(context.Context) error { return nil }))
+}
+
+func TestStepAnnotations(t *testing.T) {
+	t.Parallel()
+
+	pipelines := NewPipelines(New("annotated").
+		Step(LogStep("charged").Annotate("team", "payments").Annotate("cost-center", "42")).
+		Step(NewStep("failed", "stop", StopParams{Condition: "true", IsError: "true"}).Annotate("criticality", "high")).
+		Build())
+
+	var annotations []map[string]string
+
+	logger := &memoryLogger{}
+
+	_, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"annotated"},
+		WithLogger(logger),
+		WithInterceptors(nil, func(ctx context.Context, scope Scope, step Step, executor StepExecutor) (Scope, error) {
+			annotations = append(annotations, step.Annotations)
+
+			return executor.Execute(ctx, scope, step)
+		}),
+	)
+
+	assert.Equal(t, []map[string]string{{"team": "payments", "cost-center": "42"}, {"criticality": "high"}}, annotations)
+
+	var stepErr *StepError
+	if assert.ErrorAs(t, err, &stepErr) {
+		assert.Equal(t, map[string]string{"criticality": "high"}, stepErr.Annotations)
+	}
+
+	i := slices.Index(logger.messages, "charged")
+	if assert.NotEqual(t, -1, i) {
+		assert.Contains(t, logger.fields[i], log.Field{Key: LogFieldAnnotation + "team", Value: "payments"})
+		assert.Contains(t, logger.fields[i], log.Field{Key: LogFieldAnnotation + "cost-center", Value: "42"})
+	}
+}
@@ -0,0 +1,166 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+)
+
+// Types of the pipeline inputs.
+const (
+	InputTypeAny    = "any"
+	InputTypeString = "string"
+	InputTypeInt    = "int"
+	InputTypeNumber = "number"
+	InputTypeBool   = "bool"
+	InputTypeList   = "list"
+	InputTypeMap    = "map"
+)
+
+// ErrInvalidInput is returned when a pipeline input is missing or has an unexpected type.
+var ErrInvalidInput = errors.New("invalid input")
+
+// Input declares a pipeline input, read from the variable with its name when the pipeline starts.
+type Input struct {
+	// Type is one of the InputType constants, InputTypeAny by default.
+	Type        string `yaml:"type"`
+	Required    bool   `yaml:"required"`
+	Default     any    `yaml:"default"`
+	Description string `yaml:"description"`
+}
+
+// VariableDeclaration documents a variable of the pipeline data contract, eg.: set by the caller or the environment.
+// Declared variables missing when the pipeline starts are set to their default, and the present ones are checked
+// against their type. Pipelines declaring variables are linted against their usage, see Pipelines.Lint.
+type VariableDeclaration struct {
+	Name string `yaml:"name" json:"name"`
+	// Type is one of the InputType constants, InputTypeAny by default.
+	Type        string `yaml:"type" json:"type,omitempty"`
+	Description string `yaml:"description" json:"description,omitempty"`
+	Default     any    `yaml:"default" json:"default,omitempty"`
+}
+
+// check returns the input value converted to its type, eg.: JSON numbers to int.
+func (i Input) check(value any) (any, bool) {
+	switch i.Type {
+	case "", InputTypeAny:
+		return value, true
+	case InputTypeString:
+		_, ok := value.(string)
+
+		return value, ok
+	case InputTypeInt:
+		switch value := value.(type) {
+		case int:
+			return value, true
+		case int64:
+			return int(value), true
+		case float64:
+			return int(value), value == math.Trunc(value)
+		}
+	case InputTypeNumber:
+		switch value := value.(type) {
+		case int:
+			return float64(value), true
+		case int64:
+			return float64(value), true
+		case float64:
+			return value, true
+		}
+	case InputTypeBool:
+		_, ok := value.(bool)
+
+		return value, ok
+	case InputTypeList:
+		_, ok := value.([]any)
+
+		return value, ok
+	case InputTypeMap:
+		_, ok := value.(map[string]any)
+
+		return value, ok
+	}
+
+	return value, false
+}
+
+// withInputs validates the declared inputs of the scope, setting the defaults of the missing ones.
+func (p Pipeline) withInputs(scope Scope) (Scope, error) {
+	for _, name := range slices.Sorted(maps.Keys(p.Inputs)) {
+		input := p.Inputs[name]
+
+		value, err := scope.Variable(VariablePath(name))
+		if err != nil {
+			switch {
+			case input.Required:
+				return scope, fmt.Errorf("%w: %s is required", ErrInvalidInput, name)
+			case input.Default == nil:
+				continue
+			}
+
+			value = input.Default
+		}
+
+		converted, ok := input.check(value)
+		if !ok {
+			return scope, fmt.Errorf("%w: %s is not a %s: %v", ErrInvalidInput, name, input.Type, value)
+		}
+
+		scope = scope.WithVariable(VariablePath(name), converted)
+	}
+
+	return scope, nil
+}
+
+// withVariables checks the declared variables of the scope, setting the defaults of the missing ones.
+func (p Pipeline) withVariables(scope Scope) (Scope, error) {
+	for _, declared := range p.Variables {
+		value, err := scope.Variable(VariablePath(declared.Name))
+		if err != nil {
+			if declared.Default == nil {
+				continue
+			}
+
+			value = declared.Default
+		}
+
+		converted, ok := Input{Type: declared.Type}.check(value)
+		if !ok {
+			return scope, fmt.Errorf("%w: variable %s is not a %s: %v", ErrInvalidInput, declared.Name, declared.Type, value)
+		}
+
+		scope = scope.WithVariable(VariablePath(declared.Name), converted)
+	}
+
+	return scope, nil
+}
+
+// exportOutputs returns the caller scope with only the declared outputs, evaluated over the pipeline result,
+// set by name within the pipeline namespace.
+func (p Pipeline) exportOutputs(ctx context.Context, caller, result Scope) (Scope, error) {
+	exported := caller
+	exported.Finished, exported.stopScope = result.Finished, result.stopScope
+
+	for _, name := range slices.Sorted(maps.Keys(p.Outputs)) {
+		value, err := p.Outputs[name].Eval(ctx, result)
+		if err != nil {
+			return exported, fmt.Errorf("output %s: %w", name, err)
+		}
+
+		exported = exported.WithVariable(p.outputPath(name), value)
+	}
+
+	return exported, nil
+}
+
+// outputPath returns the path of the output within the pipeline namespace.
+func (p Pipeline) outputPath(name string) VariablePath {
+	if p.ID == "" {
+		return VariablePath(name)
+	}
+
+	return VariablePath(p.ID + "." + name)
+}
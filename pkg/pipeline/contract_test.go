@@ -0,0 +1,84 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelineContract(t *testing.T) {
+	t.Parallel()
+
+	pipelines := NewPipelines(
+		New("greet").
+			Input("name", Input{Type: InputTypeString, Required: true}).
+			Input("times", Input{Type: InputTypeInt, Default: 1}).
+			Set("greeting", map[string]any{"text": `hello {{ variable . "name" }} x{{ variable . "times" }}`}).
+			Set("internal", map[string]any{"leaked": true}).
+			Output("text", `{{ variableGet . "greeting" "text" }}`).
+			Output("times", `{{ variable . "times" }}`).
+			Build(),
+		New("main").Uses("greet").Build(),
+		New("namespaced").Step(NewStep("", "pipeline", New("").ID("greeter").Uses("greet").Build())).Build(),
+		New("call").Step(NewStep("greeting", "call", CallParams{Pipeline: "greet", With: `{ name: carol }`})).Build(),
+	)
+
+	execute := func(name string, variables map[VariablePath]any) (Scope, error) {
+		return pipelines.Execute(context.Background(), NewScope(pipelines), []string{name}, WithVariables(variables))
+	}
+
+	t.Run("exports only the outputs", func(t *testing.T) {
+		t.Parallel()
+
+		scope, err := execute("main", map[VariablePath]any{"name": "bob"})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		text, _ := scope.Variable("text")
+		assert.Equal(t, "hello bob x1", text)
+
+		times, _ := scope.Variable("times")
+		assert.Equal(t, 1, times)
+
+		_, err = scope.Variable("internal")
+		assert.ErrorIs(t, err, ErrVariableNotFound)
+	})
+
+	t.Run("exports within the namespace", func(t *testing.T) {
+		t.Parallel()
+
+		scope, err := execute("namespaced", map[VariablePath]any{"name": "alice", "times": 2.0})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		text, _ := scope.Variable("greeter.text")
+		assert.Equal(t, "hello alice x2", text)
+	})
+
+	t.Run("validates the inputs", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := execute("main", nil)
+		assert.ErrorIs(t, err, ErrInvalidInput)
+		assert.ErrorContains(t, err, "name is required")
+
+		_, err = execute("main", map[VariablePath]any{"name": 42})
+		assert.ErrorIs(t, err, ErrInvalidInput)
+		assert.ErrorContains(t, err, "name is not a string")
+	})
+
+	t.Run("calls return the outputs", func(t *testing.T) {
+		t.Parallel()
+
+		scope, err := execute("call", nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		greeting, _ := Get[map[string]any](scope, "greeting")
+		assert.Equal(t, map[string]any{"text": "hello carol x1", "times": 1}, greeting)
+	})
+}
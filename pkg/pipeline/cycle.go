@@ -0,0 +1,171 @@
+package pipeline
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// detectCycles fails when pipelines use each other unconditionally, through their uses, pipeline or call steps.
+// Recursion through conditional steps (eg.: switch, until or steps with if) is legit and bounded at run time, see WithMaxDepth.
+func (p Pipelines) detectCycles() error {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+
+	state := map[string]int{}
+
+	var visit func(name string, path []string) error
+
+	visit = func(name string, path []string) error {
+		path = append(path, name)
+
+		switch state[name] {
+		case visiting:
+			start := slices.Index(path, name)
+
+			return fmt.Errorf("%w: %s", ErrCycle, strings.Join(path[start:], " -> "))
+		case visited:
+			return nil
+		}
+
+		pipe, found := p.pipelines[name]
+		if !found {
+			return nil
+		}
+
+		state[name] = visiting
+
+		for _, used := range pipe.uses() {
+			if err := visit(used, path); err != nil {
+				return err
+			}
+		}
+
+		state[name] = visited
+
+		return nil
+	}
+
+	for _, name := range p.Names() {
+		if err := visit(name, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// uses returns the names of the pipelines always executed by the pipeline.
+func (p Pipeline) uses() []string {
+	return p.references(false)
+}
+
+// references returns the names of the pipelines executed by the pipeline, only the ones always executed
+// unless conditional, which includes the ones executed by conditional, iterating or fanned out steps.
+func (p Pipeline) references(conditional bool) []string {
+	var names []string
+
+	if p.Uses != "" {
+		names = append(names, p.Uses)
+	}
+
+	for _, step := range p.Steps {
+		if step.If != "" && !conditional {
+			continue
+		}
+
+		switch step.Type {
+		case "pipeline":
+			var inline Pipeline
+			if err := step.decodeParams(&inline); err != nil {
+				continue
+			}
+
+			names = append(names, inline.references(conditional)...)
+		case "try":
+			var try TryParams
+			if err := step.decodeParams(&try); err != nil {
+				continue
+			}
+
+			names = append(names, try.references(conditional)...)
+
+			if try.Catch != nil && conditional {
+				names = append(names, try.Catch.references(conditional)...)
+			}
+
+			if try.Finally != nil {
+				names = append(names, try.Finally.references(conditional)...)
+			}
+		case "parallel":
+			var parallel ParallelParams
+			if err := step.decodeParams(&parallel); err != nil {
+				continue
+			}
+
+			names = append(names, Pipeline{Steps: parallel.Steps}.references(conditional)...)
+		case "call":
+			var call CallParams
+			if err := step.decodeParams(&call); err != nil || strings.Contains(string(call.Pipeline), "{{") {
+				continue
+			}
+
+			names = append(names, string(call.Pipeline))
+		}
+
+		if conditional {
+			names = append(names, step.conditionalReferences()...)
+		}
+	}
+
+	return names
+}
+
+// conditionalReferences returns the names of the pipelines executed by the switch, until, range and fanout steps.
+func (s Step) conditionalReferences() []string {
+	var pipes []Pipeline
+
+	switch s.Type {
+	case "switch":
+		var params SwitchParams
+		if err := s.decodeParams(&params); err != nil {
+			return nil
+		}
+
+		for _, c := range params.Cases {
+			pipes = append(pipes, c.Pipeline)
+		}
+
+		pipes = append(pipes, params.Default)
+	case "until":
+		var params UntilParams
+		if err := s.decodeParams(&params); err != nil {
+			return nil
+		}
+
+		pipes = append(pipes, params.Pipeline)
+	case "range":
+		var params RangeParams
+		if err := s.decodeParams(&params); err != nil {
+			return nil
+		}
+
+		pipes = append(pipes, params.Pipeline)
+	case "fanout":
+		var params FanoutParams
+		if err := s.decodeParams(&params); err != nil {
+			return nil
+		}
+
+		pipes = params.Pipelines
+	}
+
+	var names []string
+	for _, pipe := range pipes {
+		names = append(names, pipe.references(true)...)
+	}
+
+	return names
+}
@@ -0,0 +1,6 @@
+//go:build pipelinedebug
+
+package pipeline
+
+// debugBuild enables the costly debugging features, eg.: the step snapshots.
+const debugBuild = true
@@ -0,0 +1,348 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+)
+
+// ChangeType describes how a value differs between the left and the right side of a diff.
+type ChangeType string
+
+const (
+	ChangeAdded   ChangeType = "added"
+	ChangeRemoved ChangeType = "removed"
+	ChangeChanged ChangeType = "changed"
+)
+
+const defaultDiffContext = 3
+
+// DiffParams defines the parameters for the DiffExecutor.
+type DiffParams struct {
+	Left      VariablePath      `yaml:"left"`
+	Right     VariablePath      `yaml:"right"`
+	LeftFile  expression.String `yaml:"left_file"`
+	RightFile expression.String `yaml:"right_file"`
+	Context   expression.Int    `yaml:"context"`
+}
+
+// Validate checks the diff compares variables or files.
+func (p DiffParams) Validate() error {
+	if p.Left == "" && p.Right == "" && p.LeftFile == "" && p.RightFile == "" {
+		return errors.New("diff requires left and right variables or files")
+	}
+
+	return nil
+}
+
+// DiffExecutor compares two variables, or two files, setting whether they're identical and their diff.
+// Variables are compared deeply: the diff is the list of changes (path, type, left and right) of the
+// map keys and list items, with template-produced scalars (eg.: "3") compared by their value.
+// Files are compared line by line: the diff is a unified diff with `context` lines (3 by default).
+// Example YAML:
+//
+//	id: diff-example
+//	steps:
+//	- id: drift
+//	  type: diff
+//	  params:
+//	    left: 'desired'
+//	    right: 'actual'
+//	- type: stop
+//	  params:
+//	    condition: '{{ variableGet . "drift" "identical" }}'
+//	    message: 'No drift detected'
+func DiffExecutor(ctx context.Context, scope Scope, step Step, params DiffParams) (Scope, error) {
+	var (
+		diff any
+		err  error
+	)
+
+	if err := params.Validate(); err != nil {
+		return scope, err
+	}
+
+	if params.Left != "" || params.Right != "" {
+		diff, err = diffVariables(scope, params)
+	} else {
+		diff, err = diffFiles(ctx, scope, params)
+	}
+
+	if err != nil {
+		return scope, err
+	}
+
+	identical := reflect.ValueOf(diff).Len() == 0
+
+	return scope.WithVariable(step.VariablePath(), map[string]any{
+		"identical": identical,
+		"diff":      diff,
+	}), nil
+}
+
+func diffVariables(scope Scope, params DiffParams) ([]any, error) {
+	values := make([]any, 2)
+
+	for i, path := range []VariablePath{params.Left, params.Right} {
+		value, err := scope.Variable(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := decode(value, &values[i]); err != nil {
+			return nil, fmt.Errorf("decoding %s: %w", path, err)
+		}
+	}
+
+	changes := []any{}
+	compare("", values[0], values[1], &changes)
+
+	return changes, nil
+}
+
+func compare(path string, left, right any, changes *[]any) {
+	switch l := left.(type) {
+	case map[string]any:
+		if r, ok := right.(map[string]any); ok {
+			compareMaps(path, l, r, changes)
+
+			return
+		}
+	case []any:
+		if r, ok := right.([]any); ok {
+			compareLists(path, l, r, changes)
+
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(left, right) {
+		*changes = append(*changes, change(path, ChangeChanged, left, right))
+	}
+}
+
+func compareMaps(path string, left, right map[string]any, changes *[]any) {
+	keys := make([]string, 0, len(left)+len(right))
+	for key := range left {
+		keys = append(keys, key)
+	}
+
+	for key := range right {
+		if _, ok := left[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		l, inLeft := left[key]
+		r, inRight := right[key]
+
+		switch {
+		case !inRight:
+			*changes = append(*changes, change(join(path, key), ChangeRemoved, l, nil))
+		case !inLeft:
+			*changes = append(*changes, change(join(path, key), ChangeAdded, nil, r))
+		default:
+			compare(join(path, key), l, r, changes)
+		}
+	}
+}
+
+func compareLists(path string, left, right []any, changes *[]any) {
+	for i := range max(len(left), len(right)) {
+		key := join(path, fmt.Sprint(i))
+
+		switch {
+		case i >= len(right):
+			*changes = append(*changes, change(key, ChangeRemoved, left[i], nil))
+		case i >= len(left):
+			*changes = append(*changes, change(key, ChangeAdded, nil, right[i]))
+		default:
+			compare(key, left[i], right[i], changes)
+		}
+	}
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func change(path string, kind ChangeType, left, right any) map[string]any {
+	return map[string]any{
+		"path":  path,
+		"type":  string(kind),
+		"left":  left,
+		"right": right,
+	}
+}
+
+func diffFiles(ctx context.Context, scope Scope, params DiffParams) (string, error) {
+	leftPath, err := params.LeftFile.Eval(ctx, scope)
+	if err != nil {
+		return "", err
+	}
+
+	rightPath, err := params.RightFile.Eval(ctx, scope)
+	if err != nil {
+		return "", err
+	}
+
+	contextLines, err := params.Context.Eval(ctx, scope)
+	if err != nil {
+		return "", err
+	}
+
+	if params.Context == "" {
+		contextLines = defaultDiffContext
+	}
+
+	lines := make([][]string, 2)
+
+	for i, path := range []string{leftPath, rightPath} {
+		//nolint:gosec // ignore G304: comparing files chosen by the pipeline is the purpose of the step.
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+
+		lines[i] = splitLines(string(content))
+	}
+
+	return unifiedDiff(leftPath, rightPath, lines[0], lines[1], contextLines), nil
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+type edit struct {
+	kind byte
+	line string
+}
+
+// edits returns the shortest edit script from a to b, based on their longest common subsequence.
+func edits(a, b []string) []edit {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	script := make([]edit, 0, len(a)+len(b))
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			script = append(script, edit{' ', a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			script = append(script, edit{'-', a[i]})
+			i++
+		default:
+			script = append(script, edit{'+', b[j]})
+			j++
+		}
+	}
+
+	return script
+}
+
+func unifiedDiff(leftName, rightName string, a, b []string, contextLines int) string {
+	script := edits(a, b)
+
+	var out strings.Builder
+
+	// start is the first edit of the current hunk, aLine and bLine the lines preceding it.
+	aLine, bLine := 0, 0
+
+	for i := 0; i < len(script); {
+		if script[i].kind == ' ' {
+			aLine++
+			bLine++
+			i++
+
+			continue
+		}
+
+		start := max(0, i-contextLines)
+		aStart, bStart := aLine-(i-start), bLine-(i-start)
+
+		// The hunk ends when more than twice the context unchanged lines follow a change.
+		end, unchanged := i, 0
+		for ; end < len(script) && unchanged <= 2*contextLines; end++ {
+			if script[end].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+
+		end -= max(0, unchanged-contextLines)
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", leftName, rightName)
+		}
+
+		aCount, bCount := 0, 0
+
+		var body strings.Builder
+
+		for _, e := range script[start:end] {
+			if e.kind != '+' {
+				aCount++
+			}
+
+			if e.kind != '-' {
+				bCount++
+			}
+
+			fmt.Fprintf(&body, "%c%s\n", e.kind, e.line)
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n%s", hunkRange(aStart, aCount), hunkRange(bStart, bCount), body.String())
+
+		aLine, bLine = aStart+aCount, bStart+bCount
+		i = end
+	}
+
+	return out.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+
+	if count == 1 {
+		return fmt.Sprint(start + 1)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
@@ -0,0 +1,79 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffExecutor(t *testing.T) {
+	t.Parallel()
+
+	t.Run("compares variables deeply", func(t *testing.T) {
+		t.Parallel()
+
+		scope := NewScope(Pipelines{}).
+			WithVariable("desired", map[string]any{"replicas": "3", "image": "app:1", "ports": []any{80, 443}}).
+			WithVariable("actual", map[string]any{"replicas": 3, "image": "app:2", "ports": []any{80}, "paused": true})
+
+		scope, err := DiffExecutor(context.Background(), scope, Step{ID: "drift"}, DiffParams{Left: "desired", Right: "actual"})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		drift, _ := Get[map[string]any](scope, "drift")
+		assert.Equal(t, false, drift["identical"])
+		assert.Equal(t, []any{
+			map[string]any{"path": "image", "type": "changed", "left": "app:1", "right": "app:2"},
+			map[string]any{"path": "paused", "type": "added", "left": nil, "right": true},
+			map[string]any{"path": "ports.1", "type": "removed", "left": 443, "right": nil},
+		}, drift["diff"])
+	})
+
+	t.Run("sets identical variables", func(t *testing.T) {
+		t.Parallel()
+
+		scope := NewScope(Pipelines{}).
+			WithVariable("left", []any{map[string]any{"a": 1}}).
+			WithVariable("right", []any{map[string]any{"a": "1"}})
+
+		scope, err := DiffExecutor(context.Background(), scope, Step{ID: "drift"}, DiffParams{Left: "left", Right: "right"})
+		assert.NoError(t, err)
+
+		drift, _ := Get[map[string]any](scope, "drift")
+		assert.Equal(t, true, drift["identical"])
+	})
+
+	t.Run("compares files", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		left, right := filepath.Join(dir, "left.txt"), filepath.Join(dir, "right.txt")
+
+		assert.NoError(t, os.WriteFile(left, []byte("a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n"), 0o600))
+		assert.NoError(t, os.WriteFile(right, []byte("a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"), 0o600))
+
+		scope := NewScope(Pipelines{}).WithVariable("left", left).WithVariable("right", right)
+		params := DiffParams{LeftFile: `{{ variable . "left" }}`, RightFile: `{{ variable . "right" }}`, Context: "1"}
+
+		scope, err := DiffExecutor(context.Background(), scope, Step{ID: "files"}, params)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		files, _ := Get[map[string]any](scope, "files")
+		assert.Equal(t, "--- "+left+"\n+++ "+right+"\n"+
+			"@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"+
+			"@@ -10 +10,2 @@\n j\n+k\n", files["diff"])
+	})
+
+	t.Run("requires variables or files", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := DiffExecutor(context.Background(), NewScope(Pipelines{}), Step{ID: "diff"}, DiffParams{})
+		assert.ErrorContains(t, err, "requires left and right")
+	})
+}
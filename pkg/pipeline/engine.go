@@ -0,0 +1,205 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"text/template"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+)
+
+// Engine owns the step executors, interceptors, logger, template functions, codec and pooled clients used by executions.
+// Multiple independently configured engines can coexist in a process; the package-level functions
+// (eg.: RegisterStepExecutor, SetInterceptor) configure the default engine.
+// Engines are safe for concurrent use: executors can be registered while pipelines are executed.
+type Engine struct {
+	mu              sync.RWMutex
+	executors       StepExecutors
+	interceptor     Interceptor
+	stepInterceptor StepInterceptor
+	logger          log.Logger
+	template        *expression.Template
+	apiVersions     map[string]*expression.Template
+	codec           Codec
+	clients         clientPool
+}
+
+// NewEngine creates an engine with the built-in step executors, the default interceptors
+// and the built-in template functions.
+func NewEngine() *Engine {
+	e := &Engine{
+		executors:       StepExecutors{},
+		interceptor:     defaultInterceptor,
+		stepInterceptor: defaultStepInterceptorfunc,
+		template:        expression.NewTemplate(templateFuncs),
+		codec:           NewJSONCodec(),
+	}
+
+	e.registerStepExecutors()
+
+	return e
+}
+
+// RegisterStepExecutor registers a step executor with a given name.
+func (e *Engine) RegisterStepExecutor(name string, executor StepExecutor) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.executors[name] = executor
+}
+
+// LookupStepExecutor returns the step executor registered with a given name.
+func (e *Engine) LookupStepExecutor(name string) (StepExecutor, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	executor, found := e.executors[name]
+
+	return executor, found
+}
+
+// UnregisterStepExecutor removes the step executor registered with a given name.
+func (e *Engine) UnregisterStepExecutor(name string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.executors, name)
+}
+
+// SetInterceptor sets the pipeline interceptor.
+func (e *Engine) SetInterceptor(itc Interceptor) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.interceptor = itc
+}
+
+// SetStepInterceptor sets the step interceptor.
+func (e *Engine) SetStepInterceptor(itc StepInterceptor) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.stepInterceptor = itc
+}
+
+// AddInterceptor chains the pipeline interceptors after the configured ones, so tracing, metrics or logging
+// interceptors coexist with the default one. They run in the order added, each one wrapping the following ones.
+func (e *Engine) AddInterceptor(itcs ...Interceptor) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.interceptor = ChainInterceptors(append([]Interceptor{e.interceptor}, itcs...)...)
+}
+
+// AddStepInterceptor chains the step interceptors after the configured ones, see AddInterceptor.
+func (e *Engine) AddStepInterceptor(itcs ...StepInterceptor) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.stepInterceptor = ChainStepInterceptors(append([]StepInterceptor{e.stepInterceptor}, itcs...)...)
+}
+
+// SetLogger sets the logger of the engine executions. When not set, the logger configured by log.SetUp is used.
+func (e *Engine) SetLogger(logger log.Logger) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.logger = logger
+}
+
+// RegisterFuncs makes the functions available to the expressions evaluated by the engine executions.
+func (e *Engine) RegisterFuncs(funcs template.FuncMap) {
+	e.template.RegisterFuncs(funcs)
+}
+
+// RegisterAPIVersion evaluates the expressions of the pipelines declaring the apiVersion with the template functions,
+// so upgrading the engine (eg.: sprig) or registering functions doesn't change the behavior of existing expressions.
+// The built-in functions are registered in the template.
+func (e *Engine) RegisterAPIVersion(apiVersion string, t *expression.Template) {
+	t.RegisterFuncs(templateFuncs)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.apiVersions == nil {
+		e.apiVersions = map[string]*expression.Template{}
+	}
+
+	e.apiVersions[apiVersion] = t
+}
+
+func (e *Engine) apiVersion(apiVersion string) (*expression.Template, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	t, found := e.apiVersions[apiVersion]
+
+	return t, found
+}
+
+// Execute runs the pipelines by their names with the engine, see Pipelines.Execute.
+func (e *Engine) Execute(ctx context.Context, scope Scope, names []string, opts ...Option) (Scope, error) {
+	return scope.Pipelines.Execute(e.context(ctx), scope, names, opts...)
+}
+
+func (e *Engine) context(ctx context.Context) context.Context {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	ctx = context.WithValue(ctx, engineKey{}, e)
+
+	if e.template != nil {
+		ctx = expression.WithTemplate(ctx, e.template)
+	}
+
+	if e.logger != nil {
+		ctx = log.WithLogger(ctx, e.logger)
+	}
+
+	return ctx
+}
+
+func (e *Engine) interceptors() interceptors {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return interceptors{pipeline: e.interceptor, step: e.stepInterceptor}
+}
+
+// executeStep executes the step with the executor registered for its type.
+func (e *Engine) executeStep(ctx context.Context, scope Scope, step Step) (Scope, error) {
+	executor, found := e.LookupStepExecutor(step.Type)
+
+	return executeStep(ctx, scope, step, executor, found)
+}
+
+func (e *Engine) registerStepExecutors() {
+	e.RegisterStepExecutor("pipeline", ReadOnly(TypedStepExecutor[Pipeline](PipelineExecutor)))
+	e.RegisterStepExecutor("set", ReadOnly(TypedStepExecutor[SetParams](SetExecutor)))
+	e.RegisterStepExecutor("switch", ReadOnly(TypedStepExecutor[SwitchParams](SwitchExecutor)))
+	e.RegisterStepExecutor("range", ReadOnly(TypedStepExecutor[RangeParams](RangeExecutor)))
+	e.RegisterStepExecutor("wait", ReadOnly(TypedStepExecutor[WaitParams](WaitExecutor)))
+	e.RegisterStepExecutor("stop", ReadOnly(TypedStepExecutor[StopParams](StopExecutor)))
+	e.RegisterStepExecutor("until", ReadOnly(TypedStepExecutor[UntilParams](UntilExecutor)))
+	e.RegisterStepExecutor("log", ReadOnly(TypedStepExecutor[LogParams](LogExecutor)))
+	e.RegisterStepExecutor("fanout", ReadOnly(TypedStepExecutor[FanoutParams](FanoutExecutor)))
+	e.RegisterStepExecutor("wait-for", ReadOnly(TypedStepExecutor[WaitForParams](WaitForExecutor)))
+	e.RegisterStepExecutor("diff", ReadOnly(TypedStepExecutor[DiffParams](DiffExecutor)))
+	e.RegisterStepExecutor("lookup", ReadOnly(TypedStepExecutor[LookupParams](LookupExecutor)))
+	e.RegisterStepExecutor("call", ReadOnly(TypedStepExecutor[CallParams](CallExecutor)))
+	e.RegisterStepExecutor("try", ReadOnly(TypedStepExecutor[TryParams](TryExecutor)))
+	e.RegisterStepExecutor("parallel", ReadOnly(TypedStepExecutor[ParallelParams](ParallelExecutor)))
+	e.RegisterStepExecutor("cache", ReadOnly(TypedStepExecutor[CacheParams](CacheExecutor)))
+}
+
+type engineKey struct{}
+
+// engineFrom returns the engine running the execution, or the default engine.
+func engineFrom(ctx context.Context) *Engine {
+	if e, ok := ctx.Value(engineKey{}).(*Engine); ok {
+		return e
+	}
+
+	return defaultEngine
+}
@@ -0,0 +1,98 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"text/template"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnginesAreIndependent(t *testing.T) {
+	t.Parallel()
+
+	pipelines := NewPipelines(New("main").
+		Step(Step{ID: "custom", Type: "custom"}).
+		Set("greeting", map[string]any{"text": `{{ shout "hi" }}`}).
+		Build())
+
+	custom := NewEngine()
+	custom.RegisterStepExecutor("custom", TypedStepExecutor[struct{}](func(_ context.Context, scope Scope, step Step, _ struct{}) (Scope, error) {
+		return scope.WithVariable(step.VariablePath(), "custom"), nil
+	}))
+	custom.RegisterFuncs(template.FuncMap{"shout": func(s string) string { return s + "!" }})
+
+	logger := &memoryLogger{}
+	custom.SetLogger(logger)
+
+	result, err := custom.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	greeting, _ := result.Variable("greeting")
+	assert.Equal(t, map[string]any{"text": "hi!"}, greeting)
+	assert.NotEmpty(t, logger.messages)
+
+	_, err = NewEngine().Execute(context.Background(), NewScope(pipelines), []string{"main"})
+	assert.ErrorContains(t, err, "unknown step type: custom")
+
+	_, found := LookupStepExecutor("custom")
+	assert.False(t, found)
+}
+
+func TestEngineAPIVersions(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine()
+	engine.RegisterFuncs(template.FuncMap{"greet": func() string { return "hello v2" }})
+	engine.RegisterAPIVersion("v1", expression.NewBaseTemplate(template.FuncMap{"greet": func() string { return "hello v1" }}))
+
+	greet := func(name, apiVersion string) Pipeline {
+		return New(name).
+			APIVersion(apiVersion).
+			Set("name", map[string]any{"value": "bob"}).
+			Set("greeting", map[string]any{"text": `{{ greet }} {{ variableGet . "name" "value" }}`}).
+			Build()
+	}
+
+	pipelines := NewPipelines(
+		greet("legacy", "v1"),
+		greet("current", ""),
+		greet("unknown", "v9"),
+		New("nested").APIVersion("v1").Step(NewStep("", "pipeline", Pipeline{Uses: "current"})).Build(),
+	)
+
+	tests := map[string]string{"legacy": "hello v1 bob", "current": "hello v2 bob", "nested": "hello v1 bob"}
+
+	for name, expected := range tests {
+		scope, err := engine.Execute(context.Background(), NewScope(pipelines), []string{name})
+		if assert.NoError(t, err, name) {
+			greeting, _ := Get[map[string]any](scope, "greeting")
+			assert.Equal(t, expected, greeting["text"], name)
+		}
+	}
+
+	_, err := engine.Execute(context.Background(), NewScope(pipelines), []string{"unknown"})
+	assert.ErrorIs(t, err, ErrUnknownAPIVersion)
+}
+
+func TestEngineEval(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine()
+	engine.RegisterFuncs(template.FuncMap{"greet": func(name string) string { return "hello " + name }})
+
+	scope := NewScope(Pipelines{}, WithVariables(map[VariablePath]any{"user": map[string]any{"name": "bob"}}))
+
+	result, err := engine.Eval(context.Background(), scope, `{{ variable . "user.name" | greet }}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello bob", result)
+
+	_, err = engine.Eval(context.Background(), scope, `{{ variable . "missing" }}`)
+	assert.ErrorIs(t, err, ErrVariableNotFound)
+
+	_, err = Eval(context.Background(), scope, `{{ greet "bob" }}`)
+	assert.Error(t, err, "expected the default engine to not have the engine functions")
+}
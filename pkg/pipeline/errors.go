@@ -0,0 +1,78 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrMaxDepthExceeded is returned when the nested pipelines exceed the maximum depth, see WithMaxDepth.
+	ErrMaxDepthExceeded = errors.New("maximum pipeline depth exceeded")
+	// ErrCycle is returned by Load when pipelines unconditionally use each other.
+	ErrCycle = errors.New("pipeline cycle detected")
+	// ErrWaitTimeout is returned by a wait-for step when its timeout expires.
+	ErrWaitTimeout = errors.New("wait-for timed out")
+	// ErrNoLookupMatch is returned by a lookup step when no entry matches the value and no default is set.
+	ErrNoLookupMatch = errors.New("no lookup entry matches")
+	// ErrUnknownAPIVersion is returned when a pipeline declares an apiVersion not registered, see Engine.RegisterAPIVersion.
+	ErrUnknownAPIVersion = errors.New("unknown apiVersion")
+	// ErrStepTimeout is returned when a step exceeds its timeout.
+	ErrStepTimeout = errors.New("step timed out")
+	// ErrItemTimeout is returned when a range item or a fanout branch exceeds its item_timeout, see StragglerCancel.
+	ErrItemTimeout = errors.New("item timed out")
+	// ErrLimitExceeded is returned when an execution exceeds its limits, see WithLimits.
+	ErrLimitExceeded = errors.New("execution limit exceeded")
+)
+
+// PipelineError is returned when a pipeline fails, wrapping the error of the failed step.
+type PipelineError struct {
+	Pipeline string
+	Err      error
+}
+
+func (e *PipelineError) Error() string {
+	return fmt.Sprintf("error executing pipeline %s: %v", e.Pipeline, e.Err)
+}
+
+func (e *PipelineError) Unwrap() error {
+	return e.Err
+}
+
+// StepError is returned when a step fails, wrapping the executor error.
+type StepError struct {
+	Pipeline string
+	StepID   VariablePathNode
+	StepType string
+	// Annotations are the annotations of the failed step, eg.: to route the error to its team.
+	Annotations map[string]string
+	// Attempt is the 1-based number of the failed step execution.
+	Attempt int
+	// Snapshots are the scopes of the last steps executed, the failed one included, recorded by the binaries
+	// built with the pipelinedebug tag, see WithSnapshots and DumpSnapshots.
+	Snapshots []Snapshot
+	Err       error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("error executing step %s: %v", Step{ID: e.StepID, Type: e.StepType}, e.Err)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// StopError is returned by a stop step with is_error, so callers can tell a deliberate stop from a failure, eg.:
+//
+//	var stopErr *pipeline.StopError
+//	if errors.As(err, &stopErr) {
+//		fmt.Println(stopErr.Message)
+//	}
+type StopError struct {
+	Pipeline string
+	StepID   VariablePathNode
+	Message  string
+}
+
+func (e *StopError) Error() string {
+	return fmt.Sprintf("stop error: %s", e.Message)
+}
@@ -0,0 +1,64 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorsAs(t *testing.T) {
+	t.Parallel()
+
+	t.Run("stop with is_error", func(t *testing.T) {
+		t.Parallel()
+
+		pipelines := NewPipelines(New("main").
+			Stop(StopParams{Condition: "true", Message: "nothing to do", IsError: "true"}).
+			Build())
+
+		_, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+
+		var stopErr *StopError
+		if assert.ErrorAs(t, err, &stopErr) {
+			assert.Equal(t, "nothing to do", stopErr.Message)
+			assert.Equal(t, "main", stopErr.Pipeline)
+		}
+
+		var pipelineErr *PipelineError
+		if assert.ErrorAs(t, err, &pipelineErr) {
+			assert.Equal(t, "main", pipelineErr.Pipeline)
+		}
+	})
+
+	t.Run("expression failure", func(t *testing.T) {
+		t.Parallel()
+
+		pipelines := NewPipelines(New("main").
+			Call("child", "child").
+			Build(), New("child").
+			Set("broken", map[string]any{"value": `{{ variable . "missing" }}`}).
+			Build())
+
+		_, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+
+		var stepErr *StepError
+		if assert.ErrorAs(t, err, &stepErr) {
+			assert.Equal(t, "main", stepErr.Pipeline)
+			assert.Equal(t, "pipeline", stepErr.StepType)
+			assert.Equal(t, 1, stepErr.Attempt)
+		}
+
+		var exprErr *expression.ExpressionError
+		if assert.ErrorAs(t, err, &exprErr) {
+			assert.Contains(t, exprErr.Expression, `{{ variable . "missing" }}`)
+		}
+
+		assert.True(t, errors.Is(err, ErrVariableNotFound))
+
+		var stopErr *StopError
+		assert.False(t, errors.As(err, &stopErr))
+	})
+}
@@ -0,0 +1,137 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFanoutExecutor(t *testing.T) {
+	t.Parallel()
+
+	branch := func(value int, wait time.Duration) Pipeline {
+		return New("").Wait(wait).Set("value", map[string]any{"from": value}).Build()
+	}
+
+	t.Run("merges branches in declaration order", func(t *testing.T) {
+		t.Parallel()
+
+		pipelines := NewPipelines(New("main").
+			Fanout("fanout", 3, branch(0, 30*time.Millisecond), branch(1, 10*time.Millisecond), branch(2, 0)).
+			Build())
+
+		scope, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		value, _ := scope.Variable("value")
+		assert.Equal(t, map[string]any{"from": 2}, value)
+
+		results, _ := scope.Variable("fanout.$results")
+		assert.Len(t, results, 3)
+
+		first, _ := Get[map[string]any](scope, "fanout.$results.0")
+		assert.Equal(t, BranchStatusSuccess, first["status"])
+		assert.Equal(t, "", first["error"])
+
+		duration, err := time.ParseDuration(first["duration"].(string))
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, duration, 30*time.Millisecond)
+	})
+
+	t.Run("reports failed and canceled branches", func(t *testing.T) {
+		t.Parallel()
+
+		failing := New("").Stop(StopParams{Condition: "true", Message: "boom", IsError: "true"}).Build()
+		pipelines := NewPipelines(New("main").
+			Fanout("fanout", 1, failing, branch(1, 0)).
+			Build())
+
+		scope, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+		assert.ErrorContains(t, err, "boom")
+
+		failed, _ := Get[map[string]any](scope, "fanout.$results.0")
+		assert.Equal(t, BranchStatusError, failed["status"])
+		assert.Contains(t, failed["error"], "boom")
+
+		canceled, _ := Get[map[string]any](scope, "fanout.$results.1")
+		assert.Equal(t, BranchStatusCanceled, canceled["status"])
+	})
+
+	t.Run("isolates branches", func(t *testing.T) {
+		t.Parallel()
+
+		pipelines := NewPipelines(New("main").
+			Step(NewStep("fanout", "fanout", FanoutParams{Isolate: "true", Pipelines: []Pipeline{branch(0, 0), branch(1, 0)}})).
+			Range("range", RangeParams{Items: []any{1, 2}, Isolate: "true", Concurrency: "2"}, SetStep("item", map[string]any{"value": 1})).
+			Build())
+
+		scope, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		_, err = scope.Variable("value")
+		assert.ErrorIs(t, err, ErrVariableNotFound)
+
+		_, err = scope.Variable("item")
+		assert.ErrorIs(t, err, ErrVariableNotFound)
+
+		for _, path := range []VariablePath{"fanout.$results.1", "range.$results.1"} {
+			result, _ := Get[map[string]any](scope, path)
+			assert.Equal(t, BranchStatusSuccess, result["status"], path)
+		}
+	})
+}
+
+func TestWaitForExecutor(t *testing.T) {
+	t.Parallel()
+
+	consumer := func(params WaitForParams) Pipeline {
+		return New("").
+			Step(NewStep("", "wait-for", params)).
+			Set("consumed", map[string]any{"value": `{{ variableGet . "token" "value" }}`}).
+			Build()
+	}
+	producer := New("").Wait(10*time.Millisecond).Set("token", map[string]any{"value": "abc"}).Build()
+
+	t.Run("waits for a variable set by another branch", func(t *testing.T) {
+		t.Parallel()
+
+		pipelines := NewPipelines(New("main").
+			Fanout("fanout", 2, consumer(WaitForParams{Variable: "token", Timeout: "5s"}), producer).
+			Build())
+
+		scope, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		consumed, _ := scope.Variable("consumed")
+		assert.Equal(t, map[string]any{"value": "abc"}, consumed)
+	})
+
+	t.Run("waits for a condition", func(t *testing.T) {
+		t.Parallel()
+
+		params := WaitForParams{Condition: `{{ eq (variableGet . "token" "value") "abc" }}`, Timeout: "5s"}
+		pipelines := NewPipelines(New("main").
+			Fanout("fanout", 2, consumer(params), producer).
+			Build())
+
+		_, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		t.Parallel()
+
+		pipelines := NewPipelines(New("main").Step(NewStep("", "pipeline", consumer(WaitForParams{Variable: "missing", Timeout: "10ms"}))).Build())
+
+		_, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+		assert.ErrorIs(t, err, ErrWaitTimeout)
+	})
+}
@@ -0,0 +1,56 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var errFinishOutsideExecution = errors.New("finish function registered outside an execution")
+
+// FinishFunc is called with the outcome of an execution, see OnFinish.
+type FinishFunc func(ctx context.Context, err error)
+
+// finishers are the functions called once the execution finishes.
+type finishers struct {
+	mu       sync.Mutex
+	funcs    []FinishFunc
+	finished bool
+}
+
+func (f *finishers) add(fn FinishFunc) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.finished {
+		return ErrExecutionFinished
+	}
+
+	f.funcs = append(f.funcs, fn)
+
+	return nil
+}
+
+// run calls the functions in reverse registration order, like deferred calls.
+func (f *finishers) run(ctx context.Context, err error) {
+	f.mu.Lock()
+	funcs := f.funcs
+	f.funcs, f.finished = nil, true
+	f.mu.Unlock()
+
+	for i := len(funcs) - 1; i >= 0; i-- {
+		funcs[i](ctx, err)
+	}
+}
+
+// OnFinish registers a function called once the execution running with the context finishes, with its error
+// or nil when it succeeds, eg.: for executors acknowledging received messages only when the execution succeeds.
+// The function context isn't canceled by the execution, so it's still usable after a timeout.
+func OnFinish(ctx context.Context, fn FinishFunc) error {
+	exec := executionFrom(ctx)
+	if exec == nil {
+		return errFinishOutsideExecution
+	}
+
+	return exec.finishers.add(fn)
+}
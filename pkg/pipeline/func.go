@@ -0,0 +1,48 @@
+package pipeline
+
+import (
+	"context"
+)
+
+// Func registers a plain Go function as a step executor.
+// The step params are evaluated like the set step params and decoded into In through its yaml tags,
+// parsing template-produced strings into numeric and boolean fields, and the returned Out is stored in the step variable path.
+// Example:
+//
+//	pipeline.Func("greet", func(ctx context.Context, in struct {
+//		Name string `yaml:"name"`
+//	}) (string, error) {
+//		return "hello, " + in.Name, nil
+//	})
+//
+// And the YAML:
+//
+//	id: greeting
+//	type: greet
+//	params:
+//	  name: '{{ variableGet . "user" "name" }}'
+func Func[In, Out any](name string, fn func(ctx context.Context, in In) (Out, error)) {
+	RegisterStepExecutor(name, FuncExecutor(fn))
+}
+
+// FuncExecutor adapts a plain Go function into a step executor, see Func.
+func FuncExecutor[In, Out any](fn func(ctx context.Context, in In) (Out, error)) StepExecutor {
+	return TypedStepExecutor[SetParams](func(ctx context.Context, scope Scope, step Step, params SetParams) (Scope, error) {
+		raw, err := params.Eval(ctx, scope)
+		if err != nil {
+			return scope, err
+		}
+
+		var in In
+		if err := decode(raw, &in); err != nil {
+			return scope, err
+		}
+
+		out, err := fn(ctx, in)
+		if err != nil {
+			return scope, err
+		}
+
+		return scope.WithVariable(step.VariablePath(), out), nil
+	})
+}
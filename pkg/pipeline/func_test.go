@@ -0,0 +1,56 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type quoteInput struct {
+	Symbol   string  `yaml:"symbol"`
+	Quantity int     `yaml:"quantity"`
+	Price    float64 `yaml:"price"`
+}
+
+type quoteOutput struct {
+	Symbol string
+	Total  float64
+}
+
+func quote(_ context.Context, in quoteInput) (quoteOutput, error) {
+	if in.Quantity <= 0 {
+		return quoteOutput{}, errors.New("quantity must be positive")
+	}
+
+	return quoteOutput{Symbol: in.Symbol, Total: float64(in.Quantity) * in.Price}, nil
+}
+
+func TestFuncExecutor(t *testing.T) {
+	t.Parallel()
+
+	executor := FuncExecutor(quote)
+	scope := NewScope(Pipelines{}).WithVariable("order", map[string]any{"quantity": 3})
+
+	result, err := executor.Execute(context.Background(), scope, Step{
+		ID:   "quote",
+		Type: "quote",
+		Params: map[string]any{
+			"symbol":   "ACME",
+			"quantity": `{{ variableGet . "order" "quantity" }}`,
+			"price":    1.5,
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	value, err := result.Variable("quote")
+	if assert.NoError(t, err) {
+		assert.Equal(t, quoteOutput{Symbol: "ACME", Total: 4.5}, value)
+	}
+
+	_, err = executor.Execute(context.Background(), scope, Step{ID: "quote", Params: map[string]any{"quantity": 0}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,37 @@
+package pipeline
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"gopkg.in/yaml.v3"
+)
+
+func FuzzLoad(f *testing.F) {
+	f.Add([]byte("name: root\nsteps: []\n"))
+	f.Add([]byte("name: range\nsteps:\n- id: r\n  type: range\n  params:\n    items: [1, 2]\n    steps:\n    - type: log\n"))
+	f.Add([]byte("name: [\n"))
+	f.Add([]byte("steps: {}\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _ = Load(fstest.MapFS{"pipeline.yaml": {Data: data}})
+	})
+}
+
+func FuzzStepParams(f *testing.F) {
+	f.Add([]byte("items: [1, 2]\nconcurrency: '2'\nsteps:\n- type: log\n"))
+	f.Add([]byte("cases:\n- condition: 'true'\n  steps: []\ndefault:\n  uses: other\n"))
+	f.Add([]byte("counter: '{{ add 1 2 }}'\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var raw map[string]any
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return
+		}
+
+		_, _ = StepParams[RangeParams](raw)
+		_, _ = StepParams[SwitchParams](raw)
+		_, _ = StepParams[SetParams](raw)
+		_, _ = StepParams[StopParams](raw)
+	})
+}
@@ -0,0 +1,106 @@
+package pipeline
+
+import (
+	"context"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+)
+
+// DefaultHeartbeatInterval is the interval of the heartbeats of running steps, see HeartbeatStepInterceptor.
+const DefaultHeartbeatInterval = time.Minute
+
+// Heartbeat is emitted periodically while a step runs.
+type Heartbeat struct {
+	Step    Step
+	Elapsed time.Duration
+	// Remaining is the time left until the context deadline, when HasDeadline.
+	Remaining   time.Duration
+	HasDeadline bool
+}
+
+// HeartbeatFunc handles the heartbeats, eg.: to publish them as events or metrics.
+type HeartbeatFunc func(ctx context.Context, heartbeat Heartbeat)
+
+type heartbeatOptions struct {
+	interval time.Duration
+	handle   HeartbeatFunc
+}
+
+// HeartbeatOption configures the HeartbeatStepInterceptor.
+type HeartbeatOption func(*heartbeatOptions)
+
+// WithHeartbeatInterval sets how often the heartbeats are emitted, DefaultHeartbeatInterval by default.
+func WithHeartbeatInterval(interval time.Duration) HeartbeatOption {
+	return func(o *heartbeatOptions) {
+		o.interval = interval
+	}
+}
+
+// WithHeartbeatFunc handles the heartbeats with the function instead of logging them.
+func WithHeartbeatFunc(fn HeartbeatFunc) HeartbeatOption {
+	return func(o *heartbeatOptions) {
+		o.handle = fn
+	}
+}
+
+// HeartbeatStepInterceptor wraps the step interceptor, the default one when nil, emitting a heartbeat periodically
+// while each step runs, eg.: "Step deploy running for 5m0s (10m0s until the deadline)", so operators can tell
+// slow steps from hung ones.
+func HeartbeatStepInterceptor(next StepInterceptor, opts ...HeartbeatOption) StepInterceptor {
+	o := heartbeatOptions{interval: DefaultHeartbeatInterval, handle: logHeartbeat}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if next == nil {
+		next = defaultStepInterceptorfunc
+	}
+
+	return func(ctx context.Context, scope Scope, step Step, executor StepExecutor) (Scope, error) {
+		start := time.Now()
+		done := make(chan struct{})
+		stopped := make(chan struct{})
+
+		go func() {
+			defer close(stopped)
+
+			ticker := time.NewTicker(o.interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-done:
+					return
+				case now := <-ticker.C:
+					heartbeat := Heartbeat{Step: step, Elapsed: now.Sub(start)}
+
+					if deadline, ok := ctx.Deadline(); ok {
+						heartbeat.Remaining, heartbeat.HasDeadline = deadline.Sub(now), true
+					}
+
+					o.handle(ctx, heartbeat)
+				}
+			}
+		}()
+
+		defer func() {
+			close(done)
+			<-stopped
+		}()
+
+		return next(ctx, scope, step, executor)
+	}
+}
+
+func logHeartbeat(ctx context.Context, heartbeat Heartbeat) {
+	elapsed := heartbeat.Elapsed.Round(time.Second)
+
+	if heartbeat.HasDeadline {
+		log.Log().Info(ctx, "Step %s running for %s (%s until the deadline)", heartbeat.Step, elapsed, heartbeat.Remaining.Round(time.Second))
+
+		return
+	}
+
+	log.Log().Info(ctx, "Step %s running for %s", heartbeat.Step, elapsed)
+}
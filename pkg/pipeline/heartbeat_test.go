@@ -0,0 +1,54 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeartbeatStepInterceptor(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu         sync.Mutex
+		heartbeats []Heartbeat
+	)
+
+	engine := NewEngine()
+	engine.SetStepInterceptor(HeartbeatStepInterceptor(nil,
+		WithHeartbeatInterval(10*time.Millisecond),
+		WithHeartbeatFunc(func(_ context.Context, heartbeat Heartbeat) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			heartbeats = append(heartbeats, heartbeat)
+		}),
+	))
+
+	pipelines := NewPipelines(New("main").Wait(55 * time.Millisecond).Build())
+
+	_, err := engine.Execute(context.Background(), NewScope(pipelines), []string{"main"}, WithTimeout(time.Minute))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	count := len(heartbeats)
+	if !assert.GreaterOrEqual(t, count, 2) {
+		return
+	}
+
+	last := heartbeats[count-1]
+	assert.Equal(t, "wait", last.Step.Type)
+	assert.GreaterOrEqual(t, last.Elapsed, 20*time.Millisecond)
+	assert.True(t, last.HasDeadline)
+	assert.Greater(t, last.Remaining, 50*time.Second)
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Len(t, heartbeats, count, "heartbeats must stop once the step finishes")
+}
@@ -4,16 +4,14 @@ import (
 	"github.com/crowleyfelix/go-pipeline/pkg/expression"
 )
 
-var (
-	executors       StepExecutors
-	interceptor     Interceptor
-	stepInterceptor StepInterceptor
-)
+// defaultEngine is configured by the package-level functions. Its expressions use the
+// expression package default template, so expression.RegisterFuncs applies to it.
+var defaultEngine *Engine
 
 func init() {
 	expression.RegisterFuncs(templateFuncs)
 
-	executors = StepExecutors{}
+	defaultEngine = &Engine{executors: StepExecutors{}, codec: NewJSONCodec()}
 
 	RegisterStepExecutors()
 	SetInterceptor(defaultInterceptor)
@@ -2,6 +2,7 @@ package pipeline
 
 import (
 	"context"
+	"slices"
 	"time"
 
 	"github.com/crowleyfelix/go-pipeline/pkg/log"
@@ -15,12 +16,86 @@ type Interceptor func(ctx context.Context, scope Scope, pipeline Pipeline, execu
 // StepInterceptor defines a function that intercepts the execution of a step within a pipeline.
 type StepInterceptor func(ctx context.Context, scope Scope, step Step, executor StepExecutor) (Scope, error)
 
+// SetInterceptor sets the pipeline interceptor of the default engine.
 func SetInterceptor(itc Interceptor) {
-	interceptor = itc
+	defaultEngine.SetInterceptor(itc)
 }
 
+// SetStepInterceptor sets the step interceptor of the default engine.
 func SetStepInterceptor(itc StepInterceptor) {
-	stepInterceptor = itc
+	defaultEngine.SetStepInterceptor(itc)
+}
+
+// AddInterceptor chains the pipeline interceptors after the ones of the default engine, see Engine.AddInterceptor.
+func AddInterceptor(itcs ...Interceptor) {
+	defaultEngine.AddInterceptor(itcs...)
+}
+
+// AddStepInterceptor chains the step interceptors after the ones of the default engine, see Engine.AddStepInterceptor.
+func AddStepInterceptor(itcs ...StepInterceptor) {
+	defaultEngine.AddStepInterceptor(itcs...)
+}
+
+// ChainInterceptors composes the pipeline interceptors in order, like http middleware: each one wraps the following
+// ones, the execute function it's given running them and then the pipeline. Nil interceptors are skipped.
+func ChainInterceptors(itcs ...Interceptor) Interceptor {
+	var chain Interceptor
+
+	for _, itc := range slices.Backward(itcs) {
+		if itc == nil {
+			continue
+		}
+
+		if chain == nil {
+			chain = itc
+
+			continue
+		}
+
+		next := chain
+		chain = func(ctx context.Context, scope Scope, pipeline Pipeline, execute Executor) (Scope, error) {
+			return itc(ctx, scope, pipeline, func(ctx context.Context, scope Scope) (Scope, error) {
+				return next(ctx, scope, pipeline, execute)
+			})
+		}
+	}
+
+	return chain
+}
+
+// ChainStepInterceptors composes the step interceptors in order, like http middleware: each one wraps the following
+// ones, the executor it's given running them and then the step. Nil interceptors are skipped.
+func ChainStepInterceptors(itcs ...StepInterceptor) StepInterceptor {
+	var chain StepInterceptor
+
+	for _, itc := range slices.Backward(itcs) {
+		if itc == nil {
+			continue
+		}
+
+		if chain == nil {
+			chain = itc
+
+			continue
+		}
+
+		next := chain
+		chain = func(ctx context.Context, scope Scope, step Step, executor StepExecutor) (Scope, error) {
+			return itc(ctx, scope, step, chainedStepExecutor{next: next, executor: executor})
+		}
+	}
+
+	return chain
+}
+
+// chainedStepExecutor runs the following interceptors of a chain with the step executor.
+type chainedStepExecutor struct {
+	next     StepInterceptor
+	executor StepExecutor
+}
+
+func (e chainedStepExecutor) Execute(ctx context.Context, scope Scope, step Step) (Scope, error) {
+	return e.next(ctx, scope, step, e.executor)
 }
 
 func defaultInterceptor(ctx context.Context, scope Scope, pipeline Pipeline, executor Executor) (Scope, error) {
@@ -0,0 +1,107 @@
+package pipeline
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEngineAddInterceptors(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+
+	trace := func(name string) Interceptor {
+		return func(ctx context.Context, scope Scope, pipeline Pipeline, execute Executor) (Scope, error) {
+			calls = append(calls, name+" "+pipeline.Name)
+			defer func() { calls = append(calls, name+" done") }()
+
+			return execute(ctx, scope)
+		}
+	}
+
+	traceStep := func(name string) StepInterceptor {
+		return func(ctx context.Context, scope Scope, step Step, executor StepExecutor) (Scope, error) {
+			calls = append(calls, name+" "+step.Type)
+			defer func() { calls = append(calls, name+" done") }()
+
+			return executor.Execute(ctx, scope, step)
+		}
+	}
+
+	pipelines := NewPipelines(New("main").Set("greeting", map[string]any{"text": "hi"}).Build())
+
+	logger := &memoryLogger{}
+
+	engine := NewEngine()
+	engine.SetLogger(logger)
+	engine.AddInterceptor(trace("tracing"), nil)
+	engine.AddInterceptor(trace("metrics"))
+	engine.AddStepInterceptor(traceStep("tracing"), traceStep("metrics"))
+
+	scope, err := engine.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	greeting, _ := Get[map[string]any](scope, "greeting")
+	assert.Equal(t, "hi", greeting["text"])
+
+	assert.Equal(t, []string{
+		"tracing main", "metrics main",
+		"tracing set", "metrics set", "metrics done", "tracing done",
+		"metrics done", "tracing done",
+	}, calls)
+
+	timed := 0
+
+	for _, message := range logger.messages {
+		if strings.Contains(message, "executed in") {
+			timed++
+		}
+	}
+
+	assert.Equal(t, 2, timed, "expected the default interceptors to keep timing the pipeline and the step")
+
+	calls = nil
+
+	_, err = engine.Execute(context.Background(), NewScope(pipelines), []string{"main"},
+		WrapInterceptors(trace("events"), traceStep("events")))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []string{
+		"events main", "tracing main", "metrics main",
+		"events set", "tracing set", "metrics set", "metrics done", "tracing done", "events done",
+		"metrics done", "tracing done", "events done",
+	}, calls)
+}
+
+func TestChainInterceptors(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, ChainInterceptors())
+	assert.Nil(t, ChainStepInterceptors(nil, nil))
+
+	var calls []string
+
+	chain := ChainStepInterceptors(
+		func(ctx context.Context, scope Scope, step Step, executor StepExecutor) (Scope, error) {
+			calls = append(calls, "outer")
+
+			return executor.Execute(ctx, scope, step)
+		},
+		func(_ context.Context, scope Scope, _ Step, _ StepExecutor) (Scope, error) {
+			calls = append(calls, "inner")
+
+			return scope, nil
+		},
+	)
+
+	_, err := chain(context.Background(), NewScope(Pipelines{}), Step{Type: "set"}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"outer", "inner"}, calls)
+}
@@ -0,0 +1,162 @@
+package pipeline
+
+import (
+	"slices"
+	"strings"
+	"text/template/parse"
+
+	"github.com/samber/lo"
+)
+
+// variableFuncs are the template functions reading a variable path given as their second argument.
+var variableFuncs = []string{"variable", "variableGet"}
+
+// Names returns the names of the pipelines, sorted.
+func (p Pipelines) Names() []string {
+	names := lo.Keys(p.pipelines)
+	slices.Sort(names)
+
+	return names
+}
+
+// Get returns the pipeline with the given name.
+func (p Pipelines) Get(name string) (Pipeline, bool) {
+	pipe, found := p.pipelines[name]
+
+	return pipe, found
+}
+
+// Dependency is a pipeline executed by another one, see Pipelines.Graph.
+type Dependency struct {
+	Pipeline string
+	// Conditional reports whether the pipeline is only executed conditionally, eg.: by steps with if, switch cases
+	// or range items.
+	Conditional bool
+}
+
+// Graph returns the pipelines executed by each pipeline, through uses, pipeline and call steps, nested or not,
+// sorted by name. Calls to pipelines named at run time can't be known and are not returned.
+func (p Pipelines) Graph() map[string][]Dependency {
+	graph := make(map[string][]Dependency, len(p.pipelines))
+
+	for name, pipe := range p.pipelines {
+		always := pipe.references(false)
+		dependencies := []Dependency{}
+
+		for _, used := range lo.Uniq(pipe.references(true)) {
+			dependencies = append(dependencies, Dependency{Pipeline: used, Conditional: !slices.Contains(always, used)})
+		}
+
+		slices.SortFunc(dependencies, func(a, b Dependency) int { return strings.Compare(a.Pipeline, b.Pipeline) })
+		graph[name] = dependencies
+	}
+
+	return graph
+}
+
+// StepInfo describes a step for tooling, eg.: UIs and validators.
+type StepInfo struct {
+	ID     VariablePathNode
+	Type   string
+	Params map[string]any
+	// Variables are the variable paths referenced by the params expressions, including the nested steps ones, sorted.
+	Variables []VariablePath
+}
+
+// Inspect describes the pipeline steps.
+func (p Pipeline) Inspect() []StepInfo {
+	infos := make([]StepInfo, len(p.Steps))
+	for i, step := range p.Steps {
+		infos[i] = StepInfo{
+			ID:        step.ID,
+			Type:      step.Type,
+			Params:    step.Params,
+			Variables: step.Variables(),
+		}
+	}
+
+	return infos
+}
+
+// Variables returns the variable paths referenced with literal paths by the step params expressions, sorted.
+// Paths built at runtime (eg.: {{ variable . (printf "item-%d" 1) }}) can't be known and are not returned.
+func (s Step) Variables() []VariablePath {
+	found := map[VariablePath]struct{}{}
+	collectVariables(s.Params, found)
+
+	paths := lo.Keys(found)
+	slices.Sort(paths)
+
+	return paths
+}
+
+func collectVariables(value any, found map[VariablePath]struct{}) {
+	switch typed := value.(type) {
+	case string:
+		tree := parse.New("")
+		tree.Mode = parse.SkipFuncCheck
+
+		if _, err := tree.Parse(typed, "{{", "}}", map[string]*parse.Tree{}); err != nil || tree.Root == nil {
+			return
+		}
+
+		collectNodeVariables(tree.Root, found)
+	case map[string]any:
+		for _, item := range typed {
+			collectVariables(item, found)
+		}
+	case []any:
+		for _, item := range typed {
+			collectVariables(item, found)
+		}
+	}
+}
+
+func collectNodeVariables(node parse.Node, found map[VariablePath]struct{}) {
+	switch typed := node.(type) {
+	case *parse.ListNode:
+		if typed == nil {
+			return
+		}
+
+		for _, child := range typed.Nodes {
+			collectNodeVariables(child, found)
+		}
+	case *parse.ActionNode:
+		collectNodeVariables(typed.Pipe, found)
+	case *parse.IfNode:
+		collectBranchVariables(&typed.BranchNode, found)
+	case *parse.RangeNode:
+		collectBranchVariables(&typed.BranchNode, found)
+	case *parse.WithNode:
+		collectBranchVariables(&typed.BranchNode, found)
+	case *parse.TemplateNode:
+		collectNodeVariables(typed.Pipe, found)
+	case *parse.PipeNode:
+		if typed == nil {
+			return
+		}
+
+		for _, cmd := range typed.Cmds {
+			collectNodeVariables(cmd, found)
+		}
+	case *parse.CommandNode:
+		if len(typed.Args) > 2 {
+			if ident, ok := typed.Args[0].(*parse.IdentifierNode); ok && slices.Contains(variableFuncs, ident.Ident) {
+				if path, ok := typed.Args[2].(*parse.StringNode); ok {
+					found[VariablePath(path.Text)] = struct{}{}
+				}
+			}
+		}
+
+		for _, arg := range typed.Args {
+			collectNodeVariables(arg, found)
+		}
+	}
+}
+
+func collectBranchVariables(branch *parse.BranchNode, found map[VariablePath]struct{}) {
+	collectNodeVariables(branch.Pipe, found)
+	collectNodeVariables(branch.List, found)
+	collectNodeVariables(branch.ElseList, found)
+}
@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelinesIntrospection(t *testing.T) {
+	t.Parallel()
+
+	pipelines := NewPipelines(
+		New("main").
+			Set("greeting", map[string]any{
+				"text":  `{{ if variable . "enabled" }}{{ variableGet . "user" "name" | upper }}{{ end }}`,
+				"count": `{{ len (variable . "items") }}`,
+			}).
+			Range("item", RangeParams{Variable: "items"}, LogStep(`{{ variable . "item" }}`)).
+			Build(),
+		New("child").Build(),
+	)
+
+	assert.Equal(t, []string{"child", "main"}, pipelines.Names())
+
+	_, found := pipelines.Get("missing")
+	assert.False(t, found)
+
+	main, found := pipelines.Get("main")
+	if !assert.True(t, found) {
+		return
+	}
+
+	infos := main.Inspect()
+	if !assert.Len(t, infos, 2) {
+		return
+	}
+
+	assert.Equal(t, "set", infos[0].Type)
+	assert.Equal(t, []VariablePath{"enabled", "items", "user"}, infos[0].Variables)
+	assert.Equal(t, VariablePathNode("item"), infos[1].ID)
+	assert.Equal(t, []VariablePath{"item"}, infos[1].Variables)
+}
+
+func TestPipelinesGraph(t *testing.T) {
+	t.Parallel()
+
+	pipelines := NewPipelines(
+		New("main").
+			Uses("setup").
+			Call("report", "report").
+			Step(NewStep("notify", "call", CallParams{Pipeline: "notify"}).When(`{{ variable . "notify" }}`)).
+			Range("item", RangeParams{Variable: "items"}, NewStep("", "pipeline", Pipeline{Uses: "process"})).
+			Step(NewStep("dynamic", "call", CallParams{Pipeline: `{{ variable . "name" }}`})).
+			Build(),
+		New("setup").Build(),
+		New("report").Uses("setup").Build(),
+	)
+
+	assert.Equal(t, map[string][]Dependency{
+		"main": {
+			{Pipeline: "notify", Conditional: true},
+			{Pipeline: "process", Conditional: true},
+			{Pipeline: "report"},
+			{Pipeline: "setup"},
+		},
+		"setup":  {},
+		"report": {{Pipeline: "setup"}},
+	}, pipelines.Graph())
+}
@@ -0,0 +1,107 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Limits are guardrails protecting shared engines from runaway executions, eg.: an until step whose condition
+// never flips. Zero values are unlimited. Executions exceeding them fail with ErrLimitExceeded.
+type Limits struct {
+	// MaxSteps is the maximum number of steps executed, counting the steps of nested pipelines and branches.
+	MaxSteps int
+	// MaxGoroutines is the maximum number of concurrent branches running at once, eg.: by range and fanout.
+	MaxGoroutines int
+	// MaxVariables is the maximum number of variables of a scope.
+	MaxVariables int
+}
+
+// WithLimits limits the execution, see Limits.
+func WithLimits(limits Limits) Option {
+	return func(o *options) {
+		o.limits = limits
+	}
+}
+
+// limits count the resources used by an execution.
+type limits struct {
+	mu         sync.Mutex
+	limits     Limits
+	steps      int
+	goroutines int
+}
+
+func limitsFrom(ctx context.Context) *limits {
+	if exec := executionFrom(ctx); exec != nil {
+		return exec.limits
+	}
+
+	return nil
+}
+
+func (l *limits) set(limits Limits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limits = limits
+}
+
+// step counts a step execution.
+func (l *limits) step() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.steps++
+
+	if l.limits.MaxSteps > 0 && l.steps > l.limits.MaxSteps {
+		return fmt.Errorf("%w: more than %d steps executed", ErrLimitExceeded, l.limits.MaxSteps)
+	}
+
+	return nil
+}
+
+// acquire counts n goroutines started, released by the returned function once they finish.
+func (l *limits) acquire(n int) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limits.MaxGoroutines > 0 && l.goroutines+n > l.limits.MaxGoroutines {
+		return nil, fmt.Errorf("%w: %d goroutines running, %d more requested, up to %d allowed",
+			ErrLimitExceeded, l.goroutines, n, l.limits.MaxGoroutines)
+	}
+
+	l.goroutines += n
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		l.goroutines -= n
+	}, nil
+}
+
+// check verifies the scope size.
+func (l *limits) check(scope Scope) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	maxVariables := l.limits.MaxVariables
+	l.mu.Unlock()
+
+	if maxVariables > 0 && scope.variables.len() > maxVariables {
+		return fmt.Errorf("%w: %d variables set, up to %d allowed", ErrLimitExceeded, scope.variables.len(), maxVariables)
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecuteLimits(t *testing.T) {
+	t.Parallel()
+
+	pipelines := NewPipelines(
+		New("runaway").Step(NewStep("", "until", UntilParams{
+			Condition: "true",
+			Pipeline:  New("").Set("counter", map[string]any{"value": 1}).Build(),
+		})).Build(),
+		New("branches").
+			Range("item", RangeParams{Items: []any{1, 2, 3, 4}, Concurrency: "4"}, LogStep(`{{ variable . "item" }}`)).
+			Build(),
+		New("variables").
+			Set("a", map[string]any{"value": 1}).
+			Set("b", map[string]any{"value": 2}).
+			Set("c", map[string]any{"value": 3}).
+			Build(),
+	)
+
+	tests := []struct {
+		name     string
+		pipeline string
+		limits   Limits
+		err      string
+	}{
+		{name: "steps", pipeline: "runaway", limits: Limits{MaxSteps: 50}, err: "more than 50 steps executed"},
+		{name: "goroutines", pipeline: "branches", limits: Limits{MaxGoroutines: 2}, err: "4 more requested, up to 2 allowed"},
+		{name: "goroutines within limit", pipeline: "branches", limits: Limits{MaxGoroutines: 4}},
+		{name: "variables", pipeline: "variables", limits: Limits{MaxVariables: 2}, err: "3 variables set, up to 2 allowed"},
+		{name: "unlimited", pipeline: "variables"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{tc.pipeline}, WithLimits(tc.limits))
+
+			if tc.err == "" {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			assert.ErrorIs(t, err, ErrLimitExceeded)
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
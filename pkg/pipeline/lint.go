@@ -0,0 +1,124 @@
+package pipeline
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Lint checks the variables declared by the pipelines against their usage, sorted by pipeline. Pipelines without
+// declared variables aren't linted, as their data contract is unknown. The issues are:
+//   - variables referenced by the steps that are neither declared, inputs nor set by the steps;
+//   - declared variables no step references;
+//   - declared variables with unknown types or defaults not matching them.
+//
+// Only the variables referenced with literal paths are known, see Step.Variables.
+func (p Pipelines) Lint() []Issue {
+	var issues []Issue
+
+	for _, name := range p.Names() {
+		pipe := p.pipelines[name]
+		if len(pipe.Variables) > 0 {
+			issues = append(issues, pipe.lint(p)...)
+		}
+	}
+
+	return issues
+}
+
+func (p Pipeline) lint(pipelines Pipelines) []Issue {
+	var issues []Issue
+
+	issue := func(step VariablePathNode, format string, args ...any) {
+		issues = append(issues, Issue{Pipeline: p.Name, Step: step, Message: fmt.Sprintf(format, args...)})
+	}
+
+	known := map[string]bool{p.ID: true}
+	for name := range p.Inputs {
+		known[name] = true
+	}
+
+	declared := map[string]bool{}
+
+	for _, variable := range p.Variables {
+		declared[variable.Name] = true
+
+		if _, ok := (Input{Type: variable.Type}).check(variable.Default); variable.Default != nil && !ok {
+			issue("", "variable %s default is not a %s: %v", variable.Name, variable.Type, variable.Default)
+		} else if variable.Type != "" && !slices.Contains(inputTypes, variable.Type) {
+			issue("", "variable %s has an unknown type %s", variable.Name, variable.Type)
+		}
+	}
+
+	steps := p.Steps
+	for used, visited := p.Uses, map[string]bool{}; used != "" && !visited[used]; {
+		visited[used] = true
+
+		pipe, found := pipelines.Get(used)
+		if !found {
+			break
+		}
+
+		steps = append(slices.Clone(pipe.Steps), steps...)
+		used = pipe.Uses
+	}
+
+	for _, step := range steps {
+		collectStepIDs(step.ID, step.Params, known)
+	}
+
+	referenced := map[string]bool{}
+
+	for _, step := range p.Steps {
+		for _, path := range step.Variables() {
+			root := variableRoot(path)
+			referenced[root] = true
+
+			if !declared[root] && !known[root] && !strings.HasPrefix(root, "$") {
+				issue(step.ID, "variable %s is not declared", root)
+			}
+		}
+	}
+
+	for _, variable := range p.Variables {
+		if !referenced[variable.Name] {
+			issue("", "variable %s is declared but not used", variable.Name)
+		}
+	}
+
+	return issues
+}
+
+var inputTypes = []string{InputTypeAny, InputTypeString, InputTypeInt, InputTypeNumber, InputTypeBool, InputTypeList, InputTypeMap}
+
+// collectStepIDs adds the step ID and the IDs of the nested steps of its params, eg.: of parallel steps.
+func collectStepIDs(id VariablePathNode, params any, ids map[string]bool) {
+	if id != "" {
+		ids[string(id)] = true
+	}
+
+	switch typed := params.(type) {
+	case map[string]any:
+		if nested, ok := typed["id"].(string); ok && typed["type"] != nil {
+			ids[nested] = true
+		}
+
+		for _, item := range typed {
+			collectStepIDs("", item, ids)
+		}
+	case []any:
+		for _, item := range typed {
+			collectStepIDs("", item, ids)
+		}
+	}
+}
+
+// variableRoot returns the first node of the path, eg.: "users" of "users.$body[0]".
+func variableRoot(path VariablePath) string {
+	root := string(path)
+	if i := strings.IndexAny(root, ".["); i >= 0 {
+		root = root[:i]
+	}
+
+	return root
+}
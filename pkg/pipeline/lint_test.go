@@ -0,0 +1,87 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestPipelinesLint(t *testing.T) {
+	t.Parallel()
+
+	var deploy Pipeline
+
+	err := yaml.Unmarshal([]byte(`
+name: deploy
+inputs:
+  service:
+    type: string
+variables:
+- name: env
+  type: string
+  description: Environment to deploy to.
+  default: staging
+- name: replicas
+  type: int
+  default: many
+- name: region
+  type: text
+steps:
+- id: plan
+  type: set
+  params:
+    target: '{{ variable . "env" }}/{{ variable . "service" }}'
+- id: apply
+  type: parallel
+  params:
+    steps:
+    - id: rollout
+      type: log
+      params:
+        message: '{{ variable . "plan.target" }} {{ variable . "replicas" }}'
+    - id: notify
+      type: log
+      params:
+        message: '{{ variable . "rollout.$status" }} {{ variable . "channel" }}'
+`), &deploy)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	pipelines := NewPipelines(deploy, New("undeclared").Log(`{{ variable . "anything" }}`).Build())
+
+	assert.Equal(t, []Issue{
+		{Pipeline: "deploy", Message: "variable replicas default is not a int: many"},
+		{Pipeline: "deploy", Message: "variable region has an unknown type text"},
+		{Pipeline: "deploy", Step: "apply", Message: "variable channel is not declared"},
+		{Pipeline: "deploy", Message: "variable region is declared but not used"},
+	}, pipelines.Lint())
+}
+
+func TestPipelineVariables(t *testing.T) {
+	t.Parallel()
+
+	pipelines := NewPipelines(New("deploy").
+		Variable(VariableDeclaration{Name: "env", Type: InputTypeString, Default: "staging"}).
+		Variable(VariableDeclaration{Name: "replicas", Type: InputTypeInt}).
+		Set("plan", map[string]any{"target": `{{ variable . "env" }}`}).
+		Build())
+
+	execute := func(variables map[VariablePath]any) (Scope, error) {
+		return pipelines.Execute(context.Background(), NewScope(pipelines), []string{"deploy"}, WithVariables(variables))
+	}
+
+	scope, err := execute(map[VariablePath]any{"replicas": 3.0})
+	if assert.NoError(t, err) {
+		target, _ := scope.Variable("plan.target")
+		assert.Equal(t, "staging", target)
+
+		replicas, _ := scope.Variable("replicas")
+		assert.Equal(t, 3, replicas)
+	}
+
+	_, err = execute(map[VariablePath]any{"replicas": "three"})
+	assert.ErrorIs(t, err, ErrInvalidInput)
+}
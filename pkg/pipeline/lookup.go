@@ -0,0 +1,78 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+)
+
+// LookupPattern maps the values matching a regular expression.
+type LookupPattern struct {
+	Pattern string `yaml:"pattern"`
+	Value   any    `yaml:"value"`
+}
+
+// LookupParams defines the parameters for the LookupExecutor.
+type LookupParams struct {
+	Value    expression.String `yaml:"value"`
+	Table    map[string]any    `yaml:"table"`
+	Patterns []LookupPattern   `yaml:"patterns"`
+	Default  *any              `yaml:"default"`
+}
+
+// LookupExecutor maps a value through a lookup table, setting the mapped value in the step variable path.
+// The value is looked up in the table first, then matched against the patterns in order; string values
+// of a pattern can reference its capture groups (eg.: `$1`). When nothing matches, the default is set,
+// or the step fails with ErrNoLookupMatch when there's no default.
+// Example YAML:
+//
+//	id: lookup-example
+//	steps:
+//	- id: region
+//	  type: lookup
+//	  params:
+//	    value: '{{ variable . "env" }}'
+//	    table:
+//	      prod: 'us-east-1'
+//	      staging: 'us-west-2'
+//	    patterns:
+//	    - pattern: '^preview-(\w+)$'
+//	      value: 'eu-$1'
+//	    default: 'local'
+func LookupExecutor(ctx context.Context, scope Scope, step Step, params LookupParams) (Scope, error) {
+	value, err := params.Value.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	if mapped, found := params.Table[value]; found {
+		return scope.WithVariable(step.VariablePath(), mapped), nil
+	}
+
+	for _, pattern := range params.Patterns {
+		re, err := regexp.Compile(pattern.Pattern)
+		if err != nil {
+			return scope, fmt.Errorf("invalid lookup pattern %q: %w", pattern.Pattern, err)
+		}
+
+		match := re.FindStringSubmatchIndex(value)
+		if match == nil {
+			continue
+		}
+
+		mapped := pattern.Value
+		if template, ok := mapped.(string); ok {
+			mapped = string(re.ExpandString(nil, template, value, match))
+		}
+
+		return scope.WithVariable(step.VariablePath(), mapped), nil
+	}
+
+	if params.Default == nil {
+		return scope, fmt.Errorf("%w %q", ErrNoLookupMatch, value)
+	}
+
+	return scope.WithVariable(step.VariablePath(), *params.Default), nil
+}
@@ -0,0 +1,55 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupExecutor(t *testing.T) {
+	t.Parallel()
+
+	params := map[string]any{
+		"value":    `{{ variable . "env" }}`,
+		"table":    map[string]any{"prod": "us-east-1", "staging": map[string]any{"region": "us-west-2"}},
+		"patterns": []any{map[string]any{"pattern": `^preview-(\w+)$`, "value": "eu-$1"}},
+		"default":  "local",
+	}
+
+	tests := []struct {
+		env      string
+		expected any
+	}{
+		{env: "prod", expected: "us-east-1"},
+		{env: "staging", expected: map[string]any{"region": "us-west-2"}},
+		{env: "preview-42", expected: "eu-42"},
+		{env: "dev", expected: "local"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			t.Parallel()
+
+			scope := NewScope(Pipelines{}).WithVariable("env", tt.env)
+
+			scope, err := TypedStepExecutor[LookupParams](LookupExecutor).Execute(context.Background(), scope, NewStep("region", "lookup", params))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			region, _ := scope.Variable("region")
+			assert.Equal(t, tt.expected, region)
+		})
+	}
+
+	t.Run("fails without default", func(t *testing.T) {
+		t.Parallel()
+
+		scope := NewScope(Pipelines{}).WithVariable("env", "dev")
+		step := NewStep("region", "lookup", map[string]any{"value": `{{ variable . "env" }}`, "table": map[string]any{"prod": "us-east-1"}})
+
+		_, err := TypedStepExecutor[LookupParams](LookupExecutor).Execute(context.Background(), scope, step)
+		assert.ErrorIs(t, err, ErrNoLookupMatch)
+	})
+}
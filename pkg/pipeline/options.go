@@ -0,0 +1,224 @@
+package pipeline
+
+import (
+	"context"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+)
+
+// DefaultMaxDepth is the maximum number of nested pipelines of an execution, see WithMaxDepth.
+const DefaultMaxDepth = 100
+
+type options struct {
+	timeout         time.Duration
+	variables       map[VariablePath]any
+	interceptor     Interceptor
+	stepInterceptor StepInterceptor
+	wrapInterceptor Interceptor
+	wrapStep        StepInterceptor
+	logger          log.Logger
+	maxDepth        int
+	workspace       string
+	budgets         map[string]Budget
+	limits          Limits
+	output          OutputFunc
+	checkpoints     CheckpointStore
+	sandbox         string
+	snapshots       int
+	caches          map[string]Cache
+	panicPolicy     *PanicPolicy
+	seed            *uint64
+	readOnly        string
+}
+
+// Option configures a single execution, see Pipelines.Execute.
+type Option func(*options)
+
+// WithTimeout cancels the execution after the duration.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithVariables sets variables in the scope before executing the pipelines.
+func WithVariables(variables map[VariablePath]any) Option {
+	return func(o *options) {
+		if o.variables == nil {
+			o.variables = map[VariablePath]any{}
+		}
+
+		for path, value := range variables {
+			o.variables[path] = value
+		}
+	}
+}
+
+// WithInterceptors replaces the interceptors set by SetInterceptor and SetStepInterceptor during the execution.
+// Nil interceptors keep the configured ones. Compose several with ChainInterceptors and ChainStepInterceptors.
+func WithInterceptors(interceptor Interceptor, stepInterceptor StepInterceptor) Option {
+	return func(o *options) {
+		o.interceptor = interceptor
+		o.stepInterceptor = stepInterceptor
+	}
+}
+
+// WrapInterceptors chains the interceptors around the configured ones during the execution, including the ones
+// set by WithInterceptors, eg.: to observe an execution without dropping the interceptors of the engine.
+func WrapInterceptors(interceptor Interceptor, stepInterceptor StepInterceptor) Option {
+	return func(o *options) {
+		o.wrapInterceptor = interceptor
+		o.wrapStep = stepInterceptor
+	}
+}
+
+// WithLogger logs the execution messages with the logger instead of the one configured by log.SetUp.
+func WithLogger(logger log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithMaxDepth limits the number of nested pipelines, DefaultMaxDepth by default.
+// Executions exceeding it, eg.: a pipeline recursively using itself, fail with ErrMaxDepthExceeded.
+func WithMaxDepth(depth int) Option {
+	return func(o *options) {
+		o.maxDepth = depth
+	}
+}
+
+// WithWorkspace sets the execution working directory, kept once the execution finishes.
+// By default, a temporary directory is created on first use and removed once the execution finishes.
+func WithWorkspace(dir string) Option {
+	return func(o *options) {
+		o.workspace = dir
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+type (
+	interceptorsKey struct{}
+	maxDepthKey     struct{}
+)
+
+type interceptors struct {
+	pipeline Interceptor
+	step     StepInterceptor
+}
+
+// apply configures the context and the scope for the execution.
+// The returned cancel function must be called once the execution finishes.
+func (o options) apply(ctx context.Context, scope Scope) (context.Context, Scope, context.CancelFunc) {
+	cancel := func() {}
+
+	if o.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+	}
+
+	if o.logger != nil {
+		ctx = log.WithLogger(ctx, o.logger)
+	}
+
+	if exec := executionFrom(ctx); exec != nil && o.workspace != "" {
+		exec.workspace.use(o.workspace)
+	}
+
+	if exec := executionFrom(ctx); exec != nil && o.sandbox != "" {
+		exec.sandbox = sandbox{root: o.sandbox}
+	}
+
+	if exec := executionFrom(ctx); exec != nil {
+		for name, budget := range o.budgets {
+			exec.budgets.set(name, budget)
+		}
+
+		if o.limits != (Limits{}) {
+			exec.limits.set(o.limits)
+		}
+
+		if o.snapshots > 0 {
+			exec.snapshots.resize(o.snapshots)
+		}
+
+		for name, cache := range o.caches {
+			exec.caches[name] = cache
+		}
+
+		if o.seed != nil {
+			exec.random = newRandom(*o.seed)
+		}
+
+		if FromContext(ctx).Depth == 0 {
+			log.Log().Debug(ctx, "Execution seed %d", exec.random.seed)
+		}
+	}
+
+	if o.maxDepth > 0 {
+		ctx = context.WithValue(ctx, maxDepthKey{}, o.maxDepth)
+	}
+
+	if o.panicPolicy != nil {
+		ctx = context.WithValue(ctx, panicPolicyKey{}, *o.panicPolicy)
+	}
+
+	if o.readOnly != "" {
+		ctx = context.WithValue(ctx, readOnlyKey{}, o.readOnly)
+	}
+
+	if o.output != nil {
+		ctx = context.WithValue(ctx, outputKey{}, o.output)
+	}
+
+	if o.checkpoints != nil {
+		ctx = context.WithValue(ctx, checkpointsKey{}, o.checkpoints)
+	}
+
+	if o.interceptor != nil || o.stepInterceptor != nil {
+		current := interceptorsFrom(ctx)
+
+		if o.interceptor != nil {
+			current.pipeline = o.interceptor
+		}
+
+		if o.stepInterceptor != nil {
+			current.step = o.stepInterceptor
+		}
+
+		ctx = context.WithValue(ctx, interceptorsKey{}, current)
+	}
+
+	if o.wrapInterceptor != nil || o.wrapStep != nil {
+		current := interceptorsFrom(ctx)
+		current.pipeline = ChainInterceptors(o.wrapInterceptor, current.pipeline)
+		current.step = ChainStepInterceptors(o.wrapStep, current.step)
+
+		ctx = context.WithValue(ctx, interceptorsKey{}, current)
+	}
+
+	return ctx, scope.WithVariables(o.variables), cancel
+}
+
+func interceptorsFrom(ctx context.Context) interceptors {
+	if current, ok := ctx.Value(interceptorsKey{}).(interceptors); ok {
+		return current
+	}
+
+	return engineFrom(ctx).interceptors()
+}
+
+func maxDepthFrom(ctx context.Context) int {
+	if depth, ok := ctx.Value(maxDepthKey{}).(int); ok {
+		return depth
+	}
+
+	return DefaultMaxDepth
+}
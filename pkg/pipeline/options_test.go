@@ -0,0 +1,113 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryLogger struct {
+	mu       sync.Mutex
+	messages []string
+	levels   []string
+	fields   [][]log.Field
+}
+
+func (m *memoryLogger) record(ctx context.Context, level, msg string, args ...any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, fmt.Sprintf(msg, args...))
+	m.levels = append(m.levels, level)
+	m.fields = append(m.fields, log.Fields(ctx))
+}
+
+func (m *memoryLogger) Error(ctx context.Context, msg string, args ...any) {
+	m.record(ctx, "error", msg, args...)
+}
+func (m *memoryLogger) Warn(ctx context.Context, msg string, args ...any) {
+	m.record(ctx, "warn", msg, args...)
+}
+func (m *memoryLogger) Info(ctx context.Context, msg string, args ...any) {
+	m.record(ctx, "info", msg, args...)
+}
+func (m *memoryLogger) Debug(ctx context.Context, msg string, args ...any) {
+	m.record(ctx, "debug", msg, args...)
+}
+
+func TestExecuteOptions(t *testing.T) {
+	t.Parallel()
+
+	pipelines := NewPipelines(
+		New("greet").Log(`hello {{ variable . "name" }}`).Build(),
+		New("slow").Step(NewStep("", "until", UntilParams{
+			Condition: "true",
+			Pipeline:  New("").Wait(time.Millisecond).Build(),
+		})).Build(),
+	)
+
+	t.Run("variables and logger", func(t *testing.T) {
+		t.Parallel()
+
+		logger := &memoryLogger{}
+
+		_, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"greet"},
+			WithVariables(map[VariablePath]any{"name": "bob"}),
+			WithLogger(logger),
+		)
+		assert.NoError(t, err)
+		assert.Contains(t, logger.messages, "hello bob")
+	})
+
+	t.Run("interceptors", func(t *testing.T) {
+		t.Parallel()
+
+		var steps []string
+
+		_, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"greet"},
+			WithVariables(map[VariablePath]any{"name": "bob"}),
+			WithInterceptors(nil, func(ctx context.Context, scope Scope, step Step, executor StepExecutor) (Scope, error) {
+				steps = append(steps, step.Type)
+
+				return executor.Execute(ctx, scope, step)
+			}),
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"log"}, steps)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"slow"}, WithTimeout(20*time.Millisecond))
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestNewScopeVariables(t *testing.T) {
+	t.Parallel()
+
+	pipelines := NewPipelines(New("main").Set("greeting", map[string]any{"text": `hello {{ variable . "name" }}`}).Build())
+
+	scope := NewScope(pipelines, WithVariables(map[VariablePath]any{"name": "bob"}), WithTimeout(time.Minute))
+
+	name, err := scope.Variable("name")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "bob", name)
+	}
+
+	result, err := pipelines.Execute(context.Background(), scope, []string{"main"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	text, err := result.Variable("greeting.text")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "hello bob", text)
+	}
+}
@@ -0,0 +1,63 @@
+package pipeline
+
+import (
+	"context"
+	"io"
+)
+
+// Output streams written by the steps, see Output.
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// OutputChunk is a piece of an output stream written by a step.
+type OutputChunk struct {
+	Pipeline string
+	Step     Step
+	Stream   string
+	Data     []byte
+}
+
+// OutputFunc handles the output written by the steps, eg.: to store it per step or stream it to a UI.
+// It's called concurrently by the steps of concurrent branches, and by the streams of a step.
+type OutputFunc func(ctx context.Context, chunk OutputChunk)
+
+type outputKey struct{}
+
+// WithOutput handles the output streams written by the steps of the execution with the function,
+// instead of discarding them.
+func WithOutput(fn OutputFunc) Option {
+	return func(o *options) {
+		o.output = fn
+	}
+}
+
+// Output returns the writer of the stream of the step running with the context, eg.: the stdout of the commands
+// it runs, so the output is kept apart from the execution logs. Outside executions handling their output with WithOutput
+// the writes are discarded.
+func Output(ctx context.Context, stream string) io.Writer {
+	handle, ok := ctx.Value(outputKey{}).(OutputFunc)
+	if !ok {
+		return io.Discard
+	}
+
+	info := FromContext(ctx)
+
+	return outputWriter{ctx: ctx, handle: handle, chunk: OutputChunk{Pipeline: info.Pipeline, Step: info.Step, Stream: stream}}
+}
+
+type outputWriter struct {
+	ctx    context.Context
+	handle OutputFunc
+	chunk  OutputChunk
+}
+
+func (w outputWriter) Write(p []byte) (int, error) {
+	chunk := w.chunk
+	chunk.Data = append([]byte{}, p...)
+
+	w.handle(w.ctx, chunk)
+
+	return len(p), nil
+}
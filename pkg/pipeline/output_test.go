@@ -0,0 +1,52 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutput(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine()
+	engine.RegisterStepExecutor("echo", FuncExecutor(func(ctx context.Context, in struct {
+		Message string `yaml:"message"`
+	}) (any, error) {
+		_, err := fmt.Fprintln(Output(ctx, StreamStdout), in.Message)
+
+		return nil, err
+	}))
+
+	pipelines := NewPipelines(New("main").
+		Step(NewStep("hello", "echo", map[string]any{"message": "hello"})).
+		Step(NewStep("bye", "echo", map[string]any{"message": "bye"})).
+		Build())
+
+	var (
+		mu     sync.Mutex
+		chunks []OutputChunk
+	)
+
+	_, err := engine.Execute(context.Background(), NewScope(pipelines), []string{"main"}, WithOutput(func(_ context.Context, chunk OutputChunk) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		chunks = append(chunks, chunk)
+	}))
+	assert.NoError(t, err)
+
+	if assert.Len(t, chunks, 2) {
+		assert.Equal(t, "main", chunks[0].Pipeline)
+		assert.Equal(t, VariablePathNode("hello"), chunks[0].Step.ID)
+		assert.Equal(t, StreamStdout, chunks[0].Stream)
+		assert.Equal(t, "hello\n", string(chunks[0].Data))
+		assert.Equal(t, VariablePathNode("bye"), chunks[1].Step.ID)
+	}
+
+	_, err = engine.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,113 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+)
+
+// Actions of the panic policies, see WithPanicPolicy.
+const (
+	// PanicFailStep fails the panicking step like any other error, so it can be retried or caught, the default.
+	PanicFailStep = "fail-step"
+	// PanicFailPipeline fails the pipelines of the panicking step, which is neither retried nor caught by try steps.
+	PanicFailPipeline = "fail-pipeline"
+	// PanicRestartStep restarts the panicking step up to PanicPolicy.Restarts times before failing it.
+	PanicRestartStep = "restart-step"
+)
+
+// PanicError is returned by the steps whose executor panics, with the stack of the panicking goroutine.
+type PanicError struct {
+	Value any
+	Stack []byte
+	// Fatal reports whether the error fails the pipelines, see PanicFailPipeline.
+	Fatal bool
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value when it's an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+
+	return err
+}
+
+// PanicPolicy defines how the executions handle the steps whose executor panics.
+type PanicPolicy struct {
+	// Action is one of the Panic actions, PanicFailStep by default.
+	Action string
+	// Restarts is the maximum number of restarts of the panicking steps with PanicRestartStep.
+	Restarts int
+}
+
+// WithPanicPolicy sets how the execution handles the panicking steps, failing them by default.
+func WithPanicPolicy(policy PanicPolicy) Option {
+	return func(o *options) {
+		o.panicPolicy = &policy
+	}
+}
+
+type panicPolicyKey struct{}
+
+func panicPolicyFrom(ctx context.Context) PanicPolicy {
+	policy, _ := ctx.Value(panicPolicyKey{}).(PanicPolicy)
+
+	return policy
+}
+
+// recovered returns the PanicError of the recovered value, capturing the stack.
+func recovered(r any) *PanicError {
+	return &PanicError{Value: r, Stack: debug.Stack()}
+}
+
+// fatalPanic reports whether the error is a panic failing the pipelines.
+func fatalPanic(err error) bool {
+	var panicErr *PanicError
+
+	return errors.As(err, &panicErr) && panicErr.Fatal
+}
+
+// executeRecoveredStep executes the step, recovering from the executor panics following the policy of the execution.
+func executeRecoveredStep(ctx context.Context, scope Scope, step Step, executor StepExecutor) (Scope, error) {
+	policy := panicPolicyFrom(ctx)
+
+	for restart := 0; ; restart++ {
+		result, panicErr, err := executeStepRecovering(ctx, scope, step, executor)
+		if panicErr == nil {
+			return result, err
+		}
+
+		switch {
+		case policy.Action == PanicFailPipeline:
+			panicErr.Fatal = true
+		case policy.Action == PanicRestartStep && restart < policy.Restarts && ctx.Err() == nil:
+			log.Log().Warn(ctx, "Step %s panicked, restarting it (%d of %d): %v", step, restart+1, policy.Restarts, panicErr.Value)
+
+			continue
+		}
+
+		log.Log().Error(ctx, "Step %s panicked: %v\n%s", step, panicErr.Value, panicErr.Stack)
+
+		return result, panicErr
+	}
+}
+
+// executeStepRecovering executes the step, returning the PanicError of its executor panic, if any.
+// The panics of the nested steps are recovered by their own executions, and returned as errors.
+func executeStepRecovering(ctx context.Context, scope Scope, step Step, executor StepExecutor) (result Scope, panicErr *PanicError, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, panicErr = scope, recovered(r)
+		}
+	}()
+
+	result, err = executeLimitedStep(ctx, scope, step, executor)
+
+	return result, nil, err
+}
@@ -0,0 +1,129 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPanicPolicy(t *testing.T) {
+	t.Parallel()
+
+	errBoom := errors.New("boom")
+
+	tests := []struct {
+		name          string
+		policy        *PanicPolicy
+		panics        int32
+		step          Step
+		expectCalls   int32
+		expectFatal   bool
+		expectSuccess bool
+	}{
+		{
+			name:        "fails the step by default",
+			panics:      1,
+			step:        NewStep("flaky", "flaky", map[string]any{}),
+			expectCalls: 1,
+		},
+		{
+			name:          "fails the step retried like other errors",
+			policy:        &PanicPolicy{Action: PanicFailStep},
+			panics:        1,
+			step:          NewStep("flaky", "flaky", map[string]any{}).WithRetry(StepRetry{MaxAttempts: 2}),
+			expectCalls:   2,
+			expectSuccess: true,
+		},
+		{
+			name:          "restarts the step",
+			policy:        &PanicPolicy{Action: PanicRestartStep, Restarts: 2},
+			panics:        2,
+			step:          NewStep("flaky", "flaky", map[string]any{}),
+			expectCalls:   3,
+			expectSuccess: true,
+		},
+		{
+			name:        "fails once the restarts are exhausted",
+			policy:      &PanicPolicy{Action: PanicRestartStep, Restarts: 1},
+			panics:      2,
+			step:        NewStep("flaky", "flaky", map[string]any{}),
+			expectCalls: 2,
+		},
+		{
+			name:        "fails the pipeline without retrying the step",
+			policy:      &PanicPolicy{Action: PanicFailPipeline},
+			panics:      1,
+			step:        NewStep("flaky", "flaky", map[string]any{}).WithRetry(StepRetry{MaxAttempts: 3}),
+			expectCalls: 1,
+			expectFatal: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls atomic.Int32
+
+			engine := NewEngine()
+			engine.RegisterStepExecutor("flaky", FuncExecutor(func(context.Context, struct{}) (bool, error) {
+				if calls.Add(1) <= tc.panics {
+					panic(errBoom)
+				}
+
+				return true, nil
+			}))
+
+			var opts []Option
+			if tc.policy != nil {
+				opts = append(opts, WithPanicPolicy(*tc.policy))
+			}
+
+			pipelines := NewPipelines(New("main").Step(tc.step).Build())
+
+			_, err := engine.Execute(context.Background(), NewScope(pipelines), []string{"main"}, opts...)
+			assert.Equal(t, tc.expectCalls, calls.Load())
+
+			if tc.expectSuccess {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			var panicErr *PanicError
+			if assert.ErrorAs(t, err, &panicErr) {
+				assert.ErrorIs(t, err, errBoom)
+				assert.Contains(t, string(panicErr.Stack), "panic_test.go")
+				assert.Equal(t, tc.expectFatal, panicErr.Fatal)
+			}
+		})
+	}
+}
+
+func TestPanicCaughtByTry(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine()
+	engine.RegisterStepExecutor("boom", FuncExecutor(func(context.Context, struct{}) (bool, error) {
+		panic("boom")
+	}))
+
+	pipelines := NewPipelines(New("main").
+		Step(NewStep("guarded", "try", TryParams{
+			Pipeline: Pipeline{Steps: []Step{NewStep("explode", "boom", map[string]any{})}},
+			Catch:    &Pipeline{Steps: []Step{LogStep("recovered")}},
+		})).
+		Build())
+
+	scope, err := engine.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+	if assert.NoError(t, err) {
+		message, _ := scope.Variable("guarded.$error")
+		assert.Equal(t, "panic: boom", message)
+	}
+
+	_, err = engine.Execute(context.Background(), NewScope(pipelines), []string{"main"}, WithPanicPolicy(PanicPolicy{Action: PanicFailPipeline}))
+	assert.True(t, fatalPanic(err))
+}
@@ -0,0 +1,121 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+)
+
+// Merge strategies of the parallel step, chosen by its `merge` param.
+const (
+	// MergeOverride merges the variables in the declaration order, the later steps overriding the earlier ones.
+	MergeOverride = "override"
+	// MergeKeep keeps the variables of the earlier steps, ignoring the ones set again by the later steps.
+	MergeKeep = "keep"
+	// MergeError fails the step when several steps set the same variable to different values.
+	MergeError = "error"
+)
+
+// ParallelParams defines the parameters for the ParallelExecutor.
+type ParallelParams struct {
+	Concurrency expression.Int    `yaml:"concurrency"`
+	Merge       expression.String `yaml:"merge"`
+	Steps       []Step            `yaml:"steps"`
+}
+
+// ParallelExecutor executes sibling steps concurrently, merging the variables set by each one with the merge strategy:
+// MergeOverride (default), MergeKeep or MergeError. Each step result is set like the fanout ones, in the
+// `step_id.$results` list and in the `step_id.$results.<index>` paths.
+// Example YAML:
+//
+//	id: parallel-example
+//	steps:
+//	- id: fetch
+//	  type: parallel
+//	  params:
+//	    merge: 'error'
+//	    steps:
+//	    - id: users
+//	      type: http
+//	      params:
+//	        url: 'https://api.example.com/users'
+//	    - id: orders
+//	      type: http
+//	      params:
+//	        url: 'https://api.example.com/orders'
+//	- type: log
+//	  params:
+//	    message: '{{ variable . "users.$body" }} {{ variable . "orders.$body" }}'
+func ParallelExecutor(ctx context.Context, scope Scope, step Step, params ParallelParams) (Scope, error) {
+	concurrency, err := params.Concurrency.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	if concurrency == 0 {
+		concurrency = len(params.Steps)
+	}
+
+	strategy, err := params.Merge.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	merge, err := mergeStrategy(scope, strategy)
+	if err != nil {
+		return scope, err
+	}
+
+	steps := params.Steps
+
+	scope, results, err := fanout(ctx, scope, concurrency, false, merge, func(item Step, i int) workerParams {
+		return workerParams{Pipeline: Pipeline{Steps: []Step{item}}}
+	}, steps...)
+
+	return withBranchResults(scope, step, results, func(i int) string { return string(steps[i].ID) }), err
+}
+
+// mergeStrategy returns the function merging the variables set by the branches, compared to the base scope.
+func mergeStrategy(base Scope, strategy string) (mergeFunc, error) {
+	switch strategy {
+	case "", MergeOverride, MergeKeep, MergeError:
+	default:
+		return nil, fmt.Errorf("unknown merge strategy: %s", strategy)
+	}
+
+	merged := map[VariablePath]bool{}
+
+	return func(scope, branch Scope) (Scope, error) {
+		changes := map[VariablePath]any{}
+
+		for path, value := range branch.variables.since(base.variables) {
+			if current, found := base.variables.get(path); found && reflect.DeepEqual(current, value) {
+				continue
+			}
+
+			if merged[path] {
+				switch strategy {
+				case MergeKeep:
+					continue
+				case MergeError:
+					if current, _ := scope.variables.get(path); !reflect.DeepEqual(current, value) {
+						return scope, fmt.Errorf("conflicting values of variable %s", path)
+					}
+				}
+			}
+
+			changes[path] = value
+		}
+
+		for path := range changes {
+			merged[path] = true
+		}
+
+		scope = scope.copy()
+		scope.variables = scope.variables.with(changes)
+
+		return scope, nil
+	}, nil
+}
@@ -0,0 +1,77 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParallelExecutor(t *testing.T) {
+	t.Parallel()
+
+	execute := func(merge string) (Scope, error) {
+		pipelines := NewPipelines(New("main").
+			Set("config", map[string]any{"env": "dev"}).
+			Parallel("fetch", merge,
+				SetStep("users", map[string]any{"count": 2}),
+				NewStep("", "pipeline", New("").Wait(20*time.Millisecond).Set("shared", map[string]any{"from": "slow"}).Build()),
+				SetStep("shared", map[string]any{"from": "fast"}),
+			).
+			Build())
+
+		return pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+	}
+
+	t.Run("overrides in declaration order", func(t *testing.T) {
+		t.Parallel()
+
+		scope, err := execute("")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		shared, _ := Get[map[string]any](scope, "shared")
+		assert.Equal(t, map[string]any{"from": "fast"}, shared)
+
+		users, _ := Get[map[string]any](scope, "users")
+		assert.Equal(t, map[string]any{"count": 2}, users)
+
+		config, _ := Get[map[string]any](scope, "config")
+		assert.Equal(t, map[string]any{"env": "dev"}, config)
+
+		results, _ := scope.Variable("fetch.$results")
+		assert.Len(t, results, 3)
+
+		first, _ := Get[map[string]any](scope, "fetch.$results.0")
+		assert.Equal(t, BranchStatusSuccess, first["status"])
+		assert.Equal(t, "users", first["id"])
+	})
+
+	t.Run("keeps the earlier steps variables", func(t *testing.T) {
+		t.Parallel()
+
+		scope, err := execute(MergeKeep)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		shared, _ := Get[map[string]any](scope, "shared")
+		assert.Equal(t, map[string]any{"from": "slow"}, shared)
+	})
+
+	t.Run("fails on conflicts", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := execute(MergeError)
+		assert.ErrorContains(t, err, "conflicting values of variable shared")
+	})
+
+	t.Run("fails on unknown strategies", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := execute("union")
+		assert.ErrorContains(t, err, "unknown merge strategy")
+	})
+}
@@ -1,11 +1,16 @@
 package pipeline
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
+	"path"
 	"strings"
 
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
 	"github.com/crowleyfelix/go-pipeline/pkg/log"
 	"github.com/samber/lo"
 	"gopkg.in/yaml.v3"
@@ -16,46 +21,141 @@ type Pipelines struct {
 	pipelines map[string]Pipeline
 }
 
+// NewPipelines creates a Pipelines instance indexing the given pipelines by their names.
+func NewPipelines(pipelines ...Pipeline) Pipelines {
+	indexed := make(map[string]Pipeline, len(pipelines))
+	for _, pipe := range pipelines {
+		indexed[pipe.Name] = pipe
+	}
+
+	return Pipelines{pipelines: indexed}
+}
+
 // Execute runs the specified pipelines by their names in the given context.
-// It creates a Datadog span for each pipeline execution and returns the updated context or an error if any pipeline fails.
-func (p Pipelines) Execute(ctx context.Context, scope Scope, names ...string) (Scope, error) {
-	for _, name := range names {
+// It returns the updated scope or an error if any pipeline fails.
+// The options configure this execution only, eg.: WithTimeout, WithVariables, WithInterceptors and WithLogger.
+// It's safe to call it from multiple goroutines, each execution having its own scope.
+// Resources bound to the execution context (eg.: mock servers, spooled HTTP bodies) are released once it returns.
+// With WithCheckpoints, a checkpoint is saved after each step so the execution can be resumed, see Pipelines.Resume.
+func (p Pipelines) Execute(ctx context.Context, scope Scope, names []string, opts ...Option) (_ Scope, err error) {
+	ctx, finish := withExecution(ctx)
+	defer func() { finish(err) }()
+
+	if exec := executionFrom(ctx); scope.execution != exec {
+		scope.execution = exec
+
+		for path, item := range scope.variables.all() {
+			scope.registerSecrets(string(path), item)
+		}
+	}
+
+	ctx, scope, cancel := newOptions(opts).apply(ctx, scope)
+	defer cancel()
+
+	for i, name := range names {
 		pipe, ok := p.pipelines[name]
 		if !ok {
 			return scope, fmt.Errorf("Pipeline %s not found: available %+v", name, lo.Keys(p.pipelines))
 		}
 
-		var err error
-		scope, err = pipe.Execute(ctx, scope)
+		pipeCtx, resumed := withCheckpointing(ctx, names, i)
+		if resumed {
+			continue
+		}
 
+		scope, err = pipe.Execute(pipeCtx, scope)
 		if err != nil {
 			return scope, err
 		}
 	}
 
+	if store, ok := ctx.Value(checkpointsKey{}).(CheckpointStore); ok && FromContext(ctx).Depth == 0 {
+		if err := store.Delete(ctx, ExecutionID(ctx)); err != nil {
+			log.Log().Warn(ctx, "Error deleting the checkpoint: %s", err)
+		}
+	}
+
 	return scope, nil
 }
 
 // Pipeline represents a single pipeline with an ID and a sequence of steps to execute.
 type Pipeline struct {
+	// APIVersion selects the template functions of the pipeline expressions, see Engine.RegisterAPIVersion.
+	// Pipelines without it use the functions of the pipeline executing them, or the engine ones.
+	APIVersion  string `yaml:"apiVersion"`
 	Uses        string `yaml:"uses"`
 	ID          string `yaml:"id"`
 	Name        string `yaml:"name"`
 	Description string `yaml:"description"`
-	Steps       []Step `yaml:"steps"`
+	// Tags label the pipeline, eg.: to authorize who can execute it.
+	Tags []string `yaml:"tags"`
+	// Schedule is the cron expression the pipeline is executed on by the schedule.Scheduler, eg.: "*/5 * * * *".
+	Schedule string `yaml:"schedule"`
+	// Priority orders its scheduled executions, the higher ones running first, see schedule.WithMaxConcurrent.
+	Priority int `yaml:"priority"`
+	// Inputs are validated when the pipeline starts, setting the defaults of the missing ones.
+	Inputs map[string]Input `yaml:"inputs"`
+	// Variables document the other variables the pipeline reads, see VariableDeclaration.
+	Variables []VariableDeclaration `yaml:"variables"`
+	// Outputs are evaluated once the pipeline finishes and, when declared, are the only variables the pipeline
+	// exports to the caller, set by name within the pipeline namespace.
+	Outputs map[string]expression.YAML[any] `yaml:"outputs"`
+	Steps   []Step                          `yaml:"steps"`
+	Retries Retries                         `yaml:"retries"`
+}
+
+// LoadOption configures how Load reads the pipelines.
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	maxDepth   int
+	namespaces bool
+}
+
+// WithLoadDepth restricts the loaded files to the ones up to the depth of subdirectories, 0 loading the root ones only.
+// All the subdirectories are loaded by default.
+func WithLoadDepth(depth int) LoadOption {
+	return func(o *loadOptions) {
+		o.maxDepth = depth
+	}
+}
+
+// WithDirectoryNamespaces namespaces the pipelines of the subdirectories by their directory: their names are
+// prefixed by its path and their IDs, when set, by its nodes, eg.: the pipeline charge with id charge of
+// billing/charge.yaml is named billing/charge and sets its variables under billing.charge.
+// Pipelines reference each other, eg.: in uses or call steps, by their namespaced names.
+func WithDirectoryNamespaces() LoadOption {
+	return func(o *loadOptions) {
+		o.namespaces = true
+	}
 }
 
 // Load creates a new Pipelines instance by loading pipeline definitions from the provided file system.
-// It reads all YAML files, unmarshals them into Pipeline objects, and maps them by their names.
-func Load(fileSystem fs.FS) (Pipelines, error) {
+// It reads all YAML files (.yaml and .yml) of the root and its subdirectories, recursively, unmarshals them into
+// Pipeline objects, and maps them by their names, failing when names are repeated.
+func Load(fileSystem fs.FS, opts ...LoadOption) (Pipelines, error) {
+	o := loadOptions{maxDepth: -1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	pipelines := make(map[string]Pipeline)
+	files := make(map[string]string)
 
 	err := fs.WalkDir(fileSystem, ".", func(name string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if d.IsDir() || (!strings.HasSuffix(name, ".yaml") && !strings.HasSuffix(name, ".yml")) {
+		if d.IsDir() {
+			if name != "." && o.maxDepth >= 0 && strings.Count(name, "/") >= o.maxDepth {
+				return fs.SkipDir
+			}
+
+			return nil
+		}
+
+		if !strings.HasSuffix(name, ".yaml") && !strings.HasSuffix(name, ".yml") {
 			return nil
 		}
 
@@ -64,17 +164,34 @@ func Load(fileSystem fs.FS) (Pipelines, error) {
 			return err
 		}
 
-		var pipe Pipeline
-
-		if err := yaml.Unmarshal(blob, &pipe); err != nil {
-			return err
+		pipes, err := decodePipelines(blob)
+		if err != nil {
+			return fmt.Errorf("decoding file %s: %w", name, err)
 		}
 
-		if pipe.Name == "" {
-			return fmt.Errorf("pipeline name is required in file %s", name)
-		}
+		for _, pipe := range pipes {
+			if pipe.Name == "" {
+				return fmt.Errorf("pipeline name is required in file %s", name)
+			}
+
+			if dir := path.Dir(name); o.namespaces && dir != "." {
+				pipe.Name = dir + "/" + pipe.Name
 
-		pipelines[pipe.Name] = pipe
+				if pipe.ID != "" {
+					pipe.ID = strings.ReplaceAll(dir, "/", ".") + "." + pipe.ID
+				}
+			}
+
+			switch other, found := files[pipe.Name]; {
+			case found && other == name:
+				return fmt.Errorf("pipeline %s is defined twice in file %s", pipe.Name, name)
+			case found:
+				return fmt.Errorf("pipeline %s is defined in files %s and %s", pipe.Name, other, name)
+			}
+
+			pipelines[pipe.Name] = pipe
+			files[pipe.Name] = name
+		}
 
 		return nil
 	})
@@ -82,56 +199,210 @@ func Load(fileSystem fs.FS) (Pipelines, error) {
 		return Pipelines{}, err
 	}
 
-	return Pipelines{
+	loaded := Pipelines{
 		pipelines: pipelines,
-	}, nil
+	}
+
+	if err := loaded.detectCycles(); err != nil {
+		return Pipelines{}, err
+	}
+
+	return loaded, nil
+}
+
+// Loader loads pipeline definitions from a source, eg.: the remote ones of the loader package, so deployments
+// don't need them in their images. Loaders may cache the pipelines of the unchanged sources.
+type Loader interface {
+	Load(ctx context.Context) (Pipelines, error)
+}
+
+// LoaderFunc is a function implementing Loader.
+type LoaderFunc func(ctx context.Context) (Pipelines, error)
+
+// Load calls the function.
+func (f LoaderFunc) Load(ctx context.Context) (Pipelines, error) {
+	return f(ctx)
+}
+
+// FSLoader returns the Loader of the pipelines of the file system, see Load.
+func FSLoader(fileSystem fs.FS, opts ...LoadOption) Loader {
+	return LoaderFunc(func(context.Context) (Pipelines, error) {
+		return Load(fileSystem, opts...)
+	})
+}
+
+// decodePipelines decodes the pipelines of the YAML documents separated by ---, each one either a pipeline
+// or a list of pipelines under the pipelines key.
+func decodePipelines(blob []byte) ([]Pipeline, error) {
+	var pipes []Pipeline
+
+	decoder := yaml.NewDecoder(bytes.NewReader(blob))
+
+	for {
+		var document yaml.Node
+
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			return pipes, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+
+		var listed struct {
+			Pipelines []Pipeline `yaml:"pipelines"`
+		}
+
+		if err := document.Decode(&listed); err != nil {
+			return nil, err
+		}
+
+		if listed.Pipelines != nil {
+			pipes = append(pipes, listed.Pipelines...)
+
+			continue
+		}
+
+		var pipe Pipeline
+
+		if err := document.Decode(&pipe); err != nil {
+			return nil, err
+		}
+
+		pipes = append(pipes, pipe)
+	}
 }
 
 // Execute runs all the steps in the pipeline in the given context.
-// It logs the execution progress and returns the updated context or an error if any step fails.
+// It logs the execution progress and returns the updated context or a PipelineError if any step fails.
 func (p Pipeline) Execute(ctx context.Context, scope Scope) (Scope, error) {
+	caller := scope
 	baseNamespace := append([]VariablePathNode{}, scope.namespace...)
 
 	if p.ID != "" {
 		scope = scope.WithNamespace(VariablePathNode(p.ID))
 	}
 
-	result, err := interceptor(ctx, scope, p, func(ctx context.Context, scope Scope) (Scope, error) {
-		log.Log().Info(ctx, "Executing pipeline %s", p)
+	ctx = withPipeline(ctx, p)
 
-		var err error
+	if p.APIVersion != "" {
+		t, found := engineFrom(ctx).apiVersion(p.APIVersion)
+		if !found {
+			scope.namespace = baseNamespace
 
-		if p.Uses != "" {
-			scope, err = scope.Pipelines.Execute(ctx, scope, p.Uses)
-			if err != nil {
-				return scope, err
-			}
+			return scope, &PipelineError{Pipeline: p.String(), Err: fmt.Errorf("%w: %s", ErrUnknownAPIVersion, p.APIVersion)}
 		}
 
-		for _, step := range p.Steps {
-			if scope.Finished {
-				return scope, nil
-			}
+		ctx = expression.WithTemplate(ctx, t)
+	}
 
-			scope, err = executors.Execute(ctx, scope, step)
+	if depth, maxDepth := FromContext(ctx).Depth, maxDepthFrom(ctx); depth > maxDepth {
+		scope.namespace = baseNamespace
 
-			if err != nil {
-				log.Log().Error(ctx, "Error executing step %s: %s", step, err)
+		return scope, &PipelineError{Pipeline: p.String(), Err: fmt.Errorf("%w: %d nested pipelines", ErrMaxDepthExceeded, maxDepth)}
+	}
 
-				return scope, err
-			}
-		}
+	scope, err := p.withInputs(scope)
+	if err == nil {
+		scope, err = p.withVariables(scope)
+	}
+
+	if err != nil {
+		scope.namespace = baseNamespace
 
-		log.Log().Info(ctx, "Executed pipeline %s", p)
+		return scope, &PipelineError{Pipeline: p.String(), Err: err}
+	}
 
-		return scope, nil
+	result, err := p.executeWithRetries(ctx, scope, func(ctx context.Context) (Scope, error) {
+		return interceptorsFrom(ctx).pipeline(ctx, scope, p, p.executeSteps)
 	})
 
+	if len(p.Outputs) > 0 {
+		if err != nil {
+			result = caller
+		} else {
+			result, err = p.exportOutputs(ctx, caller, result)
+		}
+	}
+
 	result.namespace = baseNamespace
 
+	if result.Finished && result.stopScope == StopScopePipeline {
+		result.Finished = false
+		result.stopScope = ""
+	}
+
+	if err != nil {
+		err = &PipelineError{Pipeline: p.String(), Err: err}
+	}
+
 	return result, err
 }
 
+// executeSteps executes the pipeline steps, calling the functions registered with Cleanup once they finish.
+func (p Pipeline) executeSteps(ctx context.Context, scope Scope) (result Scope, err error) {
+	ctx, c := withCleanups(ctx)
+
+	defer func() {
+		err = c.run(ctx, err)
+	}()
+
+	return p.runSteps(ctx, scope)
+}
+
+// runSteps executes the used pipeline and then the steps, until the scope is finished.
+func (p Pipeline) runSteps(ctx context.Context, scope Scope) (Scope, error) {
+	log.Log().Info(ctx, "Executing pipeline %s", p)
+
+	var err error
+
+	checkpoints, checkpointed := checkpointingFrom(ctx)
+
+	if p.Uses != "" && checkpoints.start == 0 {
+		scope, err = scope.Pipelines.Execute(ctx, scope, []string{p.Uses})
+		if err != nil {
+			return scope, err
+		}
+	}
+
+	for i, step := range p.Steps {
+		if i < checkpoints.start {
+			continue
+		}
+
+		if scope.Finished {
+			return scope, nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			return scope, err
+		}
+
+		scope, err = engineFrom(ctx).executeStep(ctx, scope, step)
+
+		if err != nil {
+			log.Log().Error(ctx, "Error executing step %s: %s", step, err)
+
+			return scope, err
+		}
+
+		if checkpointed && !scope.Finished {
+			if err := checkpoints.save(ctx, scope, i+1); err != nil {
+				return scope, err
+			}
+		}
+	}
+
+	log.Log().Info(ctx, "Executed pipeline %s", p)
+
+	return scope, nil
+}
+
 func (p Pipeline) String() string {
 	if p.ID != "" {
 		return p.ID
@@ -2,9 +2,11 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"testing/fstest"
 
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -26,7 +28,7 @@ func TestLoadLoadsPipelinesFromNestedFolders(t *testing.T) {
 	assert.Len(t, pipelines.pipelines, 3)
 
 	scope := NewScope(pipelines)
-	_, err = pipelines.Execute(context.Background(), scope, "root-pipeline", "child-pipeline", "grandchild-pipeline")
+	_, err = pipelines.Execute(context.Background(), scope, []string{"root-pipeline", "child-pipeline", "grandchild-pipeline"})
 	assert.NoError(t, err)
 }
 
@@ -115,7 +117,7 @@ func TestPipelineNamespaces(t *testing.T) {
 
 		scope := NewScope(pipelines)
 
-		result, err := pipelines.Execute(context.Background(), scope, "main")
+		result, err := pipelines.Execute(context.Background(), scope, []string{"main"})
 		if !assert.NoError(t, err) {
 			return
 		}
@@ -204,7 +206,7 @@ func TestPipelineNamespaces(t *testing.T) {
 
 		scope := NewScope(pipelines)
 
-		result, err := pipelines.Execute(context.Background(), scope, "main")
+		result, err := pipelines.Execute(context.Background(), scope, []string{"main"})
 		if !assert.NoError(t, err) {
 			return
 		}
@@ -255,7 +257,7 @@ func TestPipelineNamespaces(t *testing.T) {
 
 		scope := NewScope(pipelines)
 
-		result, err := pipelines.Execute(context.Background(), scope, "main")
+		result, err := pipelines.Execute(context.Background(), scope, []string{"main"})
 		if !assert.NoError(t, err) {
 			return
 		}
@@ -269,3 +271,391 @@ func TestPipelineNamespaces(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestLoadedStepsDecodeParams(t *testing.T) {
+	t.Parallel()
+
+	fileSystem := fstest.MapFS{
+		"main.yaml": {Data: []byte(`name: main
+steps:
+- id: defaults
+  type: set
+  params: &defaults
+    retries: '3'
+- id: copy
+  type: set
+  params:
+    <<: *defaults
+    name: '{{ variableGet . "defaults" "retries" }}'
+`)},
+	}
+
+	pipelines, err := Load(fileSystem)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	scope, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	value, err := scope.Variable("copy")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"retries": "3", "name": "3"}, value)
+}
+
+func TestLoadDetectsCycles(t *testing.T) {
+	t.Parallel()
+
+	fileSystem := fstest.MapFS{
+		"a.yaml": {Data: []byte("name: a\nuses: b\n")},
+		"b.yaml": {Data: []byte("name: b\nsteps:\n- type: pipeline\n  params:\n    steps:\n    - type: pipeline\n      params:\n        uses: a\n")},
+		"c.yaml": {Data: []byte("name: c\nsteps:\n- type: switch\n  params:\n    cases:\n    - condition: 'false'\n      uses: c\n")},
+		"d.yaml": {Data: []byte("name: d\nsteps:\n- type: pipeline\n  if: '{{ variable . \"again\" }}'\n  params:\n    uses: d\n")},
+	}
+
+	_, err := Load(fileSystem)
+	assert.ErrorIs(t, err, ErrCycle)
+	assert.ErrorContains(t, err, "a -> b -> a")
+
+	delete(fileSystem, "a.yaml")
+
+	_, err = Load(fileSystem)
+	assert.NoError(t, err, "conditional recursion is allowed")
+}
+
+func TestExecuteLimitsDepth(t *testing.T) {
+	t.Parallel()
+
+	pipelines := NewPipelines(New("recursive").
+		Step(NewStep("", "switch", SwitchParams{Cases: []SwitchCase{{Condition: "true", Pipeline: Pipeline{Uses: "recursive"}}}})).
+		Build())
+
+	_, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"recursive"}, WithMaxDepth(5))
+	assert.ErrorIs(t, err, ErrMaxDepthExceeded)
+}
+
+func TestStopScopes(t *testing.T) {
+	t.Parallel()
+
+	stop := func(scope StopScope) Step {
+		return NewStep("", "stop", StopParams{Condition: `{{ eq (variable . "item") 1 }}`, Scope: expression.String(scope)})
+	}
+
+	tests := []struct {
+		scope     StopScope
+		processed []any
+		after     bool
+	}{
+		{scope: StopScopePipeline, processed: []any{0, 1, 2}, after: true},
+		{scope: StopScopeBranch, processed: []any{0, 2}, after: true},
+		{scope: StopScopeExecution, processed: []any{0}, after: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.scope), func(t *testing.T) {
+			t.Parallel()
+
+			pipelines := NewPipelines(New("main").
+				Range("item", RangeParams{Items: []any{0, 1, 2}},
+					NewStep("", "pipeline", Pipeline{Steps: []Step{stop(tc.scope)}}),
+					SetStep("processed", map[string]any{"item": `{{ variable . "item" }}`}),
+				).
+				Set("after", map[string]any{"ok": true}).
+				Build())
+
+			var processed []any
+
+			interceptor := func(ctx context.Context, scope Scope, step Step, executor StepExecutor) (Scope, error) {
+				if step.ID == "processed" {
+					item, _ := scope.Variable("item")
+					processed = append(processed, item)
+				}
+
+				return executor.Execute(ctx, scope, step)
+			}
+
+			scope, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"}, WithInterceptors(nil, interceptor))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, tc.processed, processed)
+
+			_, err = scope.Variable("after")
+			assert.Equal(t, tc.after, err == nil)
+		})
+	}
+}
+
+func TestPipelineRetries(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		failures int
+		attempts int
+		err      bool
+	}{
+		{name: "succeeds after retrying", failures: 2, attempts: 3},
+		{name: "fails once exhausted", failures: 5, attempts: 3, err: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			pipelines := NewPipelines(New("flaky").
+				Retry(Retries{Max: 2, Backoff: `{{ variable . "backoff" }}`}).
+				Set("flaky", map[string]any{"ok": true}).
+				Build())
+
+			attempts := 0
+
+			interceptor := func(ctx context.Context, scope Scope, step Step, executor StepExecutor) (Scope, error) {
+				attempts++
+				if attempts <= tc.failures {
+					return scope, errors.New("flaky")
+				}
+
+				return executor.Execute(ctx, scope, step)
+			}
+
+			scope, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"flaky"},
+				WithInterceptors(nil, interceptor), WithVariables(map[VariablePath]any{"backoff": "1ms"}))
+
+			assert.Equal(t, tc.attempts, attempts)
+
+			if tc.err {
+				assert.Error(t, err)
+
+				return
+			}
+
+			if assert.NoError(t, err) {
+				flaky, err := Get[map[string]any](scope, "flaky")
+				assert.NoError(t, err)
+				assert.Equal(t, map[string]any{"ok": true}, flaky)
+			}
+		})
+	}
+}
+
+func TestPipelineRetriesSkipStops(t *testing.T) {
+	t.Parallel()
+
+	pipelines := NewPipelines(New("stopped").
+		Retry(Retries{Max: 3}).
+		Step(NewStep("", "stop", StopParams{Condition: "true", IsError: "true"})).
+		Build())
+
+	attempts := 0
+
+	interceptor := func(ctx context.Context, scope Scope, step Step, executor StepExecutor) (Scope, error) {
+		attempts++
+
+		return executor.Execute(ctx, scope, step)
+	}
+
+	_, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"stopped"}, WithInterceptors(nil, interceptor))
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts)
+}
+
+func TestStepRetry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		retry    StepRetry
+		failures int
+		attempts int
+		err      bool
+	}{
+		{name: "succeeds after retrying", retry: StepRetry{MaxAttempts: 3, Jitter: "1ms"}, failures: 2, attempts: 3},
+		{name: "fails once exhausted", retry: StepRetry{MaxAttempts: 2, Backoff: `{{ "1ms" }}`}, failures: 5, attempts: 2, err: true},
+		{name: "matches errors", retry: StepRetry{MaxAttempts: 3, When: `{{ contains "timeout" (variable . "flaky.$error") }}`}, failures: 1, attempts: 2},
+		{
+			name:     "skips unmatched errors",
+			retry:    StepRetry{MaxAttempts: 3, When: `{{ contains "refused" (variable . "flaky.$error") }}`},
+			failures: 1, attempts: 1, err: true,
+		},
+		{name: "runs once by default", failures: 1, attempts: 1, err: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			attempts := 0
+
+			engine := NewEngine()
+			engine.RegisterStepExecutor("flaky", FuncExecutor(func(ctx context.Context, in struct{}) (string, error) {
+				attempts++
+				if attempts <= tc.failures {
+					return "", errors.New("timeout")
+				}
+
+				return "ok", nil
+			}))
+
+			pipelines := NewPipelines(New("main").Step(Step{ID: "flaky", Type: "flaky"}.WithRetry(tc.retry)).Build())
+
+			scope, err := engine.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+
+			assert.Equal(t, tc.attempts, attempts)
+
+			if tc.err {
+				var stepErr *StepError
+				if assert.ErrorAs(t, err, &stepErr) {
+					assert.Equal(t, tc.attempts, stepErr.Attempt)
+				}
+
+				return
+			}
+
+			if assert.NoError(t, err) {
+				value, _ := scope.Variable("flaky")
+				assert.Equal(t, "ok", value)
+			}
+		})
+	}
+}
+
+func TestStructuredConditions(t *testing.T) {
+	t.Parallel()
+
+	fileSystem := fstest.MapFS{"conditions.yaml": {Data: []byte(`
+name: conditions
+steps:
+- id: check
+  type: set
+  params:
+    status: 503
+    env: prod
+- id: alert
+  type: set
+  if:
+    all:
+    - {var: check.status, op: gte, value: 500}
+    - not: {var: check.env, op: in, value: [dev, test]}
+  params:
+    sent: true
+- id: skipped
+  type: set
+  if: {var: check.env, value: dev}
+  params:
+    sent: true
+- type: stop
+  params:
+    condition: {var: alert.sent, op: exists}
+- id: unreachable
+  type: set
+  params:
+    sent: true
+`)}}
+
+	pipelines, err := Load(fileSystem)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	scope, err := NewEngine().Execute(context.Background(), NewScope(pipelines), []string{"conditions"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, err = scope.Variable("alert")
+	assert.NoError(t, err)
+
+	_, err = scope.Variable("skipped")
+	assert.ErrorIs(t, err, ErrVariableNotFound)
+
+	_, err = scope.Variable("unreachable")
+	assert.ErrorIs(t, err, ErrVariableNotFound)
+
+	_, err = Load(fstest.MapFS{"invalid.yaml": {Data: []byte("name: invalid\nsteps:\n- type: log\n  if: {var: env, op: between}\n")}})
+	assert.ErrorIs(t, err, expression.ErrInvalidCondition)
+}
+
+func TestLoadOptions(t *testing.T) {
+	t.Parallel()
+
+	fileSystem := fstest.MapFS{
+		"deploy.yaml":                {Data: []byte("name: deploy\nsteps: []\n")},
+		"billing/deploy.yaml":        {Data: []byte("name: deploy\nid: deploy\nsteps: []\n")},
+		"billing/jobs/invoice.yml":   {Data: []byte("name: invoice\nid: invoice\nsteps:\n- id: total\n  type: set\n  params:\n    value: 10\n")},
+		"billing/jobs/deep/dry.yaml": {Data: []byte("name: dry\nsteps: []\n")},
+	}
+
+	t.Run("fails on repeated names", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Load(fileSystem)
+		assert.ErrorContains(t, err, "pipeline deploy is defined in files billing/deploy.yaml and deploy.yaml")
+	})
+
+	t.Run("namespaces by directory", func(t *testing.T) {
+		t.Parallel()
+
+		pipelines, err := Load(fileSystem, WithDirectoryNamespaces())
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, []string{"billing/deploy", "billing/jobs/deep/dry", "billing/jobs/invoice", "deploy"}, pipelines.Names())
+
+		deploy, _ := pipelines.Get("billing/deploy")
+		assert.Equal(t, "billing.deploy", deploy.ID)
+
+		scope, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"billing/jobs/invoice"})
+		if assert.NoError(t, err) {
+			total, _ := scope.Variable("billing.jobs.invoice.total")
+			assert.Equal(t, map[string]any{"value": 10}, total)
+		}
+	})
+
+	t.Run("restricts the depth", func(t *testing.T) {
+		t.Parallel()
+
+		pipelines, err := Load(fileSystem, WithDirectoryNamespaces(), WithLoadDepth(1))
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"billing/deploy", "deploy"}, pipelines.Names())
+		}
+
+		pipelines, err = Load(fileSystem, WithLoadDepth(0))
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"deploy"}, pipelines.Names())
+		}
+	})
+}
+
+func TestLoadMultiplePipelinesPerFile(t *testing.T) {
+	t.Parallel()
+
+	t.Run("loads documents and lists", func(t *testing.T) {
+		t.Parallel()
+
+		fileSystem := fstest.MapFS{
+			"flows.yaml": {Data: []byte("name: build\nsteps: []\n---\nname: deploy\nuses: build\nsteps: []\n---\n")},
+			"jobs.yml":   {Data: []byte("pipelines:\n- name: cleanup\n  steps: []\n- name: report\n  steps: []\n")},
+		}
+
+		pipelines, err := Load(fileSystem)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"build", "cleanup", "deploy", "report"}, pipelines.Names())
+		}
+	})
+
+	t.Run("fails on repeated names", func(t *testing.T) {
+		t.Parallel()
+
+		fileSystem := fstest.MapFS{
+			"flows.yaml": {Data: []byte("pipelines:\n- name: build\n  steps: []\n---\nname: build\nsteps: []\n")},
+		}
+
+		_, err := Load(fileSystem)
+		assert.ErrorContains(t, err, "pipeline build is defined twice in file flows.yaml")
+	})
+}
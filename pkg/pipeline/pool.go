@@ -0,0 +1,109 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// clientPool holds the clients shared by the executors of an engine, by key, see Client.
+type clientPool struct {
+	mu      sync.Mutex
+	clients map[string]any
+}
+
+// Client returns the client of the key pooled by the engine executing the context, eg.: an HTTP client per profile
+// or a SQL connection pool, created on its first use and shared by the following executions until the engine is
+// closed, see Engine.Close. Keys are prefixed by the kind of client, eg.: "sql:main", to not clash.
+// Creations are serialized, so executors never create the same client twice.
+func Client[T any](ctx context.Context, key string, create func() (T, error)) (T, error) {
+	pool := &engineFrom(ctx).clients
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if client, found := pool.clients[key]; found {
+		typed, ok := client.(T)
+		if !ok {
+			return typed, fmt.Errorf("pooled client %s is a %T", key, client)
+		}
+
+		return typed, nil
+	}
+
+	client, err := create()
+	if err != nil {
+		return client, err
+	}
+
+	if pool.clients == nil {
+		pool.clients = map[string]any{}
+	}
+
+	pool.clients[key] = client
+
+	return client, nil
+}
+
+// SetClient pools the client of the key in the engine, eg.: configured by the caller, closing the replaced one.
+func (e *Engine) SetClient(key string, client any) error {
+	e.clients.mu.Lock()
+	defer e.clients.mu.Unlock()
+
+	if e.clients.clients == nil {
+		e.clients.clients = map[string]any{}
+	}
+
+	replaced, found := e.clients.clients[key]
+	e.clients.clients[key] = client
+
+	if found && replaced != client {
+		return closeClient(replaced)
+	}
+
+	return nil
+}
+
+// Close closes the clients pooled by the engine executors, the ones implementing io.Closer or
+// CloseIdleConnections, eg.: *sql.DB and *http.Client. Clients used afterwards are created again.
+func (e *Engine) Close() error {
+	e.clients.mu.Lock()
+	defer e.clients.mu.Unlock()
+
+	var errs []error
+
+	for _, key := range slices.Sorted(maps.Keys(e.clients.clients)) {
+		if err := closeClient(e.clients.clients[key]); err != nil {
+			errs = append(errs, fmt.Errorf("closing client %s: %w", key, err))
+		}
+	}
+
+	e.clients.clients = nil
+
+	return errors.Join(errs...)
+}
+
+// SetClient pools the client of the key in the default engine, see Engine.SetClient.
+func SetClient(key string, client any) error {
+	return defaultEngine.SetClient(key, client)
+}
+
+// CloseClients closes the clients pooled by the default engine executors, see Engine.Close.
+func CloseClients() error {
+	return defaultEngine.Close()
+}
+
+func closeClient(client any) error {
+	switch typed := client.(type) {
+	case io.Closer:
+		return typed.Close()
+	case interface{ CloseIdleConnections() }:
+		typed.CloseIdleConnections()
+	}
+
+	return nil
+}
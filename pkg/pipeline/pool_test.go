@@ -0,0 +1,64 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type closingClient struct {
+	closed int
+}
+
+func (c *closingClient) Close() error {
+	c.closed++
+
+	return nil
+}
+
+func TestClientPool(t *testing.T) {
+	t.Parallel()
+
+	var created []*closingClient
+
+	engine := NewEngine()
+	engine.RegisterStepExecutor("connect", FuncExecutor(func(ctx context.Context, _ struct{}) (bool, error) {
+		client, err := Client(ctx, "test:main", func() (*closingClient, error) {
+			client := &closingClient{}
+			created = append(created, client)
+
+			return client, nil
+		})
+
+		return client != nil, err
+	}))
+
+	pipelines := NewPipelines(New("main").Step(NewStep("connect", "connect", map[string]any{})).Build())
+
+	for range 2 {
+		_, err := engine.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+		assert.NoError(t, err)
+	}
+
+	if !assert.Len(t, created, 1) {
+		return
+	}
+
+	assert.NoError(t, engine.Close())
+	assert.Equal(t, 1, created[0].closed)
+
+	_, err := engine.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+	assert.NoError(t, err)
+	assert.Len(t, created, 2, "clients are created again once the engine is closed")
+
+	replacement := &closingClient{}
+	assert.NoError(t, engine.SetClient("test:main", replacement))
+	assert.Equal(t, 1, created[1].closed)
+
+	_, err = Client(engine.context(context.Background()), "test:main", func() (string, error) {
+		return "", errors.New("not created")
+	})
+	assert.ErrorContains(t, err, "pooled client test:main is a *pipeline.closingClient")
+}
@@ -0,0 +1,157 @@
+package pipeline
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// RangeParseJSON parses the lines of the range step file as JSON, eg.: NDJSON records.
+const RangeParseJSON = "json"
+
+// MaxRangeLineSize is the maximum size of the lines of the range step files.
+const MaxRangeLineSize = 1 << 20
+
+// rangeFile executes the pipeline for each line of the file, reading the lines in batches of the concurrency size,
+// so only the running items are kept in memory. Blank lines are skipped when parsed as JSON.
+//
+//	id: lines-example
+//	steps:
+//	- id: record
+//	  type: range
+//	  params:
+//	    file: '{{ workspace . "events.ndjson" }}'
+//	    parse: json
+//	    where: '{{ eq (variableGet . "record" "level") "error" }}'
+//	    concurrency: 4
+//	    steps:
+//	    - type: log
+//	      params:
+//	        message: '{{ variableGet . "record" "message" }}'
+func (p RangeParams) rangeFile(ctx context.Context, scope Scope, step Step) (Scope, error) {
+	if len(p.Items) > 0 || p.Variable != "" || p.JSON != "" {
+		return scope, errors.New("range file can't be combined with items, variable or json")
+	}
+
+	path, err := p.File.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	parse, err := p.Parse.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	if parse != "" && parse != RangeParseJSON {
+		return scope, fmt.Errorf("unknown range parse: %s", parse)
+	}
+
+	offset, err := p.Offset.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	limit, err := p.Limit.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	concurrency, isolate, err := p.execution(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	timeout, err := newItemTimeout(ctx, scope, p.ItemTimeout, p.StragglerPolicy)
+	if err != nil {
+		return scope, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return scope, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), MaxRangeLineSize)
+
+	var (
+		batch   []any
+		results []workerResult
+		matched int
+		line    int
+	)
+
+	flush := func() error {
+		var batchResults []workerResult
+
+		scope, batchResults, err = fanout(ctx, scope, concurrency, isolate, nil, p.mapper(step, len(results), timeout), batch...)
+		results = append(results, batchResults...)
+		batch = batch[:0]
+
+		return err
+	}
+
+	for (p.Limit == "" || limit < 0 || matched < max(offset, 0)+limit) && scanner.Scan() {
+		line++
+
+		var item any = scanner.Text()
+
+		if parse == RangeParseJSON {
+			if strings.TrimSpace(scanner.Text()) == "" {
+				continue
+			}
+
+			if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+				return scope, fmt.Errorf("parsing line %d of %s: %w", line, path, err)
+			}
+		}
+
+		match, err := p.matches(ctx, scope, step, item, line-1)
+		if err != nil {
+			return scope, err
+		}
+
+		if !match {
+			continue
+		}
+
+		if matched++; matched <= offset {
+			continue
+		}
+
+		batch = append(batch, item)
+
+		if len(batch) < concurrency {
+			continue
+		}
+
+		if err := flush(); err != nil || scope.Finished {
+			return isolatedResults(scope, step, isolate, results), err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return scope, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	if len(batch) > 0 {
+		err = flush()
+	}
+
+	return isolatedResults(scope, step, isolate, results), err
+}
+
+// isolatedResults sets the results of the isolated items, see withBranchResults.
+func isolatedResults(scope Scope, step Step, isolate bool, results []workerResult) Scope {
+	if !isolate {
+		return scope
+	}
+
+	return withBranchResults(scope, step, results, nil)
+}
@@ -0,0 +1,97 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRangeFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	lines := filepath.Join(dir, "hosts.txt")
+	records := filepath.Join(dir, "events.ndjson")
+
+	assert.NoError(t, os.WriteFile(lines, []byte("a\nb\nc\n"), 0o600))
+	assert.NoError(t, os.WriteFile(records, []byte(`{"level":"info","n":1}
+
+{"level":"error","n":2}
+{"level":"error","n":3}
+{"level":"error","n":4}
+`), 0o600))
+
+	execute := func(params RangeParams) ([]any, Scope, error) {
+		var (
+			mu   sync.Mutex
+			seen []any
+		)
+
+		engine := NewEngine()
+		engine.RegisterStepExecutor("collect", FuncExecutor(func(_ context.Context, in struct {
+			Item any `yaml:"item"`
+		}) (any, error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			seen = append(seen, in.Item)
+
+			return nil, nil
+		}))
+
+		collect := NewStep("", "collect", map[string]any{"item": `{{ variable . "line" | toJson }}`})
+		pipelines := NewPipelines(New("main").Range("line", params, collect).Build())
+
+		scope, err := engine.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+
+		return seen, scope, err
+	}
+
+	t.Run("iterates the lines", func(t *testing.T) {
+		t.Parallel()
+
+		seen, scope, err := execute(RangeParams{File: expression.String(lines), Concurrency: "2", Isolate: "true"})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.ElementsMatch(t, []any{`"a"`, `"b"`, `"c"`}, seen)
+
+		results, _ := scope.Variable("line.$results")
+		assert.Len(t, results, 3)
+	})
+
+	t.Run("parses NDJSON records", func(t *testing.T) {
+		t.Parallel()
+
+		seen, _, err := execute(RangeParams{
+			File:   expression.String(records),
+			Parse:  RangeParseJSON,
+			Where:  `{{ eq (variableGet . "line" "level") "error" }}`,
+			Offset: "1",
+			Limit:  "1",
+		})
+		if assert.NoError(t, err) {
+			assert.Equal(t, []any{`{"level":"error","n":3}`}, seen)
+		}
+	})
+
+	t.Run("rejects other sources", func(t *testing.T) {
+		t.Parallel()
+
+		_, _, err := execute(RangeParams{File: expression.String(lines), Items: []any{1}})
+		assert.ErrorContains(t, err, "can't be combined")
+	})
+
+	t.Run("reports invalid records", func(t *testing.T) {
+		t.Parallel()
+
+		_, _, err := execute(RangeParams{File: expression.String(lines), Parse: RangeParseJSON})
+		assert.ErrorContains(t, err, "parsing line 1")
+	})
+}
@@ -0,0 +1,102 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+)
+
+// Read-only modes of the executions, see WithReadOnly.
+const (
+	// ReadOnlyBlock fails the side-effecting steps with ErrSideEffect.
+	ReadOnlyBlock = "block"
+	// ReadOnlyNoop skips the side-effecting steps, logging them and setting their $skipped variable.
+	ReadOnlyNoop = "noop"
+)
+
+// ErrSideEffect is returned by the side-effecting steps of the read-only executions, see ReadOnlyBlock.
+var ErrSideEffect = errors.New("side-effecting step in a read-only execution")
+
+// ReadOnlyExecutor is implemented by the step executors declaring whether a step only reads, eg.: an http GET,
+// or has side effects, eg.: writing a file. Steps of executors not implementing it are side-effecting.
+// The built-in steps only read, their nested steps being classified on their own.
+type ReadOnlyExecutor interface {
+	StepExecutor
+	ReadOnly(step Step) bool
+}
+
+// ReadOnlyParams is implemented by the params of typed step executors declaring whether their step only reads,
+// see ReadOnlyExecutor. The params hold the expressions unevaluated, so the ones deciding it aren't read-only.
+type ReadOnlyParams interface {
+	ReadOnly() bool
+}
+
+// ReadOnly declares the steps of the executor as read-only, eg.: the ones of custom control flow executors.
+func ReadOnly(executor StepExecutor) ReadOnlyExecutor {
+	return readOnlyExecutor{StepExecutor: executor}
+}
+
+type readOnlyExecutor struct {
+	StepExecutor
+}
+
+func (readOnlyExecutor) ReadOnly(Step) bool {
+	return true
+}
+
+func (e readOnlyExecutor) validateParams(step Step) error {
+	if validator, ok := e.StepExecutor.(paramsValidator); ok {
+		return validator.validateParams(step)
+	}
+
+	return nil
+}
+
+// WithReadOnly blocks the side-effecting steps of the execution following the mode, ReadOnlyBlock or ReadOnlyNoop,
+// eg.: to safely exercise the pipelines against production configurations. See ReadOnlyExecutor.
+func WithReadOnly(mode string) Option {
+	return func(o *options) {
+		o.readOnly = mode
+	}
+}
+
+type readOnlyKey struct{}
+
+func readOnlyFrom(ctx context.Context) string {
+	mode, _ := ctx.Value(readOnlyKey{}).(string)
+
+	return mode
+}
+
+// sideEffecting reports whether the step has side effects, see ReadOnlyExecutor.
+func sideEffecting(executor StepExecutor, step Step) bool {
+	classifier, ok := executor.(ReadOnlyExecutor)
+
+	return !ok || !classifier.ReadOnly(step)
+}
+
+// blockSideEffects blocks the side-effecting step of the read-only executions, reporting whether it was,
+// skipping it with ReadOnlyNoop.
+func blockSideEffects(ctx context.Context, scope Scope, step Step, executor StepExecutor) (Scope, bool, error) {
+	mode := readOnlyFrom(ctx)
+	if mode == "" || !sideEffecting(executor, step) {
+		return scope, false, nil
+	}
+
+	switch mode {
+	case ReadOnlyBlock:
+		return scope, true, fmt.Errorf("%w: %s", ErrSideEffect, step)
+	case ReadOnlyNoop:
+		log.Log().Info(ctx, "Skipping side-effecting %s in read-only mode", step)
+
+		if step.ID != "" {
+			scope = scope.WithVariable(step.VariablePath(PathNodeSkipped), true)
+		}
+
+		return scope, true, nil
+	}
+
+	return scope, true, fmt.Errorf("unknown read-only mode: %s", mode)
+}
@@ -0,0 +1,94 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fetchParams struct {
+	Method string `yaml:"method"`
+}
+
+func (p fetchParams) ReadOnly() bool {
+	return p.Method == "GET"
+}
+
+func TestReadOnly(t *testing.T) {
+	t.Parallel()
+
+	pipelines := NewPipelines(New("main").
+		Set("greeting", map[string]any{"text": "hi"}).
+		Step(NewStep("get", "fetch", fetchParams{Method: "GET"})).
+		Step(NewStep("post", "fetch", fetchParams{Method: "POST"})).
+		Step(Step{ID: "write", Type: "write"}).
+		Build())
+
+	newEngine := func(calls *[]string) *Engine {
+		engine := NewEngine()
+		engine.RegisterStepExecutor("fetch", TypedStepExecutor[fetchParams](func(_ context.Context, scope Scope, step Step, params fetchParams) (Scope, error) {
+			*calls = append(*calls, params.Method)
+
+			return scope.WithVariable(step.VariablePath(), params.Method), nil
+		}))
+		engine.RegisterStepExecutor("write", FuncExecutor(func(context.Context, struct{}) (bool, error) {
+			*calls = append(*calls, "write")
+
+			return true, nil
+		}))
+
+		return engine
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+
+		var calls []string
+
+		_, err := newEngine(&calls).Execute(context.Background(), NewScope(pipelines), []string{"main"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"GET", "POST", "write"}, calls)
+		}
+	})
+
+	t.Run("block", func(t *testing.T) {
+		t.Parallel()
+
+		var calls []string
+
+		_, err := newEngine(&calls).Execute(context.Background(), NewScope(pipelines), []string{"main"}, WithReadOnly(ReadOnlyBlock))
+		assert.ErrorIs(t, err, ErrSideEffect)
+		assert.Equal(t, []string{"GET"}, calls)
+	})
+
+	t.Run("noop", func(t *testing.T) {
+		t.Parallel()
+
+		var calls []string
+
+		scope, err := newEngine(&calls).Execute(context.Background(), NewScope(pipelines), []string{"main"}, WithReadOnly(ReadOnlyNoop))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, []string{"GET"}, calls)
+
+		greeting, _ := Get[map[string]any](scope, "greeting")
+		assert.Equal(t, "hi", greeting["text"])
+
+		for _, id := range []VariablePathNode{"post", "write"} {
+			skipped, _ := scope.Variable(Step{ID: id}.VariablePath(PathNodeSkipped))
+			assert.Equal(t, true, skipped, id)
+		}
+	})
+
+	t.Run("unknown mode", func(t *testing.T) {
+		t.Parallel()
+
+		var calls []string
+
+		_, err := newEngine(&calls).Execute(context.Background(), NewScope(pipelines), []string{"main"}, WithReadOnly("dry"))
+		assert.ErrorContains(t, err, "unknown read-only mode: dry")
+	})
+}
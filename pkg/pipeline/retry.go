@@ -0,0 +1,186 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+)
+
+// PathNodeError is the step path node holding the error message while evaluating the retry condition, see StepRetry.
+const PathNodeError VariablePathNode = "$error"
+
+// Retries re-executes a failed pipeline from the beginning, eg.: flaky end-to-end flows triggered by schedulers.
+//
+//	name: e2e
+//	retries:
+//	  max: 2
+//	  backoff: '10s'
+//	  max_backoff: '1m'
+type Retries struct {
+	// Max is the number of re-executions after the first failure.
+	Max int `yaml:"max"`
+	// Backoff is the wait before the first re-execution, doubled on each following one.
+	Backoff expression.Duration `yaml:"backoff"`
+	// MaxBackoff caps the wait, when set.
+	MaxBackoff expression.Duration `yaml:"max_backoff"`
+}
+
+// wait returns the wait before the re-execution following the 1-based failed attempt.
+func (r Retries) wait(ctx context.Context, scope Scope, attempt int) (time.Duration, error) {
+	backoff, err := r.Backoff.Eval(ctx, scope)
+	if err != nil {
+		return 0, err
+	}
+
+	maxBackoff, err := r.MaxBackoff.Eval(ctx, scope)
+	if err != nil {
+		return 0, err
+	}
+
+	return exponentialBackoff(backoff, maxBackoff, attempt), nil
+}
+
+// exponentialBackoff returns the backoff doubled on each 1-based failed attempt, capped by maxBackoff when set.
+func exponentialBackoff(backoff, maxBackoff time.Duration, attempt int) time.Duration {
+	wait := backoff << (attempt - 1)
+	if wait < backoff || (maxBackoff > 0 && wait > maxBackoff) {
+		wait = maxBackoff
+	}
+
+	return wait
+}
+
+// retryable reports whether a pipeline or step failing with the error can be re-executed:
+// deliberate stops, exceeded depths or limits, panics failing the pipelines and done contexts are not.
+func retryable(ctx context.Context, err error) bool {
+	var stopErr *StopError
+
+	return ctx.Err() == nil && !errors.As(err, &stopErr) && !errors.Is(err, ErrMaxDepthExceeded) && !errors.Is(err, ErrLimitExceeded) &&
+		!fatalPanic(err)
+}
+
+// executeWithRetries executes the pipeline, each time over the initial scope, until it succeeds or its retries are exhausted.
+// The waits are evaluated with the initial scope.
+func (p Pipeline) executeWithRetries(ctx context.Context, scope Scope, execute func(ctx context.Context) (Scope, error)) (Scope, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := execute(ctx)
+		if err == nil || attempt > p.Retries.Max || !retryable(ctx, err) {
+			return result, err
+		}
+
+		wait, waitErr := p.Retries.wait(ctx, scope, attempt)
+		if waitErr != nil {
+			return result, errors.Join(err, waitErr)
+		}
+
+		log.Log().Warn(ctx, "Pipeline %s failed on attempt %d, retrying in %s: %s", p, attempt, wait, err)
+
+		select {
+		case <-ctx.Done():
+			return result, err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// StepRetry re-executes a failed step of any type, eg.: a flaky HTTP request.
+//
+//	id: retry-example
+//	steps:
+//	- id: fetch
+//	  type: http
+//	  retry:
+//	    max_attempts: 3
+//	    backoff: '1s'
+//	    jitter: '500ms'
+//	    when: '{{ contains "timeout" (variable . "fetch.$error") }}'
+type StepRetry struct {
+	// MaxAttempts is the maximum number of executions, including the first one.
+	MaxAttempts int `yaml:"max_attempts"`
+	// Backoff is the wait before the second attempt, doubled on each following one.
+	Backoff expression.Duration `yaml:"backoff"`
+	// MaxBackoff caps the wait, when set.
+	MaxBackoff expression.Duration `yaml:"max_backoff"`
+	// Jitter is the maximum random duration added to each wait, spreading concurrent retries.
+	Jitter expression.Duration `yaml:"jitter"`
+	// When matches the retryable errors, evaluated with the error message in the step $error path.
+	// Every error is retried when empty.
+	When expression.Bool `yaml:"when"`
+}
+
+// WithRetry returns a copy of the step retried on failure, see StepRetry.
+func (s Step) WithRetry(retry StepRetry) Step {
+	s.Retry = retry
+
+	return s
+}
+
+// wait returns the wait before the attempt following the 1-based failed attempt, its jitter drawn from the
+// execution generator, see WithSeed.
+func (r StepRetry) wait(ctx context.Context, scope Scope, attempt int) (time.Duration, error) {
+	wait, err := Retries{Backoff: r.Backoff, MaxBackoff: r.MaxBackoff}.wait(ctx, scope, attempt)
+	if err != nil {
+		return 0, err
+	}
+
+	jitter, err := r.Jitter.Eval(ctx, scope)
+	if err != nil {
+		return 0, err
+	}
+
+	if jitter > 0 {
+		wait += time.Duration(Rand(ctx).Int64N(int64(jitter)))
+	}
+
+	return wait, nil
+}
+
+// matches reports whether the step failing with the error on the 1-based attempt must be retried.
+func (r StepRetry) matches(ctx context.Context, scope Scope, step Step, attempt int, err error) (bool, error) {
+	if attempt >= r.MaxAttempts || !retryable(ctx, err) {
+		return false, nil
+	}
+
+	if r.When == "" {
+		return true, nil
+	}
+
+	return r.When.Eval(ctx, scope.WithVariable(step.VariablePath(PathNodeError), err.Error()))
+}
+
+// executeWithRetry executes the step until it succeeds or its attempts are exhausted, returning the last attempt.
+func (r StepRetry) executeWithRetry(
+	ctx context.Context, scope Scope, step Step, execute func(ctx context.Context, scope Scope) (Scope, error),
+) (Scope, int, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := execute(ctx, scope)
+		if err == nil {
+			return result, attempt, nil
+		}
+
+		retry, matchErr := r.matches(ctx, scope, step, attempt, err)
+		if matchErr != nil {
+			return result, attempt, errors.Join(err, matchErr)
+		}
+
+		if !retry {
+			return result, attempt, err
+		}
+
+		wait, waitErr := r.wait(ctx, scope, attempt)
+		if waitErr != nil {
+			return result, attempt, errors.Join(err, waitErr)
+		}
+
+		log.Log().Warn(ctx, "Step %s failed on attempt %d of %d, retrying in %s: %s", step, attempt, r.MaxAttempts, wait, err)
+
+		select {
+		case <-ctx.Done():
+			return result, attempt, err
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// MaxSandboxFileSize is the maximum size of the files read by the fileRead template function.
+const MaxSandboxFileSize = 1 << 20
+
+var (
+	// ErrNoSandbox is returned by the file template functions of executions without a sandbox root, see WithSandbox.
+	ErrNoSandbox = errors.New("no sandbox root")
+	// ErrOutsideSandbox is returned by the file template functions for paths escaping the sandbox root,
+	// symbolic links included.
+	ErrOutsideSandbox = errors.New("path outside the sandbox root")
+)
+
+// WithSandbox sets the directory the fileRead, fileExists and dirList template functions are restricted to,
+// so expressions can read small config snippets without a file step. The functions fail without it.
+func WithSandbox(root string) Option {
+	return func(o *options) {
+		o.sandbox = root
+	}
+}
+
+// sandbox resolves the paths of the file template functions under its root.
+type sandbox struct {
+	root string
+}
+
+// path returns the path of the name relative to the sandbox root, after resolving its symbolic links.
+func (s sandbox) path(name string) (string, error) {
+	if s.root == "" {
+		return "", ErrNoSandbox
+	}
+
+	if !filepath.IsLocal(name) && filepath.Clean(name) != "." {
+		return "", fmt.Errorf("%w: %s", ErrOutsideSandbox, name)
+	}
+
+	root, err := filepath.EvalSymlinks(s.root)
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := filepath.EvalSymlinks(filepath.Join(root, name))
+	if err != nil {
+		return "", err
+	}
+
+	if rel, err := filepath.Rel(root, resolved); err != nil || (!filepath.IsLocal(rel) && rel != ".") {
+		return "", fmt.Errorf("%w: %s", ErrOutsideSandbox, name)
+	}
+
+	return resolved, nil
+}
+
+func (s sandbox) read(name string) (string, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, MaxSandboxFileSize+1))
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) > MaxSandboxFileSize {
+		return "", fmt.Errorf("file %s exceeds %d bytes", name, MaxSandboxFileSize)
+	}
+
+	return string(data), nil
+}
+
+func (s sandbox) exists(name string) (bool, error) {
+	_, err := s.path(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// list returns the sorted names of the directory entries, suffixing the directories with a slash.
+func (s sandbox) list(name string) ([]string, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+		if entry.IsDir() {
+			names[i] += "/"
+		}
+	}
+
+	return names, nil
+}
@@ -0,0 +1,62 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSandbox(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	outside := t.TempDir()
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "config", "envs"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "config", "app.yaml"), []byte("replicas: 2"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(outside, "secret"), []byte("hunter22"), 0o600))
+	assert.NoError(t, os.Symlink(filepath.Join(outside, "secret"), filepath.Join(root, "link")))
+
+	execute := func(expression string, opts ...Option) (Scope, error) {
+		pipelines := NewPipelines(New("main").Set("result", map[string]any{"value": expression}).Build())
+
+		return NewEngine().Execute(context.Background(), NewScope(pipelines), []string{"main"}, opts...)
+	}
+
+	tests := []struct {
+		expression string
+		expected   string
+		err        error
+	}{
+		{expression: `{{ fileRead . "config/app.yaml" }}`, expected: "replicas: 2"},
+		{expression: `{{ fileExists . "config/app.yaml" }} {{ fileExists . "missing.yaml" }}`, expected: "true false"},
+		{expression: `{{ dirList . "config" | join "," }}`, expected: "app.yaml,envs/"},
+		{expression: `{{ fileRead . "../secret" }}`, err: ErrOutsideSandbox},
+		{expression: `{{ fileRead . "link" }}`, err: ErrOutsideSandbox},
+		{expression: `{{ fileExists . "/etc/passwd" }}`, err: ErrOutsideSandbox},
+	}
+
+	for _, tc := range tests {
+		scope, err := execute(tc.expression, WithSandbox(root))
+		if tc.err != nil {
+			assert.ErrorIs(t, err, tc.err, tc.expression)
+
+			continue
+		}
+
+		if assert.NoError(t, err, tc.expression) {
+			result, _ := scope.Variable("result")
+			assert.Equal(t, map[string]any{"value": tc.expected}, result, tc.expression)
+		}
+	}
+
+	t.Run("requires a sandbox root", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := execute(`{{ fileRead . "config/app.yaml" }}`)
+		assert.ErrorIs(t, err, ErrNoSandbox)
+	})
+}
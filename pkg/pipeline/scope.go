@@ -2,102 +2,208 @@ package pipeline
 
 import (
 	"errors"
+	"maps"
+	"path"
+	"reflect"
+	"slices"
 	"strings"
 	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
 )
 
 type VariablePathNode string
 
 const (
-	PathNodeIndex VariablePathNode = "$index"
+	PathNodeIndex   VariablePathNode = "$index"
+	PathNodeResults VariablePathNode = "$results"
+	PathNodeSkipped VariablePathNode = "$skipped"
 )
 
 type VariablePath string
 
 var ErrVariableNotFound = errors.New("variable not found")
 
+// Scope is the immutable state of an execution: its methods return new scopes, sharing the variables of the ones
+// they derive from instead of copying them, see variableMap.
 type Scope struct {
 	Finished  bool
 	CreatedAt time.Time
 	Pipelines Pipelines
-	variables map[VariablePath]any
+	variables *variableMap
+	// base are the variables the scope was cloned from, which Merge compares its variables with.
+	base      *variableMap
 	namespace []VariablePathNode
+	stopScope StopScope
+	execution *execution
 }
 
-func NewScope(pipelines Pipelines) Scope {
-	return Scope{
+// NewScope returns the initial scope of the pipelines executions. Options setting variables, eg.: WithVariables,
+// parameterize the runs without editing the pipelines; the other options only apply to the executions.
+func NewScope(pipelines Pipelines, opts ...Option) Scope {
+	scope := Scope{
 		CreatedAt: time.Now(),
-		variables: map[VariablePath]any{},
 		Pipelines: pipelines,
 	}
+
+	return scope.WithVariables(newOptions(opts).variables)
 }
 
 func (c Scope) WithVariable(path VariablePath, item any) Scope {
-	if path == "" {
-		return c
-	}
+	return c.WithVariables(map[VariablePath]any{path: item})
+}
+
+func (c Scope) WithVariables(items map[VariablePath]any) Scope {
+	qualified := make(map[VariablePath]any, len(items))
 
-	path = c.qualifyPath(path)
+	for path, item := range items {
+		if path == "" {
+			continue
+		}
 
-	variable := map[VariablePath]any{}
-	for k, v := range c.variables {
-		variable[k] = v
+		path = c.qualifyPath(path)
+		c.registerSecrets(string(path), item)
+
+		qualified[path] = item
 	}
 
-	variable[path] = item
-	c.variables = variable
+	c.variables = c.variables.with(qualified)
 
 	return c
 }
 
-func (c Scope) WithVariables(items map[VariablePath]any) Scope {
-	for path, item := range items {
-		c = c.WithVariable(path, item)
-	}
+// Clone returns a copy of the scope, sharing its variables until either one sets them.
+// The copy is a branch of the scope: merging it back only merges the variables it set, see Merge.
+func (c Scope) Clone() Scope {
+	clone := c.copy()
+	clone.base = c.variables
 
-	return c
+	return clone
 }
 
-func (c Scope) Clone() Scope {
+// copy returns a copy of the scope, keeping the variables it was cloned from.
+func (c Scope) copy() Scope {
 	clone := c
-	clone.variables = make(map[VariablePath]any)
-
 	clone.namespace = append([]VariablePathNode{}, c.namespace...)
 
-	for k, v := range c.variables {
-		clone.variables[k] = v
-	}
-
 	return clone
 }
 
+// Merge returns a copy of the scope with the variables set by ctx since it was cloned, see Clone, walking only
+// its layers not shared with the clone base. Variables of the base copied into those layers are compared with
+// the base ones, so branches merged one after another don't revert each other's changes.
+// Without a base, ctx variables are compared with the scope ones.
 func (c Scope) Merge(ctx Scope) Scope {
-	merged := c.Clone()
+	base := ctx.base
+	if base == nil {
+		base = c.variables
+	}
 
-	for path, item := range ctx.variables {
-		merged.variables[path] = item
+	changes := map[VariablePath]any{}
+
+	for path, value := range ctx.variables.since(base) {
+		if current, found := base.get(path); found && reflect.DeepEqual(current, value) {
+			continue
+		}
+
+		changes[path] = value
 	}
 
+	merged := c.copy()
+	merged.variables = c.variables.with(changes)
+
 	return merged
 }
 
+// Variable returns the variable of the path, relative to the current namespace or any of its parents.
+// Paths deeper than the variables walk their maps, slices and structs, eg.: "http-step.$body.items[0].id",
+// with keys holding dots quoted within brackets, eg.: `config["app.name"]`.
 func (c Scope) Variable(path VariablePath) (any, error) {
-	for _, candidate := range c.candidates(path) {
-		item, found := c.variables[candidate]
+	candidates := c.candidates(path)
+
+	for _, candidate := range candidates {
+		item, found := c.variables.get(candidate)
 		if found {
 			return item, nil
 		}
 	}
 
+	for _, candidate := range candidates {
+		if item, found := c.deepVariable(string(candidate)); found {
+			return item, nil
+		}
+	}
+
 	return nil, ErrVariableNotFound
 }
 
+// deepVariable walks the rest of the path from the longest variable prefixing it.
+func (c Scope) deepVariable(path string) (any, bool) {
+	for i := len(path) - 1; i > 0; i-- {
+		if path[i] != '.' && path[i] != '[' {
+			continue
+		}
+
+		item, found := c.variables.get(VariablePath(path[:i]))
+		if !found {
+			continue
+		}
+
+		if value, ok := walkPath(item, path[i:]); ok {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
+
+// Variables returns a copy of all variables keyed by their fully qualified paths.
+func (c Scope) Variables() map[VariablePath]any {
+	variables := make(map[VariablePath]any, c.variables.len())
+	maps.Insert(variables, c.variables.all())
+
+	return variables
+}
+
+// Match returns the variable paths matching the pattern, sorted. Patterns with wildcards follow path.Match
+// (eg.: "users.*.name"), other patterns match the path itself and the paths under it (eg.: "users").
+// Paths under the current namespace are relative to it, like the ones accepted by Variable.
+func (c Scope) Match(pattern string) []VariablePath {
+	prefix := c.namespacePrefix()
+	matches := []VariablePath{}
+
+	for qualified := range c.variables.all() {
+		candidate := string(qualified)
+		if prefix != "" && strings.HasPrefix(candidate, prefix+".") {
+			candidate = strings.TrimPrefix(candidate, prefix+".")
+		}
+
+		if matchPath(pattern, candidate) {
+			matches = append(matches, VariablePath(candidate))
+		}
+	}
+
+	slices.Sort(matches)
+
+	return matches
+}
+
+func matchPath(pattern, candidate string) bool {
+	if strings.ContainsAny(pattern, "*?[") {
+		matched, _ := path.Match(pattern, candidate)
+
+		return matched
+	}
+
+	return pattern == "" || candidate == pattern || strings.HasPrefix(candidate, pattern+".")
+}
+
 func (c Scope) WithNamespace(node VariablePathNode) Scope {
 	if node == "" {
 		return c
 	}
 
-	next := c.Clone()
+	next := c.copy()
 	next.namespace = append(next.namespace, node)
 
 	return next
@@ -161,3 +267,43 @@ func joinNodes(nodes []VariablePathNode) string {
 
 	return strings.Join(parts, ".")
 }
+
+// registerSecrets registers string values stored under secret-like keys in the execution secrets, so they are
+// redacted from its logs. Scopes out of executions register them once executed, see Pipelines.Execute.
+func (c Scope) registerSecrets(key string, item any) {
+	if c.execution == nil {
+		return
+	}
+
+	if index := strings.LastIndex(key, "."); index >= 0 {
+		key = key[index+1:]
+	}
+
+	switch value := item.(type) {
+	case string:
+		if log.IsSecretKey(key) {
+			c.execution.secrets.Add(value)
+		}
+	case map[string]any:
+		for k, v := range value {
+			c.registerSecrets(k, v)
+		}
+	case map[string]string:
+		for k, v := range value {
+			c.registerSecrets(k, v)
+		}
+	case []any:
+		for _, v := range value {
+			c.registerSecrets(key, v)
+		}
+	}
+}
+
+// redact masks the execution secrets and the log redactions in the message.
+func (c Scope) redact(msg string) string {
+	if c.execution != nil {
+		msg = c.execution.secrets.Redact(msg)
+	}
+
+	return log.Redact(msg)
+}
@@ -0,0 +1,89 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+)
+
+// WithSeed seeds the randomness of the execution, eg.: the retries jitter and the random template functions,
+// so it can be replayed deterministically while debugging. Executions without it are seeded randomly, see Seed.
+// The draws of concurrent branches depend on their scheduling, so only their sequential steps are reproducible.
+func WithSeed(seed uint64) Option {
+	return func(o *options) {
+		o.seed = &seed
+	}
+}
+
+// random is the seeded generator of an execution, safe for concurrent use.
+type random struct {
+	mu   sync.Mutex
+	seed uint64
+	pcg  *rand.PCG
+}
+
+func newRandom(seed uint64) *random {
+	return &random{seed: seed, pcg: rand.NewPCG(seed, seed)}
+}
+
+func (r *random) Uint64() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.pcg.Uint64()
+}
+
+// Seed returns the seed of the execution running with the context, see WithSeed, or false outside executions.
+func Seed(ctx context.Context) (uint64, bool) {
+	exec := executionFrom(ctx)
+	if exec == nil {
+		return 0, false
+	}
+
+	return exec.random.seed, true
+}
+
+// Rand returns the random generator of the execution running with the context, seeded by WithSeed, so the
+// executors randomness is replayed with the execution. It's safe for concurrent use.
+// Outside executions, the generator is seeded randomly.
+func Rand(ctx context.Context) *rand.Rand {
+	return executionFrom(ctx).rand()
+}
+
+func (e *execution) rand() *rand.Rand {
+	if e == nil {
+		return rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+
+	return rand.New(e.random)
+}
+
+// randomInt returns a random int in [min, max) drawn from the execution generator.
+func randomInt(scope Scope, low, high int) (int, error) {
+	if high <= low {
+		return 0, errors.New("randomInt max must be greater than min")
+	}
+
+	return low + scope.execution.rand().IntN(high-low), nil
+}
+
+// randomItem returns a random item of the list drawn from the execution generator, or nil when it's empty.
+func randomItem(scope Scope, items []any) any {
+	if len(items) == 0 {
+		return nil
+	}
+
+	return items[scope.execution.rand().IntN(len(items))]
+}
+
+// randomShuffle returns a copy of the list shuffled by the execution generator.
+func randomShuffle(scope Scope, items []any) []any {
+	shuffled := append([]any{}, items...)
+
+	scope.execution.rand().Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	return shuffled
+}
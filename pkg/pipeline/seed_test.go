@@ -0,0 +1,56 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeed(t *testing.T) {
+	t.Parallel()
+
+	var seeds []uint64
+
+	engine := NewEngine()
+	engine.RegisterStepExecutor("seed", FuncExecutor(func(ctx context.Context, _ struct{}) (int64, error) {
+		seed, _ := Seed(ctx)
+		seeds = append(seeds, seed)
+
+		return Rand(ctx).Int64N(1000), nil
+	}))
+
+	pipelines := NewPipelines(New("main").
+		Step(NewStep("seed", "seed", map[string]any{})).
+		Set("random", map[string]any{
+			"int":      `{{ randomInt . 0 1000 }}`,
+			"item":     `{{ randomItem . (list "a" "b" "c" "d") }}`,
+			"shuffled": `{{ randomShuffle . (list 1 2 3 4 5 6 7 8) | toJson }}`,
+		}).
+		Build())
+
+	execute := func(opts ...Option) map[string]any {
+		scope, err := engine.Execute(context.Background(), NewScope(pipelines), []string{"main"}, opts...)
+		if !assert.NoError(t, err) {
+			return nil
+		}
+
+		step, _ := scope.Variable("seed")
+		random, _ := Get[map[string]any](scope, "random")
+		random["step"] = step
+
+		return random
+	}
+
+	first := execute(WithSeed(42))
+	assert.Equal(t, first, execute(WithSeed(42)), "executions with the same seed draw the same values")
+	assert.NotEqual(t, first, execute(WithSeed(7)))
+
+	execute()
+	assert.Equal(t, []uint64{42, 42, 7}, seeds[:3])
+	assert.Len(t, seeds, 4)
+
+	_, ok := Seed(context.Background())
+	assert.False(t, ok)
+	assert.Less(t, Rand(context.Background()).IntN(10), 10)
+}
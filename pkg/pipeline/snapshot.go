@@ -0,0 +1,127 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// DefaultSnapshots is the number of steps whose scope is recorded by debug builds, see WithSnapshots.
+const DefaultSnapshots = 10
+
+// Snapshot is the scope of an execution once a step finished, see StepError.Snapshots.
+type Snapshot struct {
+	Pipeline  string               `json:"pipeline"`
+	Step      string               `json:"step"`
+	Time      time.Time            `json:"time"`
+	Failed    bool                 `json:"failed,omitempty"`
+	Variables map[VariablePath]any `json:"variables"`
+}
+
+// WithSnapshots sets the number of steps whose scope is recorded, DefaultSnapshots by default.
+// Snapshots are only recorded by the binaries built with the pipelinedebug tag, eg.: go build -tags pipelinedebug,
+// since copying the scope after each step is costly.
+func WithSnapshots(n int) Option {
+	return func(o *options) {
+		o.snapshots = n
+	}
+}
+
+// snapshots is a ring buffer of the last step snapshots of an execution.
+type snapshots struct {
+	mu    sync.Mutex
+	size  int
+	ring  []Snapshot
+	start int
+}
+
+func newSnapshots() *snapshots {
+	if !debugBuild {
+		return &snapshots{}
+	}
+
+	return &snapshots{size: DefaultSnapshots}
+}
+
+func (s *snapshots) resize(size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if debugBuild {
+		s.size, s.ring, s.start = size, nil, 0
+	}
+}
+
+// record records the scope of the step, replacing the oldest snapshot once the buffer is full.
+func (s *snapshots) record(ctx context.Context, scope Scope, step Step, failed bool) {
+	if s == nil || s.size <= 0 {
+		return
+	}
+
+	snapshot := Snapshot{
+		Pipeline:  FromContext(ctx).Pipeline,
+		Step:      step.String(),
+		Time:      time.Now(),
+		Failed:    failed,
+		Variables: scope.Variables(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.ring) < s.size {
+		s.ring = append(s.ring, snapshot)
+
+		return
+	}
+
+	s.ring[s.start] = snapshot
+	s.start = (s.start + 1) % s.size
+}
+
+// list returns the snapshots, oldest first.
+func (s *snapshots) list() []Snapshot {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.ring) == 0 {
+		return nil
+	}
+
+	return append(append([]Snapshot{}, s.ring[s.start:]...), s.ring[:s.start]...)
+}
+
+func snapshotsFrom(ctx context.Context) *snapshots {
+	if exec := executionFrom(ctx); exec != nil {
+		return exec.snapshots
+	}
+
+	return nil
+}
+
+// DumpSnapshots writes the snapshots leading up to the failed step as indented JSON, oldest first,
+// redacting the values under secret-like keys. It writes an empty list unless built with the pipelinedebug tag.
+func (e *StepError) DumpSnapshots(w io.Writer) error {
+	dump := make([]Snapshot, len(e.Snapshots))
+
+	for i, snapshot := range e.Snapshots {
+		variables := make(map[VariablePath]any, len(snapshot.Variables))
+		for path, value := range snapshot.Variables {
+			variables[path] = redactValue(string(path), value)
+		}
+
+		snapshot.Variables = variables
+		dump[i] = snapshot
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(dump)
+}
@@ -0,0 +1,67 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshots(t *testing.T) {
+	t.Parallel()
+
+	ring := &snapshots{size: 2}
+	scope := NewScope(Pipelines{})
+
+	for _, id := range []VariablePathNode{"first", "second", "third"} {
+		scope = scope.WithVariable(VariablePath(id), true)
+		ring.record(context.Background(), scope, SetStep(id, nil), id == "third")
+	}
+
+	listed := ring.list()
+	if assert.Len(t, listed, 2) {
+		assert.Equal(t, "step-set-second", listed[0].Step)
+		assert.Equal(t, "step-set-third", listed[1].Step)
+		assert.True(t, listed[1].Failed)
+		assert.Len(t, listed[1].Variables, 3)
+	}
+
+	stepErr := &StepError{Snapshots: []Snapshot{{Step: "step-set-db", Variables: map[VariablePath]any{"db": map[string]any{"password": "hunter22"}}}}}
+
+	var dump bytes.Buffer
+
+	assert.NoError(t, stepErr.DumpSnapshots(&dump))
+	assert.Contains(t, dump.String(), `"step": "step-set-db"`)
+	assert.NotContains(t, dump.String(), "hunter22")
+}
+
+func TestStepErrorSnapshots(t *testing.T) {
+	t.Parallel()
+
+	pipelines := NewPipelines(New("main").
+		Set("first", map[string]any{"value": 1}).
+		Set("second", map[string]any{"value": 2}).
+		Step(SetStep("failing", map[string]any{"value": `{{ fail "boom" }}`})).
+		Build())
+
+	_, err := NewEngine().Execute(context.Background(), NewScope(pipelines), []string{"main"}, WithSnapshots(2))
+
+	var stepErr *StepError
+	if !assert.True(t, errors.As(err, &stepErr)) {
+		return
+	}
+
+	if !debugBuild {
+		assert.Empty(t, stepErr.Snapshots, "snapshots are only recorded by debug builds")
+
+		return
+	}
+
+	if assert.Len(t, stepErr.Snapshots, 2) {
+		assert.Equal(t, "step-set-second", stepErr.Snapshots[0].Step)
+		assert.Equal(t, "step-set-failing", stepErr.Snapshots[1].Step)
+		assert.True(t, stepErr.Snapshots[1].Failed)
+	}
+}
@@ -2,7 +2,9 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -12,17 +14,9 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-// RegisterStepExecutors registers all available step executors.
+// RegisterStepExecutors registers all available step executors in the default engine.
 func RegisterStepExecutors() {
-	RegisterStepExecutor("pipeline", TypedStepExecutor[Pipeline](PipelineExecutor))
-	RegisterStepExecutor("set", TypedStepExecutor[SetParams](SetExecutor))
-	RegisterStepExecutor("switch", TypedStepExecutor[SwitchParams](SwitchExecutor))
-	RegisterStepExecutor("range", TypedStepExecutor[RangeParams](RangeExecutor))
-	RegisterStepExecutor("wait", TypedStepExecutor[WaitParams](WaitExecutor))
-	RegisterStepExecutor("stop", TypedStepExecutor[StopParams](StopExecutor))
-	RegisterStepExecutor("until", TypedStepExecutor[UntilParams](UntilExecutor))
-	RegisterStepExecutor("log", TypedStepExecutor[LogParams](LogExecutor))
-	RegisterStepExecutor("fanout", TypedStepExecutor[FanoutParams](FanoutExecutor))
+	defaultEngine.registerStepExecutors()
 }
 
 // Step represents a single step in the pipeline with its ID, type, and parameters.
@@ -30,6 +24,35 @@ type Step struct {
 	ID     VariablePathNode `yaml:"id"`
 	Type   string           `yaml:"type"`
 	Params map[string]any   `yaml:"params"`
+	// If skips the step when it evaluates to false, setting true in the step $skipped path.
+	If expression.Bool `yaml:"if"`
+	// Annotations describe the step for governance, eg.: team, cost-center or criticality.
+	// They're exposed to interceptors with the step, logged as fields prefixed with LogFieldAnnotation
+	// and set in the StepError of failed steps.
+	Annotations map[string]string `yaml:"annotations"`
+	// Retry re-executes the step on failure, see StepRetry.
+	Retry StepRetry `yaml:"retry"`
+	// Timeout cancels the context of each step attempt after the duration, failing it with ErrStepTimeout.
+	Timeout expression.Duration `yaml:"timeout"`
+	// params is Params encoded once when the step is loaded, decoded into the executors typed params.
+	// Steps built in Go without NewStep don't have it, and their Params are encoded on every execution.
+	params *yaml.Node
+}
+
+// UnmarshalYAML decodes the step and encodes its params node, so executors decode their typed params from it.
+// The node is encoded from the decoded Params instead of kept from the document, resolving aliases and merge keys.
+func (s *Step) UnmarshalYAML(node *yaml.Node) error {
+	type plain Step
+
+	var step plain
+	if err := node.Decode(&step); err != nil {
+		return err
+	}
+
+	*s = Step(step)
+	s.params = &yaml.Node{}
+
+	return s.params.Encode(s.Params)
 }
 
 // String returns a string representation of the step, including its type and ID.
@@ -43,6 +66,33 @@ func (s Step) String() string {
 	return str
 }
 
+// WithTimeout returns a copy of the step failing after the timeout, see Step.Timeout.
+func (s Step) WithTimeout(timeout time.Duration) Step {
+	s.Timeout = expression.Duration(timeout.String())
+
+	return s
+}
+
+// When returns a copy of the step skipped unless the condition evaluates to true, see Step.If.
+func (s Step) When(condition expression.Bool) Step {
+	s.If = condition
+
+	return s
+}
+
+// Annotate returns a copy of the step with the annotation set.
+func (s Step) Annotate(key, value string) Step {
+	annotations := make(map[string]string, len(s.Annotations)+1)
+	for k, v := range s.Annotations {
+		annotations[k] = v
+	}
+
+	annotations[key] = value
+	s.Annotations = annotations
+
+	return s
+}
+
 func (s Step) VariablePath(pathNodes ...VariablePathNode) VariablePath {
 	var allNodes = []VariablePathNode{}
 	if s.ID != "" {
@@ -74,25 +124,133 @@ type StepExecutors map[string]StepExecutor
 
 // Execute executes the executor for the given step type with the provided context.
 func (p StepExecutors) Execute(ctx context.Context, scope Scope, step Step) (Scope, error) {
+	executor, found := p[step.Type]
+
+	return executeStep(ctx, scope, step, executor, found)
+}
+
+func executeStep(ctx context.Context, scope Scope, step Step, executor StepExecutor, found bool) (Scope, error) {
+	ctx = withStep(ctx, step)
+
 	log.Log().Debug(ctx, "Executing %s", step)
 
-	executor, found := p[step.Type]
+	scope, attempt, err := runStep(ctx, scope, step, executor, found)
 
+	snapshots := snapshotsFrom(ctx)
+	snapshots.record(ctx, scope, step, err != nil)
+
+	if err != nil {
+		stepErr := &StepError{
+			Pipeline:    FromContext(ctx).Pipeline,
+			StepID:      step.ID,
+			StepType:    step.Type,
+			Annotations: step.Annotations,
+			Attempt:     attempt,
+			Err:         err,
+		}
+
+		if inner := (*StepError)(nil); !errors.As(err, &inner) {
+			stepErr.Snapshots = snapshots.list()
+		}
+
+		err = stepErr
+	}
+
+	return scope, err
+}
+
+// runStep executes the step unless skipped, returning the number of attempts.
+func runStep(ctx context.Context, scope Scope, step Step, executor StepExecutor, found bool) (Scope, int, error) {
 	if !found {
-		return scope, fmt.Errorf("unknown step type: %s", step.Type)
+		return scope, 1, fmt.Errorf("unknown step type: %s", step.Type)
 	}
 
-	scope, err := stepInterceptor(ctx, scope, step, executor)
+	skipped, err := step.skipped(ctx, scope)
 	if err != nil {
-		err = fmt.Errorf("error executing step %s: %w", step, err)
+		return scope, 1, err
 	}
 
-	return scope, err
+	if skipped {
+		log.Log().Info(ctx, "Skipping %s", step)
+
+		if step.ID != "" {
+			scope = scope.WithVariable(step.VariablePath(PathNodeSkipped), true)
+		}
+
+		return scope, 1, nil
+	}
+
+	if scope, blocked, err := blockSideEffects(ctx, scope, step, executor); blocked {
+		return scope, 1, err
+	}
+
+	timeout, err := step.Timeout.Eval(ctx, scope)
+	if err != nil {
+		return scope, 1, err
+	}
+
+	scope, attempt, err := step.Retry.executeWithRetry(ctx, scope, step, func(ctx context.Context, scope Scope) (Scope, error) {
+		if timeout <= 0 {
+			return executeRecoveredStep(ctx, scope, step, executor)
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		scope, err := executeRecoveredStep(attemptCtx, scope, step, executor)
+		if err != nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			err = fmt.Errorf("%w after %s: %w", ErrStepTimeout, timeout, err)
+		}
+
+		return scope, err
+	})
+	if err == nil {
+		boardFrom(ctx).publish(scope, step)
+	}
+
+	return scope, attempt, err
+}
+
+// skipped reports whether the step if condition evaluates to false.
+func (s Step) skipped(ctx context.Context, scope Scope) (bool, error) {
+	if s.If == "" {
+		return false, nil
+	}
+
+	run, err := s.If.Eval(ctx, scope)
+
+	return !run, err
 }
 
-// RegisterStepExecutor registers a step executor function with a given name.
+// executeLimitedStep executes the step within the execution limits, see WithLimits.
+func executeLimitedStep(ctx context.Context, scope Scope, step Step, executor StepExecutor) (Scope, error) {
+	l := limitsFrom(ctx)
+
+	if err := l.step(); err != nil {
+		return scope, err
+	}
+
+	scope, err := interceptorsFrom(ctx).step(ctx, scope, step, executor)
+	if err != nil {
+		return scope, err
+	}
+
+	return scope, l.check(scope)
+}
+
+// RegisterStepExecutor registers a step executor function with a given name in the default engine.
 func RegisterStepExecutor(name string, executor StepExecutor) {
-	executors[name] = executor
+	defaultEngine.RegisterStepExecutor(name, executor)
+}
+
+// LookupStepExecutor returns the step executor registered with a given name in the default engine.
+func LookupStepExecutor(name string) (StepExecutor, bool) {
+	return defaultEngine.LookupStepExecutor(name)
+}
+
+// UnregisterStepExecutor removes the step executor registered with a given name from the default engine.
+func UnregisterStepExecutor(name string) {
+	defaultEngine.UnregisterStepExecutor(name)
 }
 
 type TypedStepExecutor[Params any] func(ctx context.Context, scope Scope, step Step, params Params) (Scope, error)
@@ -100,16 +258,52 @@ type TypedStepExecutor[Params any] func(ctx context.Context, scope Scope, step S
 func (f TypedStepExecutor[Params]) Execute(ctx context.Context, scope Scope, step Step) (Scope, error) {
 	var params Params
 
-	blob, err := yaml.Marshal(step.Params)
-	if err != nil {
+	if err := step.decodeParams(&params); err != nil {
 		return scope, err
 	}
 
-	if err := yaml.Unmarshal(blob, &params); err != nil {
-		return scope, err
+	return f(ctx, scope, step, params)
+}
+
+// ReadOnly reports whether the step params implement ReadOnlyParams and declare it read-only.
+func (f TypedStepExecutor[Params]) ReadOnly(step Step) bool {
+	var params Params
+
+	if err := step.decodeParams(&params); err != nil {
+		return false
 	}
 
-	return f(ctx, scope, step, params)
+	classifier, ok := any(params).(ReadOnlyParams)
+
+	return ok && classifier.ReadOnly()
+}
+
+// validateParams decodes the step params, validating them when they implement ParamsValidator.
+func (f TypedStepExecutor[Params]) validateParams(step Step) error {
+	var params Params
+
+	if err := step.decodeParams(&params); err != nil {
+		return err
+	}
+
+	if validator, ok := any(params).(ParamsValidator); ok {
+		return validator.Validate()
+	}
+
+	return nil
+}
+
+func (s Step) decodeParams(out any) error {
+	if s.params != nil {
+		return s.params.Decode(out)
+	}
+
+	blob, err := yaml.Marshal(s.Params)
+	if err != nil {
+		return err
+	}
+
+	return yaml.Unmarshal(blob, out)
 }
 
 // StepExecutor defines the interface for executing a step in the pipeline.
@@ -126,16 +320,40 @@ type SetParams struct {
 }
 
 type SwitchCase struct {
-	Condition expression.Bool `yaml:"condition"`
+	// Value matches the case when equal to the switch value.
+	Value     expression.String `yaml:"value"`
+	Condition expression.Bool   `yaml:"condition"`
 	Pipeline  `yaml:",inline"`
 }
 
 type SwitchParams struct {
-	Cases   []SwitchCase `yaml:"cases"`
-	Default Pipeline     `yaml:"default"`
+	// Value is compared to the value of each case.
+	Value   expression.String `yaml:"value"`
+	Cases   []SwitchCase      `yaml:"cases"`
+	Default Pipeline          `yaml:"default"`
 }
 
+// Modes of the set step, chosen by its `$mode` param.
+const (
+	// SetModeReplace overwrites the variable wholesale.
+	SetModeReplace = "replace"
+	// SetModeMerge deep-merges the values into the existing variable map.
+	SetModeMerge = "merge"
+	// SetModeAppend appends the values to the lists under the same keys of the existing variable map.
+	SetModeAppend = "append"
+)
+
+const (
+	setModeKey  = "$mode"
+	setTypesKey = "$types"
+)
+
 // # SetExecutor sets a map[string]any in the context.
+// The `$mode` param controls how repeated sets of the same variable accumulate: `replace` (default)
+// overwrites it, `merge` deep-merges the values into it, and `append` appends each value to the list
+// under the same key, concatenating lists.
+// The `$types` param declares the type of the values by key, converting them (eg.: a template-produced "3")
+// into an `int`, `float`, `bool`, `duration`, `string` or a decoded `json`.
 // Example YAML:
 //
 //	id: set-example
@@ -148,17 +366,123 @@ type SwitchParams struct {
 //	  type: set
 //	  params:
 //	    counter: '{{ add (variableGet . "setup" "counter") 10 }}'
+//	    $types:
+//	      counter: 'int'
+//	- id: 'setup'
+//	  type: set
+//	  params:
+//	    $mode: 'append'
+//	    history: '{{ variableGet . "plus" "counter" }}'
 func SetExecutor(ctx context.Context, scope Scope, step Step, params SetParams) (Scope, error) {
 	value, err := params.Eval(ctx, scope)
 	if err != nil {
 		return scope, err
 	}
 
+	mode, _ := value[setModeKey].(string)
+	types, _ := value[setTypesKey].(map[string]any)
+
+	delete(value, setModeKey)
+	delete(value, setTypesKey)
+
+	for key, typ := range types {
+		current, found := value[key]
+		if !found {
+			return scope, fmt.Errorf("typed key %s is not set", key)
+		}
+
+		if value[key], err = coerce(current, fmt.Sprint(typ)); err != nil {
+			return scope, fmt.Errorf("cannot convert %s to %v: %w", key, typ, err)
+		}
+	}
+
+	if mode == "" || mode == SetModeReplace {
+		return scope.WithVariable(step.VariablePath(), value), nil
+	}
+
+	existing := map[string]any{}
+
+	if current, err := scope.Variable(step.VariablePath()); err == nil {
+		currentMap, ok := current.(map[string]any)
+		if !ok {
+			return scope, fmt.Errorf("cannot %s into %s: not a map", mode, step.VariablePath())
+		}
+
+		existing = currentMap
+	}
+
+	switch mode {
+	case SetModeMerge:
+		value = mergeMaps(existing, value)
+	case SetModeAppend:
+		value, err = appendLists(existing, value)
+		if err != nil {
+			return scope, fmt.Errorf("cannot append into %s: %w", step.VariablePath(), err)
+		}
+	default:
+		return scope, fmt.Errorf("unknown set mode: %s", mode)
+	}
+
 	return scope.WithVariable(step.VariablePath(), value), nil
 }
 
+// mergeMaps returns a copy of dst deep-merged with src, leaving both unchanged.
+func mergeMaps(dst, src map[string]any) map[string]any {
+	merged := make(map[string]any, len(dst)+len(src))
+	for key, value := range dst {
+		merged[key] = value
+	}
+
+	for key, value := range src {
+		current, currentIsMap := merged[key].(map[string]any)
+		incoming, incomingIsMap := value.(map[string]any)
+
+		if currentIsMap && incomingIsMap {
+			merged[key] = mergeMaps(current, incoming)
+
+			continue
+		}
+
+		merged[key] = value
+	}
+
+	return merged
+}
+
+// appendLists returns a copy of dst with each src value appended to the list under its key, leaving both unchanged.
+func appendLists(dst, src map[string]any) (map[string]any, error) {
+	appended := make(map[string]any, len(dst)+len(src))
+	for key, value := range dst {
+		appended[key] = value
+	}
+
+	for key, value := range src {
+		var list []any
+
+		if current, found := appended[key]; found {
+			currentList, ok := current.([]any)
+			if !ok {
+				return nil, fmt.Errorf("%s is not a list", key)
+			}
+
+			list = append(list, currentList...)
+		}
+
+		if values, ok := value.([]any); ok {
+			list = append(list, values...)
+		} else {
+			list = append(list, value)
+		}
+
+		appended[key] = list
+	}
+
+	return appended, nil
+}
+
 // SwitchExecutor evaluates cases in order and executes the first matching pipeline.
-// If no case matches, it executes the optional default pipeline when provided.
+// Cases match when their condition is true or, when the switch has a value, when their value equals it
+// (and their condition, if any, is true). If no case matches, it executes the optional default pipeline when provided.
 // Example YAML:
 //
 //	name: switch-example
@@ -176,9 +500,22 @@ func SetExecutor(ctx context.Context, scope Scope, step Step, params SetParams)
 //	      - type: log
 //	        params:
 //	          message: 'running default flow'
+//	- type: switch
+//	  params:
+//	    value: '{{ variableGet . "setup" "env" }}'
+//	    cases:
+//	    - value: 'prod'
+//	      uses: 'deploy-prod'
+//	    - value: 'staging'
+//	      uses: 'deploy-staging'
 func SwitchExecutor(ctx context.Context, scope Scope, step Step, params SwitchParams) (Scope, error) {
+	value, err := params.Value.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
 	for _, current := range params.Cases {
-		matches, err := current.Condition.Eval(ctx, scope)
+		matches, err := current.matches(ctx, scope, params.Value != "", value)
 		if err != nil {
 			return scope, err
 		}
@@ -195,11 +532,44 @@ func SwitchExecutor(ctx context.Context, scope Scope, step Step, params SwitchPa
 	return params.Default.Execute(ctx, scope)
 }
 
+func (c SwitchCase) matches(ctx context.Context, scope Scope, hasValue bool, value string) (bool, error) {
+	if c.Value != "" {
+		if !hasValue {
+			return false, errors.New("switch case value without a switch value")
+		}
+
+		expected, err := c.Value.Eval(ctx, scope)
+		if err != nil || expected != value {
+			return false, err
+		}
+
+		if c.Condition == "" {
+			return true, nil
+		}
+	}
+
+	return c.Condition.Eval(ctx, scope)
+}
+
+// StopScope defines how far a stop step finishes the execution.
+type StopScope string
+
+const (
+	// StopScopePipeline finishes the current (sub-)pipeline only, eg.: a switch case or a pipeline step.
+	StopScopePipeline StopScope = "pipeline"
+	// StopScopeBranch finishes the current range or fanout branch, or the whole execution outside them.
+	StopScopeBranch StopScope = "branch"
+	// StopScopeExecution finishes the whole execution, canceling the running range and fanout branches.
+	StopScopeExecution StopScope = "execution"
+)
+
 // StopParams defines the parameters for the StopExecutor.
 type StopParams struct {
 	Condition expression.Bool   `yaml:"condition"`
 	Message   expression.String `yaml:"message"`
 	IsError   expression.Bool   `yaml:"is_error"`
+	// Scope is one of StopScopePipeline, StopScopeBranch (default) or StopScopeExecution.
+	Scope expression.String `yaml:"scope"`
 }
 
 // StopExecutor stops the pipeline execution if the condition evaluates to true.
@@ -212,6 +582,7 @@ type StopParams struct {
 //	  	condition: '{{ gt 2 1 | and (eq "true" "true") }}'
 //	  	message: 'Stopping pipeline'
 //	  	is_error: 'true'
+//	  	scope: 'execution'
 func StopExecutor(ctx context.Context, scope Scope, step Step, params StopParams) (Scope, error) {
 	stop, err := params.Condition.Eval(ctx, scope)
 	if err != nil {
@@ -228,14 +599,28 @@ func StopExecutor(ctx context.Context, scope Scope, step Step, params StopParams
 		return scope, err
 	}
 
+	stopScope, err := params.Scope.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	switch StopScope(stopScope) {
+	case StopScopePipeline, StopScopeBranch, StopScopeExecution:
+	case "":
+		stopScope = string(StopScopeBranch)
+	default:
+		return scope, fmt.Errorf("unknown stop scope: %s", stopScope)
+	}
+
 	if isError {
-		err = fmt.Errorf("stop error: %s", msg)
+		err = &StopError{Pipeline: FromContext(ctx).Pipeline, StepID: step.ID, Message: msg}
 	}
 
 	if stop {
 		log.Log().Info(ctx, msg)
 
 		scope.Finished = true
+		scope.stopScope = StopScope(stopScope)
 
 		return scope, err
 	}
@@ -249,10 +634,28 @@ type RangeParams struct {
 	Variable    VariablePath           `yaml:"variable"`
 	JSON        expression.JSON[[]any] `yaml:"json"`
 	Concurrency expression.Int         `yaml:"concurrency"`
-	Pipeline    `yaml:",inline"`
+	Isolate     expression.Bool        `yaml:"isolate"`
+	Where       expression.Bool        `yaml:"where"`
+	Offset      expression.Int         `yaml:"offset"`
+	Limit       expression.Int         `yaml:"limit"`
+	// File iterates the lines of the file, read as the items are executed instead of loaded into the scope.
+	// It can't be combined with the other sources.
+	File expression.String `yaml:"file"`
+	// Parse parses the file lines, RangeParseJSON decoding NDJSON records. Lines are strings by default.
+	Parse expression.String `yaml:"parse"`
+	// ItemTimeout bounds the execution of each item, handled by the StragglerPolicy when exceeded.
+	ItemTimeout expression.Duration `yaml:"item_timeout"`
+	// StragglerPolicy is one of the Straggler policies, StragglerCancel by default.
+	StragglerPolicy expression.String `yaml:"straggler_policy"`
+	Pipeline        `yaml:",inline"`
 }
 
 // RangeExecutor executes a pipeline for each item in the source with optional concurrency.
+// Isolated items don't merge their variables back into the scope, only their results are set
+// like the fanout ones, eg.: for fire-and-forget batches.
+// The items can be filtered by the where condition, evaluated with the item in the step variable path,
+// and then sliced by the offset and limit. The items can be streamed from the lines of a file instead, see RangeParams.File.
+// Items exceeding the item timeout are handled by the straggler policy, so one slow item doesn't hold the batch.
 // Example YAML:
 //
 //	id: range-example
@@ -264,11 +667,17 @@ type RangeParams struct {
 //	  	variable: 'step-id'
 //	  	json: '{{ list 4 5 6 | toJson }}'
 //	  	concurrency: '{{ env "RANGE_CONCURRENCY" | default "2" }}'
+//	  	where: '{{ gt (variable . "range") 2 }}'
+//	  	limit: 2
 //	  	steps:
 //		- type: log
 //	  	  params:
 //	  		message: '{{ printf "Processing item %v: %v" ( variable . "range.$index") ( variable . "range" )}}'
 func RangeExecutor(ctx context.Context, scope Scope, step Step, params RangeParams) (Scope, error) {
+	if params.File != "" {
+		return params.rangeFile(ctx, scope, step)
+	}
+
 	items := params.Items
 
 	if params.Variable != "" {
@@ -294,32 +703,128 @@ func RangeExecutor(ctx context.Context, scope Scope, step Step, params RangePara
 		items = append(items, json...)
 	}
 
-	concurrency, err := params.Concurrency.Eval(ctx, scope)
+	items, err := params.filter(ctx, scope, step, items)
+	if err != nil {
+		return scope, err
+	}
+
+	concurrency, isolate, err := params.execution(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	timeout, err := newItemTimeout(ctx, scope, params.ItemTimeout, params.StragglerPolicy)
 	if err != nil {
 		return scope, err
 	}
 
+	scope, results, err := fanout(ctx, scope, concurrency, isolate, nil, params.mapper(step, 0, timeout), items...)
+
+	if isolate {
+		scope = withBranchResults(scope, step, results, nil)
+	}
+
+	return scope, err
+}
+
+// execution returns the concurrency, 1 by default, and whether the items are isolated.
+func (p RangeParams) execution(ctx context.Context, scope Scope) (int, bool, error) {
+	concurrency, err := p.Concurrency.Eval(ctx, scope)
+	if err != nil {
+		return 0, false, err
+	}
+
 	if concurrency == 0 {
 		concurrency = 1
 	}
 
-	return fanout(ctx, scope, concurrency, func(item any, i int) workerParams {
+	isolate, err := p.Isolate.Eval(ctx, scope)
+
+	return concurrency, isolate, err
+}
+
+// mapper maps the items to the branches executing the pipeline within the timeout, indexed from the start.
+func (p RangeParams) mapper(step Step, start int, timeout itemTimeout) func(item any, i int) workerParams {
+	return func(item any, i int) workerParams {
 		return workerParams{
-			Pipeline: params.Pipeline,
+			Pipeline: p.Pipeline,
 			Variables: map[VariablePath]any{
 				step.VariablePath():              item,
-				step.VariablePath(PathNodeIndex): i,
+				step.VariablePath(PathNodeIndex): start + i,
 			},
+			Fields:  []log.Field{{Key: LogFieldIndex, Value: start + i}},
+			timeout: timeout,
+		}
+	}
+}
+
+// filter returns the items matching the where condition, sliced by the offset and limit.
+func (p RangeParams) filter(ctx context.Context, scope Scope, step Step, items []any) ([]any, error) {
+	if p.Where != "" {
+		matching := make([]any, 0, len(items))
+
+		for i, item := range items {
+			match, err := p.matches(ctx, scope, step, item, i)
+			if err != nil {
+				return nil, err
+			}
+
+			if match {
+				matching = append(matching, item)
+			}
 		}
-	}, items...)
+
+		items = matching
+	}
+
+	offset, err := p.Offset.Eval(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := p.Limit.Eval(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	items = items[min(max(offset, 0), len(items)):]
+
+	if p.Limit != "" && limit >= 0 && limit < len(items) {
+		items = items[:limit]
+	}
+
+	return items, nil
 }
 
+// matches reports whether the item with the index matches the where condition.
+func (p RangeParams) matches(ctx context.Context, scope Scope, step Step, item any, i int) (bool, error) {
+	if p.Where == "" {
+		return true, nil
+	}
+
+	return p.Where.Eval(ctx, scope.WithVariables(map[VariablePath]any{
+		step.VariablePath():              item,
+		step.VariablePath(PathNodeIndex): i,
+	}))
+}
+
+// Log levels of the log step.
+const (
+	LogLevelDebug = "debug"
+	LogLevelInfo  = "info"
+	LogLevelWarn  = "warn"
+	LogLevelError = "error"
+)
+
 // LogParams defines the parameters for the LogExecutor.
 type LogParams struct {
-	Message expression.String `yaml:"message"`
+	Message expression.String               `yaml:"message"`
+	Level   expression.String               `yaml:"level"`
+	Fields  expression.YAML[map[string]any] `yaml:"fields"`
 }
 
-// LogExecutor logs a message to the context logger.
+// LogExecutor logs a message to the context logger, at the info level by default.
+// The fields are attached to the message as structured fields, with the values under secret-like keys redacted.
 // Example YAML:
 //
 //	id: log-example
@@ -327,13 +832,46 @@ type LogParams struct {
 //	- type: log
 //	  params:
 //	  	message: '{{ printf "Step %s completed at %s" (variableGet . "some_step" "id") (now | date "2006-01-02 15:04:05") }}'
+//	  	level: 'warn'
+//	  	fields:
+//	  	  status: '{{ variableGet . "some_step" "status" }}'
 func LogExecutor(ctx context.Context, scope Scope, step Step, params LogParams) (Scope, error) {
 	message, err := params.Message.Eval(ctx, scope)
 	if err != nil {
 		return scope, err
 	}
 
-	log.Log().Info(ctx, message)
+	level, err := params.Level.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	fields, err := params.Fields.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	keys := lo.Keys(fields)
+	sort.Strings(keys)
+
+	ctx = log.WithFields(ctx, lo.Map(keys, func(key string, _ int) log.Field {
+		return log.Field{Key: key, Value: redactSecrets(ctx, redactValue(key, fields[key]))}
+	})...)
+
+	logger := log.Log()
+
+	switch strings.ToLower(level) {
+	case LogLevelDebug:
+		logger.Debug(ctx, message)
+	case LogLevelInfo, "":
+		logger.Info(ctx, message)
+	case LogLevelWarn, "warning":
+		logger.Warn(ctx, message)
+	case LogLevelError:
+		logger.Error(ctx, message)
+	default:
+		return scope, fmt.Errorf("unknown log level: %s", level)
+	}
 
 	return scope, nil
 }
@@ -396,17 +934,129 @@ func WaitExecutor(ctx context.Context, scope Scope, step Step, params WaitParams
 		return scope, err
 	}
 
-	time.Sleep(duration)
+	select {
+	case <-ctx.Done():
+		return scope, ctx.Err()
+	case <-time.After(duration):
+		return scope, nil
+	}
+}
+
+// WaitForParams defines the parameters for the WaitForExecutor.
+type WaitForParams struct {
+	Variable  VariablePath        `yaml:"variable"`
+	Condition expression.Bool     `yaml:"condition"`
+	Timeout   expression.Duration `yaml:"timeout"`
+}
+
+// Validate checks the wait-for step waits for a variable or a condition.
+func (p WaitForParams) Validate() error {
+	if p.Variable == "" && p.Condition == "" {
+		return errors.New("wait-for requires a variable or a condition")
+	}
 
-	return scope, nil
+	return nil
+}
+
+// WaitForExecutor blocks until a variable exists or a condition is true, coordinating concurrent branches:
+// the variables set by the steps of any range or fanout branch of the execution are visible to it.
+// Once unblocked, the variables set by the other branches are added to the scope.
+// It fails with ErrWaitTimeout when the optional timeout expires.
+// Example YAML:
+//
+//	id: wait-for-example
+//	steps:
+//	- type: fanout
+//	  params:
+//	    pipelines:
+//	    - steps:
+//	      - type: wait-for
+//	        params:
+//	          variable: 'token'
+//	          timeout: '10s'
+//	      - type: log
+//	        params:
+//	          message: 'Got {{ variableGet . "token" "value" }}'
+//	    - steps:
+//	      - id: token
+//	        type: set
+//	        params:
+//	          value: 'abc'
+func WaitForExecutor(ctx context.Context, scope Scope, step Step, params WaitForParams) (Scope, error) {
+	if err := params.Validate(); err != nil {
+		return scope, err
+	}
+
+	timeout, err := params.Timeout.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	var expired <-chan time.Time
+
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		expired = timer.C
+	}
+
+	shared := boardFrom(ctx)
+
+	for {
+		variables, changed := shared.snapshot()
+		current := scope.withShared(variables)
+
+		done, err := waitForDone(ctx, current, params)
+		if err != nil || done {
+			return current, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return scope, ctx.Err()
+		case <-expired:
+			return scope, fmt.Errorf("%w after %s", ErrWaitTimeout, timeout)
+		case <-changed:
+		}
+	}
+}
+
+func waitForDone(ctx context.Context, scope Scope, params WaitForParams) (bool, error) {
+	if params.Variable != "" {
+		if _, err := scope.Variable(params.Variable); err != nil {
+			return false, nil
+		}
+	}
+
+	if params.Condition == "" {
+		return true, nil
+	}
+
+	done, err := params.Condition.Eval(ctx, scope)
+	if errors.Is(err, ErrVariableNotFound) {
+		// the variables used by the condition are not set yet.
+		return false, nil
+	}
+
+	return done, err
 }
 
 type FanoutParams struct {
-	Concurrency expression.Int `yaml:"concurrency"`
-	Pipelines   []Pipeline     `yaml:"pipelines"`
+	Concurrency expression.Int  `yaml:"concurrency"`
+	Isolate     expression.Bool `yaml:"isolate"`
+	Pipelines   []Pipeline      `yaml:"pipelines"`
+	// ItemTimeout bounds the execution of each branch, handled by the StragglerPolicy when exceeded.
+	ItemTimeout expression.Duration `yaml:"item_timeout"`
+	// StragglerPolicy is one of the Straggler policies, StragglerCancel by default.
+	StragglerPolicy expression.String `yaml:"straggler_policy"`
 }
 
-// FanoutExecutor executes multiple pipelines concurrently.
+// FanoutExecutor executes multiple pipelines concurrently, merging their variables in the declaration order.
+// Each branch result (index, id, status, duration and error) is set in the `step_id.$results` list
+// and in the `step_id.$results.<index>` paths, even when a branch fails.
+// Isolated branches don't merge their variables back into the scope.
+// Branches exceeding the item timeout are handled by the straggler policy, eg.: continue-without dropping them.
 // Example YAML:
 //
 //	id: fanout-example
@@ -434,82 +1084,248 @@ func FanoutExecutor(ctx context.Context, scope Scope, step Step, params FanoutPa
 		concurrency = len(params.Pipelines)
 	}
 
+	isolate, err := params.Isolate.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	timeout, err := newItemTimeout(ctx, scope, params.ItemTimeout, params.StragglerPolicy)
+	if err != nil {
+		return scope, err
+	}
+
 	pipelines := params.Pipelines
 
-	return fanout(ctx, scope, concurrency, func(item Pipeline, i int) workerParams {
-		return workerParams{Pipeline: item}
+	scope, results, err := fanout(ctx, scope, concurrency, isolate, nil, func(item Pipeline, i int) workerParams {
+		return workerParams{Pipeline: item, timeout: timeout}
 	}, pipelines...)
+
+	return withBranchResults(scope, step, results, func(i int) string { return pipelines[i].String() }), err
 }
 
-func fanout[T any](ctx context.Context, scope Scope, concurrency int, mapper func(item T, i int) workerParams, items ...T) (Scope, error) {
-	in := make(chan workerParams, concurrency)
-	out := make(chan workerResult, concurrency)
+// withBranchResults sets each branch result (index, status, duration, error and the id when given)
+// in the `step_id.$results` list and in the `step_id.$results.<index>` paths.
+func withBranchResults(scope Scope, step Step, results []workerResult, id func(i int) string) Scope {
+	summaries := make([]any, len(results))
+	variables := make(map[VariablePath]any, len(results)+1)
+
+	for i, result := range results {
+		summary := map[string]any{
+			"index":    i,
+			"status":   result.status,
+			"duration": result.duration.String(),
+			"error":    "",
+		}
 
-	defer func() {
-		close(in)
-	}()
+		if id != nil {
+			summary["id"] = id(i)
+		}
 
-	ctx, cancel := context.WithCancel(ctx)
+		if result.err != nil {
+			summary["error"] = result.err.Error()
+		}
 
-	defer cancel()
+		summaries[i] = summary
+		variables[step.VariablePath(PathNodeResults, VariablePathNode(fmt.Sprint(i)))] = summary
+	}
 
-	for range concurrency {
-		go worker(ctx, scope, in, out)
+	variables[step.VariablePath(PathNodeResults)] = summaries
+
+	return scope.WithVariables(variables)
+}
+
+// Branch statuses reported by the fanout step results.
+const (
+	BranchStatusSuccess  = "success"
+	BranchStatusError    = "error"
+	BranchStatusStopped  = "stopped"
+	BranchStatusCanceled = "canceled"
+	// BranchStatusTimeout is the status of the branches dropped by the StragglerContinue policy.
+	BranchStatusTimeout = "timeout"
+)
+
+// mergeFunc merges a branch scope into the scope.
+type mergeFunc func(scope, branch Scope) (Scope, error)
+
+// fanout executes the pipeline mapped from each item with the given concurrency.
+// The branches scopes are merged in the items order regardless of their completion order, with merge
+// or Scope.Merge when nil, unless isolated: then they're dropped once completed, keeping only their status.
+// The first failure or execution stop cancels the remaining branches.
+func fanout[T any](
+	ctx context.Context, scope Scope, concurrency int, isolate bool, merge mergeFunc, mapper func(item T, i int) workerParams, items ...T,
+) (Scope, []workerResult, error) {
+	parent := ctx
+
+	if merge == nil {
+		merge = func(scope, branch Scope) (Scope, error) {
+			return scope.Merge(branch), nil
+		}
+	}
+
+	release, err := limitsFrom(ctx).acquire(concurrency)
+	if err != nil {
+		return scope, nil, err
 	}
+	defer release()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	in := make(chan workerParams)
+	out := make(chan workerResult, len(items))
 
 	go func() {
+		defer close(in)
+
 		for i, item := range items {
-			in <- mapper(item, i)
+			params := mapper(item, i)
+			params.index = i
+
+			select {
+			case <-ctx.Done():
+				return
+			case in <- params:
+			}
 		}
 	}()
 
+	for range concurrency {
+		go worker(ctx, cancel, scope, in, out)
+	}
+
+	results := make([]workerResult, len(items))
+	for i := range results {
+		results[i] = workerResult{index: i, status: BranchStatusCanceled}
+	}
+
+	completed := make([]bool, len(items))
+	next := 0
+
 	for range len(items) {
-		if scope.Finished {
-			return scope, nil
+		var result workerResult
+
+		select {
+		case <-parent.Done():
+			return scope, results, parent.Err()
+		case result = <-out:
 		}
 
-		result := <-out
-		if result.error != nil {
-			return scope, result.error
+		if result.err != nil && ctx.Err() != nil && parent.Err() == nil {
+			// canceled by a branch stopping the execution, whose result is still to be received.
+			continue
 		}
 
-		scope = scope.Merge(result.Scope)
+		results[result.index] = result
+		if result.err != nil && result.status != BranchStatusTimeout {
+			return scope, results, result.err
+		}
+
+		completed[result.index] = true
+
+		if result.status == BranchStatusTimeout {
+			log.Log().Warn(ctx, "Continuing without branch %d: %s", result.index, result.err)
+		}
+
+		if isolate {
+			results[result.index].Scope = Scope{}
+
+			if result.Finished && result.stopScope == StopScopeExecution {
+				scope.Finished = true
+				scope.stopScope = StopScopeExecution
+
+				return scope, results, nil
+			}
+
+			continue
+		}
+
+		if result.Finished && result.stopScope == StopScopeExecution {
+			for i := next; i < len(items); i++ {
+				if completed[i] && results[i].status != BranchStatusTimeout {
+					if scope, err = merge(scope, results[i].Scope); err != nil {
+						return scope, results, err
+					}
+				}
+			}
+
+			scope.Finished = true
+			scope.stopScope = StopScopeExecution
+
+			return scope, results, nil
+		}
+
+		for next < len(items) && completed[next] {
+			if results[next].status == BranchStatusTimeout {
+				next++
+
+				continue
+			}
+
+			if scope, err = merge(scope, results[next].Scope); err != nil {
+				return scope, results, err
+			}
+
+			next++
+		}
 	}
 
-	return scope, nil
+	return scope, results, nil
 }
 
 type workerParams struct {
 	Pipeline
 	Variables map[VariablePath]any
+	Fields    []log.Field
+	index     int
+	timeout   itemTimeout
 }
 
 type workerResult struct {
 	Scope
-	error
+	index    int
+	status   string
+	duration time.Duration
+	err      error
+}
+
+// worker executes the branches received until the input is closed.
+// A branch stopping the execution cancels the other ones before its result is sent.
+func worker(ctx context.Context, cancel context.CancelFunc, scope Scope, in chan workerParams, out chan workerResult) {
+	for input := range in {
+		result := executeBranch(ctx, scope, input)
+		if result.Finished && result.stopScope == StopScopeExecution {
+			cancel()
+		}
+
+		out <- result
+	}
 }
 
-func worker(ctx context.Context, scope Scope, in chan workerParams, out chan workerResult) {
+// executeBranch executes the input pipeline over a copy of the base scope, see workerParams.execute.
+func executeBranch(ctx context.Context, scope Scope, input workerParams) (result workerResult) {
+	start := time.Now()
+	result = workerResult{Scope: scope, index: input.index}
+
 	defer func() {
 		if r := recover(); r != nil {
-			out <- workerResult{scope, fmt.Errorf("panic: %v", r)}
+			result.err = recovered(r)
 		}
-	}()
 
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case input, closed := <-in:
-			if !closed {
-				return
-			}
+		result.duration = time.Since(start)
+
+		switch {
+		case input.straggling(result.err):
+			result.status = BranchStatusTimeout
+		case result.err != nil:
+			result.status = BranchStatusError
+		case result.Finished:
+			result.status = BranchStatusStopped
+		default:
+			result.status = BranchStatusSuccess
+		}
+	}()
 
-			scope = scope.Clone().WithVariables(input.Variables)
+	result.Scope, result.err = input.execute(ctx, scope)
 
-			var err error
-			scope, err = input.Execute(ctx, scope)
-			out <- workerResult{scope, err}
-		}
-	}
+	return result
 }
@@ -0,0 +1,299 @@
+package pipeline
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogExecutor(t *testing.T) {
+	t.Parallel()
+
+	logger := &memoryLogger{}
+	ctx := log.WithLogger(context.Background(), logger)
+	scope := NewScope(Pipelines{}).WithVariable("status", "degraded")
+
+	step := NewStep("", "log", map[string]any{
+		"message": "checked",
+		"level":   "warn",
+		"fields":  map[string]any{"status": `{{ variable . "status" }}`, "api_token": "abc", "attempt": 2},
+	})
+
+	_, err := TypedStepExecutor[LogParams](LogExecutor).Execute(ctx, scope, step)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	last := len(logger.messages) - 1
+	assert.Equal(t, "checked", logger.messages[last])
+	assert.Equal(t, "warn", logger.levels[last])
+	assert.Equal(t, []log.Field{
+		{Key: "api_token", Value: log.Redacted},
+		{Key: "attempt", Value: 2},
+		{Key: "status", Value: "degraded"},
+	}, logger.fields[last])
+
+	_, err = TypedStepExecutor[LogParams](LogExecutor).Execute(ctx, scope, NewStep("", "log", map[string]any{"message": "x", "level": "loud"}))
+	assert.ErrorContains(t, err, "unknown log level")
+}
+
+func TestLogExecutorRedactsExecutionSecrets(t *testing.T) {
+	t.Parallel()
+
+	pipelines := NewPipelines(New("main").
+		Set("db", map[string]any{"password": "hunter22-execution"}).
+		Step(NewStep("", "log", map[string]any{"message": `connecting with {{ variableGet . "db" "password" }}`})).
+		Build())
+
+	logger := &memoryLogger{}
+
+	_, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"}, WithLogger(logger))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Contains(t, logger.messages, "connecting with "+log.Redacted)
+	assert.Equal(t, "hunter22-execution", log.Redact("hunter22-execution"), "expected the secret to be dropped with the execution")
+}
+
+func TestLogExecutorRedactsFieldSecrets(t *testing.T) {
+	t.Parallel()
+
+	pipelines := NewPipelines(New("main").
+		Set("db", map[string]any{"password": "hunter22-field"}).
+		Step(NewStep("", "log", map[string]any{
+			"message": "connecting",
+			"fields": map[string]any{
+				"dsn":     `postgres://app@db?pass={{ variableGet . "db" "password" }}`,
+				"options": map[string]any{"hosts": []any{`{{ variableGet . "db" "password" }}`}},
+			},
+		})).
+		Build())
+
+	logger := &memoryLogger{}
+
+	_, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"}, WithLogger(logger))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	logged := slices.Index(logger.messages, "connecting")
+	if !assert.GreaterOrEqual(t, logged, 0) {
+		return
+	}
+
+	assert.Contains(t, logger.fields[logged], log.Field{Key: "dsn", Value: "postgres://app@db?pass=" + log.Redacted})
+	assert.Contains(t, logger.fields[logged], log.Field{Key: "options", Value: map[string]any{"hosts": []any{log.Redacted}}})
+}
+
+func TestSetExecutorModes(t *testing.T) {
+	t.Parallel()
+
+	pipelines := NewPipelines(New("main").
+		Set("config", map[string]any{"db": map[string]any{"host": "localhost", "port": 5432}, "tags": []any{"a"}}).
+		Set("config", map[string]any{"$mode": "merge", "db": map[string]any{"host": "db"}, "debug": true}).
+		Set("config", map[string]any{"$mode": "append", "tags": "b", "extra": []any{"c", "d"}}).
+		Build())
+
+	scope, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	config, _ := scope.Variable("config")
+	assert.Equal(t, map[string]any{
+		"db":    map[string]any{"host": "db", "port": 5432},
+		"debug": true,
+		"tags":  []any{"a", "b"},
+		"extra": []any{"c", "d"},
+	}, config)
+
+	failing := NewPipelines(New("main").
+		Set("config", map[string]any{"name": "x"}).
+		Set("config", map[string]any{"$mode": "append", "name": "y"}).
+		Build())
+
+	_, err = failing.Execute(context.Background(), NewScope(failing), []string{"main"})
+	assert.ErrorContains(t, err, "name is not a list")
+}
+
+func TestSetExecutorTypes(t *testing.T) {
+	t.Parallel()
+
+	pipelines := NewPipelines(New("main").
+		Set("typed", map[string]any{
+			"count":     `{{ add 1 2 }}`,
+			"ratio":     `{{ "0.5" }}`,
+			"enabled":   `{{ "true" }}`,
+			"timeout":   `{{ "1m30s" }}`,
+			"payload":   `{{ dict "a" 1 | toJson }}`,
+			"version":   1.2,
+			"$types":    map[string]any{"count": "int", "ratio": "float", "enabled": "bool", "timeout": "duration", "payload": "json", "version": "string"},
+			"untouched": `{{ "7" }}`,
+		}).
+		Build())
+
+	scope, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	typed, _ := scope.Variable("typed")
+	assert.Equal(t, map[string]any{
+		"count":     3,
+		"ratio":     0.5,
+		"enabled":   true,
+		"timeout":   90 * time.Second,
+		"payload":   map[string]any{"a": float64(1)},
+		"version":   "1.2",
+		"untouched": "7",
+	}, typed)
+
+	invalid := NewPipelines(New("main").Set("typed", map[string]any{"count": "x", "$types": map[string]any{"count": "int"}}).Build())
+
+	_, err = invalid.Execute(context.Background(), NewScope(invalid), []string{"main"})
+	assert.ErrorContains(t, err, "cannot convert count to int")
+}
+
+func TestRangeExecutorFilters(t *testing.T) {
+	t.Parallel()
+
+	pipelines := NewPipelines(New("main").
+		Range("item", RangeParams{
+			Items:  []any{1, 2, 3, 4, 5, 6},
+			Where:  `{{ eq (mod (variable . "item") 2) 0 }}`,
+			Offset: "1",
+			Limit:  "1",
+		}, LogStep(`{{ variable . "item" }}`)).
+		Build())
+
+	engine := NewEngine()
+	seen := []any{}
+
+	engine.SetStepInterceptor(func(ctx context.Context, scope Scope, step Step, executor StepExecutor) (Scope, error) {
+		if step.Type == "log" {
+			item, _ := scope.Variable("item")
+			seen = append(seen, item)
+		}
+
+		return executor.Execute(ctx, scope, step)
+	})
+
+	_, err := engine.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, []any{4}, seen)
+}
+
+func TestRangeExecutorNativeJSON(t *testing.T) {
+	t.Parallel()
+
+	greet := SetStep("greeting", map[string]any{"text": `hi {{ variable . "user" }}`})
+	pipelines := NewPipelines(New("main").Range("user", RangeParams{JSON: `{{ variable . "users" }}`, Isolate: "true"}, greet).Build())
+
+	scope, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"},
+		WithVariables(map[VariablePath]any{"users": []any{"bob", "alice"}}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	results, _ := scope.Variable("user.$results")
+	assert.Len(t, results, 2)
+}
+
+func TestStepIf(t *testing.T) {
+	t.Parallel()
+
+	pipelines := NewPipelines(New("main").
+		Set("config", map[string]any{"enabled": false}).
+		Step(SetStep("skipped", map[string]any{"ran": true}).When(`{{ variableGet . "config" "enabled" }}`)).
+		Step(SetStep("executed", map[string]any{"ran": true}).When(`{{ not (variableGet . "config" "enabled") }}`)).
+		Step(SetStep("invalid", map[string]any{"ran": true}).When(`{{ fail "boom" }}`)).
+		Build())
+
+	scope, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+	assert.ErrorContains(t, err, "boom")
+
+	skipped, _ := scope.Variable("skipped.$skipped")
+	assert.Equal(t, true, skipped)
+
+	_, err = scope.Variable("skipped")
+	assert.Error(t, err)
+
+	executed, _ := Get[map[string]any](scope, "executed")
+	assert.Equal(t, map[string]any{"ran": true}, executed)
+
+	_, err = scope.Variable("executed.$skipped")
+	assert.Error(t, err)
+}
+
+func TestStepTimeout(t *testing.T) {
+	t.Parallel()
+
+	slow := NewStep("", "wait", WaitParams{Duration: "1s"})
+
+	pipelines := NewPipelines(
+		New("slow").Step(slow.WithTimeout(10*time.Millisecond)).Build(),
+		New("fast").Step(NewStep("", "wait", WaitParams{Duration: "1ms"}).WithTimeout(time.Second)).Build(),
+		New("retried").Step(slow.WithTimeout(5*time.Millisecond).WithRetry(StepRetry{MaxAttempts: 2})).Build(),
+	)
+
+	start := time.Now()
+
+	_, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"slow"})
+	assert.ErrorIs(t, err, ErrStepTimeout)
+	assert.ErrorContains(t, err, "step timed out after 10ms")
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	_, err = pipelines.Execute(context.Background(), NewScope(pipelines), []string{"fast"})
+	assert.NoError(t, err)
+
+	_, err = pipelines.Execute(context.Background(), NewScope(pipelines), []string{"retried"})
+
+	var stepErr *StepError
+	if assert.ErrorAs(t, err, &stepErr) {
+		assert.ErrorIs(t, err, ErrStepTimeout)
+		assert.Equal(t, 2, stepErr.Attempt)
+	}
+}
+
+func TestSwitchExecutorValue(t *testing.T) {
+	t.Parallel()
+
+	branch := func(name string) Pipeline {
+		return Pipeline{Steps: []Step{SetStep("result", map[string]any{"branch": name})}}
+	}
+
+	execute := func(env string, params SwitchParams) (Scope, error) {
+		pipelines := NewPipelines(New("main").Set("setup", map[string]any{"env": env}).Step(NewStep("", "switch", params)).Build())
+
+		return pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+	}
+
+	params := SwitchParams{
+		Value: `{{ variableGet . "setup" "env" }}`,
+		Cases: []SwitchCase{
+			{Value: "prod", Condition: "false", Pipeline: branch("disabled")},
+			{Value: "prod", Pipeline: branch("prod")},
+			{Value: "staging", Pipeline: branch("staging")},
+		},
+		Default: branch("default"),
+	}
+
+	for env, expected := range map[string]string{"prod": "prod", "staging": "staging", "dev": "default"} {
+		scope, err := execute(env, params)
+		assert.NoError(t, err)
+
+		result, _ := Get[map[string]any](scope, "result")
+		assert.Equal(t, map[string]any{"branch": expected}, result, env)
+	}
+
+	_, err := execute("prod", SwitchParams{Cases: []SwitchCase{{Value: "prod", Pipeline: branch("prod")}}})
+	assert.ErrorContains(t, err, "without a switch value")
+}
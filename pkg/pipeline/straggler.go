@@ -0,0 +1,88 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+)
+
+// Straggler policies of the range items and fanout branches exceeding their item_timeout.
+const (
+	// StragglerCancel fails the step with ErrItemTimeout, canceling the remaining items, the default.
+	StragglerCancel = "cancel"
+	// StragglerContinue drops the item, neither merging its variables nor failing the step, its result status
+	// being BranchStatusTimeout.
+	StragglerContinue = "continue-without"
+	// StragglerRetry executes the item once more on a new branch, eg.: reaching another replica, canceling the
+	// remaining items when it times out again.
+	StragglerRetry = "retry-elsewhere"
+)
+
+// itemTimeout bounds the execution of each range item or fanout branch.
+type itemTimeout struct {
+	duration time.Duration
+	policy   string
+}
+
+// newItemTimeout evaluates the item timeout, zero not bounding the items, and its straggler policy.
+func newItemTimeout(ctx context.Context, scope Scope, timeout expression.Duration, policy expression.String) (itemTimeout, error) {
+	duration, err := timeout.Eval(ctx, scope)
+	if err != nil {
+		return itemTimeout{}, err
+	}
+
+	straggler, err := policy.Eval(ctx, scope)
+	if err != nil {
+		return itemTimeout{}, err
+	}
+
+	switch straggler {
+	case "":
+		straggler = StragglerCancel
+	case StragglerCancel, StragglerContinue, StragglerRetry:
+	default:
+		return itemTimeout{}, fmt.Errorf("unknown straggler policy: %s", straggler)
+	}
+
+	return itemTimeout{duration: duration, policy: straggler}, nil
+}
+
+// execute executes the branch pipeline over a copy of the scope, within the item timeout.
+func (w workerParams) execute(ctx context.Context, scope Scope) (Scope, error) {
+	ctx = log.WithFields(ctx, w.Fields...)
+
+	for attempt := 1; ; attempt++ {
+		branch := scope.Clone().WithVariables(w.Variables)
+
+		if w.timeout.duration <= 0 {
+			return w.Execute(ctx, branch)
+		}
+
+		itemCtx, cancel := context.WithTimeout(ctx, w.timeout.duration)
+		result, err := w.Execute(itemCtx, branch)
+		timedOut := err != nil && errors.Is(itemCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
+
+		cancel()
+
+		if !timedOut {
+			return result, err
+		}
+
+		err = fmt.Errorf("%w after %s: %w", ErrItemTimeout, w.timeout.duration, err)
+
+		if w.timeout.policy != StragglerRetry || attempt > 1 {
+			return result, err
+		}
+
+		log.Log().Warn(ctx, "Item %d: %s, retrying it", w.index, err)
+	}
+}
+
+// straggling reports whether the branch result is dropped by the StragglerContinue policy.
+func (w workerParams) straggling(err error) bool {
+	return w.timeout.policy == StragglerContinue && errors.Is(err, ErrItemTimeout)
+}
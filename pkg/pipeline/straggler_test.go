@@ -0,0 +1,119 @@
+package pipeline
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStragglerPolicies(t *testing.T) {
+	t.Parallel()
+
+	slow := func(delay time.Duration) Pipeline {
+		return New("").Wait(delay).Set("done", map[string]any{"value": true}).Build()
+	}
+
+	ranged := func(policy string) Pipelines {
+		return NewPipelines(New("main").Step(NewStep("range", "range", RangeParams{
+			Items:           []any{"1ms", "10s"},
+			Isolate:         "true",
+			ItemTimeout:     "50ms",
+			StragglerPolicy: expression.String(policy),
+			Pipeline:        New("").Step(NewStep("wait", "wait", WaitParams{Duration: `{{ variable . "range" }}`})).Build(),
+		})).Build())
+	}
+
+	t.Run("cancels the step by default", func(t *testing.T) {
+		t.Parallel()
+
+		pipelines := ranged("")
+
+		_, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+		assert.ErrorIs(t, err, ErrItemTimeout)
+	})
+
+	t.Run("continues without the stragglers", func(t *testing.T) {
+		t.Parallel()
+
+		pipelines := ranged(StragglerContinue)
+
+		scope, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		first, _ := Get[map[string]any](scope, "range.$results.0")
+		assert.Equal(t, BranchStatusSuccess, first["status"])
+
+		straggler, _ := Get[map[string]any](scope, "range.$results.1")
+		assert.Equal(t, BranchStatusTimeout, straggler["status"])
+		assert.Contains(t, straggler["error"], "item timed out after 50ms")
+	})
+
+	t.Run("drops the fanout stragglers variables", func(t *testing.T) {
+		t.Parallel()
+
+		pipelines := NewPipelines(New("main").Step(NewStep("fanout", "fanout", FanoutParams{
+			Pipelines:       []Pipeline{slow(10 * time.Second)},
+			ItemTimeout:     "50ms",
+			StragglerPolicy: StragglerContinue,
+		})).Build())
+
+		scope, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		_, err = scope.Variable("done")
+		assert.ErrorIs(t, err, ErrVariableNotFound)
+
+		straggler, _ := Get[map[string]any](scope, "fanout.$results.0")
+		assert.Equal(t, BranchStatusTimeout, straggler["status"])
+	})
+
+	t.Run("retries the stragglers once", func(t *testing.T) {
+		t.Parallel()
+
+		var attempts atomic.Int32
+
+		engine := NewEngine()
+		engine.RegisterStepExecutor("flaky", FuncExecutor(func(ctx context.Context, _ struct{}) (bool, error) {
+			if attempts.Add(1) > 1 {
+				return true, nil
+			}
+
+			<-ctx.Done()
+
+			return false, ctx.Err()
+		}))
+
+		pipelines := NewPipelines(New("main").Step(NewStep("fanout", "fanout", FanoutParams{
+			Pipelines:       []Pipeline{New("").Step(NewStep("flaky", "flaky", map[string]any{})).Build()},
+			ItemTimeout:     "50ms",
+			StragglerPolicy: StragglerRetry,
+		})).Build())
+
+		scope, err := engine.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, int32(2), attempts.Load())
+
+		flaky, _ := scope.Variable("flaky")
+		assert.Equal(t, true, flaky)
+	})
+
+	t.Run("fails on unknown policies", func(t *testing.T) {
+		t.Parallel()
+
+		pipelines := ranged("wait-forever")
+
+		_, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+		assert.ErrorContains(t, err, "unknown straggler policy: wait-forever")
+	})
+}
@@ -1,16 +1,36 @@
 package pipeline
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 	"text/template"
 
 	"github.com/PaesslerAG/jsonpath"
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
 )
 
+// RegisterAPIVersion registers the template functions of an apiVersion in the default engine, see Engine.RegisterAPIVersion.
+func RegisterAPIVersion(apiVersion string, t *expression.Template) {
+	defaultEngine.RegisterAPIVersion(apiVersion, t)
+}
+
+// Eval evaluates the expression over the scope with the template functions of the default engine, see Engine.Eval.
+func Eval(ctx context.Context, scope Scope, expr string) (string, error) {
+	return defaultEngine.Eval(ctx, scope, expr)
+}
+
+// Eval evaluates the expression over the scope with the engine template functions, outside executions, so
+// expressions can be tried out without running the pipelines, eg.: against a scope fixture.
+func (e *Engine) Eval(ctx context.Context, scope Scope, expr string) (string, error) {
+	return expression.String(expr).Eval(e.context(ctx), scope)
+}
+
 var templateFuncs = template.FuncMap{
 	"variable": func(ctx Scope, path VariablePath) (any, error) {
 		result, err := ctx.Variable(path)
@@ -39,6 +59,95 @@ var templateFuncs = template.FuncMap{
 
 		return nil, errors.New("expected a map[string]any or map[string]string")
 	},
+	"variables": func(ctx Scope, pattern string) []string {
+		matches := ctx.Match(pattern)
+
+		paths := make([]string, len(matches))
+		for i, match := range matches {
+			paths[i] = string(match)
+		}
+
+		return paths
+	},
+	"variablesDump": func(ctx Scope, pattern string) (string, error) {
+		dump := map[string]any{}
+
+		for _, match := range ctx.Match(pattern) {
+			value, err := ctx.Variable(match)
+			if err != nil {
+				return "", err
+			}
+
+			dump[string(match)] = redactValue(string(match), value)
+		}
+
+		blob, err := json.MarshalIndent(dump, "", "  ")
+		if err != nil {
+			return "", err
+		}
+
+		return ctx.redact(string(blob)), nil
+	},
+	expression.ConditionFunc: func(ctx Scope, spec string) (bool, error) {
+		condition, err := expression.ParseCondition(spec)
+		if err != nil {
+			return false, err
+		}
+
+		return condition.Eval(func(path string) (any, bool) {
+			value, err := ctx.Variable(VariablePath(path))
+
+			return value, err == nil
+		})
+	},
+	"workspace": func(ctx Scope, elems ...string) (string, error) {
+		if ctx.execution == nil {
+			return "", errOutsideExecution
+		}
+
+		return ctx.execution.workspace.path(elems...)
+	},
+	"budget": func(ctx Scope, name string) (float64, error) {
+		if ctx.execution == nil {
+			return 0, errOutsideExecution
+		}
+
+		remaining, found := ctx.execution.budgets.get(name)
+		if !found {
+			return 0, fmt.Errorf("budget %s is not set", name)
+		}
+
+		return remaining, nil
+	},
+	"fileRead": func(ctx Scope, name string) (string, error) {
+		if ctx.execution == nil {
+			return "", errOutsideExecution
+		}
+
+		return ctx.execution.sandbox.read(name)
+	},
+	"fileExists": func(ctx Scope, name string) (bool, error) {
+		if ctx.execution == nil {
+			return false, errOutsideExecution
+		}
+
+		return ctx.execution.sandbox.exists(name)
+	},
+	"dirList": func(ctx Scope, name string) ([]string, error) {
+		if ctx.execution == nil {
+			return nil, errOutsideExecution
+		}
+
+		return ctx.execution.sandbox.list(name)
+	},
+	"cacheGet":      cacheGet,
+	"cacheSet":      cacheSet,
+	"randomInt":     randomInt,
+	"randomItem":    randomItem,
+	"randomShuffle": randomShuffle,
+	"pathJoin":      pathJoin,
+	"queryString":   queryString,
+	"uriTemplate":   uriTemplate,
 	"jsonPath": func(path string, data string) (any, error) {
 		var src any
 		err := json.Unmarshal([]byte(data), &src)
@@ -58,7 +167,12 @@ var templateFuncs = template.FuncMap{
 
 		return true, nil
 	},
-	"read": func(reader io.Reader) (string, error) {
+	"read": func(value any) (string, error) {
+		reader, err := openReader(value)
+		if err != nil {
+			return "", err
+		}
+
 		defer func() {
 			if r, ok := reader.(io.Closer); ok {
 				_ = r.Close()
@@ -81,3 +195,80 @@ var templateFuncs = template.FuncMap{
 		return value, nil
 	},
 }
+
+// openReader returns the reader of a value which is either an io.Reader or a replayable handle, eg.: an http step body.
+func openReader(value any) (io.Reader, error) {
+	switch typed := value.(type) {
+	case interface{ Open() (io.ReadCloser, error) }:
+		return typed.Open()
+	case io.Reader:
+		return typed, nil
+	}
+
+	return nil, fmt.Errorf("expected an io.Reader, got %T", value)
+}
+
+// redactValue masks the values under secret-like keys, and the values which can't be encoded as JSON by their type.
+func redactValue(key string, value any) any {
+	if index := strings.LastIndex(key, "."); index >= 0 {
+		key = key[index+1:]
+	}
+
+	if log.IsSecretKey(key) {
+		return log.Redacted
+	}
+
+	switch typed := value.(type) {
+	case map[string]any:
+		redacted := make(map[string]any, len(typed))
+		for k, v := range typed {
+			redacted[k] = redactValue(k, v)
+		}
+
+		return redacted
+	case map[string]string:
+		redacted := make(map[string]any, len(typed))
+		for k, v := range typed {
+			redacted[k] = redactValue(k, v)
+		}
+
+		return redacted
+	case []any:
+		redacted := make([]any, len(typed))
+		for i, v := range typed {
+			redacted[i] = redactValue("", v)
+		}
+
+		return redacted
+	}
+
+	if _, err := json.Marshal(value); err != nil {
+		return fmt.Sprintf("<%T>", value)
+	}
+
+	return value
+}
+
+// redactSecrets masks the secrets of the context in the strings of a value redacted by redactValue, see log.RedactContext.
+func redactSecrets(ctx context.Context, value any) any {
+	switch typed := value.(type) {
+	case string:
+		return log.RedactContext(ctx, typed)
+	case map[string]any:
+		redacted := make(map[string]any, len(typed))
+		for k, v := range typed {
+			redacted[k] = redactSecrets(ctx, v)
+		}
+
+		return redacted
+	case []any:
+		redacted := make([]any, len(typed))
+		for i, v := range typed {
+			redacted[i] = redactSecrets(ctx, v)
+		}
+
+		return redacted
+	}
+
+	return value
+}
@@ -0,0 +1,37 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVariablesFuncs(t *testing.T) {
+	t.Parallel()
+
+	scope := NewScope(Pipelines{}).
+		WithVariable("users", []any{"bob"}).
+		WithVariable("users.$index", 0).
+		WithVariable("userspace", "ignored").
+		WithNamespace("child").
+		WithVariable("db", map[string]any{"host": "localhost", "password": "hunter22"})
+
+	tests := []struct {
+		expression string
+		expected   string
+	}{
+		{expression: `{{ variables . "users" | join "," }}`, expected: "users,users.$index"},
+		{expression: `{{ variables . "users*" | join "," }}`, expected: "users,users.$index,userspace"},
+		{expression: `{{ variables . "d?" | join "," }}`, expected: "db"},
+		{expression: `{{ variablesDump . "db" }}`, expected: "{\n  \"db\": {\n    \"host\": \"localhost\",\n    \"password\": \"[REDACTED]\"\n  }\n}"},
+	}
+
+	for _, tc := range tests {
+		value, err := expression.String(tc.expression).Eval(context.Background(), scope)
+		if assert.NoError(t, err, tc.expression) {
+			assert.Equal(t, tc.expected, value, tc.expression)
+		}
+	}
+}
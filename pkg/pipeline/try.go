@@ -0,0 +1,96 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+)
+
+// PathNodeErrorStep is the step path node holding the ID of the failed step caught by a try step.
+const PathNodeErrorStep VariablePathNode = "$error_step"
+
+// TryParams defines the parameters for the TryExecutor.
+type TryParams struct {
+	Pipeline `yaml:",inline"`
+	Catch    *Pipeline `yaml:"catch"`
+	Finally  *Pipeline `yaml:"finally"`
+}
+
+// TryExecutor executes the steps, recovering from their errors with the catch pipeline and always
+// executing the finally pipeline afterwards, even when the steps fail or stop the pipeline.
+// The caught error message and the ID of the failed step are set in the step $error and $error_step paths.
+// Errors of the catch pipeline fail the step, as the errors of the steps do without a catch pipeline.
+// Panics failing the pipelines aren't caught, see PanicFailPipeline.
+// Example YAML:
+//
+//	id: try-example
+//	steps:
+//	- id: deploy
+//	  type: try
+//	  params:
+//	    steps:
+//	    - id: apply
+//	      type: command
+//	      params:
+//	        name: 'kubectl'
+//	        args: ['apply', '-f', 'manifest.yaml']
+//	    catch:
+//	      steps:
+//	      - type: log
+//	        params:
+//	          level: 'error'
+//	          message: '{{ variable . "deploy.$error_step" }} failed: {{ variable . "deploy.$error" }}'
+//	    finally:
+//	      steps:
+//	      - type: log
+//	        params:
+//	          message: 'deploy finished'
+func TryExecutor(ctx context.Context, scope Scope, step Step, params TryParams) (Scope, error) {
+	scope, err := params.Execute(ctx, scope)
+
+	if err != nil && params.Catch != nil && ctx.Err() == nil && !fatalPanic(err) {
+		message, stepID := caught(err)
+
+		// recovering from a stop step with is_error resumes the pipeline.
+		scope.Finished, scope.stopScope = false, ""
+		scope = scope.WithVariables(map[VariablePath]any{
+			step.VariablePath(PathNodeError):     message,
+			step.VariablePath(PathNodeErrorStep): string(stepID),
+		})
+
+		scope, err = params.Catch.Execute(ctx, scope)
+	}
+
+	if params.Finally == nil {
+		return scope, err
+	}
+
+	finished, stopScope := scope.Finished, scope.stopScope
+	scope.Finished, scope.stopScope = false, ""
+
+	scope, finallyErr := params.Finally.Execute(ctx, scope)
+
+	if !scope.Finished {
+		scope.Finished, scope.stopScope = finished, stopScope
+	}
+
+	return scope, errors.Join(err, finallyErr)
+}
+
+// caught returns the message of the innermost failed step error, or of the stop, and its step ID.
+func caught(err error) (string, VariablePathNode) {
+	var (
+		stepErr *StepError
+		stopErr *StopError
+		stepID  VariablePathNode
+	)
+
+	for errors.As(err, &stepErr) {
+		stepID, err = stepErr.StepID, stepErr.Err
+	}
+
+	if errors.As(err, &stopErr) {
+		return stopErr.Message, stepID
+	}
+
+	return err.Error(), stepID
+}
@@ -0,0 +1,85 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTryExecutor(t *testing.T) {
+	t.Parallel()
+
+	fail := NewStep("check", "stop", StopParams{Condition: "true", Message: "boom", IsError: "true"})
+	finally := New("").Set("cleanup", map[string]any{"done": true}).Build()
+
+	tests := []struct {
+		name     string
+		params   TryParams
+		err      string
+		caught   bool
+		finished bool
+	}{
+		{
+			name: "catches the error",
+			params: TryParams{
+				Pipeline: New("").Step(fail).Build(),
+				Catch:    &Pipeline{Steps: []Step{SetStep("recovered", map[string]any{"ok": true})}},
+				Finally:  &finally,
+			},
+			caught: true,
+		},
+		{
+			name:     "fails without catch",
+			params:   TryParams{Pipeline: New("").Step(fail).Build(), Finally: &finally},
+			err:      "boom",
+			finished: true,
+		},
+		{
+			name: "fails when catch fails",
+			params: TryParams{
+				Pipeline: New("").Step(fail).Build(),
+				Catch:    &Pipeline{Steps: []Step{NewStep("", "stop", StopParams{Condition: "true", Message: "rethrown", IsError: "true"})}},
+				Finally:  &finally,
+			},
+			err:      "rethrown",
+			finished: true,
+		},
+		{
+			name:     "runs finally after a stop",
+			params:   TryParams{Pipeline: New("").Stop(StopParams{Condition: "true", Scope: "execution"}).Build(), Finally: &finally},
+			finished: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			pipelines := NewPipelines(New("main").Step(NewStep("deploy", "try", tc.params)).Build())
+
+			scope, err := pipelines.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			cleanup, _ := Get[map[string]any](scope, "cleanup")
+			assert.Equal(t, map[string]any{"done": true}, cleanup)
+			assert.Equal(t, tc.finished, scope.Finished)
+
+			if !tc.caught {
+				return
+			}
+
+			message, _ := scope.Variable("deploy.$error")
+			stepID, _ := scope.Variable("deploy.$error_step")
+			assert.Equal(t, "boom", message)
+			assert.Equal(t, "check", stepID)
+
+			recovered, _ := Get[map[string]any](scope, "recovered")
+			assert.Equal(t, map[string]any{"ok": true}, recovered)
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package pipeline
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// pathJoin joins the base, eg.: an URL, with the escaped path segments, skipping the empty ones.
+func pathJoin(base string, segments ...any) string {
+	path := strings.TrimRight(base, "/")
+
+	for _, segment := range segments {
+		if escaped := url.PathEscape(fmt.Sprint(segment)); escaped != "" {
+			path += "/" + escaped
+		}
+	}
+
+	return path
+}
+
+// queryString encodes the map as a query string sorted by key, repeating the keys of list values
+// and skipping the nil ones.
+func queryString(values any) (string, error) {
+	m := reflect.ValueOf(values)
+	if m.Kind() != reflect.Map || m.Type().Key().Kind() != reflect.String {
+		return "", fmt.Errorf("expected a map with string keys, got %T", values)
+	}
+
+	query := url.Values{}
+
+	for iter := m.MapRange(); iter.Next(); {
+		key := iter.Key().String()
+
+		value := iter.Value()
+		if value.Kind() == reflect.Interface {
+			value = value.Elem()
+		}
+
+		if !value.IsValid() {
+			continue
+		}
+
+		switch value.Kind() {
+		case reflect.Slice, reflect.Array:
+			for i := range value.Len() {
+				query.Add(key, fmt.Sprint(value.Index(i).Interface()))
+			}
+		default:
+			query.Add(key, fmt.Sprint(value.Interface()))
+		}
+	}
+
+	return query.Encode(), nil
+}
+
+// uriOperator is an expression operator of RFC 6570, see uriTemplate.
+type uriOperator struct {
+	first    string
+	sep      string
+	named    bool
+	ifEmpty  string
+	reserved bool
+}
+
+var uriOperators = map[byte]uriOperator{
+	'+': {sep: ",", reserved: true},
+	'#': {first: "#", sep: ",", reserved: true},
+	'.': {first: ".", sep: "."},
+	'/': {first: "/", sep: "/"},
+	';': {first: ";", sep: ";", named: true},
+	'?': {first: "?", sep: "&", named: true, ifEmpty: "="},
+	'&': {first: "&", sep: "&", named: true, ifEmpty: "="},
+}
+
+// uriTemplate expands the URI template with the values by name, following RFC 6570 up to level 4,
+// eg.: "/users/{id}{?fields*}". Lists and maps are expanded by their items, maps sorted by key.
+func uriTemplate(template string, values any) (string, error) {
+	vars := reflect.ValueOf(values)
+	if values != nil && (vars.Kind() != reflect.Map || vars.Type().Key().Kind() != reflect.String) {
+		return "", fmt.Errorf("expected a map with string keys, got %T", values)
+	}
+
+	var expanded strings.Builder
+
+	for template != "" {
+		start := strings.IndexByte(template, '{')
+		if start < 0 {
+			expanded.WriteString(uriEncode(template, true))
+
+			break
+		}
+
+		end := strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unclosed uri template expression at %d", start)
+		}
+
+		expanded.WriteString(uriEncode(template[:start], true))
+
+		if err := uriExpand(&expanded, template[start+1:start+end], vars); err != nil {
+			return "", err
+		}
+
+		template = template[start+end+1:]
+	}
+
+	return expanded.String(), nil
+}
+
+// uriExpand writes the expansion of the expression, without braces, eg.: "?fields*,page".
+func uriExpand(expanded *strings.Builder, expression string, vars reflect.Value) error {
+	op := uriOperator{sep: ","}
+
+	if expression != "" {
+		if operator, found := uriOperators[expression[0]]; found {
+			op, expression = operator, expression[1:]
+		} else if strings.ContainsRune("=,!@|", rune(expression[0])) {
+			return fmt.Errorf("reserved uri template operator %q", expression[0])
+		}
+	}
+
+	defined := false
+
+	for _, spec := range strings.Split(expression, ",") {
+		name, explode := strings.CutSuffix(spec, "*")
+
+		prefix := 0
+		if n, length, found := strings.Cut(name, ":"); found {
+			var err error
+			if prefix, err = strconv.Atoi(length); err != nil || prefix <= 0 || prefix >= 10000 {
+				return fmt.Errorf("invalid uri template prefix %q", spec)
+			}
+
+			name = n
+		}
+
+		if name == "" {
+			return fmt.Errorf("invalid uri template expression %q", expression)
+		}
+
+		value, ok := uriValue(vars, name)
+		if !ok {
+			continue
+		}
+
+		if defined {
+			expanded.WriteString(op.sep)
+		} else {
+			expanded.WriteString(op.first)
+			defined = true
+		}
+
+		op.expand(expanded, name, value, explode, prefix)
+	}
+
+	return nil
+}
+
+// uriValue returns the value of the variable as a string, a list of strings or a sorted list of key value pairs,
+// and whether it's defined: nil values and empty lists and maps are undefined.
+func uriValue(vars reflect.Value, name string) (any, bool) {
+	if !vars.IsValid() {
+		return nil, false
+	}
+
+	value := vars.MapIndex(reflect.ValueOf(name).Convert(vars.Type().Key()))
+	for value.IsValid() && (value.Kind() == reflect.Interface || value.Kind() == reflect.Pointer) {
+		if value.IsNil() {
+			return nil, false
+		}
+
+		value = value.Elem()
+	}
+
+	switch {
+	case !value.IsValid():
+		return nil, false
+	case value.Kind() == reflect.Slice || value.Kind() == reflect.Array:
+		items := make([]string, value.Len())
+		for i := range items {
+			items[i] = fmt.Sprint(value.Index(i).Interface())
+		}
+
+		return items, len(items) > 0
+	case value.Kind() == reflect.Map:
+		pairs := make([][2]string, 0, value.Len())
+		for iter := value.MapRange(); iter.Next(); {
+			pairs = append(pairs, [2]string{fmt.Sprint(iter.Key().Interface()), fmt.Sprint(iter.Value().Interface())})
+		}
+
+		slices.SortFunc(pairs, func(a, b [2]string) int { return strings.Compare(a[0], b[0]) })
+
+		return pairs, len(pairs) > 0
+	}
+
+	return fmt.Sprint(value.Interface()), true
+}
+
+// expand writes the defined value of the variable, see RFC 6570 appendix A.
+func (op uriOperator) expand(expanded *strings.Builder, name string, value any, explode bool, prefix int) {
+	named := func(name, value string) {
+		expanded.WriteString(uriEncode(name, op.reserved))
+
+		if value == "" {
+			expanded.WriteString(op.ifEmpty)
+		} else {
+			expanded.WriteString("=" + uriEncode(value, op.reserved))
+		}
+	}
+
+	switch value := value.(type) {
+	case string:
+		if prefix > 0 && utf8.RuneCountInString(value) > prefix {
+			value = string([]rune(value)[:prefix])
+		}
+
+		if op.named {
+			named(name, value)
+		} else {
+			expanded.WriteString(uriEncode(value, op.reserved))
+		}
+	case []string:
+		encoded := make([]string, len(value))
+		for i, item := range value {
+			encoded[i] = uriEncode(item, op.reserved)
+		}
+
+		switch {
+		case !explode:
+			if op.named {
+				expanded.WriteString(uriEncode(name, op.reserved) + "=")
+			}
+
+			expanded.WriteString(strings.Join(encoded, ","))
+		case op.named:
+			for i, item := range value {
+				if i > 0 {
+					expanded.WriteString(op.sep)
+				}
+
+				named(name, item)
+			}
+		default:
+			expanded.WriteString(strings.Join(encoded, op.sep))
+		}
+	case [][2]string:
+		if !explode {
+			if op.named {
+				expanded.WriteString(uriEncode(name, op.reserved) + "=")
+			}
+
+			for i, pair := range value {
+				if i > 0 {
+					expanded.WriteString(",")
+				}
+
+				expanded.WriteString(uriEncode(pair[0], op.reserved) + "," + uriEncode(pair[1], op.reserved))
+			}
+
+			return
+		}
+
+		for i, pair := range value {
+			if i > 0 {
+				expanded.WriteString(op.sep)
+			}
+
+			if op.named {
+				named(pair[0], pair[1])
+			} else {
+				expanded.WriteString(uriEncode(pair[0], op.reserved) + "=" + uriEncode(pair[1], op.reserved))
+			}
+		}
+	}
+}
+
+// uriEncode percent-encodes the value but its unreserved characters and, when reserved,
+// its reserved characters and percent-encoded triplets.
+func uriEncode(value string, reserved bool) string {
+	var encoded strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', strings.IndexByte("-._~", c) >= 0:
+			encoded.WriteByte(c)
+		case reserved && strings.IndexByte(":/?#[]@!$&'()*+,;=", c) >= 0:
+			encoded.WriteByte(c)
+		case reserved && c == '%' && i+2 < len(value) && isHex(value[i+1]) && isHex(value[i+2]):
+			encoded.WriteString(value[i : i+3])
+			i += 2
+		default:
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		}
+	}
+
+	return encoded.String()
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
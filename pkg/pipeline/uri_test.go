@@ -0,0 +1,120 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURIFuncs(t *testing.T) {
+	t.Parallel()
+
+	scope := NewScope(Pipelines{}).
+		WithVariable("id", "a/b c").
+		WithVariable("filters", map[string]any{"tag": []any{"x", "y"}, "q": "a&b", "empty": nil})
+
+	tests := []struct {
+		expression string
+		expected   string
+	}{
+		{expression: `{{ pathJoin "https://api.example.com/" "users" (variable . "id") "" 42 }}`, expected: "https://api.example.com/users/a%2Fb%20c/42"},
+		{expression: `{{ queryString (variable . "filters") }}`, expected: "q=a%26b&tag=x&tag=y"},
+		{expression: `{{ uriTemplate "/users/{id}{?q}" (dict "id" (variable . "id") "q" "ok") }}`, expected: "/users/a%2Fb%20c?q=ok"},
+	}
+
+	for _, tc := range tests {
+		value, err := expression.String(tc.expression).Eval(context.Background(), scope)
+		if assert.NoError(t, err, tc.expression) {
+			assert.Equal(t, tc.expected, value, tc.expression)
+		}
+	}
+}
+
+func TestURITemplate(t *testing.T) {
+	t.Parallel()
+
+	// Examples of RFC 6570, section 3.2.
+	values := map[string]any{
+		"count": []any{"one", "two", "three"},
+		"dom":   []string{"example", "com"},
+		"dub":   "me/too",
+		"hello": "Hello World!",
+		"half":  "50%",
+		"var":   "value",
+		"who":   "fred",
+		"base":  "http://example.com/home/",
+		"path":  "/foo/bar",
+		"list":  []any{"red", "green", "blue"},
+		"keys":  map[string]any{"semi": ";", "dot": ".", "comma": ","},
+		"v":     6,
+		"x":     1024,
+		"y":     768,
+		"empty": "",
+		"undef": nil,
+	}
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{template: "{var}", expected: "value"},
+		{template: "{hello}", expected: "Hello%20World%21"},
+		{template: "{half}", expected: "50%25"},
+		{template: "O{empty}X", expected: "OX"},
+		{template: "O{undef}X", expected: "OX"},
+		{template: "{x,y}", expected: "1024,768"},
+		{template: "{x,hello,y}", expected: "1024,Hello%20World%21,768"},
+		{template: "?{x,empty}", expected: "?1024,"},
+		{template: "?{x,undef}", expected: "?1024"},
+		{template: "{var:3}", expected: "val"},
+		{template: "{list}", expected: "red,green,blue"},
+		{template: "{list*}", expected: "red,green,blue"},
+		{template: "{keys}", expected: "comma,%2C,dot,.,semi,%3B"},
+		{template: "{keys*}", expected: "comma=%2C,dot=.,semi=%3B"},
+		{template: "{+var}", expected: "value"},
+		{template: "{+hello}", expected: "Hello%20World!"},
+		{template: "{+half}", expected: "50%25"},
+		{template: "{base}index", expected: "http%3A%2F%2Fexample.com%2Fhome%2Findex"},
+		{template: "{+base}index", expected: "http://example.com/home/index"},
+		{template: "{+path}/here", expected: "/foo/bar/here"},
+		{template: "{+path:6}/here", expected: "/foo/b/here"},
+		{template: "{+keys*}", expected: "comma=,,dot=.,semi=;"},
+		{template: "{#var}", expected: "#value"},
+		{template: "{#hello}", expected: "#Hello%20World!"},
+		{template: "{#list*}", expected: "#red,green,blue"},
+		{template: "X{.var}", expected: "X.value"},
+		{template: "X{.x,y}", expected: "X.1024.768"},
+		{template: "www{.dom*}", expected: "www.example.com"},
+		{template: "X{.list*}", expected: "X.red.green.blue"},
+		{template: "{/var}", expected: "/value"},
+		{template: "{/var,x}/here", expected: "/value/1024/here"},
+		{template: "{/list*,path:4}", expected: "/red/green/blue/%2Ffoo"},
+		{template: "{/keys*}", expected: "/comma=%2C/dot=./semi=%3B"},
+		{template: "{;x,y}", expected: ";x=1024;y=768"},
+		{template: "{;x,y,empty}", expected: ";x=1024;y=768;empty"},
+		{template: "{;list*}", expected: ";list=red;list=green;list=blue"},
+		{template: "{;keys*}", expected: ";comma=%2C;dot=.;semi=%3B"},
+		{template: "{?x,y,empty}", expected: "?x=1024&y=768&empty="},
+		{template: "{?var:3}", expected: "?var=val"},
+		{template: "{?list}", expected: "?list=red,green,blue"},
+		{template: "{?list*}", expected: "?list=red&list=green&list=blue"},
+		{template: "{?keys*}", expected: "?comma=%2C&dot=.&semi=%3B"},
+		{template: "?fixed=yes{&x}", expected: "?fixed=yes&x=1024"},
+		{template: "{&list*}", expected: "&list=red&list=green&list=blue"},
+		{template: "{/who,dub}{?v}", expected: "/fred/me%2Ftoo?v=6"},
+	}
+
+	for _, tc := range tests {
+		expanded, err := uriTemplate(tc.template, values)
+		if assert.NoError(t, err, tc.template) {
+			assert.Equal(t, tc.expected, expanded, tc.template)
+		}
+	}
+
+	for _, template := range []string{"{var", "{=var}", "{var:0}", "{,}"} {
+		_, err := uriTemplate(template, values)
+		assert.Error(t, err, template)
+	}
+}
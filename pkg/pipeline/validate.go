@@ -0,0 +1,170 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+)
+
+// Issue is a problem found in a pipeline definition, see Validate and Pipelines.Lint.
+type Issue struct {
+	Pipeline string
+	// Step is the step the issue was found in, if any.
+	Step    VariablePathNode
+	Message string
+}
+
+func (i Issue) String() string {
+	if i.Step == "" {
+		return fmt.Sprintf("%s: %s", i.Pipeline, i.Message)
+	}
+
+	return fmt.Sprintf("%s: step %s: %s", i.Pipeline, i.Step, i.Message)
+}
+
+// ParamsValidator is implemented by the params of typed step executors checking them before the execution,
+// eg.: that the required params are set, see Validate.
+type ParamsValidator interface {
+	Validate() error
+}
+
+// paramsValidator is implemented by the step executors decoding and validating the step params, see TypedStepExecutor.
+type paramsValidator interface {
+	validateParams(step Step) error
+}
+
+// Validate checks the pipelines with the step executors and template functions of the default engine,
+// see Engine.Validate.
+func Validate(pipelines Pipelines) []Issue {
+	return defaultEngine.Validate(pipelines)
+}
+
+// Validate checks the pipelines can be executed by the engine, sorted by pipeline, so definitions can be
+// verified before executing them. The issues are:
+//   - steps, nested or not, whose types have no executor registered;
+//   - pipelines used or called with literal names that are not found;
+//   - expressions that can't be parsed, eg.: unclosed actions or unknown functions;
+//   - step params their typed executors can't decode or that fail their ParamsValidator, eg.: missing ones;
+//   - pipelines sharing the same ID, and so the same variables namespace.
+//
+// Unlike the Pipelines.Lint issues, these fail the executions.
+func (e *Engine) Validate(pipelines Pipelines) []Issue {
+	var issues []Issue
+
+	ids := map[string]string{}
+
+	for _, name := range pipelines.Names() {
+		pipe := pipelines.pipelines[name]
+
+		if other, found := ids[pipe.ID]; found && pipe.ID != "" {
+			issues = append(issues, Issue{Pipeline: name, Message: fmt.Sprintf("id %s is also used by pipeline %s", pipe.ID, other)})
+		} else if pipe.ID != "" {
+			ids[pipe.ID] = name
+		}
+
+		issues = append(issues, e.validate(pipelines, pipe)...)
+	}
+
+	return issues
+}
+
+func (e *Engine) validate(pipelines Pipelines, pipe Pipeline) []Issue {
+	var issues []Issue
+
+	issue := func(step VariablePathNode, format string, args ...any) {
+		issues = append(issues, Issue{Pipeline: pipe.Name, Step: step, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, used := range lo.Uniq(pipe.references(true)) {
+		if _, found := pipelines.Get(used); !found && used != "" {
+			issue("", "pipeline %s is not found", used)
+		}
+	}
+
+	ctx := e.context(context.Background())
+
+	if pipe.APIVersion != "" {
+		t, found := e.apiVersion(pipe.APIVersion)
+		if !found {
+			issue("", "%s: %s", ErrUnknownAPIVersion, pipe.APIVersion)
+		} else {
+			ctx = expression.WithTemplate(ctx, t)
+		}
+	}
+
+	for _, step := range pipe.Steps {
+		e.validateStep(ctx, step, "", issue)
+	}
+
+	return issues
+}
+
+// validateStep reports the step issues under its ID, or the ID of the step nesting it when it has none.
+func (e *Engine) validateStep(ctx context.Context, step Step, parent VariablePathNode, issue func(VariablePathNode, string, ...any)) {
+	id := lo.CoalesceOrEmpty(step.ID, parent)
+
+	executor, found := e.LookupStepExecutor(step.Type)
+	if !found {
+		issue(id, "unknown step type %q", step.Type)
+	} else if validator, ok := executor.(paramsValidator); ok {
+		if err := validator.validateParams(step); err != nil {
+			issue(id, "invalid %s params: %s", step.Type, err)
+		}
+	}
+
+	for _, field := range []string{string(step.If), string(step.Timeout)} {
+		if err := expression.String(field).Validate(ctx); err != nil {
+			issue(id, "invalid expression: %s", err)
+		}
+	}
+
+	e.validateParams(ctx, step.Params, id, issue)
+}
+
+// validateParams parses the expressions of the params, validating the steps nested in their steps lists.
+func (e *Engine) validateParams(ctx context.Context, value any, id VariablePathNode, issue func(VariablePathNode, string, ...any)) {
+	switch typed := value.(type) {
+	case string:
+		if err := expression.String(typed).Validate(ctx); err != nil {
+			issue(id, "invalid expression: %s", err)
+		}
+	case map[string]any:
+		for _, key := range slices.Sorted(maps.Keys(typed)) {
+			steps, ok := typed[key].([]any)
+			if key != "steps" || !ok {
+				e.validateParams(ctx, typed[key], id, issue)
+
+				continue
+			}
+
+			for _, item := range steps {
+				var nested Step
+				if err := decodeStep(item, &nested); err != nil {
+					issue(id, "invalid nested step: %s", err)
+
+					continue
+				}
+
+				e.validateStep(ctx, nested, id, issue)
+			}
+		}
+	case []any:
+		for _, item := range typed {
+			e.validateParams(ctx, item, id, issue)
+		}
+	}
+}
+
+func decodeStep(value any, step *Step) error {
+	blob, err := yaml.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return yaml.Unmarshal(blob, step)
+}
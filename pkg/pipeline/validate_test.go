@@ -0,0 +1,67 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	var deploy Pipeline
+
+	err := yaml.Unmarshal([]byte(`
+name: deploy
+id: release
+uses: base
+steps:
+- id: plan
+  type: set
+  params:
+    target: '{{ variable . "env" '
+- id: apply
+  type: try
+  params:
+    steps:
+    - id: rollout
+      type: rollout
+    - type: call
+      params:
+        with:
+          env: '{{ upper "staging" }}'
+    catch:
+      steps:
+      - id: alert
+        type: log
+        if: '{{ unknownFunc }}'
+        params:
+          message: 'failed'
+- id: notify
+  type: call
+  params:
+    pipeline: 'notify'
+- id: cached
+  type: cache
+`), &deploy)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	pipelines := NewPipelines(deploy, New("rollback").ID("release").Log("rolling back").Build())
+
+	assert.Equal(t, []Issue{
+		{Pipeline: "deploy", Message: "pipeline base is not found"},
+		{Pipeline: "deploy", Message: "pipeline notify is not found"},
+		{Pipeline: "deploy", Step: "plan", Message: `invalid expression: template: :1: unclosed action`},
+		{Pipeline: "deploy", Step: "alert", Message: `invalid expression: template: :1: function "unknownFunc" not defined`},
+		{Pipeline: "deploy", Step: "rollout", Message: `unknown step type "rollout"`},
+		{Pipeline: "deploy", Step: "apply", Message: "invalid call params: call requires a pipeline"},
+		{Pipeline: "deploy", Step: "cached", Message: "invalid cache params: cache key is required"},
+		{Pipeline: "rollback", Message: "id release is also used by pipeline deploy"},
+	}, Validate(pipelines))
+
+	valid := NewPipelines(New("greet").Set("greeting", map[string]any{"text": `{{ "hello" | upper }}`}).Build())
+	assert.Empty(t, NewEngine().Validate(valid))
+}
@@ -0,0 +1,95 @@
+package pipeline
+
+import (
+	"iter"
+	"maps"
+)
+
+// variableMap is the persistent map of the scope variables, shared by the scopes derived from each other instead
+// of being copied on each set or clone. Each layer overrides the variables of its parent and is never modified
+// once created. Setting variables adds a layer, merging into it the smaller layers below, like a binary counter,
+// so sets copy O(log n) variables on average and lookups walk O(log n) layers.
+// The nil map is the empty one.
+type variableMap struct {
+	parent *variableMap
+	local  map[VariablePath]any
+	size   int
+}
+
+// get returns the variable of the path.
+func (m *variableMap) get(path VariablePath) (any, bool) {
+	for layer := m; layer != nil; layer = layer.parent {
+		if item, found := layer.local[path]; found {
+			return item, true
+		}
+	}
+
+	return nil, false
+}
+
+// len returns the number of variables.
+func (m *variableMap) len() int {
+	if m == nil {
+		return 0
+	}
+
+	return m.size
+}
+
+// with returns the map with the items set over its variables, leaving it unchanged.
+func (m *variableMap) with(items map[VariablePath]any) *variableMap {
+	if len(items) == 0 {
+		return m
+	}
+
+	size := m.len()
+
+	for path := range items {
+		if _, found := m.get(path); !found {
+			size++
+		}
+	}
+
+	local, parent := maps.Clone(items), m
+
+	for parent != nil && len(parent.local) <= len(local) {
+		merged := maps.Clone(parent.local)
+		maps.Copy(merged, local)
+
+		local, parent = merged, parent.parent
+	}
+
+	return &variableMap{parent: parent, local: local, size: size}
+}
+
+// all returns the variables, in no particular order.
+func (m *variableMap) all() iter.Seq2[VariablePath, any] {
+	return m.since(nil)
+}
+
+// since returns the variables of the layers not shared with base, eg.: the ones set by a branch cloned from it.
+// Variables of base copied into merged layers are returned too, so callers compare them with the base ones.
+func (m *variableMap) since(base *variableMap) iter.Seq2[VariablePath, any] {
+	return func(yield func(VariablePath, any) bool) {
+		shared := map[*variableMap]bool{}
+		for layer := base; layer != nil; layer = layer.parent {
+			shared[layer] = true
+		}
+
+		seen := map[VariablePath]bool{}
+
+		for layer := m; layer != nil && !shared[layer]; layer = layer.parent {
+			for path, item := range layer.local {
+				if seen[path] {
+					continue
+				}
+
+				seen[path] = true
+
+				if !yield(path, item) {
+					return
+				}
+			}
+		}
+	}
+}
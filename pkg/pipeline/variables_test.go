@@ -0,0 +1,87 @@
+package pipeline
+
+import (
+	"fmt"
+	"maps"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopeCopyOnWrite(t *testing.T) {
+	t.Parallel()
+
+	base := NewScope(Pipelines{})
+	for i := range 100 {
+		base = base.WithVariable(VariablePath(fmt.Sprintf("var-%d", i)), i)
+	}
+
+	branch := base.Clone().WithVariable("var-1", "changed").WithVariable("extra", true)
+	sibling := base.WithNamespace("ns").WithVariable("var-2", "namespaced")
+
+	value, _ := base.Variable("var-1")
+	assert.Equal(t, 1, value)
+	assert.Len(t, base.Variables(), 100)
+
+	value, _ = branch.Variable("var-1")
+	assert.Equal(t, "changed", value)
+	assert.Len(t, branch.Variables(), 101)
+
+	value, _ = sibling.Variable("var-2")
+	assert.Equal(t, "namespaced", value)
+	assert.Len(t, sibling.Variables(), 101)
+
+	_, err := base.Variable("extra")
+	assert.ErrorIs(t, err, ErrVariableNotFound)
+
+	merged := base.Merge(branch)
+	assert.Equal(t, branch.Variables(), merged.Variables())
+	assert.Equal(t, 101, merged.variables.len())
+
+	assert.Same(t, base.variables, base.Merge(base.Clone()).variables, "expected an unchanged branch to add no layer")
+}
+
+func TestVariableMapSince(t *testing.T) {
+	t.Parallel()
+
+	var base *variableMap
+	for i := range 64 {
+		base = base.with(map[VariablePath]any{VariablePath(fmt.Sprintf("var-%d", i)): i})
+	}
+
+	branch := base.with(map[VariablePath]any{"var-3": "changed"}).with(map[VariablePath]any{"extra": 1})
+
+	changed := maps.Collect(branch.since(base))
+	assert.Equal(t, "changed", changed["var-3"])
+	assert.Equal(t, 1, changed["extra"])
+	assert.Less(t, len(changed), 64, "expected the layers shared with the base to be skipped")
+
+	assert.Len(t, maps.Collect(branch.all()), 65)
+	assert.Equal(t, 65, branch.len())
+}
+
+func TestScopeMergeBranches(t *testing.T) {
+	t.Parallel()
+
+	for size := 1; size <= 64; size++ {
+		base := NewScope(Pipelines{}).WithVariable("x", "old")
+		for i := 1; i < size; i++ {
+			base = base.WithVariable(VariablePath(fmt.Sprintf("var-%d", i)), i)
+		}
+
+		first := base.Clone().WithVariable("x", "new")
+		second := base.Clone().WithVariable("y", true)
+
+		merged := base.Merge(first).Merge(second)
+
+		x, _ := merged.Variable("x")
+		y, _ := merged.Variable("y")
+
+		if !assert.Equal(t, "new", x, "expected the later branch to keep the change of the earlier one with %d variables", size) {
+			return
+		}
+
+		assert.Equal(t, true, y)
+		assert.Equal(t, size+1, merged.variables.len())
+	}
+}
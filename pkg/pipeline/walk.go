@@ -0,0 +1,102 @@
+package pipeline
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// walkPath returns the value reached by the path from the item, made of ".key" and "[index]" nodes,
+// eg.: ".items[0].id" or `["app.name"]`.
+func walkPath(item any, path string) (any, bool) {
+	value := reflect.ValueOf(item)
+
+	for path != "" {
+		var (
+			node string
+			ok   bool
+		)
+
+		switch path[0] {
+		case '.':
+			end := strings.IndexAny(path[1:], ".[")
+			if end < 0 {
+				end = len(path) - 1
+			}
+
+			node, path = path[1:end+1], path[end+1:]
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return nil, false
+			}
+
+			node, path = path[1:end], path[end+1:]
+
+			if unquoted, err := strconv.Unquote(node); err == nil {
+				node = unquoted
+			}
+		default:
+			return nil, false
+		}
+
+		if value, ok = walkNode(value, node); !ok {
+			return nil, false
+		}
+	}
+
+	if !value.IsValid() {
+		return nil, true
+	}
+
+	return value.Interface(), true
+}
+
+// walkNode returns the map value with the key, the slice item with the index or the struct field with the name,
+// or the yaml or json tag.
+func walkNode(value reflect.Value, node string) (reflect.Value, bool) {
+	for value.Kind() == reflect.Interface || value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return reflect.Value{}, false
+		}
+
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}, false
+		}
+
+		item := value.MapIndex(reflect.ValueOf(node).Convert(value.Type().Key()))
+
+		return item, item.IsValid()
+	case reflect.Slice, reflect.Array:
+		index, err := strconv.Atoi(node)
+		if err != nil || index < 0 || index >= value.Len() {
+			return reflect.Value{}, false
+		}
+
+		return value.Index(index), true
+	case reflect.Struct:
+		for i := range value.NumField() {
+			field := value.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			if field.Name == node || tagName(field, "yaml") == node || tagName(field, "json") == node {
+				return value.Field(i), true
+			}
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+func tagName(field reflect.StructField, key string) string {
+	name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+
+	return name
+}
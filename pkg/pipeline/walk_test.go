@@ -0,0 +1,64 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopeDeepVariable(t *testing.T) {
+	t.Parallel()
+
+	type user struct {
+		Name  string
+		Email string `yaml:"email_address"`
+		Roles []string
+	}
+
+	scope := NewScope(Pipelines{}).
+		WithVariable("http-step.$body", map[string]any{"items": []any{map[string]any{"id": 7}}}).
+		WithVariable("http-step.$headers", map[string]string{"Content-Type": "application/json"}).
+		WithVariable("config", map[string]any{"app.name": "api"}).
+		WithVariable("user", &user{Name: "bob", Email: "bob@example.com", Roles: []string{"admin"}}).
+		WithVariable("user.Name", "shadowed").
+		WithNamespace("child")
+
+	tests := []struct {
+		path     VariablePath
+		expected any
+		err      error
+	}{
+		{path: "http-step.$body.items[0].id", expected: 7},
+		{path: "http-step.$body.items[0]", expected: map[string]any{"id": 7}},
+		{path: "http-step.$headers.Content-Type", expected: "application/json"},
+		{path: `config["app.name"]`, expected: "api"},
+		{path: "user.Name", expected: "shadowed"},
+		{path: "user.email_address", expected: "bob@example.com"},
+		{path: "user.Roles[0]", expected: "admin"},
+		{path: "http-step.$body.items[1].id", err: ErrVariableNotFound},
+		{path: "http-step.$body.items[x]", err: ErrVariableNotFound},
+		{path: "http-step.$body.missing", err: ErrVariableNotFound},
+		{path: "user.password", err: ErrVariableNotFound},
+		{path: "config.app", err: ErrVariableNotFound},
+	}
+
+	for _, tc := range tests {
+		value, err := scope.Variable(tc.path)
+		if tc.err != nil {
+			assert.ErrorIs(t, err, tc.err, tc.path)
+
+			continue
+		}
+
+		if assert.NoError(t, err, tc.path) {
+			assert.Equal(t, tc.expected, value, tc.path)
+		}
+	}
+
+	value, err := expression.String(`{{ variable . "http-step.$body.items[0].id" }}`).Eval(context.Background(), scope)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "7", value)
+	}
+}
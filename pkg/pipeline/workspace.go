@@ -0,0 +1,89 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrExecutionFinished is returned when using the workspace of a finished execution.
+	ErrExecutionFinished = errors.New("execution finished")
+
+	errOutsideExecution = errors.New("workspace used outside an execution")
+)
+
+// workspace is the working directory of an execution, created on first use in the temporary directory
+// and removed once the execution finishes, unless it was given by WithWorkspace.
+type workspace struct {
+	mu       sync.Mutex
+	dir      string
+	owned    bool
+	finished bool
+}
+
+func (w *workspace) use(dir string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.dir == "" {
+		w.dir = dir
+	}
+}
+
+// path returns the path joining the elements to the workspace directory, which they can't escape.
+func (w *workspace) path(elems ...string) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.finished {
+		return "", ErrExecutionFinished
+	}
+
+	if w.dir == "" {
+		dir, err := os.MkdirTemp("", "go-pipeline-")
+		if err != nil {
+			return "", err
+		}
+
+		w.dir = dir
+		w.owned = true
+	}
+
+	joined := filepath.Join(append([]string{w.dir}, elems...)...)
+
+	rel, err := filepath.Rel(w.dir, joined)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s is outside the workspace", filepath.Join(elems...))
+	}
+
+	return joined, nil
+}
+
+func (w *workspace) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.finished = true
+
+	if !w.owned {
+		return nil
+	}
+
+	return os.RemoveAll(w.dir)
+}
+
+// Workspace returns the path joining the elements to the working directory of the execution running with the context,
+// eg.: for executors writing files. The directory is created on first use and removed once the execution finishes.
+func Workspace(ctx context.Context, elems ...string) (string, error) {
+	exec := executionFrom(ctx)
+	if exec == nil {
+		return "", errOutsideExecution
+	}
+
+	return exec.workspace.path(elems...)
+}
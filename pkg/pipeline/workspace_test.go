@@ -0,0 +1,65 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkspace(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine()
+	engine.RegisterStepExecutor("touch", FuncExecutor(func(ctx context.Context, in struct {
+		Name string `yaml:"name"`
+	}) (string, error) {
+		path, err := Workspace(ctx, in.Name)
+		if err != nil {
+			return "", err
+		}
+
+		return path, os.WriteFile(path, []byte("ok"), 0o600)
+	}))
+
+	pipelines := NewPipelines(New("main").
+		Step(Step{ID: "file", Type: "touch", Params: map[string]any{"name": "report.txt"}}).
+		Set("paths", map[string]any{"same": `{{ eq (workspace . "report.txt") (variable . "file") }}`}).
+		Build())
+
+	t.Run("removes the temporary workspace", func(t *testing.T) {
+		t.Parallel()
+
+		scope, err := engine.Execute(context.Background(), NewScope(pipelines), []string{"main"})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		paths, _ := scope.Variable("paths")
+		assert.Equal(t, map[string]any{"same": "true"}, paths)
+
+		path, _ := Get[string](scope, "file")
+		assert.NoFileExists(t, path)
+	})
+
+	t.Run("keeps the given workspace", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+
+		_, err := engine.Execute(context.Background(), NewScope(pipelines), []string{"main"}, WithWorkspace(dir))
+		assert.NoError(t, err)
+		assert.FileExists(t, filepath.Join(dir, "report.txt"))
+	})
+
+	t.Run("rejects paths outside the workspace", func(t *testing.T) {
+		t.Parallel()
+
+		escaping := NewPipelines(New("main").Set("path", map[string]any{"value": `{{ workspace . ".." "escape" }}`}).Build())
+
+		_, err := engine.Execute(context.Background(), NewScope(escaping), []string{"main"})
+		assert.ErrorContains(t, err, "outside the workspace")
+	})
+}
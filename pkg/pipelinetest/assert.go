@@ -0,0 +1,56 @@
+package pipelinetest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// AssertVariable fails the test when the variable is missing or differs from the expected value.
+func AssertVariable(t testing.TB, scope pipeline.Scope, path pipeline.VariablePath, expected any) bool {
+	t.Helper()
+
+	value, err := scope.Variable(path)
+	if err != nil {
+		t.Errorf("variable %s: %v", path, err)
+
+		return false
+	}
+
+	if !reflect.DeepEqual(value, expected) {
+		t.Errorf("variable %s: got %#v, want %#v", path, value, expected)
+
+		return false
+	}
+
+	return true
+}
+
+// AssertNoVariable fails the test when the variable is set.
+func AssertNoVariable(t testing.TB, scope pipeline.Scope, path pipeline.VariablePath) bool {
+	t.Helper()
+
+	value, err := scope.Variable(path)
+	if !errors.Is(err, pipeline.ErrVariableNotFound) {
+		t.Errorf("variable %s: expected to be missing, got %#v", path, value)
+
+		return false
+	}
+
+	return true
+}
+
+// AssertFinished fails the test when the scope finished flag differs from the expected one.
+func AssertFinished(t testing.TB, scope pipeline.Scope, expected bool) bool {
+	t.Helper()
+
+	if scope.Finished != expected {
+		t.Errorf("scope finished: got %v, want %v", scope.Finished, expected)
+
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,172 @@
+package pipelinetest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+const goldenFileMode = 0o644
+
+var update = flag.Bool("update", false, "update pipelinetest golden files")
+
+type goldenOptions struct {
+	ignore     []string
+	normalizer func(path pipeline.VariablePath, value any) any
+}
+
+// GoldenOption configures the golden file comparison.
+type GoldenOption func(*goldenOptions)
+
+// IgnoreVariables excludes the variables matching the path.Match patterns (eg.: "http.*") from the golden file.
+func IgnoreVariables(patterns ...string) GoldenOption {
+	return func(o *goldenOptions) {
+		o.ignore = append(o.ignore, patterns...)
+	}
+}
+
+// Normalize replaces volatile values (eg.: generated IDs) before serializing them.
+func Normalize(normalizer func(path pipeline.VariablePath, value any) any) GoldenOption {
+	return func(o *goldenOptions) {
+		o.normalizer = normalizer
+	}
+}
+
+// AssertGolden compares the serialized scope variables with the golden file.
+// Running the tests with -update rewrites the golden file instead.
+// Time values are normalized and values that can't be serialized are replaced by their type name.
+func AssertGolden(t testing.TB, scope pipeline.Scope, golden string, opts ...GoldenOption) bool {
+	t.Helper()
+
+	o := goldenOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	actual, err := serializeScope(scope, o)
+	if err != nil {
+		t.Errorf("serializing scope: %v", err)
+
+		return false
+	}
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+			t.Errorf("creating golden dir: %v", err)
+
+			return false
+		}
+
+		if err := os.WriteFile(golden, actual, goldenFileMode); err != nil {
+			t.Errorf("updating golden file: %v", err)
+
+			return false
+		}
+
+		return true
+	}
+
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Errorf("reading golden file (run with -update to create it): %v", err)
+
+		return false
+	}
+
+	if string(expected) != string(actual) {
+		t.Errorf("scope differs from golden file %s (run with -update to accept it)\n--- got\n%s\n--- want\n%s", golden, actual, expected)
+
+		return false
+	}
+
+	return true
+}
+
+// RunGolden executes the pipelines and compares the resulting scope with the golden file.
+// Side effects should be stubbed beforehand, see StubStepExecutor.
+func RunGolden(t testing.TB, pipelines pipeline.Pipelines, golden string, names []string, opts ...GoldenOption) pipeline.Scope {
+	t.Helper()
+
+	scope, err := pipelines.Execute(context.Background(), pipeline.NewScope(pipelines), names)
+	if err != nil {
+		t.Fatalf("executing %v: %v", names, err)
+	}
+
+	AssertGolden(t, scope, golden, opts...)
+
+	return scope
+}
+
+func serializeScope(scope pipeline.Scope, o goldenOptions) ([]byte, error) {
+	variables := map[string]any{}
+
+	for variablePath, value := range scope.Variables() {
+		if ignored(string(variablePath), o.ignore) {
+			continue
+		}
+
+		if o.normalizer != nil {
+			value = o.normalizer(variablePath, value)
+		}
+
+		variables[string(variablePath)] = normalize(value)
+	}
+
+	var buffer bytes.Buffer
+
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(variables); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func ignored(variablePath string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, variablePath); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func normalize(value any) any {
+	switch v := value.(type) {
+	case time.Time:
+		return "<time>"
+	case map[string]any:
+		normalized := make(map[string]any, len(v))
+		for key, item := range v {
+			normalized[key] = normalize(item)
+		}
+
+		return normalized
+	case []any:
+		normalized := make([]any, len(v))
+		for i, item := range v {
+			normalized[i] = normalize(item)
+		}
+
+		return normalized
+	}
+
+	if _, err := json.Marshal(value); err != nil {
+		return fmt.Sprintf("<%T>", value)
+	}
+
+	return value
+}
@@ -0,0 +1,96 @@
+package pipelinetest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+)
+
+// Level of a captured log entry.
+type Level string
+
+const (
+	LevelError Level = "error"
+	LevelWarn  Level = "warn"
+	LevelInfo  Level = "info"
+	LevelDebug Level = "debug"
+)
+
+// Entry is a captured log message.
+type Entry struct {
+	Level   Level
+	Message string
+	Fields  []log.Field
+}
+
+// Logs is an in-memory logger capturing every message.
+type Logs struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// CaptureLogs sets up an in-memory logger during the test, restoring the previous logger on cleanup.
+// Tests using it replace the process-wide logger and must not run in parallel.
+func CaptureLogs(t testing.TB) *Logs {
+	t.Helper()
+
+	previous := log.From(context.Background())
+	logs := &Logs{}
+
+	log.SetUp(logs)
+	t.Cleanup(func() {
+		log.SetUp(previous)
+	})
+
+	return logs
+}
+
+func (l *Logs) Error(ctx context.Context, msg string, args ...any) {
+	l.record(ctx, LevelError, msg, args)
+}
+
+func (l *Logs) Warn(ctx context.Context, msg string, args ...any) {
+	l.record(ctx, LevelWarn, msg, args)
+}
+
+func (l *Logs) Info(ctx context.Context, msg string, args ...any) {
+	l.record(ctx, LevelInfo, msg, args)
+}
+
+func (l *Logs) Debug(ctx context.Context, msg string, args ...any) {
+	l.record(ctx, LevelDebug, msg, args)
+}
+
+// Entries returns the captured messages.
+func (l *Logs) Entries() []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]Entry{}, l.entries...)
+}
+
+// Contains reports whether a captured message at the level contains the text.
+func (l *Logs) Contains(level Level, text string) bool {
+	for _, entry := range l.Entries() {
+		if entry.Level == level && strings.Contains(entry.Message, text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l *Logs) record(ctx context.Context, level Level, msg string, args []any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries = append(l.entries, Entry{
+		Level:   level,
+		Message: fmt.Sprintf(msg, args...),
+		Fields:  log.Fields(ctx),
+	})
+}
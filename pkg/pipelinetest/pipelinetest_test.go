@@ -0,0 +1,101 @@
+package pipelinetest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipelinetest"
+)
+
+const definition = `
+name: fetch
+steps:
+- id: fetch
+  type: fake-http
+- type: log
+  params:
+    message: 'status {{ variableGet . "fetch" "status" }}'
+- id: again
+  type: fake-http
+`
+
+func TestStubAndAssertions(t *testing.T) {
+	logs := pipelinetest.CaptureLogs(t)
+	stub := pipelinetest.StubStepExecutor(t, "fake-http",
+		pipelinetest.Result{Value: map[string]any{"status": 200}},
+		pipelinetest.Result{Err: errors.New("boom")},
+	)
+
+	pipelines, err := pipeline.Load(fstest.MapFS{"fetch.yaml": {Data: []byte(definition)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scope, err := pipelines.Execute(context.Background(), pipeline.NewScope(pipelines), []string{"fetch"})
+	if err == nil {
+		t.Fatal("expected the second stub result error")
+	}
+
+	pipelinetest.AssertVariable(t, scope, "fetch", map[string]any{"status": 200})
+	pipelinetest.AssertNoVariable(t, scope, "again")
+	pipelinetest.AssertFinished(t, scope, false)
+
+	if calls := stub.Calls(); len(calls) != 2 || calls[1].Step.ID != "again" {
+		t.Fatalf("unexpected calls: %+v", calls)
+	}
+
+	if !logs.Contains(pipelinetest.LevelInfo, "status 200") {
+		t.Fatalf("expected log step message, got %+v", logs.Entries())
+	}
+}
+
+func TestRegisterStepExecutorRestoresPrevious(t *testing.T) {
+	t.Run("override", func(t *testing.T) {
+		pipelinetest.StubStepExecutor(t, "set")
+
+		executor, _ := pipeline.LookupStepExecutor("set")
+		if _, ok := executor.(*pipelinetest.Stub); !ok {
+			t.Fatalf("expected stub, got %T", executor)
+		}
+	})
+
+	executor, _ := pipeline.LookupStepExecutor("set")
+	if _, ok := executor.(*pipelinetest.Stub); ok {
+		t.Fatal("expected set executor to be restored")
+	}
+}
+
+func TestRunGolden(t *testing.T) {
+	t.Parallel()
+
+	pipelines, err := pipeline.Load(fstest.MapFS{"set.yaml": {Data: []byte(`
+name: set
+id: main
+steps:
+- id: setup
+  type: set
+  params:
+    counter: 1
+    items: [a, b]
+- id: secret
+  type: set
+  params:
+    generated: '{{ uuidv4 }}'
+`)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pipelinetest.RunGolden(t, pipelines, "testdata/set.golden", []string{"set"},
+		pipelinetest.Normalize(func(path pipeline.VariablePath, value any) any {
+			if path == "main.secret" {
+				return "<uuid>"
+			}
+
+			return value
+		}),
+	)
+}
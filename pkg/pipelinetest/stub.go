@@ -0,0 +1,106 @@
+// Package pipelinetest provides utilities to unit-test pipelines and custom step executors.
+package pipelinetest
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// Result is a programmable outcome of a Stub execution.
+type Result struct {
+	// Value is stored under the step variable path when not nil.
+	Value any
+	// Variables are stored in the scope as they are.
+	Variables map[pipeline.VariablePath]any
+	// Finished marks the scope as finished.
+	Finished bool
+	// Err is returned by the execution.
+	Err error
+}
+
+// Call records a Stub execution.
+type Call struct {
+	Step  pipeline.Step
+	Scope pipeline.Scope
+}
+
+// Stub is a step executor returning programmable results and recording its calls.
+// The results are returned in order, and the last one is repeated once they are exhausted.
+type Stub struct {
+	mu      sync.Mutex
+	results []Result
+	calls   []Call
+}
+
+// NewStub creates a stub returning the given results.
+func NewStub(results ...Result) *Stub {
+	return &Stub{results: results}
+}
+
+// Execute records the call and applies the next result to the scope.
+func (s *Stub) Execute(ctx context.Context, scope pipeline.Scope, step pipeline.Step) (pipeline.Scope, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = append(s.calls, Call{Step: step, Scope: scope})
+
+	if len(s.results) == 0 {
+		return scope, nil
+	}
+
+	result := s.results[min(len(s.calls), len(s.results))-1]
+
+	if result.Value != nil {
+		scope = scope.WithVariable(step.VariablePath(), result.Value)
+	}
+
+	scope = scope.WithVariables(result.Variables)
+
+	if result.Finished {
+		scope.Finished = true
+	}
+
+	return scope, result.Err
+}
+
+// Calls returns the recorded executions.
+func (s *Stub) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Call{}, s.calls...)
+}
+
+// RegisterStepExecutor registers an executor for the step type during the test,
+// restoring the previous executor on cleanup.
+// Tests using it replace a process-wide registration and must not run in parallel.
+func RegisterStepExecutor(t testing.TB, name string, executor pipeline.StepExecutor) {
+	t.Helper()
+
+	previous, found := pipeline.LookupStepExecutor(name)
+
+	pipeline.RegisterStepExecutor(name, executor)
+
+	t.Cleanup(func() {
+		if found {
+			pipeline.RegisterStepExecutor(name, previous)
+
+			return
+		}
+
+		pipeline.UnregisterStepExecutor(name)
+	})
+}
+
+// StubStepExecutor registers a Stub for the step type during the test and returns it.
+func StubStepExecutor(t testing.TB, name string, results ...Result) *Stub {
+	t.Helper()
+
+	stub := NewStub(results...)
+	RegisterStepExecutor(t, name, stub)
+
+	return stub
+}
@@ -0,0 +1,31 @@
+// Package queue provides steps publishing and receiving messages of managed queues, eg.: GCP Pub/Sub or AWS SQS/SNS.
+package queue
+
+import (
+	"context"
+	"time"
+)
+
+// Message is a queue message. Handle identifies a received message to the broker, eg.: the SQS receipt handle
+// or the Pub/Sub ack ID.
+type Message struct {
+	ID         string
+	Body       string
+	Attributes map[string]string
+	Handle     string
+}
+
+// Broker adapts a managed queue client, eg.: the Pub/Sub or SQS/SNS SDKs, to the queue steps.
+type Broker interface {
+	// Publish sends the messages to the topic, returning their IDs.
+	Publish(ctx context.Context, topic string, messages []Message) ([]string, error)
+	// Receive returns up to max messages of the queue (or subscription), hidden from other receivers
+	// during the visibility timeout.
+	Receive(ctx context.Context, queue string, max int, visibility time.Duration) ([]Message, error)
+	// Extend hides the received messages for another visibility timeout.
+	Extend(ctx context.Context, queue string, messages []Message, visibility time.Duration) error
+	// Ack deletes the received messages from the queue.
+	Ack(ctx context.Context, queue string, messages []Message) error
+	// Nack makes the received messages visible again, to be redelivered.
+	Nack(ctx context.Context, queue string, messages []Message) error
+}
@@ -0,0 +1,141 @@
+package queue
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// memoryBroker is a single queue receiving the messages published to any topic.
+type memoryBroker struct {
+	mu       sync.Mutex
+	pending  []Message
+	acked    int
+	nacked   int
+	extended int
+}
+
+func (b *memoryBroker) Publish(_ context.Context, _ string, messages []Message) ([]string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ids := make([]string, 0, len(messages))
+
+	for _, message := range messages {
+		message.ID = strconv.Itoa(len(b.pending) + 1)
+		message.Handle = "handle-" + message.ID
+		b.pending = append(b.pending, message)
+		ids = append(ids, message.ID)
+	}
+
+	return ids, nil
+}
+
+func (b *memoryBroker) Receive(_ context.Context, _ string, max int, _ time.Duration) ([]Message, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	received := b.pending[:min(max, len(b.pending))]
+	b.pending = b.pending[len(received):]
+
+	return received, nil
+}
+
+func (b *memoryBroker) Extend(context.Context, string, []Message, time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.extended++
+
+	return nil
+}
+
+func (b *memoryBroker) Ack(_ context.Context, _ string, messages []Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.acked += len(messages)
+
+	return nil
+}
+
+func (b *memoryBroker) Nack(_ context.Context, _ string, messages []Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nacked += len(messages)
+	b.pending = append(b.pending, messages...)
+
+	return nil
+}
+
+func TestQueueSteps(t *testing.T) {
+	t.Parallel()
+
+	broker := &memoryBroker{}
+	brokers := map[string]Broker{"memory": broker}
+
+	engine := pipeline.NewEngine()
+	engine.RegisterStepExecutor("queue-publish", PublishExecutor(brokers))
+	engine.RegisterStepExecutor("queue-receive", ReceiveExecutor(brokers))
+
+	receive := pipeline.Step{
+		ID:     "orders",
+		Type:   "queue-receive",
+		Params: map[string]any{"broker": "memory", "queue": "orders", "max_messages": "2", "visibility_timeout": "10ms"},
+	}
+
+	timed := receive
+	timed.Timeout = "1s"
+
+	pipelines := pipeline.NewPipelines(
+		pipeline.New("publish").
+			Step(pipeline.Step{ID: "published", Type: "queue-publish", Params: map[string]any{
+				"broker":   "memory",
+				"topic":    "orders",
+				"messages": []any{map[string]any{"body": "first"}, map[string]any{"body": "second"}, map[string]any{"body": "third"}},
+			}}).
+			Build(),
+		// The step timeout cancels its context once it returns, the messages still being extended until they're settled.
+		pipeline.New("consume").Step(timed).Wait(30*time.Millisecond).Build(),
+		pipeline.New("fail").
+			Step(receive).
+			Stop(pipeline.StopParams{Condition: "true", Message: "failed", IsError: "true"}).
+			Build(),
+	)
+
+	scope, err := engine.Execute(context.Background(), pipeline.NewScope(pipelines), []string{"publish"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ids, _ := pipeline.Get[[]any](scope, "published"); len(ids) != 3 {
+		t.Fatalf("unexpected published ids: %v", ids)
+	}
+
+	if _, err := engine.Execute(context.Background(), pipeline.NewScope(pipelines), []string{"fail"}); err == nil {
+		t.Fatal("expected the execution to fail")
+	}
+
+	if broker.acked != 0 || broker.nacked != 2 || len(broker.pending) != 3 {
+		t.Fatalf("expected the messages to be redelivered: %+v", broker)
+	}
+
+	scope, err = engine.Execute(context.Background(), pipeline.NewScope(pipelines), []string{"consume"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	messages, _ := pipeline.Get[[]map[string]any](scope, "orders")
+	if len(messages) != 2 || messages[0]["body"] != "third" {
+		t.Fatalf("unexpected received messages: %v", messages)
+	}
+
+	if broker.acked != 2 || broker.extended == 0 {
+		t.Fatalf("expected the messages to be extended and acknowledged: %+v", broker)
+	}
+}
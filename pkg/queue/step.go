@@ -0,0 +1,237 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+const (
+	// DefaultMaxMessages is the number of messages received by default.
+	DefaultMaxMessages = 10
+	// DefaultVisibilityTimeout is how long received messages are hidden before being extended.
+	DefaultVisibilityTimeout = 30 * time.Second
+)
+
+// RegisterStepExecutors registers the queue-publish and queue-receive steps using the named brokers.
+func RegisterStepExecutors(brokers map[string]Broker) {
+	pipeline.RegisterStepExecutor("queue-publish", PublishExecutor(brokers))
+	pipeline.RegisterStepExecutor("queue-receive", ReceiveExecutor(brokers))
+}
+
+func lookup(ctx context.Context, scope pipeline.Scope, brokers map[string]Broker, name expression.String) (Broker, error) {
+	broker, err := name.Eval(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if found, ok := brokers[broker]; ok {
+		return found, nil
+	}
+
+	return nil, fmt.Errorf("queue broker %q not registered", broker)
+}
+
+type PublishMessage struct {
+	Body       string            `yaml:"body"`
+	Attributes map[string]string `yaml:"attributes"`
+}
+
+type PublishParams struct {
+	Broker   expression.String                 `yaml:"broker"`
+	Topic    expression.String                 `yaml:"topic"`
+	Messages expression.YAML[[]PublishMessage] `yaml:"messages"`
+}
+
+// PublishExecutor publishes messages to a topic. The published message IDs are stored in the step variable path.
+//
+// Example YAML:
+//
+//	id: publish-example
+//	steps:
+//	- id: notify
+//	  type: queue-publish
+//	  params:
+//	    broker: 'sqs'
+//	    topic: 'orders-processed'
+//	    messages:
+//	    - body: '{{ variable . "order" | toJson }}'
+//	      attributes:
+//	        type: 'order'
+func PublishExecutor(brokers map[string]Broker) pipeline.StepExecutor {
+	return pipeline.TypedStepExecutor[PublishParams](
+		func(ctx context.Context, scope pipeline.Scope, step pipeline.Step, p PublishParams) (pipeline.Scope, error) {
+			broker, err := lookup(ctx, scope, brokers, p.Broker)
+			if err != nil {
+				return scope, err
+			}
+
+			topic, err := p.Topic.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			published, err := p.Messages.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			messages := make([]Message, 0, len(published))
+			for _, message := range published {
+				messages = append(messages, Message{Body: message.Body, Attributes: message.Attributes})
+			}
+
+			ids, err := broker.Publish(ctx, topic, messages)
+			if err != nil {
+				return scope, err
+			}
+
+			values := make([]any, 0, len(ids))
+			for _, id := range ids {
+				values = append(values, id)
+			}
+
+			return scope.WithVariable(step.VariablePath(), values), nil
+		},
+	)
+}
+
+type ReceiveParams struct {
+	Broker            expression.String   `yaml:"broker"`
+	Queue             expression.String   `yaml:"queue"`
+	MaxMessages       expression.Int      `yaml:"max_messages"`
+	VisibilityTimeout expression.Duration `yaml:"visibility_timeout"`
+}
+
+// ReceiveExecutor receives a batch of messages, stored as a list of maps (id, body and attributes) in the step
+// variable path. The messages visibility is extended while the execution runs, and they're acknowledged once
+// it succeeds, or made visible again to be redelivered when it fails.
+//
+// Example YAML:
+//
+//	id: receive-example
+//	steps:
+//	- id: orders
+//	  type: queue-receive
+//	  params:
+//	    broker: 'sqs'
+//	    queue: 'orders'
+//	    max_messages: 10
+//	    visibility_timeout: 1m
+//	- id: process
+//	  type: range
+//	  params:
+//	    variable: 'orders'
+//	    steps:
+//	    - type: log
+//	      params:
+//	        message: '{{ (variable . "process").body }}'
+func ReceiveExecutor(brokers map[string]Broker) pipeline.StepExecutor {
+	return pipeline.TypedStepExecutor[ReceiveParams](
+		func(ctx context.Context, scope pipeline.Scope, step pipeline.Step, p ReceiveParams) (pipeline.Scope, error) {
+			broker, err := lookup(ctx, scope, brokers, p.Broker)
+			if err != nil {
+				return scope, err
+			}
+
+			queue, err := p.Queue.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			max, err := p.MaxMessages.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			if max <= 0 {
+				max = DefaultMaxMessages
+			}
+
+			visibility, err := p.VisibilityTimeout.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			if visibility <= 0 {
+				visibility = DefaultVisibilityTimeout
+			}
+
+			messages, err := broker.Receive(ctx, queue, max, visibility)
+			if err != nil {
+				return scope, err
+			}
+
+			values := make([]any, 0, len(messages))
+			for _, message := range messages {
+				values = append(values, map[string]any{
+					"id":         message.ID,
+					"body":       message.Body,
+					"attributes": message.Attributes,
+				})
+			}
+
+			scope = scope.WithVariable(step.VariablePath(), values)
+
+			if len(messages) == 0 {
+				return scope, nil
+			}
+
+			return scope, settle(ctx, broker, queue, messages, visibility)
+		},
+	)
+}
+
+// settle extends the messages visibility until the execution finishes, then acknowledges them when it succeeds
+// or makes them visible again when it fails.
+func settle(ctx context.Context, broker Broker, queue string, messages []Message, visibility time.Duration) error {
+	// The visibility is extended until the execution finishes, past the step timeout canceling its context.
+	extendCtx, stop := context.WithCancel(context.WithoutCancel(ctx))
+	done := make(chan struct{})
+
+	err := pipeline.OnFinish(ctx, func(ctx context.Context, err error) {
+		stop()
+		<-done
+
+		if err != nil {
+			if err := broker.Nack(ctx, queue, messages); err != nil {
+				log.Log().Error(ctx, "Error making %d messages of %s visible again: %s", len(messages), queue, err)
+			}
+
+			return
+		}
+
+		if err := broker.Ack(ctx, queue, messages); err != nil {
+			log.Log().Error(ctx, "Error acknowledging %d messages of %s: %s", len(messages), queue, err)
+		}
+	})
+	if err != nil {
+		stop()
+
+		return err
+	}
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(visibility / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-extendCtx.Done():
+				return
+			case <-ticker.C:
+				if err := broker.Extend(extendCtx, queue, messages, visibility); err != nil && extendCtx.Err() == nil {
+					log.Log().Error(ctx, "Error extending the visibility of %d messages of %s: %s", len(messages), queue, err)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,258 @@
+// Package schedule decides when pipelines are allowed to run.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+var (
+	// ErrNotBusinessDay is returned when the time is on a day not allowed by the calendar, eg.: a weekend.
+	ErrNotBusinessDay = errors.New("not a business day")
+	// ErrHoliday is returned when the time is on a holiday.
+	ErrHoliday = errors.New("holiday")
+	// ErrBlackout is returned when the time is within a blackout window, eg.: a release freeze.
+	ErrBlackout = errors.New("blackout window")
+)
+
+// Window is a period of time, including its start and excluding its end.
+type Window struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+// Contains reports whether the time is within the window.
+func (w Window) Contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// Calendar defines the days and times pipelines are allowed to run, evaluated in its location.
+type Calendar struct {
+	// Location of the calendar days, UTC when nil.
+	Location *time.Location
+	// Weekdays allowed to run, any day when empty.
+	Weekdays []time.Weekday
+	// Holidays are the dates not allowed to run, in the 2006-01-02 format.
+	Holidays []string
+	// Blackouts are the windows not allowed to run.
+	Blackouts []Window
+}
+
+// BusinessDays are the weekdays from Monday to Friday.
+var BusinessDays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+// Check returns why the calendar doesn't allow running at the time, or nil if it does.
+func (c Calendar) Check(t time.Time) error {
+	local := t.In(c.location())
+
+	if len(c.Weekdays) > 0 && !containsWeekday(c.Weekdays, local.Weekday()) {
+		return fmt.Errorf("%w: %s", ErrNotBusinessDay, local.Weekday())
+	}
+
+	date := local.Format(dateLayout)
+	for _, holiday := range c.Holidays {
+		if holiday == date {
+			return fmt.Errorf("%w: %s", ErrHoliday, date)
+		}
+	}
+
+	for _, blackout := range c.Blackouts {
+		if blackout.Contains(t) {
+			return fmt.Errorf("%w: %s", ErrBlackout, blackout.Name)
+		}
+	}
+
+	return nil
+}
+
+// Allows reports whether the calendar allows running at the time.
+func (c Calendar) Allows(t time.Time) bool {
+	return c.Check(t) == nil
+}
+
+// In returns the time in the calendar location, eg.: to evaluate cron expressions in it.
+func (c Calendar) In(t time.Time) time.Time {
+	return t.In(c.location())
+}
+
+func (c Calendar) location() *time.Location {
+	if c.Location == nil {
+		return time.UTC
+	}
+
+	return c.Location
+}
+
+// Calendars are the calendars of each pipeline, eg.: to run pipelines of different regions in their own time zones.
+type Calendars struct {
+	// Default is the calendar of pipelines without their own.
+	Default   Calendar
+	Pipelines map[string]Calendar
+}
+
+// For returns the calendar of the pipeline.
+func (c Calendars) For(pipeline string) Calendar {
+	if calendar, ok := c.Pipelines[pipeline]; ok {
+		return calendar
+	}
+
+	return c.Default
+}
+
+func containsWeekday(weekdays []time.Weekday, day time.Weekday) bool {
+	for _, weekday := range weekdays {
+		if weekday == day {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Config is the YAML definition of a calendar, eg.:
+//
+//	timezone: 'America/Sao_Paulo'
+//	business_days: true
+//	holidays: ['2025-12-25']
+//	blackouts:
+//	- name: 'year end freeze'
+//	  start: '2025-12-20 00:00'
+//	  end: '2026-01-05 00:00'
+type Config struct {
+	Timezone     string         `yaml:"timezone"`
+	BusinessDays bool           `yaml:"business_days"`
+	Weekdays     []string       `yaml:"weekdays"`
+	Holidays     []string       `yaml:"holidays"`
+	Blackouts    []WindowConfig `yaml:"blackouts"`
+}
+
+// WindowConfig is the YAML definition of a window, with times in the calendar time zone
+// formatted as RFC 3339, "2006-01-02 15:04" or "2006-01-02".
+type WindowConfig struct {
+	Name  string `yaml:"name"`
+	Start string `yaml:"start"`
+	End   string `yaml:"end"`
+}
+
+// Calendar parses the configuration.
+func (c Config) Calendar() (Calendar, error) {
+	location, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return Calendar{}, err
+	}
+
+	calendar := Calendar{Location: location}
+
+	if c.BusinessDays {
+		calendar.Weekdays = append(calendar.Weekdays, BusinessDays...)
+	}
+
+	for _, name := range c.Weekdays {
+		weekday, err := parseWeekday(name)
+		if err != nil {
+			return Calendar{}, err
+		}
+
+		calendar.Weekdays = append(calendar.Weekdays, weekday)
+	}
+
+	for _, holiday := range c.Holidays {
+		if _, err := time.Parse(dateLayout, holiday); err != nil {
+			return Calendar{}, fmt.Errorf("invalid holiday %q: %w", holiday, err)
+		}
+
+		calendar.Holidays = append(calendar.Holidays, holiday)
+	}
+
+	for _, config := range c.Blackouts {
+		window, err := config.window(location)
+		if err != nil {
+			return Calendar{}, fmt.Errorf("invalid blackout: %w", err)
+		}
+
+		calendar.Blackouts = append(calendar.Blackouts, window)
+	}
+
+	return calendar, nil
+}
+
+func (w WindowConfig) window(location *time.Location) (Window, error) {
+	start, err := parseTime(w.Start, location)
+	if err != nil {
+		return Window{}, fmt.Errorf("window %q start: %w", w.Name, err)
+	}
+
+	end, err := parseTime(w.End, location)
+	if err != nil {
+		return Window{}, fmt.Errorf("window %q end: %w", w.Name, err)
+	}
+
+	if !end.After(start) {
+		return Window{}, fmt.Errorf("window %q: end must be after start", w.Name)
+	}
+
+	return Window{Name: w.Name, Start: start, End: end}, nil
+}
+
+// CalendarsConfig is the YAML definition of the pipeline calendars, eg.:
+//
+//	default:
+//	  business_days: true
+//	pipelines:
+//	  br-report:
+//	    timezone: 'America/Sao_Paulo'
+//	    business_days: true
+type CalendarsConfig struct {
+	Default   Config            `yaml:"default"`
+	Pipelines map[string]Config `yaml:"pipelines"`
+}
+
+// Calendars parses the configuration.
+func (c CalendarsConfig) Calendars() (Calendars, error) {
+	fallback, err := c.Default.Calendar()
+	if err != nil {
+		return Calendars{}, fmt.Errorf("invalid default calendar: %w", err)
+	}
+
+	calendars := Calendars{Default: fallback, Pipelines: make(map[string]Calendar, len(c.Pipelines))}
+
+	for pipeline, config := range c.Pipelines {
+		calendar, err := config.Calendar()
+		if err != nil {
+			return Calendars{}, fmt.Errorf("invalid %s calendar: %w", pipeline, err)
+		}
+
+		calendars.Pipelines[pipeline] = calendar
+	}
+
+	return calendars, nil
+}
+
+func parseWeekday(name string) (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), name) || strings.EqualFold(day.String()[:3], name) {
+			return day, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid weekday %q", name)
+}
+
+func parseTime(value string, location *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	for _, layout := range []string{"2006-01-02 15:04", dateLayout} {
+		if t, err := time.ParseInLocation(layout, value, location); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unsupported time format %q", value)
+}
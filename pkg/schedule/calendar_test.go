@@ -0,0 +1,111 @@
+package schedule
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestCalendar(t *testing.T) {
+	t.Parallel()
+
+	var config Config
+
+	err := yaml.Unmarshal([]byte(`
+timezone: 'America/Sao_Paulo'
+business_days: true
+holidays: ['2025-12-25']
+blackouts:
+- name: 'year end freeze'
+  start: '2025-12-29 00:00'
+  end: '2026-01-02'
+`), &config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	calendar, err := config.Calendar()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected error
+	}{
+		{name: "business day", at: time.Date(2025, 12, 23, 12, 0, 0, 0, time.UTC)},
+		{name: "weekend", at: time.Date(2025, 12, 27, 12, 0, 0, 0, time.UTC), expected: ErrNotBusinessDay},
+		{name: "holiday", at: time.Date(2025, 12, 25, 12, 0, 0, 0, time.UTC), expected: ErrHoliday},
+		{name: "holiday in the calendar time zone", at: time.Date(2025, 12, 26, 2, 0, 0, 0, time.UTC), expected: ErrHoliday},
+		{name: "blackout", at: time.Date(2025, 12, 30, 12, 0, 0, 0, time.UTC), expected: ErrBlackout},
+		{name: "blackout end", at: time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := calendar.Check(tc.at); !errors.Is(err, tc.expected) {
+				t.Fatalf("unexpected check result: got %v want %v", err, tc.expected)
+			}
+		})
+	}
+}
+
+func TestCalendars(t *testing.T) {
+	t.Parallel()
+
+	var config CalendarsConfig
+
+	err := yaml.Unmarshal([]byte(`
+default:
+  business_days: true
+pipelines:
+  tokyo-report:
+    timezone: 'Asia/Tokyo'
+    weekdays: ['mon']
+`), &config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	calendars, err := config.Calendars()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Sunday 20:00 UTC is Monday 05:00 in Tokyo.
+	at := time.Date(2025, 12, 21, 20, 0, 0, 0, time.UTC)
+
+	if calendars.For("other").Allows(at) {
+		t.Fatal("expected the default calendar to deny sundays")
+	}
+
+	if !calendars.For("tokyo-report").Allows(at) {
+		t.Fatal("expected the pipeline calendar to allow mondays in its time zone")
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	t.Parallel()
+
+	configs := map[string]Config{
+		"timezone": {Timezone: "Nowhere/City"},
+		"weekday":  {Weekdays: []string{"someday"}},
+		"holiday":  {Holidays: []string{"25/12/2025"}},
+		"blackout": {Blackouts: []WindowConfig{{Name: "freeze", Start: "2025-12-20", End: "2025-12-19"}}},
+	}
+
+	for name, config := range configs {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := config.Calendar(); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
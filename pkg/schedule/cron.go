@@ -0,0 +1,219 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const cronSearchYears = 5
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Cron is a parsed cron expression with the minute, hour, day of month, month and day of week fields.
+type Cron struct {
+	expr   string
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	anyDom bool
+	anyDow bool
+}
+
+type cronField struct {
+	min, max int
+}
+
+var cronFields = []cronField{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// ParseCron parses a standard cron expression, eg.: "*/15 9-17 * * 1-5", or one of the
+// @yearly, @monthly, @weekly, @daily and @hourly macros.
+// Each field supports "*", values, ranges, lists and steps; Sunday is either 0 or 7.
+func ParseCron(expr string) (Cron, error) {
+	spec := expr
+	if macro, ok := cronMacros[strings.TrimSpace(expr)]; ok {
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != len(cronFields) {
+		return Cron{}, fmt.Errorf("invalid cron %q: expected %d fields", expr, len(cronFields))
+	}
+
+	bits := make([]uint64, len(fields))
+
+	for i, field := range fields {
+		value, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return Cron{}, fmt.Errorf("invalid cron %q: %w", expr, err)
+		}
+
+		bits[i] = value
+	}
+
+	// Sunday is both 0 and 7.
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	return Cron{
+		expr:   expr,
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		anyDom: fields[2] == "*",
+		anyDow: fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, bounds cronField) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+
+		if hasStep {
+			var err error
+
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+		}
+
+		low, high := bounds.min, bounds.max
+
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+
+			var err error
+
+			if low, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+
+			high = low
+
+			if isRange {
+				if high, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid range %q", part)
+				}
+			} else if hasStep {
+				high = bounds.max
+			}
+		}
+
+		if low < bounds.min || high > bounds.max || low > high {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, bounds.min, bounds.max)
+		}
+
+		for value := low; value <= high; value += step {
+			bits |= 1 << value
+		}
+	}
+
+	return bits, nil
+}
+
+// String returns the cron expression.
+func (c Cron) String() string {
+	return c.expr
+}
+
+// IsZero reports whether the cron wasn't parsed.
+func (c Cron) IsZero() bool {
+	return c.expr == ""
+}
+
+// Next returns the first time matching the cron strictly after t, in the location of t,
+// or the zero time when none matches in the next years (eg.: on February 30th).
+func (c Cron) Next(t time.Time) time.Time {
+	location := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, location).Add(time.Minute)
+	limit := t.AddDate(cronSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case !has(c.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, location)
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, location)
+		case !has(c.hour, t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, location)
+		case !has(c.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// Matches reports whether the minute of t matches the cron.
+func (c Cron) Matches(t time.Time) bool {
+	return has(c.month, int(t.Month())) && c.matchesDay(t) && has(c.hour, t.Hour()) && has(c.minute, t.Minute())
+}
+
+// matchesDay follows the cron convention: when both the day of month and the day of week are restricted,
+// either of them matches.
+func (c Cron) matchesDay(t time.Time) bool {
+	dom, dow := has(c.dom, t.Day()), has(c.dow, int(t.Weekday()))
+
+	switch {
+	case c.anyDom:
+		return dow
+	case c.anyDow:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+func has(bits uint64, value int) bool {
+	return bits&(1<<value) != 0
+}
+
+// Recurrence is a window opened at each time matching the cron, lasting for its duration,
+// eg.: "0 9 * * 1-5" for 8h opens on business days from 9am to 5pm.
+type Recurrence struct {
+	Cron     Cron
+	Duration time.Duration
+	// Location the cron is evaluated in, the location of the given times when nil.
+	Location *time.Location
+}
+
+// Contains reports whether the time is within an occurrence of the recurrence.
+func (r Recurrence) Contains(t time.Time) bool {
+	start := r.Cron.Next(r.in(t).Add(-r.Duration))
+
+	return !start.IsZero() && !start.After(t)
+}
+
+// NextStart returns the start of the first occurrence after t.
+func (r Recurrence) NextStart(t time.Time) time.Time {
+	return r.Cron.Next(r.in(t))
+}
+
+func (r Recurrence) in(t time.Time) time.Time {
+	if r.Location == nil {
+		return t
+	}
+
+	return t.In(r.Location)
+}
@@ -0,0 +1,71 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2025, 12, 26, 17, 30, 0, 0, time.UTC) // Friday
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{expr: "*/15 * * * *", expected: time.Date(2025, 12, 26, 17, 45, 0, 0, time.UTC)},
+		{expr: "0 9 * * 1-5", expected: time.Date(2025, 12, 29, 9, 0, 0, 0, time.UTC)},
+		{expr: "30 17 * * *", expected: time.Date(2025, 12, 27, 17, 30, 0, 0, time.UTC)},
+		{expr: "0 0 1,15 * *", expected: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 12 * * 7", expected: time.Date(2025, 12, 28, 12, 0, 0, 0, time.UTC)},
+		{expr: "0 0 13 * 5", expected: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{expr: "@monthly", expected: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 30 2 *", expected: time.Time{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expr, func(t *testing.T) {
+			t.Parallel()
+
+			cron, err := ParseCron(tc.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if next := cron.Next(from); !next.Equal(tc.expected) {
+				t.Fatalf("unexpected next time: got %v want %v", next, tc.expected)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"* * * *", "60 * * * *", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := ParseCron(invalid); err == nil {
+			t.Fatalf("expected %q to be invalid", invalid)
+		}
+	}
+}
+
+func TestRecurrence(t *testing.T) {
+	t.Parallel()
+
+	cron, err := ParseCron("0 9 * * 1-5")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	location, _ := time.LoadLocation("America/Sao_Paulo")
+	recurrence := Recurrence{Cron: cron, Duration: 8 * time.Hour, Location: location}
+
+	if !recurrence.Contains(time.Date(2025, 12, 26, 12, 0, 0, 0, time.UTC)) {
+		t.Fatal("expected 9am in Sao Paulo to be within the recurrence")
+	}
+
+	if recurrence.Contains(time.Date(2025, 12, 26, 20, 0, 0, 0, time.UTC)) {
+		t.Fatal("expected 5pm in Sao Paulo to be outside the recurrence")
+	}
+
+	if next := recurrence.NextStart(time.Date(2025, 12, 26, 20, 0, 0, 0, time.UTC)); !next.Equal(time.Date(2025, 12, 29, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected next start: %v", next)
+	}
+}
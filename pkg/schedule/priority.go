@@ -0,0 +1,177 @@
+package schedule
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrPreempted is the cause of the executions canceled to run higher priority ones, see WithPreemption.
+var ErrPreempted = errors.New("preempted by a higher priority execution")
+
+// run is a scheduled execution of a job, keeping its execution ID across preemptions so it's resumed
+// from its checkpoint.
+type run struct {
+	job *job
+	at  time.Time
+	id  string
+}
+
+func newRun(j *job, at time.Time) *run {
+	blob := make([]byte, 16)
+	_, _ = rand.Read(blob)
+
+	return &run{job: j, at: at, id: hex.EncodeToString(blob)}
+}
+
+// before reports whether the run goes before the other one: the higher priority first, then the earlier scheduled.
+func (r *run) before(other *run) bool {
+	return cmp.Or(cmp.Compare(other.job.Priority, r.job.Priority), r.at.Compare(other.at)) < 0
+}
+
+// waiter is a run waiting for a slot, receiving the context of its execution once granted.
+type waiter struct {
+	run    *run
+	parent context.Context
+	ready  chan context.Context
+}
+
+// slots bounds the concurrent executions of the scheduler, granting the free ones to the waiting runs by priority,
+// and canceling the lower priority executions for the higher priority runs when preempting.
+type slots struct {
+	mu      sync.Mutex
+	size    int
+	preempt bool
+	running map[*run]context.CancelCauseFunc
+	// preempting are the running executions canceled by ErrPreempted not finished yet.
+	preempting map[*run]bool
+	waiting    []*waiter
+}
+
+func newSlots(size int, preempt bool) *slots {
+	return &slots{size: size, preempt: preempt, running: map[*run]context.CancelCauseFunc{}, preempting: map[*run]bool{}}
+}
+
+// acquire waits for a slot for the run, returning the context of its execution, canceled with ErrPreempted
+// when it's preempted, and the function releasing the slot once it finishes.
+func (s *slots) acquire(ctx context.Context, r *run) (context.Context, func(), error) {
+	s.mu.Lock()
+
+	if len(s.running) < s.size {
+		runCtx := s.grant(ctx, r)
+		s.mu.Unlock()
+
+		return runCtx, func() { s.release(r) }, nil
+	}
+
+	w := &waiter{run: r, parent: ctx, ready: make(chan context.Context, 1)}
+	index := slices.IndexFunc(s.waiting, func(other *waiter) bool { return r.before(other.run) })
+	if index < 0 {
+		index = len(s.waiting)
+	}
+
+	s.waiting = slices.Insert(s.waiting, index, w)
+
+	if s.preempt {
+		s.preemptFor(r)
+	}
+
+	s.mu.Unlock()
+
+	select {
+	case runCtx := <-w.ready:
+		return runCtx, func() { s.release(r) }, nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if i := slices.Index(s.waiting, w); i >= 0 {
+			s.waiting = slices.Delete(s.waiting, i, i+1)
+
+			return nil, nil, ctx.Err()
+		}
+
+		// The slot was granted meanwhile, so it's handed to the next waiting run.
+		s.free(r)
+
+		return nil, nil, ctx.Err()
+	}
+}
+
+// grant runs the run in a free slot.
+func (s *slots) grant(ctx context.Context, r *run) context.Context {
+	runCtx, cancel := context.WithCancelCause(ctx)
+	s.running[r] = cancel
+
+	return runCtx
+}
+
+// preemptFor cancels the lowest priority execution below the run one, unless enough executions are being
+// preempted for the waiting runs outranking them already.
+func (s *slots) preemptFor(r *run) {
+	var victim *run
+
+	for running := range s.running {
+		if s.preempting[running] || running.job.Priority >= r.job.Priority {
+			continue
+		}
+
+		if victim == nil || victim.before(running) {
+			victim = running
+		}
+	}
+
+	if victim == nil || len(s.preempting) >= s.outranking(victim) {
+		return
+	}
+
+	s.preempting[victim] = true
+	s.running[victim](ErrPreempted)
+}
+
+// outranking returns the number of waiting runs with a higher priority than the run.
+func (s *slots) outranking(r *run) int {
+	count := 0
+
+	for _, w := range s.waiting {
+		if w.run.job.Priority > r.job.Priority {
+			count++
+		}
+	}
+
+	return count
+}
+
+// release frees the slot of the run, granting it to the next waiting run.
+func (s *slots) release(r *run) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.free(r)
+}
+
+// free frees the slot of the run, canceling its context, and dispatches it.
+func (s *slots) free(r *run) {
+	if cancel, found := s.running[r]; found {
+		cancel(nil)
+	}
+
+	delete(s.running, r)
+	delete(s.preempting, r)
+	s.dispatch()
+}
+
+// dispatch grants the free slots to the waiting runs, by priority.
+func (s *slots) dispatch() {
+	for len(s.running) < s.size && len(s.waiting) > 0 {
+		w := s.waiting[0]
+		s.waiting = s.waiting[1:]
+
+		w.ready <- s.grant(w.parent, w.run)
+	}
+}
@@ -0,0 +1,361 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// Overlap policies of the jobs whose previous execution is still running when their cron fires again.
+const (
+	// OverlapSkip skips the new execution, the default.
+	OverlapSkip = "skip"
+	// OverlapQueue runs the new executions one after another once the running one finishes.
+	OverlapQueue = "queue"
+	// OverlapParallel runs the new execution alongside the running ones.
+	OverlapParallel = "parallel"
+)
+
+// Job executes a pipeline on a cron.
+type Job struct {
+	Pipeline string
+	Cron     Cron
+	// Overlap is one of the Overlap policies, OverlapSkip by default.
+	Overlap string
+	// Location the cron is evaluated in, the scheduler one when nil, see WithLocation.
+	Location *time.Location
+	// Priority orders the executions waiting for a slot, the higher ones first, see WithMaxConcurrent.
+	Priority int
+}
+
+type schedulerOptions struct {
+	engine    *pipeline.Engine
+	jobs      []Job
+	calendars *Calendars
+	location  *time.Location
+	opts      []pipeline.Option
+	// maxConcurrent bounds the executions of all the jobs, unbounded when zero.
+	maxConcurrent int
+	preempt       bool
+}
+
+// SchedulerOption configures a Scheduler.
+type SchedulerOption func(*schedulerOptions)
+
+// WithEngine executes the pipelines with the engine instead of the default one.
+func WithEngine(engine *pipeline.Engine) SchedulerOption {
+	return func(o *schedulerOptions) {
+		o.engine = engine
+	}
+}
+
+// WithJobs schedules the jobs, configured externally, replacing the schedules declared by their pipelines.
+func WithJobs(jobs ...Job) SchedulerOption {
+	return func(o *schedulerOptions) {
+		o.jobs = append(o.jobs, jobs...)
+	}
+}
+
+// WithCalendars skips the executions not allowed by the calendars of their pipelines, eg.: on holidays.
+func WithCalendars(calendars Calendars) SchedulerOption {
+	return func(o *schedulerOptions) {
+		o.calendars = &calendars
+	}
+}
+
+// WithLocation sets the location the crons are evaluated in, the local one by default.
+func WithLocation(location *time.Location) SchedulerOption {
+	return func(o *schedulerOptions) {
+		o.location = location
+	}
+}
+
+// WithExecuteOptions configures every execution, eg.: with pipeline.WithTimeout.
+func WithExecuteOptions(opts ...pipeline.Option) SchedulerOption {
+	return func(o *schedulerOptions) {
+		o.opts = append(o.opts, opts...)
+	}
+}
+
+// WithMaxConcurrent bounds the executions running at once across the jobs, so SLA-critical jobs aren't slowed
+// down by the others under load: the executions exceeding it wait for a slot, granted by the priority of their jobs,
+// then by their scheduled time. The overlap policies still apply to the executions of each job.
+func WithMaxConcurrent(limit int) SchedulerOption {
+	return func(o *schedulerOptions) {
+		o.maxConcurrent = limit
+	}
+}
+
+// WithPreemption cancels the lowest priority running execution, with the ErrPreempted cause, when an execution
+// of a higher priority job waits for a slot, see WithMaxConcurrent. The preempted executions wait for a slot
+// again, resuming from their checkpoint when executed with pipeline.WithCheckpoints, from the start otherwise.
+func WithPreemption() SchedulerOption {
+	return func(o *schedulerOptions) {
+		o.preempt = true
+	}
+}
+
+// job is a scheduled job with its running executions.
+type job struct {
+	Job
+
+	mu      sync.Mutex
+	running int
+	queued  []time.Time
+}
+
+// Scheduler executes the pipelines on their crons, declared by their `schedule` or configured with WithJobs,
+// until the context of Run is done.
+type Scheduler struct {
+	pipelines pipeline.Pipelines
+	o         schedulerOptions
+	jobs      []*job
+	running   sync.WaitGroup
+	slots     *slots
+}
+
+// NewScheduler creates a scheduler of the pipelines, failing on invalid crons, overlap policies or pipelines.
+func NewScheduler(pipelines pipeline.Pipelines, opts ...SchedulerOption) (*Scheduler, error) {
+	o := schedulerOptions{location: time.Local}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	configured := map[string]bool{}
+	for _, j := range o.jobs {
+		configured[j.Pipeline] = true
+	}
+
+	jobs := o.jobs
+
+	for _, name := range pipelines.Names() {
+		pipe, _ := pipelines.Get(name)
+		if pipe.Schedule == "" || configured[name] {
+			continue
+		}
+
+		cron, err := ParseCron(pipe.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("pipeline %s: %w", name, err)
+		}
+
+		jobs = append(jobs, Job{Pipeline: name, Cron: cron, Priority: pipe.Priority})
+	}
+
+	if o.maxConcurrent < 0 {
+		return nil, fmt.Errorf("invalid max concurrent executions %d", o.maxConcurrent)
+	}
+
+	s := &Scheduler{pipelines: pipelines, o: o}
+
+	if o.maxConcurrent > 0 {
+		s.slots = newSlots(o.maxConcurrent, o.preempt)
+	}
+
+	for _, j := range jobs {
+		if _, found := pipelines.Get(j.Pipeline); !found {
+			return nil, fmt.Errorf("pipeline %s not found", j.Pipeline)
+		}
+
+		if j.Cron.IsZero() {
+			return nil, fmt.Errorf("pipeline %s: missing cron", j.Pipeline)
+		}
+
+		switch j.Overlap {
+		case "":
+			j.Overlap = OverlapSkip
+		case OverlapSkip, OverlapQueue, OverlapParallel:
+		default:
+			return nil, fmt.Errorf("pipeline %s: unknown overlap policy %s", j.Pipeline, j.Overlap)
+		}
+
+		if j.Location == nil {
+			j.Location = o.location
+		}
+
+		s.jobs = append(s.jobs, &job{Job: j})
+	}
+
+	return s, nil
+}
+
+// Jobs returns the scheduled jobs.
+func (s *Scheduler) Jobs() []Job {
+	jobs := make([]Job, len(s.jobs))
+	for i, j := range s.jobs {
+		jobs[i] = j.Job
+	}
+
+	return jobs
+}
+
+// Run executes the jobs on their crons until the context is done, returning once the running executions,
+// canceled with the context, finish.
+func (s *Scheduler) Run(ctx context.Context) {
+	var loops sync.WaitGroup
+
+	for _, j := range s.jobs {
+		loops.Add(1)
+
+		go func() {
+			defer loops.Done()
+
+			s.loop(ctx, j)
+		}()
+	}
+
+	loops.Wait()
+	s.running.Wait()
+}
+
+// loop triggers the job at each time matching its cron.
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	for {
+		next := j.Cron.Next(time.Now().In(j.Location))
+		if next.IsZero() {
+			log.Log().Warn(ctx, "Schedule %s of pipeline %s never fires again", j.Cron, j.Pipeline)
+
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return
+		case <-timer.C:
+		}
+
+		s.trigger(ctx, j, next)
+	}
+}
+
+// trigger executes the job scheduled at the time, following its calendar and overlap policy.
+func (s *Scheduler) trigger(ctx context.Context, j *job, at time.Time) {
+	if s.o.calendars != nil {
+		if err := s.o.calendars.For(j.Pipeline).Check(at); err != nil {
+			log.Log().Info(ctx, "Skipping pipeline %s scheduled at %s: %s", j.Pipeline, at, err)
+
+			return
+		}
+	}
+
+	j.mu.Lock()
+
+	if j.running > 0 {
+		switch j.Overlap {
+		case OverlapSkip:
+			j.mu.Unlock()
+			log.Log().Warn(ctx, "Skipping pipeline %s scheduled at %s: previous execution still running", j.Pipeline, at)
+
+			return
+		case OverlapQueue:
+			j.queued = append(j.queued, at)
+			j.mu.Unlock()
+
+			return
+		}
+	}
+
+	j.running++
+	j.mu.Unlock()
+
+	s.running.Add(1)
+
+	go func() {
+		defer s.running.Done()
+
+		for {
+			s.execute(ctx, j, at)
+
+			j.mu.Lock()
+
+			if len(j.queued) == 0 || ctx.Err() != nil {
+				j.running--
+				j.queued = nil
+				j.mu.Unlock()
+
+				return
+			}
+
+			at, j.queued = j.queued[0], j.queued[1:]
+			j.mu.Unlock()
+		}
+	}()
+}
+
+// execute executes the job scheduled at the time in a slot, when bounded, executing it again once preempted.
+func (s *Scheduler) execute(ctx context.Context, j *job, at time.Time) {
+	r := newRun(j, at)
+
+	for resume := false; ; resume = true {
+		runCtx, release := ctx, func() {}
+
+		if s.slots != nil {
+			var err error
+
+			if runCtx, release, err = s.slots.acquire(ctx, r); err != nil {
+				log.Log().Warn(ctx, "Skipping pipeline %s scheduled at %s: %s", j.Pipeline, at, err)
+
+				return
+			}
+		}
+
+		// Runs preempted once succeeded, eg.: between their end and the slot release, aren't executed again.
+		err := s.run(pipeline.WithExecutionID(runCtx, r.id), r, resume)
+		preempted := err != nil && errors.Is(context.Cause(runCtx), ErrPreempted)
+
+		release()
+
+		switch {
+		case preempted && ctx.Err() == nil:
+			log.Log().Warn(ctx, "Pipeline %s scheduled at %s %s, resuming it once a slot is free", j.Pipeline, at, ErrPreempted)
+		case err != nil:
+			log.Log().Error(ctx, "Error executing pipeline %s scheduled at %s: %s", j.Pipeline, at, err)
+
+			return
+		default:
+			return
+		}
+	}
+}
+
+// run executes the pipeline of the run, resuming it from its checkpoint, if any, once preempted.
+func (s *Scheduler) run(ctx context.Context, r *run, resume bool) error {
+	scope := pipeline.NewScope(s.pipelines)
+
+	if resume {
+		log.Log().Info(ctx, "Resuming pipeline %s scheduled at %s", r.job.Pipeline, r.at)
+
+		var err error
+
+		if s.o.engine != nil {
+			_, err = s.o.engine.Resume(ctx, scope, r.id, s.o.opts...)
+		} else {
+			_, err = s.pipelines.Resume(ctx, scope, r.id, s.o.opts...)
+		}
+
+		if !errors.Is(err, pipeline.ErrNoCheckpointStore) && !errors.Is(err, pipeline.ErrCheckpointNotFound) {
+			return err
+		}
+	}
+
+	log.Log().Info(ctx, "Executing pipeline %s scheduled at %s", r.job.Pipeline, r.at)
+
+	var err error
+
+	if s.o.engine != nil {
+		_, err = s.o.engine.Execute(ctx, scope, []string{r.job.Pipeline}, s.o.opts...)
+	} else {
+		_, err = s.pipelines.Execute(ctx, scope, []string{r.job.Pipeline}, s.o.opts...)
+	}
+
+	return err
+}
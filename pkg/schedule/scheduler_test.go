@@ -0,0 +1,387 @@
+package schedule
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/checkpoint"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+func TestNewScheduler(t *testing.T) {
+	t.Parallel()
+
+	declared := pipeline.New("declared").Build()
+	declared.Schedule = "*/5 * * * *"
+
+	overridden := pipeline.New("overridden").Build()
+	overridden.Schedule = "@daily"
+
+	pipelines := pipeline.NewPipelines(declared, overridden, pipeline.New("unscheduled").Build())
+	hourly, _ := ParseCron("@hourly")
+
+	scheduler, err := NewScheduler(pipelines, WithJobs(Job{Pipeline: "overridden", Cron: hourly, Overlap: OverlapQueue}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	jobs := map[string]Job{}
+	for _, j := range scheduler.Jobs() {
+		jobs[j.Pipeline] = j
+	}
+
+	if len(jobs) != 2 || jobs["declared"].Cron.String() != "*/5 * * * *" || jobs["declared"].Overlap != OverlapSkip {
+		t.Fatalf("unexpected jobs: %+v", jobs)
+	}
+
+	if jobs["overridden"].Cron.String() != "@hourly" || jobs["overridden"].Overlap != OverlapQueue {
+		t.Fatalf("unexpected overridden job: %+v", jobs["overridden"])
+	}
+
+	invalid := []SchedulerOption{
+		WithJobs(Job{Pipeline: "missing", Cron: hourly}),
+		WithJobs(Job{Pipeline: "unscheduled"}),
+		WithJobs(Job{Pipeline: "unscheduled", Cron: hourly, Overlap: "replace"}),
+	}
+
+	for _, opt := range invalid {
+		if _, err := NewScheduler(pipelines, opt); err == nil {
+			t.Fatal("expected an invalid job error")
+		}
+	}
+
+	declared.Schedule = "every minute"
+	if _, err := NewScheduler(pipeline.NewPipelines(declared)); err == nil {
+		t.Fatal("expected an invalid schedule error")
+	}
+}
+
+func TestSchedulerOverlap(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		overlap    string
+		executions int
+		maxRunning int
+	}{
+		{overlap: OverlapSkip, executions: 1, maxRunning: 1},
+		{overlap: OverlapQueue, executions: 3, maxRunning: 1},
+		{overlap: OverlapParallel, executions: 3, maxRunning: 3},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.overlap, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu                            sync.Mutex
+				executions, running, maxCount int
+			)
+
+			started, release := make(chan struct{}, 3), make(chan struct{})
+
+			engine := pipeline.NewEngine()
+			engine.RegisterStepExecutor("block", pipeline.FuncExecutor(func(context.Context, struct{}) (bool, error) {
+				mu.Lock()
+				executions++
+				running++
+				maxCount = max(maxCount, running)
+				mu.Unlock()
+
+				started <- struct{}{}
+				<-release
+
+				mu.Lock()
+				running--
+				mu.Unlock()
+
+				return true, nil
+			}))
+
+			pipelines := pipeline.NewPipelines(pipeline.New("job").Step(pipeline.NewStep("block", "block", map[string]any{})).Build())
+			cron, _ := ParseCron("* * * * *")
+
+			scheduler, err := NewScheduler(pipelines, WithEngine(engine), WithJobs(Job{Pipeline: "job", Cron: cron, Overlap: tc.overlap}))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			j := scheduler.jobs[0]
+			for range 3 {
+				scheduler.trigger(context.Background(), j, time.Now())
+			}
+
+			for range tc.maxRunning {
+				<-started
+			}
+
+			for i := range tc.executions {
+				release <- struct{}{}
+
+				if i+tc.maxRunning < tc.executions {
+					<-started
+				}
+			}
+
+			scheduler.running.Wait()
+
+			if executions != tc.executions || maxCount != tc.maxRunning {
+				t.Fatalf("unexpected executions: %d, max running %d", executions, maxCount)
+			}
+		})
+	}
+}
+
+func TestSchedulerCalendars(t *testing.T) {
+	t.Parallel()
+
+	executed := false
+
+	engine := pipeline.NewEngine()
+	engine.RegisterStepExecutor("mark", pipeline.FuncExecutor(func(context.Context, struct{}) (bool, error) {
+		executed = true
+
+		return true, nil
+	}))
+
+	pipelines := pipeline.NewPipelines(pipeline.New("job").Step(pipeline.NewStep("mark", "mark", map[string]any{})).Build())
+	cron, _ := ParseCron("* * * * *")
+	calendars := Calendars{Default: Calendar{Location: time.UTC, Weekdays: BusinessDays}}
+
+	scheduler, err := NewScheduler(pipelines, WithEngine(engine), WithCalendars(calendars), WithJobs(Job{Pipeline: "job", Cron: cron}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	scheduler.trigger(context.Background(), scheduler.jobs[0], time.Date(2025, 12, 27, 10, 0, 0, 0, time.UTC)) // Saturday
+	scheduler.running.Wait()
+
+	if executed {
+		t.Fatal("expected the execution to be skipped on weekends")
+	}
+
+	scheduler.trigger(context.Background(), scheduler.jobs[0], time.Date(2025, 12, 26, 10, 0, 0, 0, time.UTC)) // Friday
+	scheduler.running.Wait()
+
+	if !executed {
+		t.Fatal("expected the execution on business days")
+	}
+}
+
+func TestSchedulerPriority(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		executed []string
+	)
+
+	started, release := make(chan struct{}), make(chan struct{})
+
+	engine := pipeline.NewEngine()
+	engine.RegisterStepExecutor("block", pipeline.FuncExecutor(func(_ context.Context, params struct{ Job string }) (bool, error) {
+		mu.Lock()
+		executed = append(executed, params.Job)
+		mu.Unlock()
+
+		started <- struct{}{}
+		<-release
+
+		return true, nil
+	}))
+
+	build := func(name string) pipeline.Pipeline {
+		return pipeline.New(name).Step(pipeline.NewStep("block", "block", map[string]any{"job": name})).Build()
+	}
+
+	pipelines := pipeline.NewPipelines(build("low"), build("batch"), build("critical"))
+	cron, _ := ParseCron("* * * * *")
+
+	scheduler, err := NewScheduler(pipelines, WithEngine(engine), WithMaxConcurrent(1), WithJobs(
+		Job{Pipeline: "low", Cron: cron},
+		Job{Pipeline: "batch", Cron: cron},
+		Job{Pipeline: "critical", Cron: cron, Priority: 10},
+	))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	waiting := func() int {
+		scheduler.slots.mu.Lock()
+		defer scheduler.slots.mu.Unlock()
+
+		return len(scheduler.slots.waiting)
+	}
+
+	now := time.Now()
+
+	scheduler.trigger(context.Background(), scheduler.jobs[0], now)
+	<-started
+
+	scheduler.trigger(context.Background(), scheduler.jobs[1], now)
+	waitFor(t, func() bool { return waiting() == 1 })
+
+	scheduler.trigger(context.Background(), scheduler.jobs[2], now.Add(time.Second))
+	waitFor(t, func() bool { return waiting() == 2 })
+
+	for range 2 {
+		release <- struct{}{}
+		<-started
+	}
+
+	release <- struct{}{}
+	scheduler.running.Wait()
+
+	if strings.Join(executed, ",") != "low,critical,batch" {
+		t.Fatalf("expected the critical job to jump ahead of the queued ones: %v", executed)
+	}
+
+	if _, err := NewScheduler(pipelines, WithMaxConcurrent(-1)); err == nil {
+		t.Fatal("expected an invalid max concurrent error")
+	}
+}
+
+func TestSchedulerPreemption(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		executed []string
+	)
+
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		executed = append(executed, event)
+	}
+
+	started, release := make(chan struct{}, 1), make(chan struct{})
+
+	engine := pipeline.NewEngine()
+	engine.RegisterStepExecutor("mark", pipeline.FuncExecutor(func(_ context.Context, params struct{ Event string }) (bool, error) {
+		record(params.Event)
+
+		return true, nil
+	}))
+	engine.RegisterStepExecutor("block", pipeline.FuncExecutor(func(ctx context.Context, _ struct{}) (bool, error) {
+		started <- struct{}{}
+
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-release:
+			return true, nil
+		}
+	}))
+
+	pipelines := pipeline.NewPipelines(
+		pipeline.New("report").
+			Step(pipeline.NewStep("prepare", "mark", map[string]any{"event": "prepare"})).
+			Step(pipeline.NewStep("render", "block", map[string]any{})).
+			Step(pipeline.NewStep("publish", "mark", map[string]any{"event": "publish"})).
+			Build(),
+		pipeline.New("billing").Step(pipeline.NewStep("charge", "mark", map[string]any{"event": "charge"})).Build(),
+	)
+	cron, _ := ParseCron("* * * * *")
+
+	scheduler, err := NewScheduler(pipelines, WithEngine(engine), WithMaxConcurrent(1), WithPreemption(),
+		WithExecuteOptions(pipeline.WithCheckpoints(checkpoint.NewFileStore(t.TempDir()))),
+		WithJobs(Job{Pipeline: "report", Cron: cron}, Job{Pipeline: "billing", Cron: cron, Priority: 1}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	scheduler.trigger(context.Background(), scheduler.jobs[0], time.Now())
+	<-started
+
+	scheduler.trigger(context.Background(), scheduler.jobs[1], time.Now())
+	<-started
+
+	close(release)
+	scheduler.running.Wait()
+
+	if strings.Join(executed, ",") != "prepare,charge,publish" {
+		t.Fatalf("expected the report to be preempted by billing and resumed from its checkpoint: %v", executed)
+	}
+}
+
+func TestSchedulerPreemptionAfterSuccess(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		executed []string
+	)
+
+	engine := pipeline.NewEngine()
+	engine.RegisterStepExecutor("mark", pipeline.FuncExecutor(func(_ context.Context, params struct{ Event string }) (bool, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		executed = append(executed, params.Event)
+
+		return true, nil
+	}))
+
+	pipelines := pipeline.NewPipelines(
+		pipeline.New("report").Step(pipeline.NewStep("publish", "mark", map[string]any{"event": "publish"})).Build(),
+		pipeline.New("billing").Step(pipeline.NewStep("charge", "mark", map[string]any{"event": "charge"})).Build(),
+	)
+	cron, _ := ParseCron("* * * * *")
+
+	var (
+		scheduler *Scheduler
+		preempt   sync.Once
+	)
+
+	// preempts the report once it succeeded, before its slot is released.
+	preemptOnSuccess := func(ctx context.Context, scope pipeline.Scope, p pipeline.Pipeline, execute pipeline.Executor) (pipeline.Scope, error) {
+		scope, err := execute(ctx, scope)
+		if err == nil && p.Name == "report" {
+			preempt.Do(func() {
+				scheduler.trigger(context.Background(), scheduler.jobs[1], time.Now())
+				<-ctx.Done()
+			})
+		}
+
+		return scope, err
+	}
+
+	scheduler, err := NewScheduler(pipelines, WithEngine(engine), WithMaxConcurrent(1), WithPreemption(),
+		WithExecuteOptions(pipeline.WithInterceptors(preemptOnSuccess, nil)),
+		WithJobs(Job{Pipeline: "report", Cron: cron}, Job{Pipeline: "billing", Cron: cron, Priority: 1}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	scheduler.trigger(context.Background(), scheduler.jobs[0], time.Now())
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return len(executed) == 2
+	})
+	scheduler.running.Wait()
+
+	if strings.Join(executed, ",") != "publish,charge" {
+		t.Fatalf("expected the succeeded report not to be executed again once preempted: %v", executed)
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,240 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// ErrWindowClosed is returned by the time-window step when no window is open.
+var ErrWindowClosed = errors.New("time window closed")
+
+// Actions of the time-window step when no window is open.
+const (
+	ActionWait = "wait"
+	ActionStop = "stop"
+	ActionFail = "fail"
+)
+
+// RegisterStepExecutor registers the time-window step.
+func RegisterStepExecutor() {
+	pipeline.RegisterStepExecutor("time-window", pipeline.TypedStepExecutor[TimeWindowParams](TimeWindowExecutor))
+}
+
+// TimeWindow is a window of the time-window step: a cron recurrence or an explicit range.
+type TimeWindow struct {
+	Cron     expression.String   `yaml:"cron"`
+	Duration expression.Duration `yaml:"duration"`
+	Start    expression.String   `yaml:"start"`
+	End      expression.String   `yaml:"end"`
+}
+
+// TimeWindowParams defines the parameters for the TimeWindowExecutor.
+type TimeWindowParams struct {
+	Timezone expression.String   `yaml:"timezone"`
+	Windows  []TimeWindow        `yaml:"windows"`
+	Action   expression.String   `yaml:"action"`
+	Timeout  expression.Duration `yaml:"timeout"`
+}
+
+// ReadOnly reports the time window as read-only, only waiting or skipping, see pipeline.ReadOnlyParams.
+func (TimeWindowParams) ReadOnly() bool {
+	return true
+}
+
+type gate struct {
+	recurrences []Recurrence
+	windows     []Window
+}
+
+// open reports whether a window is open at t, or when the next one opens.
+func (g gate) open(t time.Time) (bool, time.Time) {
+	var next time.Time
+
+	for _, recurrence := range g.recurrences {
+		if recurrence.Contains(t) {
+			return true, t
+		}
+
+		next = earliest(next, recurrence.NextStart(t))
+	}
+
+	for _, window := range g.windows {
+		if window.Contains(t) {
+			return true, t
+		}
+
+		if window.Start.After(t) {
+			next = earliest(next, window.Start)
+		}
+	}
+
+	return false, next
+}
+
+func earliest(current, candidate time.Time) time.Time {
+	if current.IsZero() || (!candidate.IsZero() && candidate.Before(current)) {
+		return candidate
+	}
+
+	return current
+}
+
+// TimeWindowExecutor only lets the pipeline proceed during the configured time windows, eg.: deployment windows.
+// Windows are cron recurrences lasting for a duration, or explicit ranges with start and end times
+// (RFC 3339, "2006-01-02 15:04" or "2006-01-02"), both evaluated in the timezone (UTC by default).
+// When no window is open, the action is to wait for the next one (the default), up to the optional timeout,
+// to stop the pipeline, or to fail with ErrWindowClosed.
+// Whether the window was `open` and the `waited` duration are set in the step variable path.
+//
+// Example YAML:
+//
+//	id: deploy
+//	steps:
+//	- type: time-window
+//	  params:
+//	    timezone: 'America/Sao_Paulo'
+//	    windows:
+//	    - cron: '0 9 * * 1-4'
+//	      duration: '7h'
+//	    - start: '2025-12-27 10:00'
+//	      end: '2025-12-27 12:00'
+//	    action: 'wait'
+//	    timeout: '2h'
+func TimeWindowExecutor(ctx context.Context, scope pipeline.Scope, step pipeline.Step, params TimeWindowParams) (pipeline.Scope, error) {
+	g, err := newGate(ctx, scope, params)
+	if err != nil {
+		return scope, err
+	}
+
+	action, err := params.Action.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	timeout, err := params.Timeout.Eval(ctx, scope)
+	if err != nil {
+		return scope, err
+	}
+
+	started := time.Now()
+
+	open, next := g.open(started)
+
+	result := func() map[string]any {
+		return map[string]any{"open": open, "waited": time.Since(started).String()}
+	}
+
+	if open {
+		return scope.WithVariable(step.VariablePath(), result()), nil
+	}
+
+	closed := fmt.Errorf("%w until %s", ErrWindowClosed, next.Format(time.RFC3339))
+	if next.IsZero() {
+		closed = fmt.Errorf("%w: no upcoming window", ErrWindowClosed)
+	}
+
+	switch action {
+	case ActionWait, "":
+	case ActionStop:
+		return pipeline.StopExecutor(ctx, scope.WithVariable(step.VariablePath(), result()), step, pipeline.StopParams{
+			Condition: "true",
+			Message:   expression.String(closed.Error()),
+		})
+	case ActionFail:
+		return scope, closed
+	default:
+		return scope, fmt.Errorf("unknown time-window action: %s", action)
+	}
+
+	if next.IsZero() || (timeout > 0 && next.After(started.Add(timeout))) {
+		return scope, closed
+	}
+
+	log.Log().Info(ctx, "Waiting for the time window opening at %s", next.Format(time.RFC3339))
+
+	select {
+	case <-ctx.Done():
+		return scope, ctx.Err()
+	case <-time.After(time.Until(next)):
+	}
+
+	open = true
+
+	return scope.WithVariable(step.VariablePath(), result()), nil
+}
+
+func newGate(ctx context.Context, scope pipeline.Scope, params TimeWindowParams) (gate, error) {
+	timezone, err := params.Timezone.Eval(ctx, scope)
+	if err != nil {
+		return gate{}, err
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return gate{}, err
+	}
+
+	if len(params.Windows) == 0 {
+		return gate{}, errors.New("time-window requires windows")
+	}
+
+	var g gate
+
+	for _, entry := range params.Windows {
+		values := make([]string, 3)
+
+		for i, value := range []expression.String{entry.Cron, entry.Start, entry.End} {
+			if values[i], err = value.Eval(ctx, scope); err != nil {
+				return gate{}, err
+			}
+		}
+
+		expr, start, end := values[0], values[1], values[2]
+
+		if expr != "" {
+			recurrence, err := newRecurrence(ctx, scope, expr, entry.Duration, location)
+			if err != nil {
+				return gate{}, err
+			}
+
+			g.recurrences = append(g.recurrences, recurrence)
+
+			continue
+		}
+
+		window, err := WindowConfig{Start: start, End: end}.window(location)
+		if err != nil {
+			return gate{}, err
+		}
+
+		g.windows = append(g.windows, window)
+	}
+
+	return g, nil
+}
+
+func newRecurrence(
+	ctx context.Context, scope pipeline.Scope, expr string, duration expression.Duration, location *time.Location,
+) (Recurrence, error) {
+	cron, err := ParseCron(expr)
+	if err != nil {
+		return Recurrence{}, err
+	}
+
+	d, err := duration.Eval(ctx, scope)
+	if err != nil {
+		return Recurrence{}, err
+	}
+
+	if d <= 0 {
+		return Recurrence{}, fmt.Errorf("time window %q requires a duration", expr)
+	}
+
+	return Recurrence{Cron: cron, Duration: d, Location: location}, nil
+}
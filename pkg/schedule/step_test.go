@@ -0,0 +1,86 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+func TestTimeWindowExecutor(t *testing.T) {
+	t.Parallel()
+
+	window := func(start, end time.Duration) map[string]any {
+		return map[string]any{
+			"start": time.Now().Add(start).Format(time.RFC3339Nano),
+			"end":   time.Now().Add(end).Format(time.RFC3339Nano),
+		}
+	}
+
+	tests := []struct {
+		name     string
+		params   map[string]any
+		open     bool
+		finished bool
+		err      error
+	}{
+		{
+			name:   "open window",
+			params: map[string]any{"windows": []any{window(-time.Hour, time.Hour)}},
+			open:   true,
+		},
+		{
+			name:   "open recurrence",
+			params: map[string]any{"windows": []any{map[string]any{"cron": "* * * * *", "duration": "1m"}}},
+			open:   true,
+		},
+		{
+			name:   "waits for the next window",
+			params: map[string]any{"windows": []any{window(50*time.Millisecond, time.Hour)}},
+			open:   true,
+		},
+		{
+			name:   "fails waiting beyond the timeout",
+			params: map[string]any{"windows": []any{window(time.Hour, 2*time.Hour)}, "timeout": "1m"},
+			err:    ErrWindowClosed,
+		},
+		{
+			name:     "stops",
+			params:   map[string]any{"windows": []any{window(time.Hour, 2*time.Hour)}, "action": "stop"},
+			finished: true,
+		},
+		{
+			name:   "fails",
+			params: map[string]any{"windows": []any{window(-2*time.Hour, -time.Hour)}, "action": "fail"},
+			err:    ErrWindowClosed,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			executor := pipeline.TypedStepExecutor[TimeWindowParams](TimeWindowExecutor)
+
+			scope, err := executor.Execute(context.Background(), pipeline.NewScope(pipeline.Pipelines{}), pipeline.NewStep("gate", "time-window", tc.params))
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("unexpected error: got %v want %v", err, tc.err)
+			}
+
+			if tc.err != nil {
+				return
+			}
+
+			if scope.Finished != tc.finished {
+				t.Fatalf("unexpected finished: %v", scope.Finished)
+			}
+
+			gate, _ := pipeline.Get[map[string]any](scope, "gate")
+			if gate["open"] != tc.open {
+				t.Fatalf("unexpected gate: %#v", gate)
+			}
+		})
+	}
+}
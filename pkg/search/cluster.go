@@ -0,0 +1,201 @@
+// Package search provides steps indexing and searching documents in Elasticsearch or OpenSearch clusters.
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrBulkFailed is returned when some documents of a bulk request fail to be indexed.
+var ErrBulkFailed = errors.New("bulk indexing failed")
+
+// Client sends the cluster requests, eg.: http.DefaultClient.
+type Client interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// Cluster is an Elasticsearch or OpenSearch cluster reachable over its REST API.
+type Cluster struct {
+	// URL is the cluster base URL, eg.: http://localhost:9200.
+	URL    string
+	Client Client
+	// Header is sent with every request, eg.: Authorization.
+	Header http.Header
+}
+
+// do sends a request with a JSON or NDJSON body and decodes the JSON response into out.
+func (c Cluster) do(ctx context.Context, method, path, contentType string, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.URL, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header = c.Header.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	blob, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, blob)
+	}
+
+	return json.Unmarshal(blob, out)
+}
+
+// Index indexes a document, with an ID generated by the cluster when empty.
+func (c Cluster) Index(ctx context.Context, index, id string, document any) (map[string]any, error) {
+	body, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	method, path := http.MethodPost, "/"+url.PathEscape(index)+"/_doc"
+	if id != "" {
+		method, path = http.MethodPut, path+"/"+url.PathEscape(id)
+	}
+
+	var result map[string]any
+
+	return result, c.do(ctx, method, path, "application/json", body, &result)
+}
+
+// BulkResult summarizes a bulk request.
+type BulkResult struct {
+	Took   int
+	Items  int
+	Failed int
+}
+
+// BulkIndex indexes the documents in a single request, taking their IDs from the idField when set.
+// Some documents may be indexed even when it fails with ErrBulkFailed.
+func (c Cluster) BulkIndex(ctx context.Context, index, idField string, documents []any) (BulkResult, error) {
+	var body bytes.Buffer
+
+	encoder := json.NewEncoder(&body)
+
+	for _, document := range documents {
+		action := map[string]any{"_index": index}
+
+		if fields, ok := document.(map[string]any); ok && idField != "" && fields[idField] != nil {
+			action["_id"] = fmt.Sprint(fields[idField])
+		}
+
+		if err := encoder.Encode(map[string]any{"index": action}); err != nil {
+			return BulkResult{}, err
+		}
+
+		if err := encoder.Encode(document); err != nil {
+			return BulkResult{}, err
+		}
+	}
+
+	var response struct {
+		Took   int  `json:"took"`
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+			Error  any `json:"error"`
+		} `json:"items"`
+	}
+
+	if err := c.do(ctx, http.MethodPost, "/_bulk", "application/x-ndjson", body.Bytes(), &response); err != nil {
+		return BulkResult{}, err
+	}
+
+	result := BulkResult{Took: response.Took, Items: len(response.Items)}
+
+	var first any
+
+	for _, item := range response.Items {
+		for _, outcome := range item {
+			if outcome.Error != nil {
+				result.Failed++
+
+				if first == nil {
+					first = outcome.Error
+				}
+			}
+		}
+	}
+
+	if result.Failed > 0 {
+		return result, fmt.Errorf("%w: %d of %d documents, first error: %v", ErrBulkFailed, result.Failed, result.Items, first)
+	}
+
+	return result, nil
+}
+
+// SearchResult holds the hits and aggregations of a search.
+type SearchResult struct {
+	Took         int
+	Total        int
+	Hits         []any
+	Aggregations map[string]any
+}
+
+// Search runs a query DSL request on the index.
+// Each hit is its source with the _id, _index and _score fields added.
+func (c Cluster) Search(ctx context.Context, index string, query map[string]any) (SearchResult, error) {
+	body, err := json.Marshal(query)
+	if err != nil {
+		return SearchResult{}, err
+	}
+
+	var response struct {
+		Took int `json:"took"`
+		Hits struct {
+			Total struct {
+				Value int `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				ID     string         `json:"_id"`
+				Index  string         `json:"_index"`
+				Score  float64        `json:"_score"`
+				Source map[string]any `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+		Aggregations map[string]any `json:"aggregations"`
+	}
+
+	if err := c.do(ctx, http.MethodPost, "/"+url.PathEscape(index)+"/_search", "application/json", body, &response); err != nil {
+		return SearchResult{}, err
+	}
+
+	result := SearchResult{Took: response.Took, Total: response.Hits.Total.Value, Hits: []any{}, Aggregations: response.Aggregations}
+
+	for _, hit := range response.Hits.Hits {
+		document := map[string]any{}
+		for key, value := range hit.Source {
+			document[key] = value
+		}
+
+		document["_id"] = hit.ID
+		document["_index"] = hit.Index
+		document["_score"] = hit.Score
+
+		result.Hits = append(result.Hits, document)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,172 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+	"gopkg.in/yaml.v3"
+)
+
+type request struct {
+	method, path, body string
+}
+
+func newCluster(t *testing.T, response string, requests *[]request) Cluster {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*requests = append(*requests, request{method: r.Method, path: r.URL.Path, body: string(body)})
+
+		if r.Header.Get("Authorization") != "ApiKey secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+
+	return Cluster{URL: server.URL + "/", Client: server.Client(), Header: http.Header{"Authorization": {"ApiKey secret"}}}
+}
+
+func loadStep(t *testing.T, definition string) pipeline.Step {
+	t.Helper()
+
+	var step pipeline.Step
+	if err := yaml.Unmarshal([]byte(definition), &step); err != nil {
+		t.Fatal(err)
+	}
+
+	return step
+}
+
+func TestIndexExecutor(t *testing.T) {
+	t.Parallel()
+
+	var requests []request
+
+	cluster := newCluster(t, `{"_id":"42","result":"created"}`, &requests)
+	step := loadStep(t, `
+id: index-order
+type: search-index
+params:
+  index: 'orders'
+  id: '{{ variable . "order" }}'
+  document:
+    id: '{{ variable . "order" }}'
+    paid: true
+`)
+
+	scope := pipeline.NewScope(pipeline.Pipelines{}).WithVariable("order", "42")
+
+	scope, err := IndexExecutor(cluster).Execute(context.Background(), scope, step)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := requests[0]; got.method != http.MethodPut || got.path != "/orders/_doc/42" || got.body != `{"id":"42","paid":true}` {
+		t.Fatalf("unexpected request: %+v", got)
+	}
+
+	if result, _ := pipeline.Get[map[string]any](scope, "index-order"); result["result"] != "created" {
+		t.Fatalf("unexpected result: %v", result)
+	}
+}
+
+func TestBulkIndexExecutor(t *testing.T) {
+	t.Parallel()
+
+	var requests []request
+
+	cluster := newCluster(t, `{"took":3,"errors":true,"items":[
+		{"index":{"status":201}},
+		{"index":{"status":400,"error":{"type":"mapper_parsing_exception"}}}
+	]}`, &requests)
+	step := loadStep(t, `
+id: index-orders
+type: search-bulk-index
+params:
+  index: 'orders'
+  id_field: 'id'
+  variable: 'orders'
+`)
+
+	scope := pipeline.NewScope(pipeline.Pipelines{}).WithVariable("orders", []any{
+		map[string]any{"id": 1},
+		map[string]any{"id": 2},
+	})
+
+	scope, err := BulkIndexExecutor(cluster).Execute(context.Background(), scope, step)
+	if !errors.Is(err, ErrBulkFailed) {
+		t.Fatalf("expected bulk failure, got %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(requests[0].body), "\n")
+	if requests[0].path != "/_bulk" || len(lines) != 4 || lines[0] != `{"index":{"_id":"1","_index":"orders"}}` {
+		t.Fatalf("unexpected request: %+v", requests[0])
+	}
+
+	if result, _ := pipeline.Get[map[string]any](scope, "index-orders"); result["failed"] != 1 || result["items"] != 2 {
+		t.Fatalf("unexpected result: %v", result)
+	}
+}
+
+func TestSearchExecutor(t *testing.T) {
+	t.Parallel()
+
+	var requests []request
+
+	cluster := newCluster(t, `{"took":1,"hits":{"total":{"value":1},"hits":[
+		{"_id":"42","_index":"orders","_score":1.5,"_source":{"paid":true}}
+	]}}`, &requests)
+	step := loadStep(t, `
+id: paid-orders
+type: search
+params:
+  index: 'orders'
+  query:
+    size: 10
+    query:
+      term:
+        paid: '{{ variable . "paid" }}'
+`)
+
+	scope := pipeline.NewScope(pipeline.Pipelines{}).WithVariable("paid", true)
+
+	scope, err := SearchExecutor(cluster).Execute(context.Background(), scope, step)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var query map[string]any
+	if err := json.Unmarshal([]byte(requests[0].body), &query); err != nil || query["size"] != float64(10) {
+		t.Fatalf("unexpected query: %s", requests[0].body)
+	}
+
+	result, _ := pipeline.Get[map[string]any](scope, "paid-orders")
+	hits, _ := result["hits"].([]any)
+
+	if result["total"] != 1 || len(hits) != 1 || hits[0].(map[string]any)["_id"] != "42" {
+		t.Fatalf("unexpected result: %v", result)
+	}
+}
+
+func TestClusterErrors(t *testing.T) {
+	t.Parallel()
+
+	var requests []request
+
+	cluster := newCluster(t, `{"error":"unauthorized"}`, &requests)
+	cluster.Header = nil
+
+	if _, err := cluster.Search(context.Background(), "orders", nil); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("expected unauthorized error, got %v", err)
+	}
+}
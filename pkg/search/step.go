@@ -0,0 +1,175 @@
+package search
+
+import (
+	"context"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// RegisterStepExecutors registers the search-index, search-bulk-index and search steps sending requests to the cluster.
+func RegisterStepExecutors(cluster Cluster) {
+	pipeline.RegisterStepExecutor("search-index", IndexExecutor(cluster))
+	pipeline.RegisterStepExecutor("search-bulk-index", BulkIndexExecutor(cluster))
+	pipeline.RegisterStepExecutor("search", SearchExecutor(cluster))
+}
+
+type IndexParams struct {
+	Index    expression.String    `yaml:"index"`
+	ID       expression.String    `yaml:"id"`
+	Document expression.YAML[any] `yaml:"document"`
+}
+
+// IndexExecutor indexes a document. The cluster response (eg.: _id, result) is stored in the step variable path.
+//
+// Example YAML:
+//
+//	id: index-example
+//	steps:
+//	- id: index-order
+//	  type: search-index
+//	  params:
+//	    index: 'orders'
+//	    id: '{{ variable . "order.id" }}'
+//	    document:
+//	      id: '{{ variable . "order.id" }}'
+//	      total: '{{ variable . "order.total" }}'
+func IndexExecutor(cluster Cluster) pipeline.StepExecutor {
+	return pipeline.TypedStepExecutor[IndexParams](
+		func(ctx context.Context, scope pipeline.Scope, step pipeline.Step, p IndexParams) (pipeline.Scope, error) {
+			index, err := p.Index.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			id, err := p.ID.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			document, err := p.Document.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			result, err := cluster.Index(ctx, index, id, document)
+			if err != nil {
+				return scope, err
+			}
+
+			return scope.WithVariable(step.VariablePath(), result), nil
+		},
+	)
+}
+
+type BulkIndexParams struct {
+	Index     expression.String      `yaml:"index"`
+	IDField   expression.String      `yaml:"id_field"`
+	Documents expression.YAML[[]any] `yaml:"documents"`
+	Variable  pipeline.VariablePath  `yaml:"variable"`
+}
+
+// BulkIndexExecutor indexes a list of documents, from the documents param or a variable, in a single request.
+// The took, items and failed counts are stored in the step variable path, even when some documents fail.
+//
+// Example YAML:
+//
+//	id: bulk-example
+//	steps:
+//	- id: index-orders
+//	  type: search-bulk-index
+//	  params:
+//	    index: 'orders'
+//	    id_field: 'id'
+//	    variable: 'orders.rows'
+func BulkIndexExecutor(cluster Cluster) pipeline.StepExecutor {
+	return pipeline.TypedStepExecutor[BulkIndexParams](
+		func(ctx context.Context, scope pipeline.Scope, step pipeline.Step, p BulkIndexParams) (pipeline.Scope, error) {
+			index, err := p.Index.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			idField, err := p.IDField.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			documents, err := p.documents(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			result, err := cluster.BulkIndex(ctx, index, idField, documents)
+
+			return scope.WithVariable(step.VariablePath(), map[string]any{
+				"took":   result.Took,
+				"items":  result.Items,
+				"failed": result.Failed,
+			}), err
+		},
+	)
+}
+
+func (p BulkIndexParams) documents(ctx context.Context, scope pipeline.Scope) ([]any, error) {
+	if p.Variable == "" {
+		return p.Documents.Eval(ctx, scope)
+	}
+
+	return pipeline.Get[[]any](scope, p.Variable)
+}
+
+type SearchParams struct {
+	Index expression.String               `yaml:"index"`
+	Query expression.YAML[map[string]any] `yaml:"query"`
+}
+
+// ReadOnly reports the search as read-only, see pipeline.ReadOnlyParams.
+func (SearchParams) ReadOnly() bool {
+	return true
+}
+
+// SearchExecutor runs a query DSL request rendered from the scope. The took, total, hits (sources with
+// _id, _index and _score) and aggregations are stored in the step variable path.
+//
+// Example YAML:
+//
+//	id: search-example
+//	steps:
+//	- id: recent-orders
+//	  type: search
+//	  params:
+//	    index: 'orders'
+//	    query:
+//	      size: 10
+//	      query:
+//	        range:
+//	          created_at:
+//	            gte: '{{ variable . "since" }}'
+func SearchExecutor(cluster Cluster) pipeline.StepExecutor {
+	return pipeline.TypedStepExecutor[SearchParams](
+		func(ctx context.Context, scope pipeline.Scope, step pipeline.Step, p SearchParams) (pipeline.Scope, error) {
+			index, err := p.Index.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			query, err := p.Query.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			result, err := cluster.Search(ctx, index, query)
+			if err != nil {
+				return scope, err
+			}
+
+			return scope.WithVariable(step.VariablePath(), map[string]any{
+				"took":         result.Took,
+				"total":        result.Total,
+				"hits":         result.Hits,
+				"aggregations": result.Aggregations,
+			}), nil
+		},
+	)
+}
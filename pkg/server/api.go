@@ -0,0 +1,147 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/samber/lo"
+)
+
+// RegisterAPI registers the REST endpoints triggering the runner pipelines and following their runs:
+//
+//	POST /pipelines/{name}/run  starts the pipeline with the variables of the JSON object body, if any,
+//	                            replying 202 with the running execution, or 200 with the finished one
+//	                            when waiting with ?wait=true.
+//	GET  /runs/{id}             replies the execution status and, once finished, its outputs and artifacts.
+//	GET  /runs/{id}/artifacts/{name...}
+//	                            replies the content of an artifact of the execution, see WithArtifacts.
+//
+// The request bodies are limited to DefaultMaxPayload. Protect the endpoints like the other ones, eg.: with Authenticate,
+// and authorize their callers with WithAuthorizer: running requires ActionExecute on the pipeline, unknown ones included,
+// and the run endpoints ActionRead on the pipelines of the execution, replying 403 otherwise.
+func RegisterAPI(mux *http.ServeMux, runner *Runner) {
+	api := restAPI{runner: runner}
+
+	mux.HandleFunc("POST /pipelines/{name}/run", api.run)
+	mux.HandleFunc("GET /runs/{id}", api.status)
+	mux.HandleFunc("GET /runs/{id}/artifacts/{name...}", api.artifact)
+}
+
+type restAPI struct {
+	runner *Runner
+}
+
+func (api restAPI) run(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	// Authorizing before the lookup denies the unknown pipelines like the forbidden ones, by their missing tags,
+	// so the callers can't probe their names.
+	if err := api.runner.Authorize(r.Context(), ActionExecute, []string{name}); err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	if _, found := api.runner.Pipelines().Get(name); !found {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": fmt.Sprintf("pipeline %s not found", name)})
+
+		return
+	}
+
+	wait := false
+	if value := r.URL.Query().Get("wait"); value != "" {
+		var err error
+		if wait, err = strconv.ParseBool(value); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid wait: " + value})
+
+			return
+		}
+	}
+
+	variables := map[string]any{}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, DefaultMaxPayload))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&variables); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid variables: " + err.Error()})
+
+		return
+	}
+
+	execution, err := api.runner.Execute(r.Context(), ExecuteRequest{Pipelines: []string{name}, Variables: variables})
+	if err != nil {
+		writeExecuteError(w, err)
+
+		return
+	}
+
+	if !wait {
+		writeJSON(w, http.StatusAccepted, execution)
+
+		return
+	}
+
+	execution, err = api.runner.Wait(r.Context(), execution.ID)
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, execution)
+}
+
+func (api restAPI) status(w http.ResponseWriter, r *http.Request) {
+	execution, err := api.runner.authorizeExecution(r.Context(), ActionRead, r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, execution)
+}
+
+func (api restAPI) artifact(w http.ResponseWriter, r *http.Request) {
+	writeArtifact(w, r, api.runner)
+}
+
+// writeArtifact replies the content of the execution artifact named by the request path, once reading it is authorized.
+func writeArtifact(w http.ResponseWriter, r *http.Request, runner *Runner) {
+	name, id := r.PathValue("name"), r.PathValue("id")
+
+	if _, err := runner.authorizeExecution(r.Context(), ActionRead, id); err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	content, err := runner.Artifact(r.Context(), id, name)
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	defer func() { _ = content.Close() }()
+
+	w.Header().Set("Content-Type", lo.CoalesceOrEmpty(mime.TypeByExtension(path.Ext(name)), "application/octet-stream"))
+	_, _ = io.Copy(w, content)
+}
+
+// writeExecuteError replies the error starting an execution, a conflict for IDs already in use and a bad request otherwise.
+func writeExecuteError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, ErrExecutionExists) {
+		status = http.StatusConflict
+	}
+
+	writeJSON(w, status, map[string]any{"error": err.Error()})
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/artifact"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+func TestRegisterAPI(t *testing.T) {
+	t.Parallel()
+
+	pipelines := pipeline.NewPipelines(
+		pipeline.New("greet").Set("greeting", map[string]any{"text": `{{ variable . "name" }} x{{ variable . "times" }}`}).Build(),
+	)
+
+	mux := http.NewServeMux()
+	RegisterAPI(mux, NewRunner(pipelines, WithEngine(pipeline.NewEngine())))
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+	}{
+		{name: "unknown pipeline", path: "/pipelines/missing/run", status: http.StatusNotFound},
+		{name: "invalid variables", path: "/pipelines/greet/run", body: `["bob"]`, status: http.StatusBadRequest},
+		{name: "invalid wait", path: "/pipelines/greet/run?wait=maybe", status: http.StatusBadRequest},
+		{name: "background run", path: "/pipelines/greet/run", body: `{"name": "bob", "times": 2}`, status: http.StatusAccepted},
+		{name: "awaited run", path: "/pipelines/greet/run?wait=true", body: `{"name": "bob", "times": 2}`, status: http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		resp, err := http.Post(server.URL+tc.path, "application/json", strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+
+		var execution Execution
+
+		err = json.NewDecoder(resp.Body).Decode(&execution)
+		resp.Body.Close()
+
+		if err != nil || resp.StatusCode != tc.status {
+			t.Fatalf("%s: unexpected response: %d, %v", tc.name, resp.StatusCode, err)
+		}
+
+		if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+			continue
+		}
+
+		if tc.status == http.StatusAccepted {
+			decode(t, server.URL+"/runs/"+execution.ID, &execution)
+
+			if execution.ID == "" || execution.Pipelines[0] != "greet" {
+				t.Fatalf("%s: unexpected run: %+v", tc.name, execution)
+			}
+
+			continue
+		}
+
+		greeting, _ := execution.Outputs["greeting"].(map[string]any)
+		if execution.Status != StatusSucceeded || greeting["text"] != "bob x2" {
+			t.Fatalf("%s: unexpected run: %+v", tc.name, execution)
+		}
+	}
+
+	resp, err := http.Get(server.URL + "/runs/missing")
+	if err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unexpected response: %v, %v", resp, err)
+	}
+
+	resp.Body.Close()
+}
+
+func TestRegisterAPIArtifacts(t *testing.T) {
+	t.Parallel()
+
+	store := artifact.NewLocalStore(t.TempDir())
+
+	engine := pipeline.NewEngine()
+	engine.RegisterStepExecutor("artifact", artifact.StepExecutor(store))
+
+	pipelines := pipeline.NewPipelines(pipeline.New("report").
+		Step(pipeline.NewStep("", "artifact", map[string]any{"name": "reports/summary.txt", "text": "all good"})).
+		Build())
+
+	mux := http.NewServeMux()
+	RegisterAPI(mux, NewRunner(pipelines, WithEngine(engine), WithArtifacts(store)))
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/pipelines/report/run?wait=true", "application/json", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var execution Execution
+
+	err = json.NewDecoder(resp.Body).Decode(&execution)
+	resp.Body.Close()
+
+	if err != nil || len(execution.Artifacts) != 1 || execution.Artifacts[0].Name != "reports/summary.txt" {
+		t.Fatalf("unexpected run: %+v, %v", execution, err)
+	}
+
+	resp, err = http.Get(server.URL + "/runs/" + execution.ID + "/artifacts/reports/summary.txt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if err != nil || resp.StatusCode != http.StatusOK || string(content) != "all good" {
+		t.Fatalf("unexpected artifact: %d, %q, %v", resp.StatusCode, content, err)
+	}
+
+	resp, err = http.Get(server.URL + "/runs/" + execution.ID + "/artifacts/missing.txt")
+	if err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unexpected response: %v, %v", resp, err)
+	}
+
+	resp.Body.Close()
+}
+
+func TestRegisterAPIAuthorization(t *testing.T) {
+	t.Parallel()
+
+	pipelines := pipeline.NewPipelines(
+		pipeline.New("deploy").Tags("deploy").Build(),
+		pipeline.New("report").Tags("reports").Build(),
+	)
+
+	runner := NewRunner(pipelines, WithEngine(pipeline.NewEngine()), WithAuthorizer(RoleAuthorizer(pipelines,
+		Role{Name: "deployer", Actions: []string{ActionExecute, ActionRead}, Tags: []string{"deploy"}},
+		Role{Name: "reporter", Actions: []string{ActionExecute}, Tags: []string{"reports"}},
+	)))
+
+	mux := http.NewServeMux()
+	RegisterAPI(mux, runner)
+
+	server := httptest.NewServer(Authenticate(APIKeys{
+		"deployer-key": {Subject: "ci", Roles: []string{"deployer"}},
+		"reporter-key": {Subject: "bi", Roles: []string{"reporter"}},
+	})(mux))
+	defer server.Close()
+
+	request := func(method, path, key string) *http.Response {
+		req, _ := http.NewRequest(method, server.URL+path, nil)
+		req.Header.Set(HeaderAPIKey, key)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return resp
+	}
+
+	resp := request(http.MethodPost, "/pipelines/deploy/run?wait=true", "reporter-key")
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected the run to be forbidden, got %d", resp.StatusCode)
+	}
+
+	resp = request(http.MethodPost, "/pipelines/missing/run", "reporter-key")
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected the unknown pipelines to be forbidden like the known ones, got %d", resp.StatusCode)
+	}
+
+	resp = request(http.MethodPost, "/pipelines/deploy/run?wait=true", "deployer-key")
+
+	var execution Execution
+
+	err := json.NewDecoder(resp.Body).Decode(&execution)
+	resp.Body.Close()
+
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected response: %d, %v", resp.StatusCode, err)
+	}
+
+	for key, status := range map[string]int{"deployer-key": http.StatusOK, "reporter-key": http.StatusForbidden} {
+		resp := request(http.MethodGet, "/runs/"+execution.ID, key)
+		resp.Body.Close()
+
+		if resp.StatusCode != status {
+			t.Fatalf("unexpected status reading the run with %s: got %d want %d", key, resp.StatusCode, status)
+		}
+	}
+}
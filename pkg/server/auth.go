@@ -0,0 +1,241 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// HeaderAPIKey is the header holding the API keys, see APIKeys.
+const HeaderAPIKey = "X-API-Key"
+
+// CookieAPIKey is the cookie holding the API keys of the browsers, set by the web UI, see APIKeys and UIPage.
+const CookieAPIKey = "pipeline_api_key"
+
+// RoleAny grants a role on every action or tag.
+const RoleAny = "*"
+
+var (
+	// ErrNoCredentials is returned by authenticators when the request lacks their credentials,
+	// so the next authenticator is tried.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrUnauthenticated is returned when the credentials of a request are invalid.
+	ErrUnauthenticated = errors.New("unauthenticated")
+)
+
+// Principal is an authenticated caller.
+type Principal struct {
+	Subject string
+	Roles   []string
+}
+
+type principalKey struct{}
+
+// WithPrincipal sets the authenticated caller in the context.
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the authenticated caller of the context, set by Authenticate.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+
+	return principal, ok
+}
+
+// Authenticator identifies the caller of a request by its headers, so it serves HTTP requests
+// as well as gRPC ones through their metadata. It returns ErrNoCredentials when the headers lack its credentials.
+type Authenticator interface {
+	Authenticate(ctx context.Context, header http.Header) (Principal, error)
+}
+
+// AuthenticatorFunc is a function implementing Authenticator.
+type AuthenticatorFunc func(ctx context.Context, header http.Header) (Principal, error)
+
+// Authenticate calls the function.
+func (f AuthenticatorFunc) Authenticate(ctx context.Context, header http.Header) (Principal, error) {
+	return f(ctx, header)
+}
+
+// APIKeys authenticates the callers by the API key in the HeaderAPIKey header, or in the CookieAPIKey cookie
+// (URL-encoded) of the browsers, which can't set headers on the event streams and links of the web UI.
+type APIKeys map[string]Principal
+
+// Authenticate returns the principal of the API key.
+func (k APIKeys) Authenticate(_ context.Context, header http.Header) (Principal, error) {
+	key := header.Get(HeaderAPIKey)
+	if key == "" {
+		key = cookieAPIKey(header)
+	}
+
+	if key == "" {
+		return Principal{}, ErrNoCredentials
+	}
+
+	for candidate, principal := range k {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(key)) == 1 {
+			return principal, nil
+		}
+	}
+
+	return Principal{}, fmt.Errorf("%w: unknown API key", ErrUnauthenticated)
+}
+
+func cookieAPIKey(header http.Header) string {
+	cookie, err := (&http.Request{Header: header}).Cookie(CookieAPIKey)
+	if err != nil {
+		return ""
+	}
+
+	key, err := url.PathUnescape(cookie.Value)
+	if err != nil {
+		return cookie.Value
+	}
+
+	return key
+}
+
+// TokenVerifier verifies bearer tokens, eg.: OIDCVerifier.
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (Principal, error)
+}
+
+// BearerTokens authenticates the callers by the bearer token in the Authorization header.
+func BearerTokens(verifier TokenVerifier) Authenticator {
+	return AuthenticatorFunc(func(ctx context.Context, header http.Header) (Principal, error) {
+		scheme, token, _ := strings.Cut(header.Get("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || token == "" {
+			return Principal{}, ErrNoCredentials
+		}
+
+		principal, err := verifier.Verify(ctx, token)
+		if err != nil {
+			return Principal{}, fmt.Errorf("%w: %w", ErrUnauthenticated, err)
+		}
+
+		return principal, nil
+	})
+}
+
+// Authenticate returns a middleware identifying the caller with the first authenticator finding its credentials,
+// setting it in the request context, see PrincipalFrom. Requests without valid credentials are rejected.
+func Authenticate(authenticators ...Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, err := authenticate(r.Context(), r.Header, authenticators)
+			if err != nil {
+				writeJSON(w, http.StatusUnauthorized, map[string]any{"error": err.Error()})
+
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+func authenticate(ctx context.Context, header http.Header, authenticators []Authenticator) (Principal, error) {
+	for _, authenticator := range authenticators {
+		principal, err := authenticator.Authenticate(ctx, header)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+
+		return principal, err
+	}
+
+	return Principal{}, fmt.Errorf("%w: %w", ErrUnauthenticated, ErrNoCredentials)
+}
+
+// Role grants the actions (eg.: ActionExecute) on the pipelines with any of the tags. RoleAny grants every action or tag.
+type Role struct {
+	Name    string
+	Actions []string
+	Tags    []string
+}
+
+func (r Role) allows(action string, tags []string) bool {
+	if !slices.Contains(r.Actions, action) && !slices.Contains(r.Actions, RoleAny) {
+		return false
+	}
+
+	if slices.Contains(r.Tags, RoleAny) {
+		return true
+	}
+
+	return slices.ContainsFunc(tags, func(tag string) bool {
+		return slices.Contains(r.Tags, tag)
+	})
+}
+
+// RoleAuthorizer authorizes the principal of the context when, for every pipeline, one of its roles grants the action
+// on the pipeline tags. Callers without a principal are denied.
+func RoleAuthorizer(pipelines pipeline.Pipelines, roles ...Role) Authorizer {
+	byName := make(map[string]Role, len(roles))
+	for _, role := range roles {
+		byName[role.Name] = role
+	}
+
+	return AuthorizerFunc(func(ctx context.Context, action string, names []string) error {
+		principal, ok := PrincipalFrom(ctx)
+		if !ok {
+			return ErrUnauthenticated
+		}
+
+		for _, name := range names {
+			pipe, _ := pipelines.Get(name)
+
+			allowed := slices.ContainsFunc(principal.Roles, func(role string) bool {
+				return byName[role].allows(action, pipe.Tags)
+			})
+			if !allowed {
+				return fmt.Errorf("%s can't %s pipeline %s", principal.Subject, action, name)
+			}
+		}
+
+		return nil
+	})
+}
+
+// WithAuthorizer authorizes the API, UI and gRPC calls on the runner pipelines and executions, eg.: with RoleAuthorizer.
+// Every call is allowed without an authorizer.
+func WithAuthorizer(authorizer Authorizer) RunnerOption {
+	return func(o *runnerOptions) {
+		o.authorizer = authorizer
+	}
+}
+
+// Authorize returns an error wrapping ErrForbidden unless the runner authorizer allows the caller of the context
+// to perform the action on the pipelines, see WithAuthorizer.
+func (rn *Runner) Authorize(ctx context.Context, action string, pipelines []string) error {
+	if rn.o.authorizer == nil {
+		return nil
+	}
+
+	if err := rn.o.authorizer.Authorize(ctx, action, pipelines); err != nil {
+		return fmt.Errorf("%w: %s %v: %w", ErrForbidden, action, pipelines, err)
+	}
+
+	return nil
+}
+
+// authorizeExecution returns the execution state once the action on its pipelines is authorized.
+func (rn *Runner) authorizeExecution(ctx context.Context, action, id string) (Execution, error) {
+	execution, err := rn.Status(id)
+	if err != nil {
+		return Execution{}, err
+	}
+
+	if err := rn.Authorize(ctx, action, execution.Pipelines); err != nil {
+		return Execution{}, err
+	}
+
+	return execution, nil
+}
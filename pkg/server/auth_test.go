@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+func TestAuthenticate(t *testing.T) {
+	t.Parallel()
+
+	keys := APIKeys{"secret-key": {Subject: "ci", Roles: []string{"deployer"}}}
+
+	var principal Principal
+
+	handler := Authenticate(keys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = PrincipalFrom(r.Context())
+	}))
+
+	for key, expected := range map[string]int{"secret-key": http.StatusOK, "other-key": http.StatusUnauthorized, "": http.StatusUnauthorized} {
+		req := httptest.NewRequest(http.MethodPost, "/executions", nil)
+		if key != "" {
+			req.Header.Set(HeaderAPIKey, key)
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		if recorder.Code != expected {
+			t.Fatalf("unexpected status for key %q: got %d want %d", key, recorder.Code, expected)
+		}
+	}
+
+	if principal.Subject != "ci" {
+		t.Fatalf("unexpected principal: %+v", principal)
+	}
+}
+
+func TestRoleAuthorizer(t *testing.T) {
+	t.Parallel()
+
+	pipelines := pipeline.NewPipelines(
+		pipeline.New("deploy").Tags("deploy").Build(),
+		pipeline.New("report").Tags("reports").Build(),
+	)
+
+	authorizer := RoleAuthorizer(pipelines,
+		Role{Name: "deployer", Actions: []string{ActionExecute, ActionRead}, Tags: []string{"deploy"}},
+		Role{Name: "admin", Actions: []string{RoleAny}, Tags: []string{RoleAny}},
+	)
+
+	deployer := WithPrincipal(context.Background(), Principal{Subject: "ci", Roles: []string{"deployer"}})
+	admin := WithPrincipal(context.Background(), Principal{Subject: "ops", Roles: []string{"admin"}})
+
+	cases := []struct {
+		ctx       context.Context
+		action    string
+		pipelines []string
+		allowed   bool
+	}{
+		{deployer, ActionExecute, []string{"deploy"}, true},
+		{deployer, ActionCancel, []string{"deploy"}, false},
+		{deployer, ActionExecute, []string{"deploy", "report"}, false},
+		{admin, ActionCancel, []string{"deploy", "report"}, true},
+		{context.Background(), ActionRead, []string{"deploy"}, false},
+	}
+
+	for _, c := range cases {
+		err := authorizer.Authorize(c.ctx, c.action, c.pipelines)
+		if (err == nil) != c.allowed {
+			t.Fatalf("unexpected authorization of %s %v: %v", c.action, c.pipelines, err)
+		}
+	}
+}
+
+func TestOIDCVerifier(t *testing.T) {
+	t.Parallel()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var issuer string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			_ = json.NewEncoder(w).Encode(map[string]any{"jwks_uri": issuer + "/keys"})
+		case "/keys":
+			_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]any{{
+				"kty": "RSA",
+				"kid": "key-1",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	issuer = server.URL
+
+	sign := func(kid string, claims map[string]any) string {
+		header, _ := json.Marshal(map[string]any{"alg": "RS256", "kid": kid})
+		payload, _ := json.Marshal(claims)
+		unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+		digest := sha256.Sum256([]byte(unsigned))
+
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+	}
+
+	claims := func(overrides map[string]any) map[string]any {
+		base := map[string]any{
+			"iss": issuer, "aud": []string{"pipelines"}, "sub": "alice", "roles": []string{"deployer"},
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}
+		for name, value := range overrides {
+			base[name] = value
+		}
+
+		return base
+	}
+
+	authenticator := BearerTokens(NewOIDCVerifier(issuer, "pipelines", WithOIDCClient(server.Client())))
+
+	header := http.Header{"Authorization": []string{"Bearer " + sign("key-1", claims(nil))}}
+
+	principal, err := authenticator.Authenticate(context.Background(), header)
+	if err != nil || principal.Subject != "alice" || !slices.Equal(principal.Roles, []string{"deployer"}) {
+		t.Fatalf("unexpected principal: %+v, %v", principal, err)
+	}
+
+	invalid := []string{
+		sign("key-1", claims(map[string]any{"exp": time.Now().Add(-time.Hour).Unix()})),
+		sign("key-1", claims(map[string]any{"aud": "other"})),
+		sign("key-1", claims(map[string]any{"iss": "https://other.example.com"})),
+		sign("key-2", claims(nil)),
+		sign("key-1", claims(nil))[:20],
+	}
+
+	for _, token := range invalid {
+		_, err := authenticator.Authenticate(context.Background(), http.Header{"Authorization": []string{"Bearer " + token}})
+		if !errors.Is(err, ErrUnauthenticated) {
+			t.Fatalf("expected unauthenticated token, got %v", err)
+		}
+	}
+
+	if _, err := authenticator.Authenticate(context.Background(), http.Header{}); !errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("expected no credentials, got %v", err)
+	}
+}
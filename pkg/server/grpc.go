@@ -0,0 +1,290 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pipelinev1 "github.com/crowleyfelix/go-pipeline/api/pipeline/v1"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// RegisterGRPC registers the runner as the pipeline.v1.PipelineService of api/pipeline/v1/pipeline.proto,
+// triggering the pipelines and streaming the lifecycle events of their executions.
+// Protect it like the other endpoints, eg.: with the AuthenticateGRPC interceptors, and authorize its callers
+// with WithAuthorizer, denying the calls with PERMISSION_DENIED.
+func RegisterGRPC(registrar grpc.ServiceRegistrar, runner *Runner) {
+	pipelinev1.RegisterPipelineServiceServer(registrar, grpcService{runner: runner})
+}
+
+// MetadataTenant is the request metadata naming the tenant of the gRPC calls, see RegisterTenantsGRPC.
+const MetadataTenant = "x-tenant"
+
+// RegisterTenantsGRPC registers the tenants as the pipeline.v1.PipelineService, like RegisterGRPC, serving each call
+// with the runner of the tenant named by its MetadataTenant metadata. Unknown tenants are replied with NOT_FOUND.
+func RegisterTenantsGRPC(registrar grpc.ServiceRegistrar, tenants *Tenants) {
+	pipelinev1.RegisterPipelineServiceServer(registrar, tenantsService{tenants: tenants})
+}
+
+type grpcService struct {
+	pipelinev1.UnimplementedPipelineServiceServer
+
+	runner *Runner
+}
+
+func (s grpcService) Execute(ctx context.Context, req *pipelinev1.ExecuteRequest) (*pipelinev1.Execution, error) {
+	if req.GetExecutionId() != "" {
+		ctx = pipeline.WithExecutionID(ctx, req.GetExecutionId())
+	}
+
+	if err := s.runner.Authorize(ctx, ActionExecute, req.GetPipelines()); err != nil {
+		return nil, grpcError(err)
+	}
+
+	execution, err := s.runner.Execute(ctx, ExecuteRequest{Pipelines: req.GetPipelines(), Variables: req.GetVariables().AsMap()})
+	if errors.Is(err, ErrExecutionExists) {
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	}
+
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return toProtoExecution(execution)
+}
+
+func (s grpcService) Status(ctx context.Context, req *pipelinev1.StatusRequest) (*pipelinev1.Execution, error) {
+	execution, err := s.runner.authorizeExecution(ctx, ActionRead, req.GetExecutionId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return toProtoExecution(execution)
+}
+
+func (s grpcService) Cancel(ctx context.Context, req *pipelinev1.CancelRequest) (*pipelinev1.Execution, error) {
+	if _, err := s.runner.authorizeExecution(ctx, ActionCancel, req.GetExecutionId()); err != nil {
+		return nil, grpcError(err)
+	}
+
+	if err := s.runner.Cancel(req.GetExecutionId()); err != nil {
+		return nil, grpcError(err)
+	}
+
+	execution, err := s.runner.Status(req.GetExecutionId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return toProtoExecution(execution)
+}
+
+func (s grpcService) Events(req *pipelinev1.EventsRequest, stream grpc.ServerStreamingServer[pipelinev1.Event]) error {
+	if _, err := s.runner.authorizeExecution(stream.Context(), ActionRead, req.GetExecutionId()); err != nil {
+		return grpcError(err)
+	}
+
+	events, err := s.runner.Events(stream.Context(), req.GetExecutionId())
+	if err != nil {
+		return grpcError(err)
+	}
+
+	for event := range events {
+		if err := stream.Send(toProtoEvent(event)); err != nil {
+			return err
+		}
+	}
+
+	return stream.Context().Err()
+}
+
+// tenantsService serves the calls with the service of the tenant named by their metadata.
+type tenantsService struct {
+	pipelinev1.UnimplementedPipelineServiceServer
+
+	tenants *Tenants
+}
+
+func (s tenantsService) service(ctx context.Context) (grpcService, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	var name string
+	if values := md.Get(MetadataTenant); len(values) > 0 {
+		name = values[0]
+	}
+
+	runner, err := s.tenants.Runner(name)
+	if err != nil {
+		return grpcService{}, grpcError(err)
+	}
+
+	return grpcService{runner: runner}, nil
+}
+
+func (s tenantsService) Execute(ctx context.Context, req *pipelinev1.ExecuteRequest) (*pipelinev1.Execution, error) {
+	service, err := s.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.Execute(ctx, req)
+}
+
+func (s tenantsService) Status(ctx context.Context, req *pipelinev1.StatusRequest) (*pipelinev1.Execution, error) {
+	service, err := s.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.Status(ctx, req)
+}
+
+func (s tenantsService) Cancel(ctx context.Context, req *pipelinev1.CancelRequest) (*pipelinev1.Execution, error) {
+	service, err := s.service(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.Cancel(ctx, req)
+}
+
+func (s tenantsService) Events(req *pipelinev1.EventsRequest, stream grpc.ServerStreamingServer[pipelinev1.Event]) error {
+	service, err := s.service(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	return service.Events(req, stream)
+}
+
+func grpcError(err error) error {
+	switch {
+	case errors.Is(err, ErrExecutionNotFound) || errors.Is(err, ErrTenantNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	return status.Error(codes.Internal, err.Error())
+}
+
+func toProtoExecution(execution Execution) (*pipelinev1.Execution, error) {
+	result := &pipelinev1.Execution{
+		Id:        execution.ID,
+		Pipelines: execution.Pipelines,
+		Status:    execution.Status,
+		Error:     execution.Error,
+		StartedAt: timestamppb.New(execution.StartedAt),
+	}
+
+	if !execution.FinishedAt.IsZero() {
+		result.FinishedAt = timestamppb.New(execution.FinishedAt)
+	}
+
+	if execution.Outputs != nil {
+		outputs, err := toStruct(execution.Outputs)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "outputs: %s", err)
+		}
+
+		result.Outputs = outputs
+	}
+
+	for _, artifact := range execution.Artifacts {
+		result.Artifacts = append(result.Artifacts, &pipelinev1.Artifact{
+			Name:      artifact.Name,
+			Size:      artifact.Size,
+			CreatedAt: timestamppb.New(artifact.CreatedAt),
+		})
+	}
+
+	return result, nil
+}
+
+// toStruct converts the values through JSON, as the REST API replies them, since structpb only takes JSON types.
+func toStruct(values map[string]any) (*structpb.Struct, error) {
+	blob, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &structpb.Struct{}
+
+	return result, result.UnmarshalJSON(blob)
+}
+
+func toProtoEvent(event Event) *pipelinev1.Event {
+	return &pipelinev1.Event{
+		ExecutionId: event.ExecutionID,
+		Type:        event.Type,
+		Pipeline:    event.Pipeline,
+		Step:        event.Step,
+		Status:      event.Status,
+		Error:       event.Error,
+		Time:        timestamppb.New(event.Time),
+		Level:       event.Level,
+		Message:     event.Message,
+		Stream:      event.Stream,
+	}
+}
+
+// AuthenticateGRPC returns the server interceptors identifying the callers with the first authenticator finding its
+// credentials in the request metadata, like Authenticate. Requests without valid credentials are rejected.
+//
+//	grpc.NewServer(grpc.ChainUnaryInterceptor(unary), grpc.ChainStreamInterceptor(stream))
+func AuthenticateGRPC(authenticators ...Authenticator) (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
+	identify := func(ctx context.Context) (context.Context, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+
+		header := http.Header{}
+		for key, values := range md {
+			header[http.CanonicalHeaderKey(key)] = values
+		}
+
+		principal, err := authenticate(ctx, header, authenticators)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		return WithPrincipal(ctx, principal), nil
+	}
+
+	unary := func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := identify(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+
+	stream := func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := identify(ss.Context())
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+
+	return unary, stream
+}
+
+// authenticatedStream carries the context with the caller principal.
+type authenticatedStream struct {
+	grpc.ServerStream
+
+	ctx context.Context
+}
+
+func (s authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	pipelinev1 "github.com/crowleyfelix/go-pipeline/api/pipeline/v1"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+func TestRegisterGRPC(t *testing.T) {
+	t.Parallel()
+
+	pipelines := pipeline.NewPipelines(
+		pipeline.New("greet").Set("greeting", map[string]any{"text": `hello {{ variable . "name" }}`}).Build(),
+	)
+
+	unary, stream := AuthenticateGRPC(APIKeys{"key": {Subject: "alice"}})
+
+	listener := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(unary), grpc.ChainStreamInterceptor(stream))
+	RegisterGRPC(srv, NewRunner(pipelines, WithEngine(pipeline.NewEngine())))
+
+	go func() { _ = srv.Serve(listener) }()
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	defer conn.Close()
+
+	client := pipelinev1.NewPipelineServiceClient(conn)
+
+	variables, _ := structpb.NewStruct(map[string]any{"name": "bob"})
+	req := &pipelinev1.ExecuteRequest{Pipelines: []string{"greet"}, Variables: variables, ExecutionId: "correlation-1"}
+
+	if _, err := client.Execute(context.Background(), req); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected the call to be unauthenticated, got %v", err)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), HeaderAPIKey, "key")
+
+	execution, err := client.Execute(ctx, req)
+	if err != nil || execution.GetId() != "correlation-1" {
+		t.Fatalf("unexpected execution: %v, %v", execution, err)
+	}
+
+	if _, err := client.Execute(ctx, req); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("expected the execution ID in use to be rejected, got %v", err)
+	}
+
+	events, err := client.Events(ctx, &pipelinev1.EventsRequest{ExecutionId: execution.GetId()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var types []string
+
+	for {
+		event, err := events.Recv()
+		if err != nil {
+			break
+		}
+
+		types = append(types, event.GetType())
+	}
+
+	if len(types) == 0 || types[0] != EventExecutionStarted || types[len(types)-1] != EventExecutionFinished {
+		t.Fatalf("unexpected events: %v", types)
+	}
+
+	execution, err = client.Status(ctx, &pipelinev1.StatusRequest{ExecutionId: execution.GetId()})
+	if err != nil || execution.GetStatus() != StatusSucceeded {
+		t.Fatalf("unexpected execution: %v, %v", execution, err)
+	}
+
+	greeting := execution.GetOutputs().GetFields()["greeting"].GetStructValue().AsMap()
+	if greeting["text"] != "hello bob" {
+		t.Fatalf("unexpected outputs: %v", execution.GetOutputs())
+	}
+
+	if _, err := client.Status(ctx, &pipelinev1.StatusRequest{ExecutionId: "missing"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestRegisterGRPCAuthorization(t *testing.T) {
+	t.Parallel()
+
+	pipelines := pipeline.NewPipelines(pipeline.New("deploy").Tags("deploy").Build())
+
+	unary, stream := AuthenticateGRPC(APIKeys{
+		"deployer-key": {Subject: "ci", Roles: []string{"deployer"}},
+		"viewer-key":   {Subject: "ops", Roles: []string{"viewer"}},
+	})
+
+	listener := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(unary), grpc.ChainStreamInterceptor(stream))
+	RegisterGRPC(srv, NewRunner(pipelines, WithEngine(pipeline.NewEngine()), WithAuthorizer(RoleAuthorizer(pipelines,
+		Role{Name: "deployer", Actions: []string{RoleAny}, Tags: []string{"deploy"}},
+		Role{Name: "viewer", Actions: []string{ActionRead}, Tags: []string{RoleAny}},
+	))))
+
+	go func() { _ = srv.Serve(listener) }()
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	defer conn.Close()
+
+	client := pipelinev1.NewPipelineServiceClient(conn)
+	deployer := metadata.AppendToOutgoingContext(context.Background(), HeaderAPIKey, "deployer-key")
+	viewer := metadata.AppendToOutgoingContext(context.Background(), HeaderAPIKey, "viewer-key")
+
+	if _, err := client.Execute(viewer, &pipelinev1.ExecuteRequest{Pipelines: []string{"deploy"}}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected the execution to be denied, got %v", err)
+	}
+
+	execution, err := client.Execute(deployer, &pipelinev1.ExecuteRequest{Pipelines: []string{"deploy"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := client.Status(viewer, &pipelinev1.StatusRequest{ExecutionId: execution.GetId()}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := client.Cancel(viewer, &pipelinev1.CancelRequest{ExecutionId: execution.GetId()}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected the cancellation to be denied, got %v", err)
+	}
+}
@@ -0,0 +1,277 @@
+package server
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRolesClaim is the claim holding the roles of the principals, see WithRolesClaim.
+	DefaultRolesClaim = "roles"
+	// keysRefreshInterval limits how often the keys are fetched again for unknown key IDs.
+	keysRefreshInterval = time.Minute
+	// clockSkew is the leeway of the token expiration and not before times.
+	clockSkew = time.Minute
+)
+
+type oidcOptions struct {
+	jwksURL    string
+	rolesClaim string
+	client     *http.Client
+}
+
+// OIDCOption configures NewOIDCVerifier.
+type OIDCOption func(*oidcOptions)
+
+// WithJWKSURL sets the URL of the issuer keys, discovered from its openid-configuration by default.
+func WithJWKSURL(url string) OIDCOption {
+	return func(o *oidcOptions) {
+		o.jwksURL = url
+	}
+}
+
+// WithRolesClaim sets the claim holding the roles, a string or a list of strings, DefaultRolesClaim by default.
+func WithRolesClaim(claim string) OIDCOption {
+	return func(o *oidcOptions) {
+		o.rolesClaim = claim
+	}
+}
+
+// WithOIDCClient sets the client fetching the issuer configuration and keys, http.DefaultClient by default.
+func WithOIDCClient(client *http.Client) OIDCOption {
+	return func(o *oidcOptions) {
+		o.client = client
+	}
+}
+
+// OIDCVerifier verifies the RS256 ID or access tokens of an OpenID Connect issuer, identifying the principals
+// by their sub claim.
+type OIDCVerifier struct {
+	issuer   string
+	audience string
+	o        oidcOptions
+
+	mu      sync.Mutex
+	keys    map[string]*rsa.PublicKey
+	fetched time.Time
+}
+
+// NewOIDCVerifier creates a verifier of the tokens signed by the issuer for the audience.
+func NewOIDCVerifier(issuer, audience string, opts ...OIDCOption) *OIDCVerifier {
+	o := oidcOptions{rolesClaim: DefaultRolesClaim, client: http.DefaultClient}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &OIDCVerifier{issuer: strings.TrimSuffix(issuer, "/"), audience: audience, o: o}
+}
+
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// Verify checks the token signature, issuer, audience and validity period.
+func (v *OIDCVerifier) Verify(ctx context.Context, token string) (Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Principal{}, errors.New("malformed token")
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Principal{}, fmt.Errorf("token header: %w", err)
+	}
+
+	if header.Algorithm != "RS256" {
+		return Principal{}, fmt.Errorf("unsupported token algorithm %s", header.Algorithm)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, fmt.Errorf("token signature: %w", err)
+	}
+
+	key, err := v.key(ctx, header.KeyID)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return Principal{}, errors.New("invalid token signature")
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Principal{}, fmt.Errorf("token claims: %w", err)
+	}
+
+	if err := v.validate(claims, time.Now()); err != nil {
+		return Principal{}, err
+	}
+
+	subject, _ := claims["sub"].(string)
+
+	return Principal{Subject: subject, Roles: stringsClaim(claims[v.o.rolesClaim])}, nil
+}
+
+func (v *OIDCVerifier) validate(claims map[string]any, now time.Time) error {
+	if issuer, _ := claims["iss"].(string); strings.TrimSuffix(issuer, "/") != v.issuer {
+		return fmt.Errorf("unexpected token issuer %s", issuer)
+	}
+
+	if !slices.Contains(stringsClaim(claims["aud"]), v.audience) {
+		return fmt.Errorf("token not issued for %s", v.audience)
+	}
+
+	expiration, found := claims["exp"].(float64)
+	if !found || now.After(time.Unix(int64(expiration), 0).Add(clockSkew)) {
+		return errors.New("token expired")
+	}
+
+	if notBefore, found := claims["nbf"].(float64); found && now.Add(clockSkew).Before(time.Unix(int64(notBefore), 0)) {
+		return errors.New("token not valid yet")
+	}
+
+	return nil
+}
+
+// key returns the issuer key, fetching the keys again for unknown IDs, eg.: once the issuer rotates them.
+func (v *OIDCVerifier) key(ctx context.Context, id string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if key, found := v.keys[id]; found {
+		return key, nil
+	}
+
+	if time.Since(v.fetched) < keysRefreshInterval {
+		return nil, fmt.Errorf("unknown token key %s", id)
+	}
+
+	keys, err := v.fetchKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching keys: %w", err)
+	}
+
+	v.keys, v.fetched = keys, time.Now()
+
+	key, found := v.keys[id]
+	if !found {
+		return nil, fmt.Errorf("unknown token key %s", id)
+	}
+
+	return key, nil
+}
+
+func (v *OIDCVerifier) fetchKeys(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	url := v.o.jwksURL
+
+	if url == "" {
+		var configuration struct {
+			JWKSURI string `json:"jwks_uri"`
+		}
+
+		if err := v.get(ctx, v.issuer+"/.well-known/openid-configuration", &configuration); err != nil {
+			return nil, err
+		}
+
+		url = configuration.JWKSURI
+	}
+
+	var set struct {
+		Keys []struct {
+			KeyType string `json:"kty"`
+			KeyID   string `json:"kid"`
+			Modulus string `json:"n"`
+			Exp     string `json:"e"`
+		} `json:"keys"`
+	}
+
+	if err := v.get(ctx, url, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+
+	for _, jwk := range set.Keys {
+		if jwk.KeyType != "RSA" {
+			continue
+		}
+
+		modulus, err := base64.RawURLEncoding.DecodeString(jwk.Modulus)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", jwk.KeyID, err)
+		}
+
+		exponent, err := base64.RawURLEncoding.DecodeString(jwk.Exp)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", jwk.KeyID, err)
+		}
+
+		keys[jwk.KeyID] = &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: int(new(big.Int).SetBytes(exponent).Int64())}
+	}
+
+	return keys, nil
+}
+
+func (v *OIDCVerifier) get(ctx context.Context, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := v.o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func decodeSegment(segment string, out any) error {
+	blob, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(blob, out)
+}
+
+// stringsClaim returns the claim as a list, whether it's a string or a list of strings.
+func stringsClaim(claim any) []string {
+	switch claim := claim.(type) {
+	case string:
+		return []string{claim}
+	case []any:
+		values := make([]string, 0, len(claim))
+
+		for _, value := range claim {
+			if value, ok := value.(string); ok {
+				values = append(values, value)
+			}
+		}
+
+		return values
+	}
+
+	return nil
+}
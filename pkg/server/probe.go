@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"sort"
+	"sync"
+	"time"
+)
+
+const checkTimeout = 5 * time.Second
+
+// Check reports whether a component (eg.: the pipeline loader or the scheduler) is ready to serve.
+type Check func(ctx context.Context) error
+
+// Probes aggregates the readiness checks exposed by /readyz.
+type Probes struct {
+	mu     sync.RWMutex
+	checks map[string]Check
+}
+
+// NewProbes creates an empty set of readiness checks.
+func NewProbes() *Probes {
+	return &Probes{checks: map[string]Check{}}
+}
+
+// AddCheck registers a named readiness check, replacing any check with the same name.
+func (p *Probes) AddCheck(name string, check Check) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.checks[name] = check
+}
+
+// Ready runs all checks and returns the failures keyed by check name.
+func (p *Probes) Ready(ctx context.Context) map[string]string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	failures := map[string]string{}
+
+	for name, check := range p.checks {
+		if err := check(ctx); err != nil {
+			failures[name] = err.Error()
+		}
+	}
+
+	return failures
+}
+
+// Healthz reports the process is alive.
+func (p *Probes) Healthz(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"status": "ok"})
+}
+
+// Readyz reports whether every readiness check succeeds.
+func (p *Probes) Readyz(w http.ResponseWriter, r *http.Request) {
+	failures := p.Ready(r.Context())
+	if len(failures) > 0 {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"status": "unavailable", "checks": failures})
+
+		return
+	}
+
+	p.mu.RLock()
+	names := make([]string, 0, len(p.checks))
+
+	for name := range p.checks {
+		names = append(names, name)
+	}
+	p.mu.RUnlock()
+
+	sort.Strings(names)
+
+	writeJSON(w, http.StatusOK, map[string]any{"status": "ok", "checks": names})
+}
+
+// RegisterProbes registers /healthz and /readyz in the mux.
+func RegisterProbes(mux *http.ServeMux, probes *Probes) {
+	mux.HandleFunc("GET /healthz", probes.Healthz)
+	mux.HandleFunc("GET /readyz", probes.Readyz)
+}
+
+// RegisterPprof registers the net/http/pprof handlers under /debug/pprof/ in the mux.
+func RegisterPprof(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
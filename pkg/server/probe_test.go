@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProbes(t *testing.T) {
+	t.Parallel()
+
+	probes := NewProbes()
+	mux := http.NewServeMux()
+	RegisterProbes(mux, probes)
+	RegisterPprof(mux)
+
+	ready := false
+
+	probes.AddCheck("loader", func(context.Context) error {
+		if !ready {
+			return errors.New("pipelines not loaded")
+		}
+
+		return nil
+	})
+
+	tests := []struct {
+		path   string
+		ready  bool
+		status int
+	}{
+		{path: "/healthz", status: http.StatusOK},
+		{path: "/readyz", status: http.StatusServiceUnavailable},
+		{path: "/readyz", ready: true, status: http.StatusOK},
+		{path: "/debug/pprof/", ready: true, status: http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		ready = tc.ready
+
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+		if recorder.Code != tc.status {
+			t.Fatalf("GET %s (ready=%v): got status %d want %d", tc.path, tc.ready, recorder.Code, tc.status)
+		}
+	}
+}
@@ -0,0 +1,658 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/artifact"
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+const (
+	// DefaultRetainedExecutions is the number of finished executions kept by a Runner, see WithRetainedExecutions.
+	DefaultRetainedExecutions = 100
+	// MaxStepOutput is the size of each step output stream kept by a Runner, see Runner.Output.
+	MaxStepOutput = 1 << 20
+)
+
+var (
+	// ErrExecutionNotFound is returned for unknown or no longer retained executions.
+	ErrExecutionNotFound = errors.New("execution not found")
+	// ErrNoArtifactStore is returned retrieving artifacts from runners without a store, see WithArtifacts.
+	ErrNoArtifactStore = errors.New("no artifact store")
+	// ErrExecutionExists is returned starting an execution whose ID is used by a running or retained one.
+	ErrExecutionExists = errors.New("execution already exists")
+)
+
+// Statuses of an execution.
+const (
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCanceled  = "canceled"
+)
+
+// Types of the lifecycle events of an execution.
+const (
+	EventExecutionStarted  = "execution_started"
+	EventPipelineStarted   = "pipeline_started"
+	EventPipelineFinished  = "pipeline_finished"
+	EventStepStarted       = "step_started"
+	EventStepFinished      = "step_finished"
+	EventExecutionFinished = "execution_finished"
+	// EventLog is a message logged by the execution, above the debug level.
+	EventLog = "log"
+	// EventOutput is a piece of a step output stream, see pipeline.Output.
+	EventOutput = "output"
+)
+
+// Event is a lifecycle event of an execution, streamed to its subscribers, see Runner.Events.
+type Event struct {
+	ExecutionID string    `json:"execution_id"`
+	Type        string    `json:"type"`
+	Pipeline    string    `json:"pipeline,omitempty"`
+	Step        string    `json:"step,omitempty"`
+	Status      string    `json:"status,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Level       string    `json:"level,omitempty"`
+	Message     string    `json:"message,omitempty"`
+	Stream      string    `json:"stream,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+// Execution is the state of an execution started by a Runner.
+type Execution struct {
+	ID        string   `json:"id"`
+	Pipelines []string `json:"pipelines"`
+	Status    string   `json:"status"`
+	// Error is the error of the failed execution, with its secrets redacted like the logs.
+	Error      string    `json:"error,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	// Outputs are the variables of the finished execution, with the values under secret-like paths redacted.
+	Outputs map[string]any `json:"outputs,omitempty"`
+	// Artifacts are the artifacts published by the finished execution, see WithArtifacts.
+	Artifacts []artifact.Artifact `json:"artifacts,omitempty"`
+}
+
+// ExecuteRequest starts an execution of the pipelines, with the variables set in its scope.
+type ExecuteRequest struct {
+	Pipelines []string       `json:"pipelines"`
+	Variables map[string]any `json:"variables"`
+}
+
+type runnerOptions struct {
+	engine          *pipeline.Engine
+	retained        int
+	stepInterceptor pipeline.StepInterceptor
+	executeOptions  []pipeline.Option
+	secrets         SecretsProvider
+	codec           *pipeline.JSONCodec
+	artifacts       artifact.Store
+	authorizer      Authorizer
+}
+
+// RunnerOption configures a Runner.
+type RunnerOption func(*runnerOptions)
+
+// WithEngine executes the pipelines with the engine instead of the default one.
+func WithEngine(engine *pipeline.Engine) RunnerOption {
+	return func(o *runnerOptions) {
+		o.engine = engine
+	}
+}
+
+// WithRetainedExecutions sets the number of finished executions kept for Status and Events,
+// DefaultRetainedExecutions by default.
+func WithRetainedExecutions(n int) RunnerOption {
+	return func(o *runnerOptions) {
+		o.retained = n
+	}
+}
+
+// WithStepInterceptor sets the step interceptor wrapped by the one emitting the step events,
+// both wrapping the interceptors of the engine during the executions.
+func WithStepInterceptor(itc pipeline.StepInterceptor) RunnerOption {
+	return func(o *runnerOptions) {
+		o.stepInterceptor = itc
+	}
+}
+
+// WithExecuteOptions configures every execution, eg.: with pipeline.WithTimeout or pipeline.WithLimits.
+// Loggers set with pipeline.WithLogger are replaced by the one emitting the log events, which delegates
+// to the logger of the Execute context instead.
+func WithExecuteOptions(opts ...pipeline.Option) RunnerOption {
+	return func(o *runnerOptions) {
+		o.executeOptions = append(o.executeOptions, opts...)
+	}
+}
+
+// WithCodec sets the codec restoring the registered types of the request variables and wrapping them
+// in the execution outputs, pipeline.NewJSONCodec by default.
+func WithCodec(codec *pipeline.JSONCodec) RunnerOption {
+	return func(o *runnerOptions) {
+		o.codec = codec
+	}
+}
+
+// WithArtifacts lists the artifacts published by the executions to the store in their results, and serves them,
+// see Runner.Artifact. The store is the one of the artifact step, eg.: artifact.RegisterStepExecutor.
+func WithArtifacts(store artifact.Store) RunnerOption {
+	return func(o *runnerOptions) {
+		o.artifacts = store
+	}
+}
+
+// Runner starts executions in the background and tracks them, so they can be inspected, canceled
+// and followed by their lifecycle events. It's the service behind the server APIs, eg.: REST or gRPC
+// (see api/pipeline/v1/pipeline.proto), and is safe for concurrent use.
+type Runner struct {
+	pipelines pipeline.Pipelines
+	o         runnerOptions
+
+	mu         sync.Mutex
+	executions map[string]*run
+	finished   []string
+}
+
+// NewRunner creates a runner executing the pipelines.
+func NewRunner(pipelines pipeline.Pipelines, opts ...RunnerOption) *Runner {
+	o := runnerOptions{retained: DefaultRetainedExecutions, codec: pipeline.NewJSONCodec()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Runner{pipelines: pipelines, o: o, executions: map[string]*run{}}
+}
+
+// run is a running or finished execution with its events.
+type run struct {
+	mu        sync.Mutex
+	execution Execution
+	events    []Event
+	outputs   map[string]map[string][]byte
+	changed   chan struct{}
+	cancel    context.CancelFunc
+	// secrets is a context of the execution, carrying the secrets redacted from its errors, see log.RedactContext.
+	secrets context.Context
+}
+
+func (r *run) emit(event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.append(event)
+}
+
+// append adds the event, notifying the subscribers. The caller holds the lock.
+func (r *run) append(event Event) {
+	event.ExecutionID, event.Time = r.execution.ID, time.Now()
+	r.events = append(r.events, event)
+
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// snapshot returns the events from the offset, whether the execution finished and a channel closed on the next event.
+func (r *run) snapshot(offset int) ([]Event, bool, <-chan struct{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Event{}, r.events[offset:]...), r.execution.Status != StatusRunning, r.changed
+}
+
+// Execute starts an execution of the request pipelines in the background, detached from the context
+// but keeping its values, eg.: the execution ID set by pipeline.WithExecutionID, which must not be used by
+// a running or retained execution, see ErrExecutionExists.
+func (rn *Runner) Execute(ctx context.Context, req ExecuteRequest) (Execution, error) {
+	for _, name := range req.Pipelines {
+		if _, found := rn.pipelines.Get(name); !found {
+			return Execution{}, fmt.Errorf("pipeline %s not found", name)
+		}
+	}
+
+	variables := make(map[pipeline.VariablePath]any, len(req.Variables)+1)
+	for path, value := range req.Variables {
+		unwrapped, err := rn.o.codec.Unwrap(value)
+		if err != nil {
+			return Execution{}, fmt.Errorf("variable %s: %w", path, err)
+		}
+
+		variables[pipeline.VariablePath(path)] = unwrapped
+	}
+
+	registered := log.NewSecrets()
+
+	if rn.o.secrets != nil {
+		values, err := secrets(ctx, rn.o.secrets, registered)
+		if err != nil {
+			return Execution{}, err
+		}
+
+		variables[VariableSecrets] = values
+	}
+
+	id := pipeline.ExecutionID(ctx)
+	if id == "" {
+		id = newExecutionID()
+	}
+
+	ctx, cancel := context.WithCancel(pipeline.WithExecutionID(context.WithoutCancel(ctx), id))
+	ctx = log.WithSecrets(ctx, registered)
+
+	r := &run{
+		execution: Execution{ID: id, Pipelines: req.Pipelines, Status: StatusRunning, StartedAt: time.Now()},
+		outputs:   map[string]map[string][]byte{},
+		changed:   make(chan struct{}),
+		cancel:    cancel,
+		secrets:   ctx,
+	}
+
+	rn.mu.Lock()
+	if _, found := rn.executions[id]; found {
+		rn.mu.Unlock()
+		cancel()
+
+		return Execution{}, fmt.Errorf("%w: %s", ErrExecutionExists, id)
+	}
+
+	rn.executions[id] = r
+	rn.mu.Unlock()
+
+	r.emit(Event{Type: EventExecutionStarted, Status: StatusRunning})
+
+	opts := append([]pipeline.Option{
+		pipeline.WithVariables(variables),
+		pipeline.WrapInterceptors(r.interceptor, pipeline.ChainStepInterceptors(r.stepInterceptor, rn.o.stepInterceptor)),
+		pipeline.WithOutput(r.output),
+	}, rn.o.executeOptions...)
+	opts = append(opts, pipeline.WithLogger(runLogger{run: r, next: log.From(ctx)}))
+
+	go func() {
+		defer cancel()
+
+		var (
+			scope pipeline.Scope
+			err   error
+		)
+
+		if rn.o.engine != nil {
+			scope, err = rn.o.engine.Execute(ctx, pipeline.NewScope(rn.pipelines), req.Pipelines, opts...)
+		} else {
+			scope, err = rn.pipelines.Execute(ctx, pipeline.NewScope(rn.pipelines), req.Pipelines, opts...)
+		}
+
+		rn.finish(ctx, r, scope, err)
+	}()
+
+	return r.status(), nil
+}
+
+func (rn *Runner) finish(ctx context.Context, r *run, scope pipeline.Scope, err error) {
+	status := StatusSucceeded
+
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		status = StatusCanceled
+	case err != nil:
+		status = StatusFailed
+	}
+
+	event := Event{Type: EventExecutionFinished, Status: status}
+
+	artifacts := rn.listArtifacts(ctx, r.execution.ID)
+
+	r.mu.Lock()
+	r.execution.Status, r.execution.FinishedAt = status, time.Now()
+	r.execution.Artifacts = artifacts
+	r.execution.Outputs = outputs(rn.o.codec, scope)
+
+	if err != nil {
+		r.execution.Error = log.RedactContext(r.secrets, err.Error())
+		event.Error = r.execution.Error
+	}
+
+	r.append(event)
+	r.mu.Unlock()
+
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+
+	rn.finished = append(rn.finished, r.execution.ID)
+
+	for len(rn.finished) > rn.o.retained {
+		delete(rn.executions, rn.finished[0])
+		rn.finished = rn.finished[1:]
+	}
+}
+
+func (r *run) status() Execution {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.execution
+}
+
+// output keeps the chunk in the step stream, up to MaxStepOutput, and emits it as an output event, with the secrets
+// of the step context redacted like the logs, see log.RedactContext.
+func (r *run) output(ctx context.Context, chunk pipeline.OutputChunk) {
+	data := []byte(log.RedactContext(ctx, string(chunk.Data)))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	step := chunk.Step.String()
+
+	if r.outputs[step] == nil {
+		r.outputs[step] = map[string][]byte{}
+	}
+
+	stream := r.outputs[step][chunk.Stream]
+	if room := MaxStepOutput - len(stream); room > 0 {
+		r.outputs[step][chunk.Stream] = append(stream, data[:min(room, len(data))]...)
+	}
+
+	r.append(Event{Type: EventOutput, Pipeline: chunk.Pipeline, Step: step, Stream: chunk.Stream, Message: string(data)})
+}
+
+func (r *run) interceptor(ctx context.Context, scope pipeline.Scope, p pipeline.Pipeline, execute pipeline.Executor) (pipeline.Scope, error) {
+	r.mu.Lock()
+	r.secrets = ctx
+	r.mu.Unlock()
+
+	r.emit(Event{Type: EventPipelineStarted, Pipeline: p.String()})
+
+	scope, err := execute(ctx, scope)
+
+	r.emit(Event{Type: EventPipelineFinished, Pipeline: p.String(), Status: outcome(err), Error: errorMessage(ctx, err)})
+
+	return scope, err
+}
+
+func (r *run) stepInterceptor(ctx context.Context, scope pipeline.Scope, step pipeline.Step, executor pipeline.StepExecutor) (pipeline.Scope, error) {
+	current := pipeline.FromContext(ctx).Pipeline
+
+	r.emit(Event{Type: EventStepStarted, Pipeline: current, Step: step.String()})
+
+	scope, err := executor.Execute(ctx, scope, step)
+
+	r.emit(Event{Type: EventStepFinished, Pipeline: current, Step: step.String(), Status: outcome(err), Error: errorMessage(ctx, err)})
+
+	return scope, err
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return StatusFailed
+	}
+
+	return StatusSucceeded
+}
+
+// errorMessage returns the error message with the secrets of the execution context redacted, see log.RedactContext.
+func errorMessage(ctx context.Context, err error) string {
+	if err != nil {
+		return log.RedactContext(ctx, err.Error())
+	}
+
+	return ""
+}
+
+func (rn *Runner) lookup(id string) (*run, error) {
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+
+	r, found := rn.executions[id]
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrExecutionNotFound, id)
+	}
+
+	return r, nil
+}
+
+// Pipelines returns the pipelines executed by the runner.
+func (rn *Runner) Pipelines() pipeline.Pipelines {
+	return rn.pipelines
+}
+
+// Executions returns the state of the running and retained executions, the latest first.
+func (rn *Runner) Executions() []Execution {
+	rn.mu.Lock()
+	runs := slices.Collect(maps.Values(rn.executions))
+	rn.mu.Unlock()
+
+	executions := make([]Execution, len(runs))
+	for i, r := range runs {
+		executions[i] = r.status()
+	}
+
+	slices.SortFunc(executions, func(a, b Execution) int {
+		return b.StartedAt.Compare(a.StartedAt)
+	})
+
+	return executions
+}
+
+// Status returns the execution state.
+func (rn *Runner) Status(id string) (Execution, error) {
+	r, err := rn.lookup(id)
+	if err != nil {
+		return Execution{}, err
+	}
+
+	return r.status(), nil
+}
+
+// Output returns the stream of the execution step written so far, up to MaxStepOutput, eg.: its pipeline.StreamStdout.
+func (rn *Runner) Output(id, step, stream string) ([]byte, error) {
+	r, err := rn.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.outputs[step][stream]), nil
+}
+
+// Artifact returns the content of an artifact published by the execution, which must be closed by the caller,
+// or an error wrapping artifact.ErrNotFound.
+func (rn *Runner) Artifact(ctx context.Context, id, name string) (io.ReadCloser, error) {
+	if _, err := rn.lookup(id); err != nil {
+		return nil, err
+	}
+
+	if rn.o.artifacts == nil {
+		return nil, ErrNoArtifactStore
+	}
+
+	return rn.o.artifacts.Get(ctx, id, name)
+}
+
+// listArtifacts returns the artifacts published by the execution, if the runner has a store.
+func (rn *Runner) listArtifacts(ctx context.Context, id string) []artifact.Artifact {
+	if rn.o.artifacts == nil {
+		return nil
+	}
+
+	artifacts, err := rn.o.artifacts.List(context.WithoutCancel(ctx), id)
+	if err != nil {
+		log.Log().Warn(ctx, "Error listing the artifacts of the execution %s: %s", id, err)
+	}
+
+	return artifacts
+}
+
+// Cancel cancels a running execution, which finishes with StatusCanceled. Canceling finished executions does nothing.
+func (rn *Runner) Cancel(id string) error {
+	r, err := rn.lookup(id)
+	if err != nil {
+		return err
+	}
+
+	r.cancel()
+
+	return nil
+}
+
+// Events streams the execution events, from the first one, until the execution finishes or the context is done.
+// The channel is closed afterwards.
+func (rn *Runner) Events(ctx context.Context, id string) (<-chan Event, error) {
+	r, err := rn.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan Event)
+
+	go func() {
+		defer close(events)
+
+		offset := 0
+
+		for {
+			pending, finished, changed := r.snapshot(offset)
+			offset += len(pending)
+
+			for _, event := range pending {
+				select {
+				case <-ctx.Done():
+					return
+				case events <- event:
+				}
+			}
+
+			if finished {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-changed:
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// Wait waits until the execution finishes, returning its final state, or the context is done.
+func (rn *Runner) Wait(ctx context.Context, id string) (Execution, error) {
+	events, err := rn.Events(ctx, id)
+	if err != nil {
+		return Execution{}, err
+	}
+
+	for range events {
+	}
+
+	if err := ctx.Err(); err != nil {
+		return Execution{}, err
+	}
+
+	return rn.Status(id)
+}
+
+// outputs returns the scope variables by path, wrapping the values of the codec registered types, redacting
+// the ones under secret-like paths and formatting the ones which can't be encoded as JSON.
+func outputs(codec *pipeline.JSONCodec, scope pipeline.Scope) map[string]any {
+	variables := scope.Variables()
+	values := make(map[string]any, len(variables))
+
+	for path, value := range variables {
+		values[string(path)] = output(string(path), codec.Wrap(value))
+	}
+
+	return values
+}
+
+func output(path string, value any) any {
+	for _, node := range strings.Split(path, ".") {
+		if log.IsSecretKey(node) {
+			return log.Redacted
+		}
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		redacted := make(map[string]any, len(value))
+		for key, v := range value {
+			redacted[key] = output(key, v)
+		}
+
+		return redacted
+	case []any:
+		redacted := make([]any, len(value))
+		for i, v := range value {
+			redacted[i] = output("", v)
+		}
+
+		return redacted
+	}
+
+	if _, err := json.Marshal(value); err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return value
+}
+
+// runLogger emits the messages above the debug level as log events of the run, delegating them to the next logger.
+type runLogger struct {
+	run  *run
+	next log.Logger
+}
+
+func (l runLogger) emit(ctx context.Context, level, msg string, args ...any) {
+	event := Event{Type: EventLog, Level: level, Message: fmt.Sprintf(msg, args...)}
+
+	for _, field := range log.Fields(ctx) {
+		switch field.Key {
+		case pipeline.LogFieldPipeline:
+			event.Pipeline = fmt.Sprint(field.Value)
+		case pipeline.LogFieldStep:
+			event.Step = fmt.Sprint(field.Value)
+		}
+	}
+
+	l.run.emit(event)
+}
+
+func (l runLogger) Error(ctx context.Context, msg string, args ...any) {
+	l.emit(ctx, "error", msg, args...)
+	l.next.Error(ctx, msg, args...)
+}
+
+func (l runLogger) Warn(ctx context.Context, msg string, args ...any) {
+	l.emit(ctx, "warn", msg, args...)
+	l.next.Warn(ctx, msg, args...)
+}
+
+func (l runLogger) Info(ctx context.Context, msg string, args ...any) {
+	l.emit(ctx, "info", msg, args...)
+	l.next.Info(ctx, msg, args...)
+}
+
+func (l runLogger) Debug(ctx context.Context, msg string, args ...any) {
+	l.next.Debug(ctx, msg, args...)
+}
+
+func newExecutionID() string {
+	blob := make([]byte, 16)
+	_, _ = rand.Read(blob)
+
+	return hex.EncodeToString(blob)
+}
@@ -0,0 +1,341 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+func TestRunner(t *testing.T) {
+	t.Parallel()
+
+	pipelines := pipeline.NewPipelines(
+		pipeline.New("greet").Set("greeting", map[string]any{"text": `hello {{ variable . "name" }}`}).Build(),
+		pipeline.New("slow").Wait(time.Minute).Build(),
+	)
+
+	runner := NewRunner(pipelines, WithEngine(pipeline.NewEngine()))
+	ctx := context.Background()
+
+	t.Run("streams the events", func(t *testing.T) {
+		t.Parallel()
+
+		execution, err := runner.Execute(ctx, ExecuteRequest{Pipelines: []string{"greet"}, Variables: map[string]any{"name": "bob"}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		events, err := runner.Events(ctx, execution.ID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var (
+			types []string
+			logs  []Event
+		)
+
+		for event := range events {
+			if event.Type == EventLog {
+				logs = append(logs, event)
+
+				continue
+			}
+
+			types = append(types, event.Type)
+		}
+
+		expected := []string{
+			EventExecutionStarted, EventPipelineStarted, EventStepStarted, EventStepFinished, EventPipelineFinished, EventExecutionFinished,
+		}
+		if !slices.Equal(types, expected) {
+			t.Fatalf("unexpected events: got %v want %v", types, expected)
+		}
+
+		if len(logs) == 0 || logs[0].Pipeline != "greet" || logs[0].Level != "info" {
+			t.Fatalf("unexpected logs: %+v", logs)
+		}
+
+		status, err := runner.Status(execution.ID)
+		if err != nil || status.Status != StatusSucceeded {
+			t.Fatalf("unexpected status: %+v, %v", status, err)
+		}
+
+		if greeting, _ := status.Outputs["greeting"].(map[string]any); greeting["text"] != "hello bob" {
+			t.Fatalf("unexpected outputs: %+v", status.Outputs)
+		}
+	})
+
+	t.Run("cancels executions", func(t *testing.T) {
+		t.Parallel()
+
+		execution, err := runner.Execute(pipeline.WithExecutionID(ctx, "slow-1"), ExecuteRequest{Pipelines: []string{"slow"}})
+		if err != nil || execution.ID != "slow-1" {
+			t.Fatalf("unexpected execution: %+v, %v", execution, err)
+		}
+
+		events, _ := runner.Events(ctx, execution.ID)
+
+		if _, err := runner.Execute(pipeline.WithExecutionID(ctx, "slow-1"), ExecuteRequest{Pipelines: []string{"greet"}}); !errors.Is(err, ErrExecutionExists) {
+			t.Fatalf("expected the execution ID in use to be rejected, got %v", err)
+		}
+
+		if err := runner.Cancel(execution.ID); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var last Event
+		for event := range events {
+			last = event
+		}
+
+		if last.Type != EventExecutionFinished || last.Status != StatusCanceled {
+			t.Fatalf("unexpected last event: %+v", last)
+		}
+	})
+
+	t.Run("rejects unknown pipelines and executions", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := runner.Execute(ctx, ExecuteRequest{Pipelines: []string{"missing"}}); err == nil {
+			t.Fatal("expected an error for an unknown pipeline")
+		}
+
+		if _, err := runner.Status("missing"); !errors.Is(err, ErrExecutionNotFound) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestRunnerOutput(t *testing.T) {
+	t.Parallel()
+
+	engine := pipeline.NewEngine()
+	engine.RegisterStepExecutor("echo", pipeline.FuncExecutor(func(ctx context.Context, in struct {
+		Message string `yaml:"message"`
+	}) (any, error) {
+		_, err := fmt.Fprintln(pipeline.Output(ctx, pipeline.StreamStderr), in.Message)
+
+		return nil, err
+	}))
+
+	pipelines := pipeline.NewPipelines(pipeline.New("main").Step(pipeline.NewStep("say", "echo", map[string]any{"message": "hello"})).Build())
+	runner := NewRunner(pipelines, WithEngine(engine))
+	ctx := context.Background()
+
+	execution, err := runner.Execute(ctx, ExecuteRequest{Pipelines: []string{"main"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events, err := runner.Events(ctx, execution.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var outputs []Event
+
+	for event := range events {
+		if event.Type == EventOutput {
+			outputs = append(outputs, event)
+		}
+	}
+
+	if len(outputs) != 1 || outputs[0].Step != "step-echo-say" || outputs[0].Stream != pipeline.StreamStderr || outputs[0].Message != "hello\n" {
+		t.Fatalf("unexpected output events: %+v", outputs)
+	}
+
+	output, err := runner.Output(execution.ID, "step-echo-say", pipeline.StreamStderr)
+	if err != nil || string(output) != "hello\n" {
+		t.Fatalf("unexpected output: %q, %v", output, err)
+	}
+}
+
+func TestRunnerInterceptors(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+
+	trace := func(name string) pipeline.StepInterceptor {
+		return func(ctx context.Context, scope pipeline.Scope, step pipeline.Step, executor pipeline.StepExecutor) (pipeline.Scope, error) {
+			mu.Lock()
+			calls = append(calls, name)
+			mu.Unlock()
+
+			return executor.Execute(ctx, scope, step)
+		}
+	}
+
+	engine := pipeline.NewEngine()
+	engine.AddStepInterceptor(trace("engine"))
+
+	pipelines := pipeline.NewPipelines(pipeline.New("main").Set("greeting", map[string]any{"text": "hi"}).Build())
+	runner := NewRunner(pipelines, WithEngine(engine), WithStepInterceptor(trace("runner")))
+	ctx := context.Background()
+
+	execution, err := runner.Execute(ctx, ExecuteRequest{Pipelines: []string{"main"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events, err := runner.Events(ctx, execution.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var steps int
+
+	for event := range events {
+		if event.Type == EventStepFinished {
+			steps++
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if steps != 1 || !slices.Equal(calls, []string{"runner", "engine"}) {
+		t.Fatalf("expected the step events around the runner and engine interceptors, got %d steps and %v", steps, calls)
+	}
+}
+
+func TestRunnerCodec(t *testing.T) {
+	t.Parallel()
+
+	pipelines := pipeline.NewPipelines(pipeline.New("main").Build())
+	runner := NewRunner(pipelines, WithEngine(pipeline.NewEngine()))
+	ctx := context.Background()
+
+	execution, err := runner.Execute(ctx, ExecuteRequest{
+		Pipelines: []string{"main"},
+		Variables: map[string]any{"at": map[string]any{"$type": "time", "$value": "2024-01-02T03:04:05Z"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events, err := runner.Events(ctx, execution.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for range events {
+	}
+
+	status, err := runner.Status(execution.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	at, _ := status.Outputs["at"].(map[string]any)
+	if at["$type"] != "time" || !time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).Equal(at["$value"].(time.Time)) {
+		t.Fatalf("unexpected outputs: %+v", status.Outputs)
+	}
+
+	unknown := map[string]any{"x": map[string]any{"$type": "unknown", "$value": 1}}
+	if _, err := runner.Execute(ctx, ExecuteRequest{Pipelines: []string{"main"}, Variables: unknown}); err == nil {
+		t.Fatal("expected an error for unregistered types")
+	}
+}
+
+func TestRunnerRedactsErrors(t *testing.T) {
+	t.Parallel()
+
+	engine := pipeline.NewEngine()
+	engine.RegisterStepExecutor("fail", pipeline.FuncExecutor(func(_ context.Context, in struct {
+		URL string `yaml:"url"`
+	}) (any, error) {
+		return nil, fmt.Errorf("GET %s: unauthorized", in.URL)
+	}))
+
+	pipelines := pipeline.NewPipelines(pipeline.New("sync").
+		Set("api", map[string]any{"api_token": "scope-token"}).
+		Step(pipeline.NewStep("call", "fail", map[string]any{
+			"url": `https://example.com?key={{ variableGet . "secrets" "key" }}&token={{ variableGet . "api" "api_token" }}`,
+		})).
+		Build())
+
+	runner := NewRunner(pipelines, WithEngine(engine), WithSecrets(StaticSecrets{"key": "provider-key"}))
+	ctx := context.Background()
+
+	execution, err := runner.Execute(ctx, ExecuteRequest{Pipelines: []string{"sync"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	execution, err = runner.Wait(ctx, execution.ID)
+	if err != nil || execution.Error == "" {
+		t.Fatalf("unexpected execution: %+v, %v", execution, err)
+	}
+
+	events, _ := runner.Events(ctx, execution.ID)
+
+	messages := []string{execution.Error}
+	for event := range events {
+		messages = append(messages, event.Error)
+	}
+
+	for _, message := range messages {
+		if strings.Contains(message, "provider-key") || strings.Contains(message, "scope-token") {
+			t.Fatalf("expected the secrets to be redacted: %s", message)
+		}
+	}
+}
+
+func TestRunnerRedactsOutput(t *testing.T) {
+	t.Parallel()
+
+	engine := pipeline.NewEngine()
+	engine.RegisterStepExecutor("echo", pipeline.FuncExecutor(func(ctx context.Context, in struct {
+		Message string `yaml:"message"`
+	}) (any, error) {
+		_, err := fmt.Fprintln(pipeline.Output(ctx, pipeline.StreamStdout), in.Message)
+
+		return nil, err
+	}))
+
+	pipelines := pipeline.NewPipelines(pipeline.New("login").
+		Step(pipeline.NewStep("say", "echo", map[string]any{"message": `using {{ variableGet . "secrets" "key" }}`})).
+		Build())
+
+	runner := NewRunner(pipelines, WithEngine(engine), WithSecrets(StaticSecrets{"key": "provider-key"}))
+	ctx := context.Background()
+
+	execution, err := runner.Execute(ctx, ExecuteRequest{Pipelines: []string{"login"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events, _ := runner.Events(ctx, execution.ID)
+
+	var messages []string
+
+	for event := range events {
+		if event.Type == EventOutput {
+			messages = append(messages, event.Message)
+		}
+	}
+
+	output, err := runner.Output(execution.ID, "step-echo-say", pipeline.StreamStdout)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	messages = append(messages, string(output))
+
+	for _, message := range messages {
+		if !strings.HasPrefix(message, "using ") || strings.Contains(message, "provider-key") {
+			t.Fatalf("expected the secrets to be redacted: %q", message)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// VariableSecrets is the variable holding the secrets of the executions, see WithSecrets.
+const VariableSecrets = "secrets"
+
+// Actions authorized on the executions of a tenant, see Authorizer.
+const (
+	ActionExecute = "execute"
+	ActionRead    = "read"
+	ActionCancel  = "cancel"
+)
+
+var (
+	// ErrTenantNotFound is returned for unknown tenants.
+	ErrTenantNotFound = errors.New("tenant not found")
+	// ErrForbidden is returned when the authorizer of a tenant denies an action.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// SecretsProvider provides the secrets of the executions, eg.: from a vault or the environment.
+type SecretsProvider interface {
+	Secrets(ctx context.Context) (map[string]any, error)
+}
+
+// StaticSecrets is a SecretsProvider with fixed secrets.
+type StaticSecrets map[string]any
+
+// Secrets returns the secrets.
+func (s StaticSecrets) Secrets(context.Context) (map[string]any, error) {
+	return s, nil
+}
+
+// WithSecrets sets the secrets of the provider in the VariableSecrets variable of every execution, replacing the
+// request variable with the same name. Their string values are redacted from logs.
+func WithSecrets(provider SecretsProvider) RunnerOption {
+	return func(o *runnerOptions) {
+		o.secrets = provider
+	}
+}
+
+// Authorizer decides whether the caller of the context, eg.: identified by an authentication middleware,
+// can perform the action on the pipelines. It returns an error to deny it.
+type Authorizer interface {
+	Authorize(ctx context.Context, action string, pipelines []string) error
+}
+
+// AuthorizerFunc is a function implementing Authorizer.
+type AuthorizerFunc func(ctx context.Context, action string, pipelines []string) error
+
+// Authorize calls the function.
+func (f AuthorizerFunc) Authorize(ctx context.Context, action string, pipelines []string) error {
+	return f(ctx, action, pipelines)
+}
+
+// Tenant is a team served by a shared deployment, with its own pipelines, executions, secrets and authorization.
+type Tenant struct {
+	Name      string
+	Pipelines pipeline.Pipelines
+	// Secrets are set in the executions, see WithSecrets.
+	Secrets SecretsProvider
+	// Authorizer authorizes every action on the tenant, allowing all of them when nil.
+	Authorizer Authorizer
+	// Options configure the runner of the tenant.
+	Options []RunnerOption
+}
+
+// Tenants serves the executions of several tenants, isolated from each other: each tenant has its own
+// runner, so executions and their histories are only reachable within the tenant that started them.
+type Tenants struct {
+	tenants map[string]*Runner
+}
+
+// NewTenants creates the runners of the tenants. Tenant names must be unique.
+func NewTenants(tenants ...Tenant) (*Tenants, error) {
+	t := &Tenants{tenants: make(map[string]*Runner, len(tenants))}
+
+	for _, current := range tenants {
+		if current.Name == "" {
+			return nil, errors.New("tenant without name")
+		}
+
+		if url.PathEscape(current.Name) != current.Name {
+			return nil, fmt.Errorf("invalid tenant name %q, expected a path segment", current.Name)
+		}
+
+		if _, found := t.tenants[current.Name]; found {
+			return nil, fmt.Errorf("duplicated tenant %s", current.Name)
+		}
+
+		opts := slices.Clone(current.Options)
+		if current.Secrets != nil {
+			opts = append(opts, WithSecrets(current.Secrets))
+		}
+
+		if current.Authorizer != nil {
+			opts = append(opts, WithAuthorizer(current.Authorizer))
+		}
+
+		t.tenants[current.Name] = NewRunner(current.Pipelines, opts...)
+	}
+
+	return t, nil
+}
+
+// RegisterTenants registers the REST API and the web UI of every tenant under /tenants/{tenant}/, eg.:
+// POST /tenants/payments/pipelines/deploy/run or GET /tenants/payments/ui/, see RegisterAPI and RegisterUI.
+// Each tenant only reaches its pipelines and executions, authorized by its authorizer.
+func RegisterTenants(mux *http.ServeMux, tenants *Tenants) {
+	for name, runner := range tenants.tenants {
+		tenantMux := http.NewServeMux()
+		RegisterAPI(tenantMux, runner)
+		RegisterUI(tenantMux, runner)
+
+		prefix := "/tenants/" + name
+		mux.Handle(prefix+"/", http.StripPrefix(prefix, tenantMux))
+	}
+}
+
+// Names returns the sorted tenant names.
+func (t *Tenants) Names() []string {
+	return slices.Sorted(maps.Keys(t.tenants))
+}
+
+// Runner returns the runner of the tenant, or an error wrapping ErrTenantNotFound.
+func (t *Tenants) Runner(name string) (*Runner, error) {
+	runner, found := t.tenants[name]
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrTenantNotFound, name)
+	}
+
+	return runner, nil
+}
+
+// execution returns the runner of the tenant once the action on the execution is authorized.
+func (t *Tenants) execution(ctx context.Context, name, action, id string) (*Runner, error) {
+	runner, err := t.Runner(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := runner.authorizeExecution(ctx, action, id); err != nil {
+		return nil, err
+	}
+
+	return runner, nil
+}
+
+// Execute starts an execution within the tenant, see Runner.Execute.
+func (t *Tenants) Execute(ctx context.Context, name string, req ExecuteRequest) (Execution, error) {
+	runner, err := t.Runner(name)
+	if err != nil {
+		return Execution{}, err
+	}
+
+	if err := runner.Authorize(ctx, ActionExecute, req.Pipelines); err != nil {
+		return Execution{}, err
+	}
+
+	return runner.Execute(ctx, req)
+}
+
+// Status returns the state of an execution of the tenant, see Runner.Status.
+func (t *Tenants) Status(ctx context.Context, name, id string) (Execution, error) {
+	runner, err := t.Runner(name)
+	if err != nil {
+		return Execution{}, err
+	}
+
+	return runner.authorizeExecution(ctx, ActionRead, id)
+}
+
+// Cancel cancels an execution of the tenant, see Runner.Cancel.
+func (t *Tenants) Cancel(ctx context.Context, name, id string) error {
+	runner, err := t.execution(ctx, name, ActionCancel, id)
+	if err != nil {
+		return err
+	}
+
+	return runner.Cancel(id)
+}
+
+// Artifact returns the content of an artifact of an execution of the tenant, see Runner.Artifact.
+func (t *Tenants) Artifact(ctx context.Context, name, id, artifactName string) (io.ReadCloser, error) {
+	runner, err := t.execution(ctx, name, ActionRead, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return runner.Artifact(ctx, id, artifactName)
+}
+
+// Events streams the events of an execution of the tenant, see Runner.Events.
+func (t *Tenants) Events(ctx context.Context, name, id string) (<-chan Event, error) {
+	runner, err := t.execution(ctx, name, ActionRead, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return runner.Events(ctx, id)
+}
+
+// secrets returns the secrets of the provider, registering their string values in the execution secrets
+// so they are redacted from its logs.
+func secrets(ctx context.Context, provider SecretsProvider, registered *log.Secrets) (map[string]any, error) {
+	values, err := provider.Secrets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: %w", err)
+	}
+
+	registerSecrets(registered, values)
+
+	return values, nil
+}
+
+func registerSecrets(registered *log.Secrets, value any) {
+	switch value := value.(type) {
+	case string:
+		registered.Add(value)
+	case map[string]any:
+		for _, v := range value {
+			registerSecrets(registered, v)
+		}
+	case []any:
+		for _, v := range value {
+			registerSecrets(registered, v)
+		}
+	}
+}
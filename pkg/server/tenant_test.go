@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	pipelinev1 "github.com/crowleyfelix/go-pipeline/api/pipeline/v1"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+type callerKey struct{}
+
+func TestTenants(t *testing.T) {
+	t.Parallel()
+
+	var received any
+
+	engine := pipeline.NewEngine()
+	engine.RegisterStepExecutor("capture", pipeline.FuncExecutor(func(_ context.Context, in struct {
+		Value any `yaml:"value"`
+	}) (any, error) {
+		received = in.Value
+
+		return nil, nil
+	}))
+
+	onlyAlice := AuthorizerFunc(func(ctx context.Context, _ string, _ []string) error {
+		if ctx.Value(callerKey{}) != "alice" {
+			return errors.New("unknown caller")
+		}
+
+		return nil
+	})
+
+	tenants, err := NewTenants(
+		Tenant{
+			Name: "payments",
+			Pipelines: pipeline.NewPipelines(pipeline.New("deploy").
+				Step(pipeline.NewStep("", "capture", map[string]any{"value": `{{ variableGet . "secrets" "token" }}`})).Build()),
+			Secrets:    StaticSecrets{"token": "payments-token"},
+			Authorizer: onlyAlice,
+			Options:    []RunnerOption{WithEngine(engine)},
+		},
+		Tenant{Name: "search", Pipelines: pipeline.NewPipelines(pipeline.New("index").Build())},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	alice := context.WithValue(context.Background(), callerKey{}, "alice")
+
+	execution, err := tenants.Execute(alice, "payments", ExecuteRequest{Pipelines: []string{"deploy"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	events, err := tenants.Events(alice, "payments", execution.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for range events {
+	}
+
+	if received != "payments-token" {
+		t.Fatalf("unexpected secret: %v", received)
+	}
+
+	if _, err := tenants.Status(context.Background(), "payments", execution.ID); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected forbidden, got %v", err)
+	}
+
+	if _, err := tenants.Execute(context.Background(), "payments", ExecuteRequest{Pipelines: []string{"deploy"}}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected forbidden, got %v", err)
+	}
+
+	if _, err := tenants.Status(alice, "search", execution.ID); !errors.Is(err, ErrExecutionNotFound) {
+		t.Fatalf("expected executions isolated by tenant, got %v", err)
+	}
+
+	if _, err := tenants.Execute(alice, "search", ExecuteRequest{Pipelines: []string{"deploy"}}); err == nil {
+		t.Fatal("expected pipelines isolated by tenant")
+	}
+
+	if _, err := tenants.Status(alice, "billing", execution.ID); !errors.Is(err, ErrTenantNotFound) {
+		t.Fatalf("expected tenant not found, got %v", err)
+	}
+
+	if _, err := NewTenants(Tenant{Name: "search"}, Tenant{Name: "search"}); err == nil {
+		t.Fatal("expected duplicated tenant error")
+	}
+
+	if _, err := NewTenants(Tenant{Name: "search/index"}); err == nil {
+		t.Fatal("expected invalid tenant name error")
+	}
+}
+
+func TestRegisterTenants(t *testing.T) {
+	t.Parallel()
+
+	tenants, err := NewTenants(
+		Tenant{Name: "payments", Pipelines: pipeline.NewPipelines(pipeline.New("deploy").Build()), Options: []RunnerOption{WithEngine(pipeline.NewEngine())}},
+		Tenant{Name: "search", Pipelines: pipeline.NewPipelines(pipeline.New("index").Build()), Options: []RunnerOption{WithEngine(pipeline.NewEngine())}},
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	RegisterTenants(mux, tenants)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/tenants/payments/pipelines/deploy/run?wait=true", "application/json", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var execution Execution
+
+	err = json.NewDecoder(resp.Body).Decode(&execution)
+	resp.Body.Close()
+
+	if err != nil || resp.StatusCode != http.StatusOK || execution.Status != StatusSucceeded {
+		t.Fatalf("unexpected run: %d, %+v, %v", resp.StatusCode, execution, err)
+	}
+
+	cases := map[string]int{
+		"/tenants/payments/runs/" + execution.ID: http.StatusOK,
+		"/tenants/payments/ui/":                  http.StatusOK,
+		"/tenants/search/runs/" + execution.ID:   http.StatusNotFound,
+		"/tenants/billing/runs/" + execution.ID:  http.StatusNotFound,
+		"/runs/" + execution.ID:                  http.StatusNotFound,
+	}
+
+	for path, expected := range cases {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		resp.Body.Close()
+
+		if resp.StatusCode != expected {
+			t.Fatalf("unexpected status of %s: got %d want %d", path, resp.StatusCode, expected)
+		}
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	RegisterTenantsGRPC(srv, tenants)
+
+	go func() { _ = srv.Serve(listener) }()
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	defer conn.Close()
+
+	client := pipelinev1.NewPipelineServiceClient(conn)
+	req := &pipelinev1.StatusRequest{ExecutionId: execution.ID}
+
+	for tenant, expected := range map[string]codes.Code{"payments": codes.OK, "search": codes.NotFound, "billing": codes.NotFound} {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), MetadataTenant, tenant)
+
+		if _, err := client.Status(ctx, req); status.Code(err) != expected {
+			t.Fatalf("unexpected status of tenant %s: got %v want %s", tenant, err, expected)
+		}
+	}
+}
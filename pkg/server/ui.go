@@ -0,0 +1,217 @@
+package server
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/artifact"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+//go:embed ui/index.html
+var uiPage []byte
+
+// PipelineSummary describes a pipeline listed by the UI.
+type PipelineSummary struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Steps       int      `json:"steps"`
+	// Variables document the data contract of the pipeline.
+	Variables []pipeline.VariableDeclaration `json:"variables,omitempty"`
+}
+
+// RegisterUI registers under /ui/ a minimal web UI for the runner executions, listing the pipelines and the executions,
+// and following the progress, logs, step output streams, outputs and artifacts of each execution live, so operators don't need
+// to tail the process logs.
+// The UI is backed by a JSON API under /ui/api/, streaming the execution events as server-sent events, its request bodies
+// limited to DefaultMaxPayload.
+// Protect it like the other server endpoints, eg.: with Authenticate, and authorize its callers with WithAuthorizer:
+// only the pipelines and executions the caller can read are listed. Browsers can't send the API keys in headers,
+// so serve the page without authentication with UIPage: it asks for the API key, kept in the CookieAPIKey cookie.
+func RegisterUI(mux *http.ServeMux, runner *Runner) {
+	ui := userInterface{runner: runner}
+
+	mux.HandleFunc("GET /ui/{$}", UIPage)
+	mux.HandleFunc("GET /ui/api/pipelines", ui.pipelines)
+	mux.HandleFunc("GET /ui/api/executions", ui.executions)
+	mux.HandleFunc("POST /ui/api/executions", ui.execute)
+	mux.HandleFunc("GET /ui/api/executions/{id}", ui.status)
+	mux.HandleFunc("POST /ui/api/executions/{id}/cancel", ui.cancel)
+	mux.HandleFunc("GET /ui/api/executions/{id}/events", ui.events)
+	mux.HandleFunc("GET /ui/api/executions/{id}/output/{step}/{stream}", ui.output)
+	mux.HandleFunc("GET /ui/api/executions/{id}/artifacts/{name...}", ui.artifact)
+}
+
+type userInterface struct {
+	runner *Runner
+}
+
+// UIPage serves the page of the web UI, see RegisterUI. It's static, loading the data from the UI API with the API key
+// it asks for when the API replies 401, so it can be served in front of Authenticate, eg.: on GET /ui/{$}.
+func UIPage(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(uiPage)
+}
+
+func (ui userInterface) pipelines(w http.ResponseWriter, r *http.Request) {
+	pipelines := ui.runner.Pipelines()
+	summaries := []PipelineSummary{}
+
+	for _, name := range pipelines.Names() {
+		if ui.runner.Authorize(r.Context(), ActionRead, []string{name}) != nil {
+			continue
+		}
+
+		pipe, _ := pipelines.Get(name)
+		summaries = append(summaries, PipelineSummary{
+			Name:        name,
+			Description: pipe.Description,
+			Tags:        pipe.Tags,
+			Steps:       len(pipe.Steps),
+			Variables:   pipe.Variables,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+func (ui userInterface) executions(w http.ResponseWriter, r *http.Request) {
+	executions := []Execution{}
+
+	for _, execution := range ui.runner.Executions() {
+		if ui.runner.Authorize(r.Context(), ActionRead, execution.Pipelines) != nil {
+			continue
+		}
+
+		execution.Outputs = nil
+		executions = append(executions, execution)
+	}
+
+	writeJSON(w, http.StatusOK, executions)
+}
+
+func (ui userInterface) execute(w http.ResponseWriter, r *http.Request) {
+	var req ExecuteRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, DefaultMaxPayload)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+
+		return
+	}
+
+	if err := ui.runner.Authorize(r.Context(), ActionExecute, req.Pipelines); err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	execution, err := ui.runner.Execute(r.Context(), req)
+	if err != nil {
+		writeExecuteError(w, err)
+
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, execution)
+}
+
+func (ui userInterface) status(w http.ResponseWriter, r *http.Request) {
+	execution, err := ui.runner.authorizeExecution(r.Context(), ActionRead, r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, execution)
+}
+
+func (ui userInterface) cancel(w http.ResponseWriter, r *http.Request) {
+	if _, err := ui.runner.authorizeExecution(r.Context(), ActionCancel, r.PathValue("id")); err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	if err := ui.runner.Cancel(r.PathValue("id")); err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ui userInterface) output(w http.ResponseWriter, r *http.Request) {
+	if _, err := ui.runner.authorizeExecution(r.Context(), ActionRead, r.PathValue("id")); err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	output, err := ui.runner.Output(r.PathValue("id"), r.PathValue("step"), r.PathValue("stream"))
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write(output)
+}
+
+func (ui userInterface) artifact(w http.ResponseWriter, r *http.Request) {
+	writeArtifact(w, r, ui.runner)
+}
+
+func (ui userInterface) events(w http.ResponseWriter, r *http.Request) {
+	if _, err := ui.runner.authorizeExecution(r.Context(), ActionRead, r.PathValue("id")); err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	events, err := ui.runner.Events(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	for event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+
+	switch {
+	case errors.Is(err, ErrExecutionNotFound) || errors.Is(err, artifact.ErrNotFound) || errors.Is(err, ErrNoArtifactStore):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrForbidden):
+		status = http.StatusForbidden
+	}
+
+	writeJSON(w, status, map[string]any{"error": err.Error()})
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+func TestRegisterUI(t *testing.T) {
+	t.Parallel()
+
+	pipelines := pipeline.NewPipelines(
+		pipeline.New("greet").Description("Greets").Tags("demo").
+			Variable(pipeline.VariableDeclaration{Name: "name", Type: pipeline.InputTypeString, Description: "Who to greet"}).
+			Set("greeting", map[string]any{"token": "abcd1234", "text": "hi"}).Build(),
+	)
+
+	mux := http.NewServeMux()
+	RegisterUI(mux, NewRunner(pipelines, WithEngine(pipeline.NewEngine())))
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/ui/")
+	if err != nil || resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Fatalf("unexpected page: %v, %v", resp, err)
+	}
+
+	resp.Body.Close()
+
+	var summaries []PipelineSummary
+
+	decode(t, server.URL+"/ui/api/pipelines", &summaries)
+
+	if len(summaries) != 1 || summaries[0].Name != "greet" || summaries[0].Steps != 1 || summaries[0].Tags[0] != "demo" ||
+		len(summaries[0].Variables) != 1 || summaries[0].Variables[0].Description != "Who to greet" {
+		t.Fatalf("unexpected pipelines: %+v", summaries)
+	}
+
+	oversized := `{"pipelines": ["greet"], "variables": {"name": "` + strings.Repeat("a", int(DefaultMaxPayload)) + `"}}`
+
+	resp, err = http.Post(server.URL+"/ui/api/executions", "application/json", strings.NewReader(oversized))
+	if err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected oversized bodies to be rejected: %v, %v", resp, err)
+	}
+
+	resp.Body.Close()
+
+	resp, err = http.Post(server.URL+"/ui/api/executions", "application/json", strings.NewReader(`{"pipelines": ["greet"]}`))
+	if err != nil || resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("unexpected response: %v, %v", resp, err)
+	}
+
+	var execution Execution
+	if err := json.NewDecoder(resp.Body).Decode(&execution); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resp.Body.Close()
+
+	resp, err = http.Get(server.URL + "/ui/api/executions/" + execution.ID + "/events")
+	if err != nil || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected response: %v, %v", resp, err)
+	}
+
+	var last Event
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if data, found := strings.CutPrefix(scanner.Text(), "data: "); found {
+			if err := json.Unmarshal([]byte(data), &last); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+
+	resp.Body.Close()
+
+	if last.Type != EventExecutionFinished || last.Status != StatusSucceeded {
+		t.Fatalf("unexpected last event: %+v", last)
+	}
+
+	decode(t, server.URL+"/ui/api/executions/"+execution.ID, &execution)
+
+	greeting, _ := execution.Outputs["greeting"].(map[string]any)
+	if greeting["text"] != "hi" || greeting["token"] != log.Redacted {
+		t.Fatalf("unexpected outputs: %+v", execution.Outputs)
+	}
+
+	resp, err = http.Get(server.URL + "/ui/api/executions/unknown")
+	if err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unexpected response: %v, %v", resp, err)
+	}
+
+	resp.Body.Close()
+}
+
+func TestUIAuthentication(t *testing.T) {
+	t.Parallel()
+
+	api := http.NewServeMux()
+	RegisterUI(api, NewRunner(pipeline.NewPipelines(pipeline.New("greet").Build()), WithEngine(pipeline.NewEngine())))
+
+	mux := http.NewServeMux()
+	mux.Handle("/", Authenticate(APIKeys{"secret/key": {Subject: "operator"}})(api))
+	mux.HandleFunc("GET /ui/{$}", UIPage)
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/ui/")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the page to be served without authentication: %v, %v", resp, err)
+	}
+
+	page, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if !strings.Contains(string(page), "'"+CookieAPIKey+"'") {
+		t.Fatalf("expected the page to keep the API key in the %s cookie", CookieAPIKey)
+	}
+
+	for cookie, expected := range map[string]int{"": http.StatusUnauthorized, "other": http.StatusUnauthorized, "secret%2Fkey": http.StatusOK} {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/ui/api/pipelines", nil)
+		if cookie != "" {
+			req.AddCookie(&http.Cookie{Name: CookieAPIKey, Value: cookie})
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		resp.Body.Close()
+
+		if resp.StatusCode != expected {
+			t.Fatalf("unexpected status for the cookie %q: got %d want %d", cookie, resp.StatusCode, expected)
+		}
+	}
+}
+
+func decode(t *testing.T, url string, out any) {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,211 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultReplayTolerance is how long a delivery is accepted after being signed, see WithReplayTolerance.
+	DefaultReplayTolerance = 5 * time.Minute
+	// DefaultMaxPayload is the maximum webhook body size, see WithMaxPayload.
+	DefaultMaxPayload int64 = 1 << 20
+)
+
+var (
+	// ErrInvalidSignature is returned when a webhook signature is missing or doesn't match the body.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrReplayed is returned when a webhook delivery was already received or was signed too long ago.
+	ErrReplayed = errors.New("replayed webhook delivery")
+)
+
+// Delivery identifies a verified webhook delivery.
+type Delivery struct {
+	// ID is unique per delivery, used to reject duplicates.
+	ID string
+	// Timestamp is when the delivery was signed, zero when the scheme doesn't sign it.
+	Timestamp time.Time
+}
+
+// Verifier verifies the signature of a webhook request against its body.
+type Verifier interface {
+	Verify(r *http.Request, body []byte) (Delivery, error)
+}
+
+// VerifierFunc is a function implementing Verifier.
+type VerifierFunc func(r *http.Request, body []byte) (Delivery, error)
+
+// Verify calls the function.
+func (f VerifierFunc) Verify(r *http.Request, body []byte) (Delivery, error) {
+	return f(r, body)
+}
+
+// GitHubVerifier verifies GitHub-style signatures: the hex HMAC-SHA256 of the body in the
+// X-Hub-Signature-256 header prefixed by "sha256=", identified by the X-GitHub-Delivery header.
+func GitHubVerifier(secret []byte) Verifier {
+	return VerifierFunc(func(r *http.Request, body []byte) (Delivery, error) {
+		signature, found := strings.CutPrefix(r.Header.Get("X-Hub-Signature-256"), "sha256=")
+		if !found || !validSignature(secret, body, signature) {
+			return Delivery{}, ErrInvalidSignature
+		}
+
+		id := r.Header.Get("X-GitHub-Delivery")
+		if id == "" {
+			id = signature
+		}
+
+		return Delivery{ID: id}, nil
+	})
+}
+
+// StripeVerifier verifies Stripe-style signatures: the Stripe-Signature header holds the signing
+// unix timestamp (t) and one or more hex HMAC-SHA256 (v1) of the timestamp and body joined by a dot.
+func StripeVerifier(secret []byte) Verifier {
+	return VerifierFunc(func(r *http.Request, body []byte) (Delivery, error) {
+		var (
+			timestamp  string
+			signatures []string
+		)
+
+		for _, part := range strings.Split(r.Header.Get("Stripe-Signature"), ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+
+			switch key {
+			case "t":
+				timestamp = value
+			case "v1":
+				signatures = append(signatures, value)
+			}
+		}
+
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return Delivery{}, fmt.Errorf("%w: invalid timestamp", ErrInvalidSignature)
+		}
+
+		payload := append([]byte(timestamp+"."), body...)
+
+		for _, signature := range signatures {
+			if validSignature(secret, payload, signature) {
+				return Delivery{ID: timestamp + "." + signature, Timestamp: time.Unix(seconds, 0)}, nil
+			}
+		}
+
+		return Delivery{}, ErrInvalidSignature
+	})
+}
+
+func validSignature(secret, payload []byte, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+type webhookOptions struct {
+	tolerance  time.Duration
+	maxPayload int64
+}
+
+// WebhookOption configures VerifyWebhook.
+type WebhookOption func(*webhookOptions)
+
+// WithReplayTolerance sets how long deliveries are accepted after being signed and remembered
+// to reject duplicates, DefaultReplayTolerance by default.
+func WithReplayTolerance(tolerance time.Duration) WebhookOption {
+	return func(o *webhookOptions) {
+		o.tolerance = tolerance
+	}
+}
+
+// WithMaxPayload limits the webhook body size, DefaultMaxPayload by default.
+func WithMaxPayload(size int64) WebhookOption {
+	return func(o *webhookOptions) {
+		o.maxPayload = size
+	}
+}
+
+// VerifyWebhook returns a middleware rejecting requests with invalid signatures or replayed deliveries
+// before calling the handler, eg.: the one executing the pipeline bound to the webhook.
+// The handler can read the request body again.
+func VerifyWebhook(verifier Verifier, opts ...WebhookOption) func(http.Handler) http.Handler {
+	o := webhookOptions{tolerance: DefaultReplayTolerance, maxPayload: DefaultMaxPayload}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	replays := &replayCache{seen: map[string]time.Time{}}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, o.maxPayload))
+			if err != nil {
+				writeJSON(w, http.StatusRequestEntityTooLarge, map[string]any{"error": err.Error()})
+
+				return
+			}
+
+			delivery, err := verifier.Verify(r, body)
+			if err == nil {
+				err = replays.check(delivery, time.Now(), o.tolerance)
+			}
+
+			if err != nil {
+				writeJSON(w, http.StatusUnauthorized, map[string]any{"error": err.Error()})
+
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// replayCache remembers the deliveries received within the replay tolerance.
+type replayCache struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func (c *replayCache) check(delivery Delivery, now time.Time, tolerance time.Duration) error {
+	if !delivery.Timestamp.IsZero() {
+		age := now.Sub(delivery.Timestamp)
+		if age > tolerance || age < -tolerance {
+			return fmt.Errorf("%w: signed at %s", ErrReplayed, delivery.Timestamp.Format(time.RFC3339))
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, expiry := range c.seen {
+		if now.After(expiry) {
+			delete(c.seen, id)
+		}
+	}
+
+	if _, found := c.seen[delivery.ID]; found {
+		return fmt.Errorf("%w: %s", ErrReplayed, delivery.ID)
+	}
+
+	c.seen[delivery.ID] = now.Add(tolerance)
+
+	return nil
+}
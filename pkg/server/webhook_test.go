@@ -0,0 +1,127 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sign(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	t.Parallel()
+
+	const body = `{"action":"opened"}`
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name     string
+		verifier Verifier
+		headers  map[string]string
+		status   int
+	}{
+		{
+			name:     "github",
+			verifier: GitHubVerifier([]byte("secret")),
+			headers:  map[string]string{"X-Hub-Signature-256": "sha256=" + sign("secret", body), "X-GitHub-Delivery": "1"},
+			status:   http.StatusOK,
+		},
+		{
+			name:     "github wrong secret",
+			verifier: GitHubVerifier([]byte("secret")),
+			headers:  map[string]string{"X-Hub-Signature-256": "sha256=" + sign("other", body), "X-GitHub-Delivery": "1"},
+			status:   http.StatusUnauthorized,
+		},
+		{
+			name:     "github missing signature",
+			verifier: GitHubVerifier([]byte("secret")),
+			status:   http.StatusUnauthorized,
+		},
+		{
+			name:     "stripe",
+			verifier: StripeVerifier([]byte("secret")),
+			headers:  map[string]string{"Stripe-Signature": fmt.Sprintf("t=%s,v1=bad,v1=%s", now, sign("secret", now+"."+body))},
+			status:   http.StatusOK,
+		},
+		{
+			name:     "stripe expired",
+			verifier: StripeVerifier([]byte("secret")),
+			headers:  map[string]string{"Stripe-Signature": fmt.Sprintf("t=%s,v1=%s", old, sign("secret", old+"."+body))},
+			status:   http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := VerifyWebhook(tc.verifier)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received, _ := io.ReadAll(r.Body)
+				if string(received) != body {
+					t.Errorf("unexpected body: got %s want %s", received, body)
+				}
+			}))
+
+			request := httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(body))
+			for key, value := range tc.headers {
+				request.Header.Set(key, value)
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != tc.status {
+				t.Fatalf("got status %d want %d: %s", recorder.Code, tc.status, recorder.Body)
+			}
+		})
+	}
+}
+
+func TestVerifyWebhookReplay(t *testing.T) {
+	t.Parallel()
+
+	const body = `{}`
+
+	handler := VerifyWebhook(GitHubVerifier([]byte("secret")))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	for i, status := range []int{http.StatusOK, http.StatusUnauthorized} {
+		request := httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(body))
+		request.Header.Set("X-Hub-Signature-256", "sha256="+sign("secret", body))
+		request.Header.Set("X-GitHub-Delivery", "delivery-1")
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+
+		if recorder.Code != status {
+			t.Fatalf("delivery %d: got status %d want %d", i, recorder.Code, status)
+		}
+	}
+}
+
+func TestVerifyWebhookMaxPayload(t *testing.T) {
+	t.Parallel()
+
+	handler := VerifyWebhook(GitHubVerifier([]byte("secret")), WithMaxPayload(2))(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(`{"a":1}`)))
+
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("got status %d want %d", recorder.Code, http.StatusRequestEntityTooLarge)
+	}
+}
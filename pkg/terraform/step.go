@@ -0,0 +1,381 @@
+// Package terraform provides a step orchestrating infrastructure with the Terraform or OpenTofu CLIs.
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/command"
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+// DefaultBinary is the CLI run by the step, see WithBinary.
+const DefaultBinary = "terraform"
+
+type options struct {
+	binary string
+}
+
+// Option configures the terraform step executor.
+type Option func(*options)
+
+// WithBinary sets the CLI run by the step, eg.: tofu for OpenTofu.
+func WithBinary(name string) Option {
+	return func(o *options) {
+		o.binary = name
+	}
+}
+
+// RegisterStepExecutor registers the terraform step running the CLI with the runner.
+func RegisterStepExecutor(runner command.Runner, opts ...Option) {
+	pipeline.RegisterStepExecutor("terraform", StepExecutor(runner, opts...))
+}
+
+type StepParams struct {
+	Command   expression.String               `yaml:"command"`
+	Dir       expression.String               `yaml:"dir"`
+	Workspace expression.String               `yaml:"workspace"`
+	Vars      expression.YAML[map[string]any] `yaml:"vars"`
+	Plan      expression.String               `yaml:"plan"`
+}
+
+// ReadOnly reports the init, plan and output commands as read-only, not changing the infrastructure, unless they
+// select a workspace, created when missing, or plan into the plan param file, see pipeline.ReadOnlyParams.
+func (p StepParams) ReadOnly() bool {
+	if p.Workspace != "" {
+		return false
+	}
+
+	switch p.Command {
+	case "init", "output":
+		return true
+	case "plan":
+		return p.Plan == ""
+	}
+
+	return false
+}
+
+// StepExecutor runs the init, plan, apply or output commands in a configuration directory,
+// selecting (or creating) the workspace when set. The step variable path is set to:
+//   - init and apply: the command stdout;
+//   - plan: the plan file, has_changes, the add, change and destroy counts and the changes (address and actions);
+//   - output: the output values by name.
+//
+// Plans are saved in the execution workspace unless the plan param is set, and applied when given to apply.
+// Relative plan paths are resolved from the configuration directory.
+//
+// Example YAML:
+//
+//	id: terraform-example
+//	steps:
+//	- id: init
+//	  type: terraform
+//	  params:
+//	    command: 'init'
+//	    dir: './infra'
+//	    workspace: 'staging'
+//	- id: plan
+//	  type: terraform
+//	  params:
+//	    command: 'plan'
+//	    dir: './infra'
+//	    workspace: 'staging'
+//	    vars:
+//	      replicas: 3
+//	- id: apply
+//	  type: terraform
+//	  params:
+//	    command: 'apply'
+//	    dir: './infra'
+//	    workspace: 'staging'
+//	    plan: '{{ (variable . "plan").plan }}'
+func StepExecutor(runner command.Runner, opts ...Option) pipeline.StepExecutor {
+	o := options{binary: DefaultBinary}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return pipeline.TypedStepExecutor[StepParams](
+		func(ctx context.Context, scope pipeline.Scope, step pipeline.Step, p StepParams) (pipeline.Scope, error) {
+			cli, err := p.cli(ctx, scope, runner, o.binary)
+			if err != nil {
+				return scope, err
+			}
+
+			name, err := p.Command.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			var result any
+
+			switch name {
+			case "init":
+				result, err = cli.init(ctx)
+			case "plan":
+				result, err = cli.plan(ctx, planFile(ctx, step, cli.planPath))
+			case "apply":
+				result, err = cli.apply(ctx)
+			case "output":
+				result, err = cli.output(ctx)
+			default:
+				return scope, fmt.Errorf("unsupported terraform command %q", name)
+			}
+
+			if err != nil {
+				return scope, err
+			}
+
+			return scope.WithVariable(step.VariablePath(), result), nil
+		},
+	)
+}
+
+// planFile returns where the plan is saved: the plan param, or a file in the execution workspace.
+func planFile(ctx context.Context, step pipeline.Step, param string) string {
+	if param != "" {
+		return param
+	}
+
+	name := "terraform"
+	if step.ID != "" {
+		name = string(step.ID)
+	}
+
+	path, err := pipeline.Workspace(ctx, name+".tfplan")
+	if err != nil {
+		return name + ".tfplan"
+	}
+
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+
+	return path
+}
+
+// cli runs the commands in a configuration directory and workspace.
+type cli struct {
+	runner    command.Runner
+	binary    string
+	dir       string
+	workspace string
+	vars      map[string]any
+	planPath  string
+}
+
+func (p StepParams) cli(ctx context.Context, scope pipeline.Scope, runner command.Runner, binary string) (cli, error) {
+	c := cli{runner: runner, binary: binary}
+
+	var err error
+
+	if c.dir, err = p.Dir.Eval(ctx, scope); err != nil {
+		return c, err
+	}
+
+	if c.workspace, err = p.Workspace.Eval(ctx, scope); err != nil {
+		return c, err
+	}
+
+	if c.vars, err = p.Vars.Eval(ctx, scope); err != nil {
+		return c, err
+	}
+
+	if c.planPath, err = p.Plan.Eval(ctx, scope); err != nil {
+		return c, err
+	}
+
+	return c, nil
+}
+
+func (c cli) run(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := command.Command{Name: c.binary, Args: args, Dir: c.dir, Env: []string{"TF_IN_AUTOMATION=1"}}
+
+	log.Log().Debug(ctx, "Running %s", cmd)
+
+	result, err := c.runner.Run(ctx, cmd)
+
+	return result.Stdout, err
+}
+
+func (c cli) selectWorkspace(ctx context.Context) error {
+	if c.workspace == "" {
+		return nil
+	}
+
+	_, err := c.run(ctx, "workspace", "select", "-or-create=true", c.workspace)
+
+	return err
+}
+
+func (c cli) varArgs() ([]string, error) {
+	names := make([]string, 0, len(c.vars))
+	for name := range c.vars {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	args := make([]string, 0, len(names))
+
+	for _, name := range names {
+		value, ok := c.vars[name].(string)
+		if !ok {
+			blob, err := json.Marshal(c.vars[name])
+			if err != nil {
+				return nil, err
+			}
+
+			value = string(blob)
+		}
+
+		args = append(args, "-var", name+"="+value)
+	}
+
+	return args, nil
+}
+
+func (c cli) init(ctx context.Context) (any, error) {
+	stdout, err := c.run(ctx, "init", "-input=false", "-no-color")
+	if err != nil {
+		return nil, err
+	}
+
+	return string(stdout), c.selectWorkspace(ctx)
+}
+
+func (c cli) plan(ctx context.Context, file string) (any, error) {
+	if err := c.selectWorkspace(ctx); err != nil {
+		return nil, err
+	}
+
+	vars, err := c.varArgs()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := c.run(ctx, append([]string{"plan", "-input=false", "-no-color", "-out=" + file}, vars...)...); err != nil {
+		return nil, err
+	}
+
+	stdout, err := c.run(ctx, "show", "-json", file)
+	if err != nil {
+		return nil, err
+	}
+
+	return summarize(stdout, file)
+}
+
+// summarize counts the resource changes of a JSON plan.
+func summarize(blob []byte, file string) (map[string]any, error) {
+	var plan struct {
+		ResourceChanges []struct {
+			Address string `json:"address"`
+			Change  struct {
+				Actions []string `json:"actions"`
+			} `json:"change"`
+		} `json:"resource_changes"`
+	}
+
+	if err := json.Unmarshal(blob, &plan); err != nil {
+		return nil, fmt.Errorf("error decoding plan: %w", err)
+	}
+
+	counts := map[string]int{}
+	changes := []any{}
+
+	for _, resource := range plan.ResourceChanges {
+		changed := false
+
+		for _, action := range resource.Change.Actions {
+			switch action {
+			case "create":
+				counts["add"]++
+			case "update":
+				counts["change"]++
+			case "delete":
+				counts["destroy"]++
+			default:
+				continue
+			}
+
+			changed = true
+		}
+
+		if changed {
+			actions := make([]any, 0, len(resource.Change.Actions))
+			for _, action := range resource.Change.Actions {
+				actions = append(actions, action)
+			}
+
+			changes = append(changes, map[string]any{"address": resource.Address, "actions": actions})
+		}
+	}
+
+	return map[string]any{
+		"plan":        file,
+		"has_changes": len(changes) > 0,
+		"add":         counts["add"],
+		"change":      counts["change"],
+		"destroy":     counts["destroy"],
+		"changes":     changes,
+	}, nil
+}
+
+func (c cli) apply(ctx context.Context) (any, error) {
+	if err := c.selectWorkspace(ctx); err != nil {
+		return nil, err
+	}
+
+	args := []string{"apply", "-input=false", "-no-color", "-auto-approve"}
+
+	if c.planPath != "" {
+		args = append(args, c.planPath)
+	} else {
+		vars, err := c.varArgs()
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, vars...)
+	}
+
+	stdout, err := c.run(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(stdout), nil
+}
+
+func (c cli) output(ctx context.Context) (any, error) {
+	if err := c.selectWorkspace(ctx); err != nil {
+		return nil, err
+	}
+
+	stdout, err := c.run(ctx, "output", "-json")
+	if err != nil {
+		return nil, err
+	}
+
+	var outputs map[string]struct {
+		Value any `json:"value"`
+	}
+
+	if err := json.Unmarshal(stdout, &outputs); err != nil {
+		return nil, fmt.Errorf("error decoding outputs: %w", err)
+	}
+
+	values := make(map[string]any, len(outputs))
+	for name, output := range outputs {
+		values[name] = output.Value
+	}
+
+	return values, nil
+}
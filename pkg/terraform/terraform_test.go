@@ -0,0 +1,150 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/command"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+const (
+	planJSON = `{"resource_changes":[
+		{"address":"aws_instance.web","change":{"actions":["create"]}},
+		{"address":"aws_instance.db","change":{"actions":["delete","create"]}},
+		{"address":"aws_s3_bucket.logs","change":{"actions":["no-op"]}}
+	]}`
+	outputJSON = `{"url":{"sensitive":false,"type":"string","value":"https://staging.example.com"},"ports":{"value":[80,443]}}`
+)
+
+// fakeRunner records the command lines and prints the JSON documents of show and output.
+func fakeRunner(commands *[]string) command.Runner {
+	return command.RunnerFunc(func(_ context.Context, cmd command.Command) (command.Result, error) {
+		*commands = append(*commands, strings.Join(cmd.Args, " "))
+
+		switch cmd.Args[0] {
+		case "show":
+			return command.Result{Stdout: []byte(planJSON)}, nil
+		case "output":
+			return command.Result{Stdout: []byte(outputJSON)}, nil
+		}
+
+		return command.Result{Stdout: []byte("ok")}, nil
+	})
+}
+
+func TestStepExecutor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		params   map[string]any
+		commands []string
+		result   string
+	}{
+		{
+			params:   map[string]any{"command": "init", "workspace": "staging"},
+			commands: []string{"init -input=false -no-color", "workspace select -or-create=true staging"},
+			result:   "ok",
+		},
+		{
+			params: map[string]any{"command": "plan", "plan": "staging.tfplan", "vars": map[string]any{"replicas": 3, "region": "us-east-1"}},
+			commands: []string{
+				"plan -input=false -no-color -out=staging.tfplan -var region=us-east-1 -var replicas=3",
+				"show -json staging.tfplan",
+			},
+			result: "map[add:2 change:0 changes:[map[actions:[create] address:aws_instance.web] " +
+				"map[actions:[delete create] address:aws_instance.db]] destroy:1 has_changes:true plan:staging.tfplan]",
+		},
+		{
+			params:   map[string]any{"command": "apply", "plan": "staging.tfplan"},
+			commands: []string{"apply -input=false -no-color -auto-approve staging.tfplan"},
+			result:   "ok",
+		},
+		{
+			params:   map[string]any{"command": "output"},
+			commands: []string{"output -json"},
+			result:   "map[ports:[80 443] url:https://staging.example.com]",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprint(tc.params["command"]), func(t *testing.T) {
+			t.Parallel()
+
+			var commands []string
+
+			step := pipeline.NewStep("infra", "terraform", tc.params)
+
+			scope, err := StepExecutor(fakeRunner(&commands), WithBinary("tofu")).Execute(context.Background(), pipeline.NewScope(pipeline.Pipelines{}), step)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, want := strings.Join(commands, "\n"), strings.Join(tc.commands, "\n"); got != want {
+				t.Fatalf("unexpected commands:\ngot:\n%s\nwant:\n%s", got, want)
+			}
+
+			value, _ := scope.Variable("infra")
+			if got := fmt.Sprint(value); got != tc.result {
+				t.Fatalf("unexpected result:\ngot:  %s\nwant: %s", got, tc.result)
+			}
+		})
+	}
+}
+
+func TestStepExecutorPlanInWorkspace(t *testing.T) {
+	t.Parallel()
+
+	var commands []string
+
+	engine := pipeline.NewEngine()
+	engine.RegisterStepExecutor("terraform", StepExecutor(fakeRunner(&commands)))
+
+	pipelines := pipeline.NewPipelines(pipeline.New("main").
+		Step(pipeline.NewStep("plan", "terraform", map[string]any{"command": "plan"})).
+		Build())
+
+	scope, err := engine.Execute(context.Background(), pipeline.NewScope(pipelines), []string{"main"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	plan, _ := pipeline.Get[map[string]any](scope, "plan")
+	if file, _ := plan["plan"].(string); !strings.HasSuffix(file, "/plan.tfplan") || !strings.Contains(commands[0], "-out="+file) {
+		t.Fatalf("expected the plan in the execution workspace: %v %v", plan["plan"], commands)
+	}
+}
+
+func TestStepExecutorUnsupportedCommand(t *testing.T) {
+	t.Parallel()
+
+	step := pipeline.NewStep("infra", "terraform", map[string]any{"command": "destroy"})
+
+	if _, err := StepExecutor(fakeRunner(new([]string))).Execute(context.Background(), pipeline.NewScope(pipeline.Pipelines{}), step); err == nil {
+		t.Fatal("expected an unsupported command error")
+	}
+}
+
+func TestStepParamsReadOnly(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		params   StepParams
+		expected bool
+	}{
+		"init":                {StepParams{Command: "init"}, true},
+		"plan":                {StepParams{Command: "plan"}, true},
+		"output":              {StepParams{Command: "output"}, true},
+		"apply":               {StepParams{Command: "apply"}, false},
+		"plan into a file":    {StepParams{Command: "plan", Plan: "infra.tfplan"}, false},
+		"output in workspace": {StepParams{Command: "output", Workspace: "staging"}, false},
+	}
+
+	for name, test := range tests {
+		if got := test.params.ReadOnly(); got != test.expected {
+			t.Fatalf("ReadOnly() of %s = %v, expected %v", name, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// Package warehouse provides a step running queries on analytical warehouses, eg.: BigQuery or Snowflake.
+package warehouse
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrJobFailed is returned when a warehouse reports a query job as failed.
+var ErrJobFailed = errors.New("warehouse job failed")
+
+// Query is a parameterized query, with params referenced by name in the warehouse syntax (eg.: @name in BigQuery).
+type Query struct {
+	SQL    string
+	Params map[string]any
+}
+
+// JobStatus is the state of a submitted query job.
+type JobStatus struct {
+	Done bool
+	// Error is the failure reported by the warehouse for done jobs.
+	Error string
+}
+
+// Page is a page of query results.
+type Page struct {
+	Rows []map[string]any
+	// NextPageToken fetches the next page, empty on the last one.
+	NextPageToken string
+}
+
+// Driver adapts a warehouse client, eg.: the BigQuery or Snowflake SDKs, to the query step.
+type Driver interface {
+	// Submit starts the query job, returning its ID.
+	Submit(ctx context.Context, query Query) (string, error)
+	// Status returns the current status of the job.
+	Status(ctx context.Context, jobID string) (JobStatus, error)
+	// Results returns a page of at most size rows of a done job, starting at the page token.
+	Results(ctx context.Context, jobID, pageToken string, size int) (Page, error)
+}
@@ -0,0 +1,188 @@
+package warehouse
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/expression"
+	"github.com/crowleyfelix/go-pipeline/pkg/log"
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+)
+
+const (
+	// DefaultPollInterval is how often the status of running jobs is checked.
+	DefaultPollInterval = time.Second
+	// DefaultPageSize is the number of rows fetched per results page.
+	DefaultPageSize = 1000
+)
+
+// RegisterStepExecutor registers the warehouse-query step running queries on the named drivers.
+func RegisterStepExecutor(drivers map[string]Driver) {
+	pipeline.RegisterStepExecutor("warehouse-query", StepExecutor(drivers))
+}
+
+type StepParams struct {
+	Warehouse    expression.String               `yaml:"warehouse"`
+	Query        expression.String               `yaml:"query"`
+	Params       expression.YAML[map[string]any] `yaml:"params"`
+	PollInterval expression.Duration             `yaml:"poll_interval"`
+	PageSize     expression.Int                  `yaml:"page_size"`
+	MaxRows      expression.Int                  `yaml:"max_rows"`
+}
+
+// StepExecutor submits a query to a named warehouse, polls its job until done and fetches the results
+// page by page. The job_id, rows (a list of maps) and truncated (whether max_rows was reached) are
+// stored in the step variable path.
+//
+// Example YAML:
+//
+//	id: warehouse-example
+//	steps:
+//	- id: orders
+//	  type: warehouse-query
+//	  params:
+//	    warehouse: 'analytics'
+//	    query: 'SELECT id, total FROM orders WHERE created_at >= @since'
+//	    params:
+//	      since: '{{ variable . "since" }}'
+//	    max_rows: 10000
+func StepExecutor(drivers map[string]Driver) pipeline.StepExecutor {
+	return pipeline.TypedStepExecutor[StepParams](
+		func(ctx context.Context, scope pipeline.Scope, step pipeline.Step, p StepParams) (pipeline.Scope, error) {
+			name, err := p.Warehouse.Eval(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			driver, found := drivers[name]
+			if !found {
+				return scope, fmt.Errorf("warehouse %q not registered", name)
+			}
+
+			query, err := p.query(ctx, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			jobID, err := driver.Submit(ctx, query)
+			if err != nil {
+				return scope, err
+			}
+
+			log.Log().Debug(ctx, "Submitted warehouse job %s", jobID)
+
+			if err := wait(ctx, driver, jobID, p.PollInterval, scope); err != nil {
+				return scope, err
+			}
+
+			rows, truncated, err := fetch(ctx, driver, jobID, p, scope)
+			if err != nil {
+				return scope, err
+			}
+
+			return scope.WithVariable(step.VariablePath(), map[string]any{
+				"job_id":    jobID,
+				"rows":      rows,
+				"truncated": truncated,
+			}), nil
+		},
+	)
+}
+
+func (p StepParams) query(ctx context.Context, scope pipeline.Scope) (Query, error) {
+	sql, err := p.Query.Eval(ctx, scope)
+	if err != nil {
+		return Query{}, err
+	}
+
+	params, err := p.Params.Eval(ctx, scope)
+	if err != nil {
+		return Query{}, err
+	}
+
+	return Query{SQL: sql, Params: params}, nil
+}
+
+// wait polls the job status until it's done or the context is done.
+func wait(ctx context.Context, driver Driver, jobID string, interval expression.Duration, scope pipeline.Scope) error {
+	every, err := interval.Eval(ctx, scope)
+	if err != nil {
+		return err
+	}
+
+	if every <= 0 {
+		every = DefaultPollInterval
+	}
+
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		status, err := driver.Status(ctx, jobID)
+		if err != nil {
+			return err
+		}
+
+		if status.Done {
+			if status.Error != "" {
+				return fmt.Errorf("%w: %s: %s", ErrJobFailed, jobID, status.Error)
+			}
+
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// fetch reads the result pages, up to max rows when positive.
+func fetch(ctx context.Context, driver Driver, jobID string, p StepParams, scope pipeline.Scope) ([]any, bool, error) {
+	size, err := p.PageSize.Eval(ctx, scope)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if size <= 0 {
+		size = DefaultPageSize
+	}
+
+	limit, err := p.MaxRows.Eval(ctx, scope)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var (
+		rows  = []any{}
+		token string
+	)
+
+	for {
+		if limit > 0 {
+			size = min(size, limit-len(rows))
+		}
+
+		page, err := driver.Results(ctx, jobID, token, size)
+		if err != nil {
+			return nil, false, err
+		}
+
+		for _, row := range page.Rows {
+			rows = append(rows, row)
+		}
+
+		token = page.NextPageToken
+
+		if token == "" {
+			return rows, false, nil
+		}
+
+		if limit > 0 && len(rows) >= limit {
+			return rows[:limit], true, nil
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package warehouse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/crowleyfelix/go-pipeline/pkg/pipeline"
+	"gopkg.in/yaml.v3"
+)
+
+// fakeDriver returns rows numbered from 0 to total, done after pending status checks.
+type fakeDriver struct {
+	total   int
+	pending int
+	failure string
+	query   Query
+	checks  int
+}
+
+func (d *fakeDriver) Submit(_ context.Context, query Query) (string, error) {
+	d.query = query
+
+	return "job-1", nil
+}
+
+func (d *fakeDriver) Status(context.Context, string) (JobStatus, error) {
+	d.checks++
+
+	return JobStatus{Done: d.checks > d.pending, Error: d.failure}, nil
+}
+
+func (d *fakeDriver) Results(_ context.Context, _, token string, size int) (Page, error) {
+	start := 0
+	if token != "" {
+		start, _ = strconv.Atoi(token)
+	}
+
+	end := min(start+size, d.total)
+
+	page := Page{}
+	for i := start; i < end; i++ {
+		page.Rows = append(page.Rows, map[string]any{"id": i})
+	}
+
+	if end < d.total {
+		page.NextPageToken = strconv.Itoa(end)
+	}
+
+	return page, nil
+}
+
+func loadStep(t *testing.T, params string) pipeline.Step {
+	t.Helper()
+
+	var step pipeline.Step
+	if err := yaml.Unmarshal([]byte("id: orders\ntype: warehouse-query\nparams:\n"+params), &step); err != nil {
+		t.Fatal(err)
+	}
+
+	return step
+}
+
+func TestStepExecutor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		params    string
+		rows      int
+		truncated bool
+	}{
+		{name: "all pages", params: "  page_size: 2\n", rows: 5},
+		{name: "max rows", params: "  page_size: 2\n  max_rows: 3\n", rows: 3, truncated: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			driver := &fakeDriver{total: 5, pending: 2}
+			step := loadStep(t, `  warehouse: 'analytics'
+  query: 'SELECT id FROM orders WHERE created_at >= @since'
+  params:
+    since: '{{ variable . "since" }}'
+  poll_interval: 1ms
+`+tc.params)
+
+			scope := pipeline.NewScope(pipeline.Pipelines{}).WithVariable("since", "2025-01-01")
+
+			scope, err := StepExecutor(map[string]Driver{"analytics": driver}).Execute(context.Background(), scope, step)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if driver.query.Params["since"] != "2025-01-01" {
+				t.Fatalf("unexpected query params: %v", driver.query.Params)
+			}
+
+			if driver.checks != 3 {
+				t.Fatalf("expected the job to be polled until done, got %d checks", driver.checks)
+			}
+
+			value, _ := scope.Variable("orders")
+			result, _ := value.(map[string]any)
+			rows, _ := result["rows"].([]any)
+
+			if len(rows) != tc.rows || result["truncated"] != tc.truncated {
+				t.Fatalf("unexpected result: %v", result)
+			}
+
+			if got := fmt.Sprint(rows[len(rows)-1]); got != fmt.Sprintf("map[id:%d]", tc.rows-1) {
+				t.Fatalf("unexpected last row: %s", got)
+			}
+		})
+	}
+}
+
+func TestStepExecutorErrors(t *testing.T) {
+	t.Parallel()
+
+	step := loadStep(t, "  warehouse: 'analytics'\n  query: 'SELECT 1'\n  poll_interval: 1ms\n")
+	scope := pipeline.NewScope(pipeline.Pipelines{})
+
+	_, err := StepExecutor(map[string]Driver{"analytics": &fakeDriver{failure: "syntax error"}}).Execute(context.Background(), scope, step)
+	if !errors.Is(err, ErrJobFailed) {
+		t.Fatalf("expected job failure, got %v", err)
+	}
+
+	if _, err := StepExecutor(nil).Execute(context.Background(), scope, step); err == nil {
+		t.Fatal("expected unregistered warehouse error")
+	}
+}